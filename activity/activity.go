@@ -0,0 +1,173 @@
+// Package activity derives a log of what happens on an n8n instance, such as
+// workflow changes and executions, by comparing snapshots of the public API,
+// which has no event log of its own.
+package activity
+
+import (
+	"sort"
+	"time"
+
+	"github.com/brandon-kyle-bailey/n8nctl/executions"
+)
+
+// Event is one change on the instance, written as a JSON line.
+type Event struct {
+	Time time.Time `json:"time"`
+	// Type is the resource and what happened to it, such as workflow.updated
+	// or execution.finished.
+	Type    string         `json:"type"`
+	ID      string         `json:"id"`
+	Name    string         `json:"name,omitempty"`
+	Details map[string]any `json:"details,omitempty"`
+}
+
+type Workflow struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Active    bool      `json:"active"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+type Credential struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Type      string    `json:"type"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// Snapshot is the state of the instance at one poll. Credentials is nil when
+// the instance does not list them.
+type Snapshot struct {
+	Workflows   []Workflow
+	Credentials []Credential
+	Executions  []executions.Execution
+}
+
+// History returns the events the timestamps of s still show, oldest first:
+// the last change of each workflow and credential, and recent executions.
+func History(s Snapshot) []Event {
+	var events []Event
+	for _, wf := range s.Workflows {
+		typ := "workflow.updated"
+		if wf.UpdatedAt.Equal(wf.CreatedAt) {
+			typ = "workflow.created"
+		}
+		events = append(events, workflowEvent(wf.UpdatedAt, typ, wf))
+	}
+	for _, cred := range s.Credentials {
+		typ := "credential.updated"
+		if cred.UpdatedAt.Equal(cred.CreatedAt) {
+			typ = "credential.created"
+		}
+		events = append(events, credentialEvent(cred.UpdatedAt, typ, cred))
+	}
+	for _, exec := range s.Executions {
+		events = append(events, executionEvents(exec, true)...)
+	}
+	sortEvents(events)
+	return events
+}
+
+// Diff returns the events between two snapshots, oldest first. Deletions
+// have no timestamp of their own and are dated now.
+func Diff(prev, next Snapshot, now time.Time) []Event {
+	var events []Event
+
+	prevWorkflows := map[string]Workflow{}
+	for _, wf := range prev.Workflows {
+		prevWorkflows[wf.ID] = wf
+	}
+	for _, wf := range next.Workflows {
+		old, ok := prevWorkflows[wf.ID]
+		delete(prevWorkflows, wf.ID)
+		switch {
+		case !ok:
+			events = append(events, workflowEvent(wf.CreatedAt, "workflow.created", wf))
+		case old.Active != wf.Active:
+			typ := "workflow.deactivated"
+			if wf.Active {
+				typ = "workflow.activated"
+			}
+			events = append(events, workflowEvent(wf.UpdatedAt, typ, wf))
+		case !old.UpdatedAt.Equal(wf.UpdatedAt):
+			event := workflowEvent(wf.UpdatedAt, "workflow.updated", wf)
+			if old.Name != wf.Name {
+				event.Details["previousName"] = old.Name
+			}
+			events = append(events, event)
+		}
+	}
+	for _, wf := range prevWorkflows {
+		events = append(events, workflowEvent(now, "workflow.deleted", wf))
+	}
+
+	if prev.Credentials != nil && next.Credentials != nil {
+		prevCredentials := map[string]Credential{}
+		for _, cred := range prev.Credentials {
+			prevCredentials[cred.ID] = cred
+		}
+		for _, cred := range next.Credentials {
+			old, ok := prevCredentials[cred.ID]
+			delete(prevCredentials, cred.ID)
+			switch {
+			case !ok:
+				events = append(events, credentialEvent(cred.CreatedAt, "credential.created", cred))
+			case !old.UpdatedAt.Equal(cred.UpdatedAt):
+				events = append(events, credentialEvent(cred.UpdatedAt, "credential.updated", cred))
+			}
+		}
+		for _, cred := range prevCredentials {
+			events = append(events, credentialEvent(now, "credential.deleted", cred))
+		}
+	}
+
+	// Executions age out of the recent list, so only new ones and status
+	// changes count, never disappearances.
+	prevExecutions := map[executions.ID]executions.Execution{}
+	for _, exec := range prev.Executions {
+		prevExecutions[exec.ID] = exec
+	}
+	for _, exec := range next.Executions {
+		old, ok := prevExecutions[exec.ID]
+		switch {
+		case !ok:
+			events = append(events, executionEvents(exec, true)...)
+		case old.StoppedAt == nil && exec.StoppedAt != nil:
+			events = append(events, executionEvents(exec, false)...)
+		}
+	}
+	sortEvents(events)
+	return events
+}
+
+func workflowEvent(at time.Time, typ string, wf Workflow) Event {
+	return Event{Time: at, Type: typ, ID: wf.ID, Name: wf.Name, Details: map[string]any{"active": wf.Active}}
+}
+
+func credentialEvent(at time.Time, typ string, cred Credential) Event {
+	return Event{Time: at, Type: typ, ID: cred.ID, Name: cred.Name, Details: map[string]any{"credentialType": cred.Type}}
+}
+
+// executionEvents returns the start of exec, if started is set, and its end
+// if it has stopped.
+func executionEvents(exec executions.Execution, started bool) []Event {
+	details := map[string]any{"workflowId": exec.WorkflowID, "mode": exec.Mode}
+	var events []Event
+	if started {
+		events = append(events, Event{Time: exec.StartedAt, Type: "execution.started", ID: string(exec.ID), Details: details})
+	}
+	if exec.StoppedAt != nil {
+		finished := map[string]any{"status": exec.Status, "durationMs": exec.StoppedAt.Sub(exec.StartedAt).Milliseconds()}
+		for k, v := range details {
+			finished[k] = v
+		}
+		events = append(events, Event{Time: *exec.StoppedAt, Type: "execution.finished", ID: string(exec.ID), Details: finished})
+	}
+	return events
+}
+
+func sortEvents(events []Event) {
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Time.Before(events[j].Time) })
+}
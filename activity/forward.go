@@ -0,0 +1,153 @@
+package activity
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// Sink receives the events of Tail.
+type Sink interface {
+	Send(ctx context.Context, event Event) error
+	Close() error
+}
+
+// OpenSink returns the sink target names: empty or "-" for stdout,
+// syslog://host[:port] (UDP) or syslog+tcp://host[:port], an http(s) URL
+// that each event is POSTed to, or else a file, optionally as file://path,
+// that events are appended to. HTTP sinks have their own client: the
+// collector is not the n8n instance, whose proxy, TLS and read-only
+// settings do not apply to it.
+func OpenSink(target string) (Sink, error) {
+	if target == "" || target == "-" {
+		return &lineSink{w: os.Stdout}, nil
+	}
+	u, err := url.Parse(target)
+	if err == nil {
+		switch u.Scheme {
+		case "syslog", "syslog+udp", "syslog+tcp":
+			return dialSyslog(u)
+		case "http", "https":
+			return &httpSink{url: target, client: &http.Client{Timeout: 30 * time.Second}}, nil
+		case "file":
+			target = u.Path
+		}
+	}
+	f, err := os.OpenFile(target, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	return &lineSink{w: f, closer: f}, nil
+}
+
+// lineSink writes events as JSON lines.
+type lineSink struct {
+	w      io.Writer
+	closer io.Closer
+}
+
+func (s *lineSink) Send(_ context.Context, event Event) error {
+	return json.NewEncoder(s.w).Encode(event)
+}
+
+func (s *lineSink) Close() error {
+	if s.closer == nil {
+		return nil
+	}
+	return s.closer.Close()
+}
+
+type httpSink struct {
+	url    string
+	client *http.Client
+}
+
+func (s *httpSink) Send(ctx context.Context, event Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("%s answered %s", s.url, resp.Status)
+	}
+	return nil
+}
+
+func (s *httpSink) Close() error { return nil }
+
+// syslogSink sends RFC 5424 messages with the event as JSON, from the local0
+// facility. TCP messages are framed by octet counting (RFC 6587).
+type syslogSink struct {
+	conn     net.Conn
+	tcp      bool
+	hostname string
+}
+
+func dialSyslog(u *url.URL) (Sink, error) {
+	network := "udp"
+	if u.Scheme == "syslog+tcp" {
+		network = "tcp"
+	}
+	addr := u.Host
+	if u.Port() == "" {
+		addr = net.JoinHostPort(u.Hostname(), "514")
+	}
+	conn, err := net.DialTimeout(network, addr, 10*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	hostname, _ := os.Hostname()
+	if hostname == "" {
+		hostname = "-"
+	}
+	return &syslogSink{conn: conn, tcp: network == "tcp", hostname: hostname}, nil
+}
+
+// severity is warning for failed executions and deletions, info otherwise.
+func severity(event Event) int {
+	if strings.HasSuffix(event.Type, ".deleted") {
+		return 4
+	}
+	if status, _ := event.Details["status"].(string); status == "error" || status == "crashed" {
+		return 4
+	}
+	return 6
+}
+
+func (s *syslogSink) Send(_ context.Context, event Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	const local0 = 16
+	msg := fmt.Sprintf("<%d>1 %s %s n8nctl %d %s - %s",
+		local0*8+severity(event), event.Time.UTC().Format(time.RFC3339Nano), s.hostname, os.Getpid(), event.Type, body)
+	if s.tcp {
+		msg = fmt.Sprintf("%d %s", len(msg), msg)
+	}
+	_, err = io.WriteString(s.conn, msg)
+	return err
+}
+
+func (s *syslogSink) Close() error {
+	return s.conn.Close()
+}
@@ -0,0 +1,72 @@
+package activity
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+)
+
+type TailOptions struct {
+	// Fetch takes a snapshot of the instance.
+	Fetch func() (Snapshot, error)
+	// Recent is how many past events to send first.
+	Recent int
+	// Follow keeps polling for new events instead of returning after the
+	// recent ones.
+	Follow   bool
+	Interval time.Duration
+	Sink     Sink
+}
+
+// Tail sends the most recent events to the sink and, with Follow, keeps
+// sending new ones until ctx is done. Failed polls and sends are reported
+// and retried on the next poll, so a flaky destination does not stop it.
+func Tail(ctx context.Context, opts TailOptions) error {
+	prev, err := opts.Fetch()
+	if err != nil {
+		return err
+	}
+	history := History(prev)
+	history = history[max(0, len(history)-opts.Recent):]
+	for _, event := range history {
+		if err := opts.Sink.Send(ctx, event); err != nil {
+			return fmt.Errorf("failed to forward event: %w", err)
+		}
+	}
+	if !opts.Follow {
+		return nil
+	}
+
+	fmt.Fprintln(os.Stderr, "Following instance activity (Ctrl+C to stop)")
+	var pending []Event
+	ticker := time.NewTicker(opts.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		next, err := opts.Fetch()
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Polling failed: %v\n", err)
+			continue
+		}
+		pending = append(pending, Diff(prev, next, time.Now())...)
+		prev = next
+		for len(pending) > 0 {
+			if err := opts.Sink.Send(ctx, pending[0]); err != nil {
+				if ctx.Err() == nil {
+					fmt.Fprintf(os.Stderr, "Forwarding failed, %d events held back: %v\n", len(pending), err)
+				}
+				break
+			}
+			pending = pending[1:]
+		}
+	}
+}
@@ -0,0 +1,155 @@
+// Package ai calls the language model behind the experimental AI commands,
+// through the chat completions API of OpenAI, Azure OpenAI or a local server
+// compatible with OpenAI's, such as Ollama or llama.cpp.
+package ai
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/brandon-kyle-bailey/n8nctl/config"
+)
+
+// The environment variables overriding the ai settings of .n8nctl.yaml.
+const (
+	ProviderEnv = "N8NCTL_AI_PROVIDER"
+	EndpointEnv = "N8NCTL_AI_ENDPOINT"
+	ModelEnv    = "N8NCTL_AI_MODEL"
+	APIKeyEnv   = "N8NCTL_AI_API_KEY"
+)
+
+// Providers.
+const (
+	OpenAI = "openai"
+	Azure  = "azure"
+)
+
+const defaultAzureAPIVersion = "2024-06-01"
+
+// ErrNotConfigured is returned when no endpoint is configured.
+var ErrNotConfigured = errors.New("no language model configured, set ai.endpoint and ai.model in " + config.ProjectFile + " or " + EndpointEnv + " and " + ModelEnv)
+
+// Client sends chat completions to a model.
+type Client struct {
+	Provider   string
+	Endpoint   string
+	Model      string
+	APIKey     string
+	APIVersion string
+	HTTPClient *http.Client
+}
+
+// Message is a message of a chat, from the system, user or assistant.
+type Message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// New returns the client the settings and the N8NCTL_AI_* variables
+// configure, or ErrNotConfigured.
+func New(settings config.AISettings) (*Client, error) {
+	c := &Client{
+		Provider:   strings.ToLower(cmp.Or(os.Getenv(ProviderEnv), settings.Provider, OpenAI)),
+		Endpoint:   strings.TrimRight(cmp.Or(os.Getenv(EndpointEnv), settings.Endpoint), "/"),
+		Model:      cmp.Or(os.Getenv(ModelEnv), settings.Model),
+		APIKey:     os.Getenv(cmp.Or(settings.APIKeyEnv, APIKeyEnv)),
+		APIVersion: cmp.Or(settings.APIVersion, defaultAzureAPIVersion),
+		HTTPClient: &http.Client{Timeout: 5 * time.Minute},
+	}
+	if c.Endpoint == "" {
+		return nil, ErrNotConfigured
+	}
+	if c.Provider != OpenAI && c.Provider != Azure {
+		return nil, fmt.Errorf("unknown ai provider %q, use %s or %s", c.Provider, OpenAI, Azure)
+	}
+	if c.Model == "" {
+		return nil, fmt.Errorf("no model configured, set ai.model in %s or %s", config.ProjectFile, ModelEnv)
+	}
+	return c, nil
+}
+
+// Complete returns the model's reply to messages.
+func (c *Client) Complete(ctx context.Context, messages []Message) (string, error) {
+	payload := map[string]any{"messages": messages, "temperature": 0.2}
+	endpoint := c.Endpoint + "/chat/completions"
+	if c.Provider == Azure {
+		endpoint = fmt.Sprintf("%s/openai/deployments/%s/chat/completions?api-version=%s",
+			c.Endpoint, url.PathEscape(c.Model), url.QueryEscape(c.APIVersion))
+	} else {
+		payload["model"] = c.Model
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.APIKey != "" {
+		if c.Provider == Azure {
+			req.Header.Set("api-key", c.APIKey)
+		} else {
+			req.Header.Set("Authorization", "Bearer "+c.APIKey)
+		}
+	}
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("calling the model failed: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	var completion struct {
+		Choices []struct {
+			Message Message `json:"message"`
+		} `json:"choices"`
+		Error *struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(body, &completion); err != nil {
+		if resp.StatusCode != http.StatusOK {
+			return "", fmt.Errorf("the model responded %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+		}
+		return "", fmt.Errorf("invalid response from the model: %w", err)
+	}
+	if completion.Error != nil {
+		return "", fmt.Errorf("the model responded %d: %s", resp.StatusCode, completion.Error.Message)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("the model responded %d", resp.StatusCode)
+	}
+	if len(completion.Choices) == 0 {
+		return "", errors.New("the model returned no reply")
+	}
+	return completion.Choices[0].Message.Content, nil
+}
+
+// StripFences returns the content of the first ``` fenced block of reply,
+// where models tend to put code, or all of reply if it has none.
+func StripFences(reply string) string {
+	_, rest, ok := strings.Cut(reply, "```")
+	if !ok {
+		return strings.TrimSpace(reply)
+	}
+	// Skip the info string, e.g. yaml.
+	if i := strings.IndexByte(rest, '\n'); i >= 0 {
+		rest = rest[i+1:]
+	}
+	code, _, _ := strings.Cut(rest, "```")
+	return strings.TrimSpace(code)
+}
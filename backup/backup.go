@@ -0,0 +1,198 @@
+// Package backup reads and writes archives of the resources of an n8n
+// instance: a gzipped tar of JSON files described by a manifest.
+package backup
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// Format identifies n8nctl backups in their manifest.
+	Format = "n8nctl-backup"
+	// Version is the layout of the archives Write produces.
+	Version = 1
+
+	manifestFile    = "manifest.json"
+	workflowsDir    = "workflows"
+	tagsFile        = "tags.json"
+	variablesFile   = "variables.json"
+	credentialsFile = "credentials.json.enc"
+)
+
+// Manifest describes a backup. It is the first file of the archive.
+type Manifest struct {
+	Format    string    `json:"format"`
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+	// Instance is the base URL of the instance backed up.
+	Instance    string `json:"instance"`
+	Workflows   int    `json:"workflows"`
+	Tags        int    `json:"tags"`
+	Variables   int    `json:"variables"`
+	Credentials int    `json:"credentials"`
+	// Files maps every other file of the archive to its SHA-256.
+	Files map[string]string `json:"files"`
+}
+
+// Backup is the content of an archive. Resources are kept as the API
+// returned them, so nothing is lost that n8nctl does not know about.
+type Backup struct {
+	Manifest  Manifest
+	Workflows []json.RawMessage
+	Tags      []json.RawMessage
+	Variables []json.RawMessage
+	// Credentials is the credentials list sealed with a passphrase, or nil.
+	Credentials []byte
+}
+
+// Write writes b as a gzipped tar to w, filling in its manifest.
+func Write(w io.Writer, b *Backup) error {
+	files := map[string][]byte{}
+	for _, wf := range b.Workflows {
+		var meta struct {
+			ID string `json:"id"`
+		}
+		if err := json.Unmarshal(wf, &meta); err != nil || meta.ID == "" {
+			return fmt.Errorf("workflow without an ID: %.80s", wf)
+		}
+		files[path.Join(workflowsDir, meta.ID+".json")] = wf
+	}
+	for name, list := range map[string][]json.RawMessage{tagsFile: b.Tags, variablesFile: b.Variables} {
+		data, err := json.MarshalIndent(orEmpty(list), "", "  ")
+		if err != nil {
+			return err
+		}
+		files[name] = data
+	}
+	if b.Credentials != nil {
+		files[credentialsFile] = b.Credentials
+	}
+
+	m := &b.Manifest
+	m.Format, m.Version = Format, Version
+	m.Workflows, m.Tags, m.Variables = len(b.Workflows), len(b.Tags), len(b.Variables)
+	m.Files = map[string]string{}
+	for name, data := range files {
+		sum := sha256.Sum256(data)
+		m.Files[name] = hex.EncodeToString(sum[:])
+	}
+	manifest, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range append([]string{manifestFile}, names...) {
+		data := manifest
+		if name != manifestFile {
+			data = files[name]
+		}
+		hdr := &tar.Header{Name: name, Mode: 0o600, Size: int64(len(data)), ModTime: m.CreatedAt, Typeflag: tar.TypeReg}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if _, err := tw.Write(data); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// Read reads an archive written by Write, checking every file against the
+// manifest.
+func Read(r io.Reader) (*Backup, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("not a backup archive: %w", err)
+	}
+	tr := tar.NewReader(gz)
+	files := map[string][]byte{}
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("corrupt backup archive: %w", err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, fmt.Errorf("corrupt backup archive: %w", err)
+		}
+		files[hdr.Name] = data
+	}
+
+	b := &Backup{}
+	data, ok := files[manifestFile]
+	if !ok {
+		return nil, fmt.Errorf("not a backup archive: no %s", manifestFile)
+	}
+	if err := json.Unmarshal(data, &b.Manifest); err != nil || b.Manifest.Format != Format {
+		return nil, fmt.Errorf("not a backup archive: invalid %s", manifestFile)
+	}
+	if b.Manifest.Version > Version {
+		return nil, fmt.Errorf("the backup has version %d, this n8nctl reads up to version %d", b.Manifest.Version, Version)
+	}
+	for name, want := range b.Manifest.Files {
+		data, ok := files[name]
+		if !ok {
+			return nil, fmt.Errorf("the backup is missing %s", name)
+		}
+		sum := sha256.Sum256(data)
+		if hex.EncodeToString(sum[:]) != want {
+			return nil, fmt.Errorf("%s in the backup is corrupt: checksum mismatch", name)
+		}
+	}
+
+	var names []string
+	for name := range b.Manifest.Files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		data := files[name]
+		switch {
+		case strings.HasPrefix(name, workflowsDir+"/"):
+			b.Workflows = append(b.Workflows, data)
+		case name == tagsFile:
+			err = json.Unmarshal(data, &b.Tags)
+		case name == variablesFile:
+			err = json.Unmarshal(data, &b.Variables)
+		case name == credentialsFile:
+			b.Credentials = data
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", name, err)
+		}
+	}
+	return b, nil
+}
+
+func orEmpty(list []json.RawMessage) []json.RawMessage {
+	if list == nil {
+		return []json.RawMessage{}
+	}
+	return list
+}
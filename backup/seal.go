@@ -0,0 +1,78 @@
+package backup
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// sealIterations is the PBKDF2-SHA256 work factor OWASP recommends.
+const sealIterations = 600_000
+
+// sealed is the envelope of data encrypted with a passphrase: AES-256-GCM
+// with a key derived by PBKDF2-SHA256.
+type sealed struct {
+	KDF        string `json:"kdf"`
+	Iterations int    `json:"iterations"`
+	Salt       []byte `json:"salt"`
+	Cipher     string `json:"cipher"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// Seal encrypts plaintext with passphrase.
+func Seal(plaintext []byte, passphrase string) ([]byte, error) {
+	if passphrase == "" {
+		return nil, errors.New("an empty passphrase cannot protect a backup")
+	}
+	s := sealed{KDF: "pbkdf2-sha256", Iterations: sealIterations, Cipher: "aes-256-gcm", Salt: make([]byte, 16)}
+	rand.Read(s.Salt)
+	gcm, err := sealCipher(passphrase, s)
+	if err != nil {
+		return nil, err
+	}
+	s.Nonce = make([]byte, gcm.NonceSize())
+	rand.Read(s.Nonce)
+	s.Ciphertext = gcm.Seal(nil, s.Nonce, plaintext, nil)
+	return json.MarshalIndent(s, "", "  ")
+}
+
+// Open decrypts data sealed with passphrase.
+func Open(data []byte, passphrase string) ([]byte, error) {
+	var s sealed
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("invalid encrypted data: %w", err)
+	}
+	if s.KDF != "pbkdf2-sha256" || s.Cipher != "aes-256-gcm" {
+		return nil, fmt.Errorf("unsupported encryption %s with %s", s.Cipher, s.KDF)
+	}
+	gcm, err := sealCipher(passphrase, s)
+	if err != nil {
+		return nil, err
+	}
+	if len(s.Nonce) != gcm.NonceSize() {
+		return nil, errors.New("invalid encrypted data: bad nonce")
+	}
+	plaintext, err := gcm.Open(nil, s.Nonce, s.Ciphertext, nil)
+	if err != nil {
+		return nil, errors.New("wrong passphrase, or the data is corrupt")
+	}
+	return plaintext, nil
+}
+
+func sealCipher(passphrase string, s sealed) (cipher.AEAD, error) {
+	key, err := pbkdf2.Key(sha256.New, passphrase, s.Salt, s.Iterations, 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,252 @@
+// Package bot runs n8nctl commands asked for in chat. Messages are matched
+// against an allowlist of commands, which run with --read-only unless they
+// need approval, in which case they wait until an approver approves them.
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/brandon-kyle-bailey/n8nctl/config"
+	"github.com/brandon-kyle-bailey/n8nctl/daemon"
+)
+
+// DefaultCommands are the commands of a bot whose project configures none.
+var DefaultCommands = []config.BotCommand{
+	{Pattern: "list workflows", Run: []string{"workflows", "list", "--columns", "id,name,active,updatedAt"}},
+	{Pattern: "show failed executions", Run: []string{"executions", "list", "--query", `[.data[] | select(.status == "error") | {id, workflowId, startedAt}]`}},
+	{Pattern: "plan", Run: []string{"workflows", "plan"}},
+	{Pattern: "plan on {profile}", Run: []string{"--profile", "{profile}", "workflows", "plan"}},
+	{Pattern: "deploy {workflow} to {profile}", Run: []string{"--profile", "{profile}", "workflows", "deploy", "workflows/{workflow}.yaml"}, Approval: true},
+	{Pattern: "deploy to {profile}", Run: []string{"--profile", "{profile}", "workflows", "deploy", "--dir", "workflows"}, Approval: true},
+}
+
+// ApprovalTTL is how long a command waits for approval.
+const ApprovalTTL = time.Hour
+
+// maxOutput is how much of the output of a command a reply quotes.
+const maxOutput = 3000
+
+// word is what a placeholder matches. It cannot start with a dash, so
+// placeholders never turn into flags, nor contain path separators.
+var word = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_.-]*$`)
+
+// Bot matches chat messages against its commands and runs them.
+type Bot struct {
+	Commands  []config.BotCommand
+	Users     []string
+	Approvers []string
+	// Dir is the absolute path of the project the commands run in.
+	Dir string
+	// Log, when set, gets a line for every command asked for.
+	Log io.Writer
+
+	mu      sync.Mutex
+	pending map[string]*Request
+}
+
+// New returns a bot for the bot settings of a project.
+func New(settings config.BotSettings, dir string) (*Bot, error) {
+	commands := settings.Commands
+	if len(commands) == 0 {
+		commands = DefaultCommands
+	}
+	for _, c := range commands {
+		if strings.TrimSpace(c.Pattern) == "" || len(c.Run) == 0 {
+			return nil, fmt.Errorf("bot commands need a pattern and the arguments to run")
+		}
+		if c.Approval && len(settings.Users) == 0 {
+			return nil, fmt.Errorf("bot command %q needs approval, set the users who may ask for and approve commands", c.Pattern)
+		}
+		for _, name := range placeholders(strings.Join(c.Run, " ")) {
+			if !slices.Contains(placeholders(c.Pattern), name) {
+				return nil, fmt.Errorf("bot command %q runs {%s}, which its pattern does not have", c.Pattern, name)
+			}
+		}
+	}
+	return &Bot{
+		Commands:  commands,
+		Users:     settings.Users,
+		Approvers: settings.Approvers,
+		Dir:       dir,
+		pending:   map[string]*Request{},
+	}, nil
+}
+
+// Request is a command asked for in chat.
+type Request struct {
+	User    string
+	Text    string
+	Args    []string
+	Command config.BotCommand
+	Created time.Time
+}
+
+// ErrHelp is returned by Parse for messages asking what the bot can do.
+var ErrHelp = errors.New("help")
+
+// Parse matches a message of user against the commands. Its errors are
+// meant to be replied to the user.
+func (b *Bot) Parse(user, text string) (*Request, error) {
+	text = strings.Join(strings.Fields(text), " ")
+	if text == "" || strings.EqualFold(text, "help") {
+		return nil, ErrHelp
+	}
+	if len(b.Users) > 0 && !slices.Contains(b.Users, user) {
+		return nil, fmt.Errorf("you are not allowed to run commands")
+	}
+	for _, c := range b.Commands {
+		values, ok := match(c.Pattern, text)
+		if !ok {
+			continue
+		}
+		args := make([]string, len(c.Run))
+		for i, arg := range c.Run {
+			for name, value := range values {
+				arg = strings.ReplaceAll(arg, "{"+name+"}", value)
+			}
+			args[i] = arg
+		}
+		b.logf("%s asked for %q", user, text)
+		return &Request{User: user, Text: text, Args: args, Command: c, Created: time.Now()}, nil
+	}
+	return nil, fmt.Errorf("unknown command %q, say help for the commands", text)
+}
+
+// Help lists the commands of the bot.
+func (b *Bot) Help() string {
+	var lines []string
+	for _, c := range b.Commands {
+		line := "• " + c.Pattern
+		if c.Approval {
+			line += " (needs approval)"
+		}
+		lines = append(lines, line)
+	}
+	return "Commands:\n" + strings.Join(lines, "\n")
+}
+
+// Hold keeps req until it is approved under key, such as the ID of the chat
+// message asking for approval.
+func (b *Bot) Hold(key string, req *Request) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for k, r := range b.pending {
+		if time.Since(r.Created) > ApprovalTTL {
+			delete(b.pending, k)
+		}
+	}
+	b.pending[key] = req
+}
+
+// Approve returns the request held under key when approver may approve it,
+// and forgets it. It returns nil, without an error, when nothing is held
+// under key.
+func (b *Bot) Approve(key, approver string) (*Request, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	req, ok := b.pending[key]
+	if !ok {
+		return nil, nil
+	}
+	if time.Since(req.Created) > ApprovalTTL {
+		delete(b.pending, key)
+		return nil, fmt.Errorf("the request expired, ask again")
+	}
+	// Approvers may ask for commands too, but not approve their own.
+	if approver == req.User {
+		return nil, fmt.Errorf("someone other than the requester has to approve %q", req.Text)
+	}
+	if len(b.Approvers) > 0 {
+		if !slices.Contains(b.Approvers, approver) {
+			return nil, fmt.Errorf("only approvers may approve %q", req.Text)
+		}
+	} else if !slices.Contains(b.Users, approver) {
+		return nil, fmt.Errorf("only users may approve %q", req.Text)
+	}
+	delete(b.pending, key)
+	b.logf("%s approved %q of %s", approver, req.Text, req.User)
+	return req, nil
+}
+
+// Execute runs req and returns the reply reporting its outcome. Commands
+// that were not approved run with --read-only; approved ones with --yes, as
+// the approval is their confirmation.
+func (b *Bot) Execute(ctx context.Context, req *Request) string {
+	guard := "--read-only"
+	if req.Command.Approval {
+		guard = "--yes"
+	}
+	result, err := daemon.Run(ctx, daemon.RunParams{Args: append([]string{guard}, req.Args...), Dir: b.Dir})
+	if err != nil {
+		return fmt.Sprintf("Could not run `%s`: %v", req.Text, err)
+	}
+	b.logf("%q exited with %d", req.Text, result.ExitCode)
+	output := strings.TrimSpace(result.Stdout + "\n" + result.Stderr)
+	if len(output) > maxOutput {
+		output = "…" + strings.ToValidUTF8(output[len(output)-maxOutput:], "")
+	}
+	status := "succeeded"
+	if result.ExitCode != 0 {
+		status = fmt.Sprintf("failed with exit code %d", result.ExitCode)
+	}
+	reply := fmt.Sprintf("`%s` %s", req.Text, status)
+	if output != "" {
+		reply += ":\n```\n" + output + "\n```"
+	}
+	return reply
+}
+
+func (b *Bot) logf(format string, args ...any) {
+	if b.Log != nil {
+		fmt.Fprintf(b.Log, "%s %s\n", time.Now().Format("15:04:05"), fmt.Sprintf(format, args...))
+	}
+}
+
+// match matches text against pattern word by word, case-insensitively,
+// returning the words its placeholders matched.
+func match(pattern, text string) (map[string]string, bool) {
+	want, got := strings.Fields(pattern), strings.Fields(text)
+	if len(want) != len(got) {
+		return nil, false
+	}
+	values := map[string]string{}
+	for i, w := range want {
+		if name, ok := placeholder(w); ok {
+			if !word.MatchString(got[i]) {
+				return nil, false
+			}
+			values[name] = got[i]
+			continue
+		}
+		if !strings.EqualFold(w, got[i]) {
+			return nil, false
+		}
+	}
+	return values, true
+}
+
+func placeholder(w string) (string, bool) {
+	if len(w) > 2 && strings.HasPrefix(w, "{") && strings.HasSuffix(w, "}") {
+		return w[1 : len(w)-1], true
+	}
+	return "", false
+}
+
+// placeholders returns the names of the {placeholders} in s.
+func placeholders(s string) []string {
+	var names []string
+	for _, m := range placeholderPattern.FindAllStringSubmatch(s, -1) {
+		names = append(names, m[1])
+	}
+	return names
+}
+
+var placeholderPattern = regexp.MustCompile(`\{([A-Za-z0-9_]+)\}`)
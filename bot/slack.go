@@ -0,0 +1,210 @@
+package bot
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// slackAPI is the base URL of the Slack Web API.
+var slackAPI = "https://slack.com/api"
+
+// mention is how Slack writes a mention of a user, such as the bot itself.
+var mention = regexp.MustCompile(`<@[A-Z0-9]+>`)
+
+// Slack answers the Events API of a Slack app: commands come in as mentions
+// of the bot or direct messages, replies go to their thread, and commands
+// that need approval run once an approver adds the approval reaction to the
+// bot's request for approval.
+type Slack struct {
+	Bot           *Bot
+	Token         string
+	SigningSecret string
+	// Reaction is the name of the approval reaction, e.g. white_check_mark.
+	Reaction string
+	// Context bounds the commands started from events, which outlive the
+	// requests delivering them.
+	Context context.Context
+
+	client http.Client
+	// threads maps the requests for approval to the threads they are in,
+	// as slackThreads. Like the requests they expire after ApprovalTTL.
+	threads sync.Map
+}
+
+// slackThread is the thread a request for approval was posted in.
+type slackThread struct {
+	ts      string
+	created time.Time
+}
+
+type slackEnvelope struct {
+	Type      string     `json:"type"`
+	Challenge string     `json:"challenge"`
+	Event     slackEvent `json:"event"`
+}
+
+type slackEvent struct {
+	Type        string `json:"type"`
+	Subtype     string `json:"subtype"`
+	BotID       string `json:"bot_id"`
+	User        string `json:"user"`
+	Text        string `json:"text"`
+	Channel     string `json:"channel"`
+	ChannelType string `json:"channel_type"`
+	TS          string `json:"ts"`
+	ThreadTS    string `json:"thread_ts"`
+	Reaction    string `json:"reaction"`
+	Item        struct {
+		Type    string `json:"type"`
+		Channel string `json:"channel"`
+		TS      string `json:"ts"`
+	} `json:"item"`
+}
+
+func (s *Slack) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Slack events are POSTed", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+	if err != nil {
+		return
+	}
+	if err := s.verify(r.Header, body); err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	// Slack retries events it did not get an answer to in time; the first
+	// delivery is being handled already.
+	if r.Header.Get("X-Slack-Retry-Num") != "" {
+		return
+	}
+	var envelope slackEnvelope
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		http.Error(w, "invalid event", http.StatusBadRequest)
+		return
+	}
+	switch envelope.Type {
+	case "url_verification":
+		w.Header().Set("Content-Type", "text/plain")
+		io.WriteString(w, envelope.Challenge)
+	case "event_callback":
+		// Slack wants an answer within 3 seconds, commands take longer.
+		go s.handle(envelope.Event)
+	}
+}
+
+// verify checks the signature Slack signs its requests with, refusing
+// requests older than five minutes so they cannot be replayed.
+func (s *Slack) verify(header http.Header, body []byte) error {
+	ts := header.Get("X-Slack-Request-Timestamp")
+	sent, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil || time.Since(time.Unix(sent, 0)).Abs() > 5*time.Minute {
+		return errors.New("missing or stale request timestamp")
+	}
+	mac := hmac.New(sha256.New, []byte(s.SigningSecret))
+	fmt.Fprintf(mac, "v0:%s:%s", ts, body)
+	want := "v0=" + hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(want), []byte(header.Get("X-Slack-Signature"))) {
+		return errors.New("invalid signature")
+	}
+	return nil
+}
+
+func (s *Slack) handle(event slackEvent) {
+	switch event.Type {
+	case "app_mention", "message":
+		if event.BotID != "" || event.Subtype != "" || (event.Type == "message" && event.ChannelType != "im") {
+			return
+		}
+		thread := event.ThreadTS
+		if thread == "" {
+			thread = event.TS
+		}
+		req, err := s.Bot.Parse(event.User, mention.ReplaceAllString(event.Text, ""))
+		switch {
+		case errors.Is(err, ErrHelp):
+			s.post(event.Channel, thread, s.Bot.Help())
+		case err != nil:
+			s.post(event.Channel, thread, err.Error())
+		case req.Command.Approval:
+			ts, err := s.post(event.Channel, thread, fmt.Sprintf("<@%s> asked for `%s`. An approver reacts with :%s: to this message to run it.", event.User, req.Text, s.Reaction))
+			if err == nil {
+				s.threads.Range(func(key, value any) bool {
+					if time.Since(value.(slackThread).created) > ApprovalTTL {
+						s.threads.Delete(key)
+					}
+					return true
+				})
+				s.threads.Store(event.Channel+"/"+ts, slackThread{ts: thread, created: time.Now()})
+				s.Bot.Hold(event.Channel+"/"+ts, req)
+			}
+		default:
+			s.post(event.Channel, thread, s.Bot.Execute(s.Context, req))
+		}
+	case "reaction_added":
+		if event.Reaction != s.Reaction || event.Item.Type != "message" {
+			return
+		}
+		key := event.Item.Channel + "/" + event.Item.TS
+		value, ok := s.threads.Load(key)
+		if !ok {
+			return
+		}
+		thread := value.(slackThread).ts
+		req, err := s.Bot.Approve(key, event.User)
+		switch {
+		case err != nil:
+			s.post(event.Item.Channel, thread, fmt.Sprintf("<@%s>: %v", event.User, err))
+		case req != nil:
+			s.threads.Delete(key)
+			s.post(event.Item.Channel, thread, fmt.Sprintf("Approved by <@%s>, running `%s`…", event.User, req.Text))
+			s.post(event.Item.Channel, thread, s.Bot.Execute(s.Context, req))
+		default:
+			// The request is gone, forgotten by the bot after it expired.
+			s.threads.Delete(key)
+		}
+	}
+}
+
+// post sends a message to the thread of a channel and returns its
+// timestamp, which identifies it. Failures are logged, as there is no one
+// else to tell.
+func (s *Slack) post(channel, thread, text string) (string, error) {
+	payload, _ := json.Marshal(map[string]string{"channel": channel, "thread_ts": thread, "text": text})
+	req, err := http.NewRequestWithContext(s.Context, http.MethodPost, slackAPI+"/chat.postMessage", bytes.NewReader(payload))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	req.Header.Set("Authorization", "Bearer "+s.Token)
+	resp, err := s.client.Do(req)
+	if err == nil {
+		defer resp.Body.Close()
+		var result struct {
+			OK    bool   `json:"ok"`
+			Error string `json:"error"`
+			TS    string `json:"ts"`
+		}
+		if err = json.NewDecoder(resp.Body).Decode(&result); err == nil {
+			if result.OK {
+				return result.TS, nil
+			}
+			err = fmt.Errorf("slack: %s", result.Error)
+		}
+	}
+	s.Bot.logf("failed to post to %s: %v", channel, err)
+	return "", err
+}
@@ -0,0 +1,57 @@
+package bot
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+// Webhook answers chat commands POSTed as JSON by any chat integration, such
+// as {"user": "...", "text": "list workflows"}. Requests carry the secret as
+// a bearer token, which every caller shares: the user is only what the
+// caller claims, so it cannot be trusted to approve anything. Commands that
+// need approval are refused, and can only be asked for in Slack.
+type Webhook struct {
+	Bot    *Bot
+	Secret string
+}
+
+// WebhookReply is the answer to a command.
+type WebhookReply struct {
+	Reply string `json:"reply"`
+}
+
+func (h *Webhook) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "commands are POSTed", http.StatusMethodNotAllowed)
+		return
+	}
+	if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte("Bearer "+h.Secret)) != 1 {
+		http.Error(w, "invalid or missing bearer token", http.StatusUnauthorized)
+		return
+	}
+	var msg struct {
+		User string `json:"user"`
+		Text string `json:"text"`
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&msg); err != nil || msg.User == "" {
+		http.Error(w, `expected {"user": "...", "text": "..."}`, http.StatusBadRequest)
+		return
+	}
+
+	var reply WebhookReply
+	req, err := h.Bot.Parse(msg.User, msg.Text)
+	switch {
+	case errors.Is(err, ErrHelp):
+		reply.Reply = h.Bot.Help()
+	case err != nil:
+		reply.Reply = err.Error()
+	case req.Command.Approval:
+		reply.Reply = "`" + req.Text + "` needs approval, which the webhook cannot tell who gives, ask for it in Slack"
+	default:
+		reply.Reply = h.Bot.Execute(r.Context(), req)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(reply)
+}
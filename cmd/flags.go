@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"flag"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/brandon-kyle-bailey/n8nctl/config"
+	"github.com/brandon-kyle-bailey/n8nctl/telemetry"
+	"github.com/brandon-kyle-bailey/n8nctl/utils"
+	"github.com/brandon-kyle-bailey/n8nctl/workflows"
+)
+
+// globalFlags are accepted anywhere on the command line, before or after the
+// entity and action.
+type globalFlags struct {
+	yes            bool
+	nonInteractive bool
+	query          string
+	profile        string
+	env            string
+	envFiles       utils.StringList
+	timeout        time.Duration
+	proxy          string
+	project        string
+	readOnly       bool
+	insecure       bool
+	debug          bool
+	otelEndpoint   string
+	stats          bool
+	// The fault injection flags are left out of the help, they only serve
+	// resilience tests.
+	injectLatency   time.Duration
+	injectErrorRate float64
+}
+
+func newGlobalFlagSet(g *globalFlags) *flag.FlagSet {
+	fs := flag.NewFlagSet("n8nctl", flag.ContinueOnError)
+	fs.BoolVar(&g.yes, "yes", false, "Answer yes to every confirmation prompt")
+	fs.BoolVar(&g.yes, "y", false, "Shorthand for --yes")
+	fs.BoolVar(&g.nonInteractive, "non-interactive", isCI(), "Never prompt, fail instead (default when CI is set)")
+	fs.StringVar(&g.profile, "profile", config.Profile, "Config profile to use (default from N8NCTL_PROFILE)")
+	fs.StringVar(&g.env, "env", workflows.Env, "Patch workflow files with the overlays of this env, e.g. prod (default from N8NCTL_ENV)")
+	fs.Var(&g.envFiles, "env-file", "Read ${{VAR}} values from this file instead of .env and .env.sops.*, or .env.<env> with --env (repeatable, later files win)")
+	fs.DurationVar(&g.timeout, "timeout", 0, "Limit each API request to this long (default from the config, else 60s)")
+	fs.StringVar(&g.proxy, "proxy", "", "Proxy URL for API requests (default from the config or HTTP_PROXY/HTTPS_PROXY)")
+	fs.StringVar(&g.project, "project", "", "Project to scope lists and creations to, by ID or name (default from the config)")
+	fs.BoolVar(&g.readOnly, "read-only", false, "Refuse every command that changes the instance")
+	fs.BoolVar(&g.insecure, "insecure-skip-verify", false, "Do not verify the TLS certificate of the instance")
+	fs.BoolVar(&g.debug, "debug", false, "Trace every API request and response to stderr")
+	fs.StringVar(&g.otelEndpoint, "otel-endpoint", telemetry.Endpoint(), "Export traces to this OTLP/HTTP collector (default from OTEL_EXPORTER_OTLP_ENDPOINT)")
+	fs.BoolVar(&g.stats, "stats", false, "Print a summary of the API calls and wall time, retries and cache hits to stderr")
+	fs.DurationVar(&g.injectLatency, "inject-latency", 0, "Delay every API request by this long")
+	fs.Float64Var(&g.injectErrorRate, "inject-error-rate", 0, "Fail this fraction of API requests (0-1) with a 503")
+	fs.StringVar(&g.query, "query", "", "jq expression applied to every JSON response")
+	return fs
+}
+
+// commandFlags are flags commands define themselves under the name of a
+// global flag. After the command's words the flag is the command's, before
+// them the global one.
+var commandFlags = map[string][]string{
+	"executions await-webhook": {"timeout"},
+	"executions retry":         {"timeout"},
+	"workflows run":            {"timeout"},
+}
+
+// splitGlobalFlags removes the flags known to fs from args, wherever they
+// appear, parses them, and returns the remaining arguments in order.
+func splitGlobalFlags(fs *flag.FlagSet, args []string) ([]string, error) {
+	var rest, global, words []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			rest = append(rest, args[i:]...)
+			break
+		}
+		if !strings.HasPrefix(arg, "-") {
+			rest = append(rest, arg)
+			words = append(words, arg)
+			continue
+		}
+		name, _, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		f := fs.Lookup(name)
+		if len(words) >= 2 && slices.Contains(commandFlags[words[0]+" "+words[1]], name) {
+			f = nil
+		}
+		if f == nil {
+			rest = append(rest, arg)
+			continue
+		}
+		global = append(global, arg)
+		if b, ok := f.Value.(interface{ IsBoolFlag() bool }); (ok && b.IsBoolFlag()) || hasValue {
+			continue
+		}
+		if i+1 < len(args) {
+			global = append(global, args[i+1])
+			i++
+		}
+	}
+	return rest, fs.Parse(global)
+}
+
+// isCI reports whether n8nctl runs in a CI pipeline, as signalled by the CI
+// environment variable most CI systems set.
+func isCI() bool {
+	switch strings.ToLower(os.Getenv("CI")) {
+	case "", "0", "false", "no":
+		return false
+	}
+	return true
+}
@@ -2,23 +2,71 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/brandon-kyle-bailey/n8nctl/config"
 	"github.com/brandon-kyle-bailey/n8nctl/entities"
+	"github.com/brandon-kyle-bailey/n8nctl/pkg/n8n"
+	"github.com/brandon-kyle-bailey/n8nctl/prompt"
+	"github.com/brandon-kyle-bailey/n8nctl/state"
+	"github.com/brandon-kyle-bailey/n8nctl/telemetry"
+	"github.com/brandon-kyle-bailey/n8nctl/utils"
+	"github.com/brandon-kyle-bailey/n8nctl/workflows"
 )
 
 func Execute() {
-	if len(os.Args) < 2 {
-		entities.PrintHelp()
+	var global globalFlags
+	args, err := splitGlobalFlags(newGlobalFlagSet(&global), os.Args[1:])
+	if err != nil {
+		os.Exit(1)
+	}
+	prompt.AssumeYes = global.yes
+	prompt.NonInteractive = global.nonInteractive
+	if err := useEnvironment(&global); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
+	config.Profile = global.profile
+	state.UseProfile(global.profile)
+	telemetry.Init(global.otelEndpoint)
+	defer telemetry.Shutdown(0)
+	ctx, span := telemetry.Start(interruptContext(), commandName(args))
+	span.SetAttribute("n8nctl.profile", global.profile)
+	entities.Context = ctx
+	entities.Debug = global.debug
+	entities.Project = global.project
+	if global.injectErrorRate < 0 || global.injectErrorRate > 1 {
+		fmt.Fprintln(os.Stderr, "Error: --inject-error-rate must be between 0 and 1")
+		telemetry.Exit(1)
+	}
+	entities.Faults = n8n.Faults{Latency: global.injectLatency, ErrorRate: global.injectErrorRate}
+	if global.stats {
+		entities.Stats = &n8n.Stats{}
+		started := time.Now()
+		telemetry.AtExit(func(int) { printStats(entities.Stats, time.Since(started)) })
+	}
+	if global.query != "" {
+		if err := utils.SetQuery(global.query); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			telemetry.Exit(1)
+		}
+	}
 
-	entity := os.Args[1]
+	if len(args) < 1 {
+		entities.PrintHelp()
+		telemetry.Exit(1)
+	}
+
+	entity := args[0]
 
 	if entity == "login" {
-		entities.HandleLogin(os.Args[2:])
+		entities.HandleLogin(args[1:])
 		return
 	}
 
@@ -27,18 +75,221 @@ func Execute() {
 		return
 	}
 
+	if entity == "dev" {
+		entities.HandleDev(args[1:])
+		return
+	}
+
+	if entity == "export" {
+		entities.HandleExport(args[1:], loadConfig(global))
+		return
+	}
+
+	if entity == "backup" {
+		entities.HandleBackup(args[1:], loadConfig(global))
+		return
+	}
+
+	if entity == "restore" {
+		entities.HandleRestore(args[1:], loadConfig(global))
+		return
+	}
+
+	if entity == "undo" {
+		entities.HandleUndo(args[1:], loadConfig(global))
+		return
+	}
+
+	if entity == "daemon" {
+		entities.HandleDaemon(args[1:])
+		return
+	}
+
+	if entity == "cron" {
+		entities.HandleCron(args[1:])
+		return
+	}
+
+	if entity == "bot" {
+		entities.HandleBot(args[1:])
+		return
+	}
+
+	if entity == "env" {
+		entities.HandleEnv(args[1:])
+		return
+	}
+
+	if entity == "fix" {
+		entities.HandleFix(args[1:], func() config.Config { return loadConfig(global) })
+		return
+	}
+
+	if entity == "mock-server" {
+		entities.HandleMockServer(args[1:])
+		return
+	}
+
+	if entity == "schema" {
+		entities.HandleSchema(args[1:], func() config.Config { return loadConfig(global) })
+		return
+	}
+
+	// Deploys to every environment run one deploy per environment, each
+	// loading the config of its own profile.
+	if entity == "workflows" && len(args) > 1 && args[1] == "deploy" && slices.ContainsFunc(args[2:], isAllEnvsFlag) {
+		entities.HandleDeployAllEnvs(args[2:])
+		return
+	}
+
 	actions, ok := entities.Entities[entity]
 	if !ok {
 		fmt.Printf("Unknown entity: %s\n\n", entity)
 		entities.PrintHelp()
-		os.Exit(1)
+		telemetry.Exit(1)
 	}
 
+	entities.HandleEntityCommand(entity, args[1:], actions, loadConfig(global))
+}
+
+// isAllEnvsFlag reports whether arg is the --all-envs flag of deploy.
+func isAllEnvsFlag(arg string) bool {
+	name, value, _ := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+	return strings.HasPrefix(arg, "-") && "--"+name == entities.AllEnvsFlag && value != "false"
+}
+
+// useEnvironment selects the overlays of the env of --env, and the profile,
+// project and variables environments.yaml declares for it, if any. The
+// project of --project wins over the declared one, and the files of
+// --env-file over the declared env_file and .env.<env>.
+func useEnvironment(global *globalFlags) error {
+	for _, path := range global.envFiles {
+		if _, err := os.Stat(path); err != nil {
+			return fmt.Errorf("--env-file %s not found", path)
+		}
+	}
+	if len(global.envFiles) > 0 {
+		workflows.EnvFiles = global.envFiles
+	}
+	if global.env == "" {
+		return nil
+	}
+	if strings.ContainsAny(global.env, `/\`) || global.env == "." || global.env == ".." {
+		return fmt.Errorf("--env names an env such as prod, not a path: %s", global.env)
+	}
+	workflows.Env = global.env
+	env, err := config.LookupEnvironment(global.env)
+	if err != nil {
+		return err
+	}
+	if env == nil {
+		if len(global.envFiles) == 0 {
+			workflows.UseEnvDotFile(global.env)
+		}
+		return nil
+	}
+	workflows.EnvDeclared = true
+	if env.Profile != "" {
+		global.profile = env.Profile
+	}
+	if env.Project != "" && global.project == "" {
+		global.project = env.Project
+	}
+	switch {
+	case len(global.envFiles) > 0:
+	case env.EnvFile != "":
+		if _, err := os.Stat(env.EnvFile); err != nil {
+			return fmt.Errorf("%s: env_file %s of %s not found", config.EnvironmentsFile, env.EnvFile, env.Name)
+		}
+		workflows.EnvFiles = []string{env.EnvFile}
+	default:
+		workflows.UseEnvDotFile(global.env)
+	}
+	return nil
+}
+
+// commandName names the trace of a command after its entity and action,
+// e.g. "n8nctl workflows deploy".
+func commandName(args []string) string {
+	name := "n8nctl"
+	for _, arg := range args[:min(len(args), 2)] {
+		if strings.HasPrefix(arg, "-") {
+			break
+		}
+		name += " " + arg
+	}
+	return name
+}
+
+// printStats prints the footer of --stats.
+func printStats(stats *n8n.Stats, elapsed time.Duration) {
+	calls := fmt.Sprintf("%d API calls", stats.Requests.Load())
+	if stats.Requests.Load() == 1 {
+		calls = "1 API call"
+	}
+	var notes []string
+	if failed := stats.Failed.Load(); failed > 0 {
+		notes = append(notes, fmt.Sprintf("%d failed", failed))
+	}
+	if retries := stats.Retries.Load(); retries > 0 {
+		notes = append(notes, fmt.Sprintf("%d retries", retries))
+	}
+	if hits := stats.CacheHits.Load() + workflows.CacheHits.Load(); hits > 0 {
+		notes = append(notes, fmt.Sprintf("%d cache hits", hits))
+	}
+	if len(notes) > 0 {
+		calls += " (" + strings.Join(notes, ", ") + ")"
+	}
+	fmt.Fprintf(os.Stderr, "\n%s, %s sent, %s received in %s\n", calls,
+		utils.FormatBytes(stats.BytesSent.Load()), utils.FormatBytes(stats.BytesReceived.Load()),
+		elapsed.Round(time.Millisecond))
+}
+
+// loadConfig loads the selected profile and applies the global flags that
+// override its settings.
+func loadConfig(global globalFlags) config.Config {
 	cfg, err := config.LoadConfig()
 	if err != nil {
 		fmt.Printf("Error loading config: %v\nPlease run `n8nctl login` first.\n", err)
-		os.Exit(1)
+		telemetry.Exit(1)
+	}
+	if global.timeout > 0 {
+		cfg.Timeout = global.timeout.String()
 	}
+	if global.proxy != "" {
+		cfg.ProxyURL = global.proxy
+		if _, err := cfg.Proxy(); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			telemetry.Exit(1)
+		}
+	}
+	if global.project != "" {
+		cfg.Project = global.project
+	}
+	if global.readOnly {
+		cfg.ReadOnly = true
+	}
+	if global.insecure {
+		cfg.InsecureSkipVerify = true
+		fmt.Fprintln(os.Stderr, "Warning: TLS certificate verification is disabled.")
+	}
+	return cfg
+}
 
-	entities.HandleEntityCommand(entity, os.Args[2:], actions, cfg)
+// interruptContext returns a context cancelled on the first Ctrl-C, which
+// abandons in-flight API requests so the command can fail cleanly and still
+// run its cleanup, such as removing containers or restoring a workflow. Only
+// a second Ctrl-C exits right away.
+func interruptContext() context.Context {
+	ctx, cancel := context.WithCancel(context.Background())
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt)
+	go func() {
+		<-signals
+		cancel()
+		<-signals
+		fmt.Fprintln(os.Stderr, "Interrupted again, exiting without cleaning up.")
+		telemetry.Exit(130)
+	}()
+	return ctx
 }
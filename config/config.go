@@ -2,14 +2,292 @@
 package config
 
 import (
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/brandon-kyle-bailey/n8nctl/cloud"
+	"github.com/brandon-kyle-bailey/n8nctl/pkg/n8n"
+	"github.com/brandon-kyle-bailey/n8nctl/secrets"
 )
 
 type Config struct {
 	APIToken string `json:"api_token"`
 	BaseURL  string `json:"base_url"`
+	// Timeout limits each API request, as a duration such as "30s".
+	Timeout string `json:"timeout,omitempty"`
+	// ProxyURL is the proxy for API requests, overriding HTTP_PROXY and HTTPS_PROXY.
+	ProxyURL string `json:"proxy_url,omitempty"`
+	// CACert is a PEM file of certificates to trust in addition to the
+	// system roots, for instances behind a private CA.
+	CACert string `json:"ca_cert,omitempty"`
+	// ClientCert and ClientKey are PEM files presented to servers that
+	// require mutual TLS.
+	ClientCert string `json:"client_cert,omitempty"`
+	ClientKey  string `json:"client_key,omitempty"`
+	// Project scopes lists and creations to a project, by ID or name, for
+	// users working within one team project of a shared instance.
+	Project string `json:"project,omitempty"`
+	// ReadOnly blocks every command that changes the instance, to give
+	// analysts safe access to production.
+	ReadOnly bool `json:"read_only,omitempty"`
+	// ExecutionQuota is the number of executions a month the instance's plan
+	// allows, which workflows estimate warns about.
+	ExecutionQuota int `json:"execution_quota,omitempty"`
+	// Vault is the HashiCorp Vault that ${{vault:path#field}} references in
+	// workflow templates are read from.
+	Vault VaultConfig `json:"vault,omitzero"`
+	// AWS and GCP are the cloud accounts that ${{aws-sm:name#field}} and
+	// ${{gcp-sm:projects/p/secrets/s}} references are read from.
+	AWS AWSConfig `json:"aws,omitzero"`
+	GCP GCPConfig `json:"gcp,omitzero"`
+	// InsecureSkipVerify disables certificate verification. It is only ever
+	// set by the --insecure-skip-verify flag and never saved.
+	InsecureSkipVerify bool `json:"-"`
+}
+
+// VaultConfig locates and authenticates to a Vault server, by token or else
+// by AppRole. The standard VAULT_ADDR, VAULT_TOKEN and VAULT_NAMESPACE
+// environment variables apply to the fields left empty.
+type VaultConfig struct {
+	Address      string `json:"address,omitempty"`
+	Namespace    string `json:"namespace,omitempty"`
+	Token        string `json:"token,omitempty"`
+	RoleID       string `json:"role_id,omitempty"`
+	SecretID     string `json:"secret_id,omitempty"`
+	AppRoleMount string `json:"approle_mount,omitempty"`
+}
+
+// VaultResolver returns the secrets resolver for the profile's Vault.
+func (c Config) VaultResolver() *secrets.Vault {
+	v := c.Vault
+	return &secrets.Vault{
+		Address:      firstNonEmpty(v.Address, os.Getenv("VAULT_ADDR")),
+		Namespace:    firstNonEmpty(v.Namespace, os.Getenv("VAULT_NAMESPACE")),
+		Token:        firstNonEmpty(v.Token, os.Getenv("VAULT_TOKEN")),
+		RoleID:       v.RoleID,
+		SecretID:     v.SecretID,
+		AppRoleMount: v.AppRoleMount,
+		HTTPClient:   &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// AWSConfig holds the AWS credentials of a profile. Without an access key the
+// AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN environment
+// variables apply, and without a region AWS_REGION.
+type AWSConfig struct {
+	Region          string `json:"region,omitempty"`
+	AccessKeyID     string `json:"access_key_id,omitempty"`
+	SecretAccessKey string `json:"secret_access_key,omitempty"`
+	SessionToken    string `json:"session_token,omitempty"`
+	// SecretsEndpoint overrides the Secrets Manager endpoint of the region.
+	SecretsEndpoint string `json:"secrets_endpoint,omitempty"`
+}
+
+// GCPConfig holds the Google Cloud credentials of a profile. Without a
+// credentials file GOOGLE_APPLICATION_CREDENTIALS applies.
+type GCPConfig struct {
+	CredentialsFile string `json:"credentials_file,omitempty"`
+	// SecretsEndpoint overrides https://secretmanager.googleapis.com.
+	SecretsEndpoint string `json:"secrets_endpoint,omitempty"`
+}
+
+// AWSSecretsResolver returns the secrets resolver for the profile's AWS
+// Secrets Manager.
+func (c Config) AWSSecretsResolver() *secrets.AWSSecretsManager {
+	a := c.AWS
+	return &secrets.AWSSecretsManager{
+		Credentials: cloud.AWSCredentials{
+			AccessKeyID:     a.AccessKeyID,
+			SecretAccessKey: a.SecretAccessKey,
+			SessionToken:    a.SessionToken,
+			Region:          firstNonEmpty(a.Region, os.Getenv("AWS_REGION"), os.Getenv("AWS_DEFAULT_REGION")),
+		},
+		Endpoint:   a.SecretsEndpoint,
+		HTTPClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// GCPSecretsResolver returns the secrets resolver for the profile's Google
+// Cloud Secret Manager.
+func (c Config) GCPSecretsResolver() *secrets.GCPSecretManager {
+	return &secrets.GCPSecretManager{
+		CredentialsFile: c.GCP.CredentialsFile,
+		Endpoint:        c.GCP.SecretsEndpoint,
+		HTTPClient:      &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// APIBase returns the URL of the instance's public API, which the API paths
+// such as /workflows are appended to.
+func (c Config) APIBase() string {
+	return strings.TrimRight(c.BaseURL, "/") + "/api/v1"
+}
+
+// cloudDomain hosts the n8n Cloud instances, which are always served at the
+// root of their own subdomain.
+const cloudDomain = ".app.n8n.cloud"
+
+// editorRoute splits a path into the base and an editor page or API path
+// ending it, which users tend to copy from the address bar along with the
+// base URL. Only whole routes at the end are recognized, so a base path that
+// merely contains a segment such as /projects/ keeps it.
+var editorRoute = regexp.MustCompile(`^(.*?)(?:` + strings.Join([]string{
+	`/api/v1(?:/.*)?`,
+	`/rest/(?:login|me|workflows|credentials|executions|projects|settings|users|node-types)(?:/.*)?`,
+	`/home(?:/(?:workflows|credentials|executions)(?:/[^/]+)?)?`,
+	`/workflow/[^/]+(?:/(?:executions|debug)(?:/[^/]+)?)?`,
+	`/workflows`,
+	`/projects/[^/]+/(?:workflows|credentials|executions|variables|settings)(?:/[^/]+)?`,
+	`/settings(?:/[^/]+)?`,
+	`/signin`,
+}, "|") + `)/?$`)
+
+// NormalizeBaseURL checks that raw is the address of an n8n instance and
+// returns it in the form API paths are appended to. The scheme and host are
+// lowercased, while a path prefix such as https://host/automation keeps its
+// case; addresses without a scheme are assumed to use https, and editor or
+// API paths pasted along with the address are dropped.
+func NormalizeBaseURL(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if raw != "" && !strings.Contains(raw, "://") {
+		raw = "https://" + raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil || (strings.ToLower(u.Scheme) != "http" && strings.ToLower(u.Scheme) != "https") || u.Host == "" {
+		return "", fmt.Errorf("invalid base URL %q, expected a URL such as https://n8n.example.com", raw)
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.ToLower(u.Host)
+	u.RawQuery, u.Fragment, u.RawPath = "", "", ""
+	if strings.HasSuffix(u.Hostname(), cloudDomain) {
+		u.Path = ""
+	}
+	if m := editorRoute.FindStringSubmatch(u.Path); m != nil {
+		u.Path = m[1]
+	}
+	u.Path = strings.TrimRight(u.Path, "/")
+	return u.String(), nil
+}
+
+// RequestTimeout returns the configured request timeout, falling back to the
+// client default when it is unset. LoadProfile rejects invalid values.
+func (c Config) RequestTimeout() time.Duration {
+	timeout, err := time.ParseDuration(c.Timeout)
+	if err != nil {
+		return n8n.DefaultTimeout
+	}
+	return timeout
+}
+
+// Proxy parses ProxyURL, returning nil when no proxy is configured.
+func (c Config) Proxy() (*url.URL, error) {
+	if c.ProxyURL == "" {
+		return nil, nil
+	}
+	u, err := url.Parse(c.ProxyURL)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid proxy URL %q", c.ProxyURL)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5":
+		return u, nil
+	}
+	return nil, fmt.Errorf("invalid proxy URL %q, expected an http, https or socks5 URL", c.ProxyURL)
+}
+
+// TLSConfig builds the TLS settings for API requests, returning nil when the
+// defaults apply.
+func (c Config) TLSConfig() (*tls.Config, error) {
+	if c.CACert == "" && c.ClientCert == "" && c.ClientKey == "" && !c.InsecureSkipVerify {
+		return nil, nil
+	}
+	cfg := &tls.Config{InsecureSkipVerify: c.InsecureSkipVerify}
+	if c.CACert != "" {
+		pem, err := os.ReadFile(c.CACert)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read ca_cert: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no PEM certificates found in ca_cert %s", c.CACert)
+		}
+		cfg.RootCAs = pool
+	}
+	if c.ClientCert != "" || c.ClientKey != "" {
+		if c.ClientCert == "" || c.ClientKey == "" {
+			return nil, fmt.Errorf("client_cert and client_key must be set together")
+		}
+		cert, err := tls.LoadX509KeyPair(c.ClientCert, c.ClientKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return cfg, nil
+}
+
+// DefaultProfile names the credentials stored at the top level of the config
+// file, used when no profile is selected.
+const DefaultProfile = "default"
+
+// Profile selects which named credentials LoadConfig and SaveConfig use. It
+// defaults to the N8NCTL_PROFILE environment variable.
+var Profile = os.Getenv("N8NCTL_PROFILE")
+
+// file is the on-disk layout: the default profile at the top level, so
+// configs written before profiles existed keep working, and the others by name.
+type file struct {
+	Config
+	Profiles map[string]Config `json:"profiles,omitempty"`
+}
+
+// String redacts the API token so a Config can never leak it when printed.
+func (c Config) String() string {
+	return fmt.Sprintf("{APIToken:%s BaseURL:%s}", RedactedToken(c.APIToken), c.BaseURL)
+}
+
+// GoString redacts the API token for %#v as well.
+func (c Config) GoString() string {
+	return fmt.Sprintf("config.Config{APIToken:%q, BaseURL:%q}", RedactedToken(c.APIToken), c.BaseURL)
+}
+
+// RedactedToken returns a placeholder that shows whether a token is set without revealing it.
+func RedactedToken(token string) string {
+	if token == "" {
+		return ""
+	}
+	return "[REDACTED]"
+}
+
+// Redact replaces every occurrence of the token in s with a placeholder.
+// Very short values are left alone as they would mangle unrelated text;
+// real n8n API keys are far longer.
+func Redact(s, token string) string {
+	if len(token) < 8 {
+		return s
+	}
+	return strings.ReplaceAll(s, token, RedactedToken(token))
 }
 
 func configPath() (string, error) {
@@ -26,32 +304,94 @@ func configPath() (string, error) {
 	return filepath.Join(configDir, "config.json"), nil
 }
 
+func readFile(path string) (file, error) {
+	var cfgFile file
+	f, err := os.Open(path)
+	if err != nil {
+		return cfgFile, err
+	}
+	defer f.Close()
+	err = json.NewDecoder(f).Decode(&cfgFile)
+	return cfgFile, err
+}
+
+func isDefault(profile string) bool {
+	return profile == "" || profile == DefaultProfile
+}
+
+// LoadConfig returns the credentials of the selected profile.
 func LoadConfig() (Config, error) {
+	return LoadProfile(Profile)
+}
+
+// LoadProfile returns the credentials stored under the given profile name.
+func LoadProfile(profile string) (Config, error) {
 	path, err := configPath()
 	if err != nil {
 		return Config{}, err
 	}
-	f, err := os.Open(path)
+	cfgFile, err := readFile(path)
 	if err != nil {
 		return Config{}, err
 	}
-	defer f.Close()
-	var cfg Config
-	err = json.NewDecoder(f).Decode(&cfg)
-	return cfg, err
+	cfg := cfgFile.Config
+	if !isDefault(profile) {
+		var ok bool
+		if cfg, ok = cfgFile.Profiles[profile]; !ok {
+			return Config{}, fmt.Errorf("profile %q not found in %s", profile, path)
+		}
+	}
+	if cfg.BaseURL, err = NormalizeBaseURL(cfg.BaseURL); err != nil {
+		return Config{}, fmt.Errorf("%w in %s", err, path)
+	}
+	if cfg.Timeout != "" {
+		if _, err := time.ParseDuration(cfg.Timeout); err != nil {
+			return Config{}, fmt.Errorf("invalid timeout %q in %s: %w", cfg.Timeout, path, err)
+		}
+	}
+	if _, err := cfg.Proxy(); err != nil {
+		return Config{}, fmt.Errorf("%w in %s", err, path)
+	}
+	if _, err := cfg.TLSConfig(); err != nil {
+		return Config{}, fmt.Errorf("%w, see %s", err, path)
+	}
+	return cfg, nil
 }
 
+// SaveConfig stores cfg as the selected profile.
 func SaveConfig(cfg Config) error {
+	return SaveProfile(Profile, cfg)
+}
+
+// SaveProfile stores cfg under the given profile name, keeping the others.
+func SaveProfile(profile string, cfg Config) error {
 	path, err := configPath()
 	if err != nil {
 		return err
 	}
-	f, err := os.Create(path)
+	cfgFile, err := readFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if isDefault(profile) {
+		cfgFile.Config = cfg
+	} else {
+		if cfgFile.Profiles == nil {
+			cfgFile.Profiles = map[string]Config{}
+		}
+		cfgFile.Profiles[profile] = cfg
+	}
+
+	// The config holds API tokens, keep it readable by the current user only.
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
 	if err != nil {
 		return err
 	}
 	defer f.Close()
+	if err := f.Chmod(0600); err != nil {
+		return err
+	}
 	encoder := json.NewEncoder(f)
 	encoder.SetIndent("", "  ")
-	return encoder.Encode(cfg)
+	return encoder.Encode(cfgFile)
 }
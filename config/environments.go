@@ -0,0 +1,79 @@
+package config
+
+import (
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// EnvironmentsFile declares the instances a project deploys to, relative to
+// the working directory.
+const EnvironmentsFile = "environments.yaml"
+
+// Environment is an instance a project deploys to, selected with --env:
+//
+//	environments:
+//	  staging:
+//	    profile: staging
+//	    env_file: .env.staging
+//	  prod:
+//	    profile: prod
+//	    project: Operations
+//	    env_file: .env.prod
+type Environment struct {
+	Name string `yaml:"-"`
+	// Profile is the config profile of the instance, the selected one when
+	// empty.
+	Profile string `yaml:"profile"`
+	// Project scopes the environment to a project like --project.
+	Project string `yaml:"project"`
+	// EnvFile holds the variables of the environment, read instead of .env.
+	EnvFile string `yaml:"env_file"`
+}
+
+// LoadEnvironments returns the environments of environments.yaml in the
+// order they are declared, or none if it does not exist.
+func LoadEnvironments() ([]Environment, error) {
+	data, err := os.ReadFile(EnvironmentsFile)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var file struct {
+		Environments yaml.Node `yaml:"environments"`
+	}
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", EnvironmentsFile, err)
+	}
+	node := file.Environments
+	if node.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("%s: environments must map names to environments", EnvironmentsFile)
+	}
+	var envs []Environment
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		env := Environment{Name: node.Content[i].Value}
+		if err := node.Content[i+1].Decode(&env); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: environment %s: %w", EnvironmentsFile, env.Name, err)
+		}
+		envs = append(envs, env)
+	}
+	return envs, nil
+}
+
+// LookupEnvironment returns the environment name declares in
+// environments.yaml, if any.
+func LookupEnvironment(name string) (*Environment, error) {
+	envs, err := LoadEnvironments()
+	if err != nil {
+		return nil, err
+	}
+	for i := range envs {
+		if envs[i].Name == name {
+			return &envs[i], nil
+		}
+	}
+	return nil, nil
+}
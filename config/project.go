@@ -0,0 +1,118 @@
+package config
+
+import (
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ProjectFile holds project-level settings, relative to the working directory.
+const ProjectFile = ".n8nctl.yaml"
+
+// Project is the content of .n8nctl.yaml.
+type Project struct {
+	Dev  DevSettings  `yaml:"dev"`
+	Lint LintSettings `yaml:"lint"`
+	Bot  BotSettings  `yaml:"bot"`
+	AI   AISettings   `yaml:"ai"`
+	// Conventions are the naming and tagging conventions n8nctl fix applies.
+	Conventions Conventions `yaml:"conventions"`
+}
+
+// DevSettings configures the local n8n instance started by `n8nctl dev`.
+type DevSettings struct {
+	// Version is the n8nio/n8n image tag, pinned so every contributor runs
+	// the same n8n release.
+	Version string `yaml:"version"`
+	Port    int    `yaml:"port"`
+}
+
+// LintSettings configures `workflows lint`.
+type LintSettings struct {
+	// Rules sets the severity of lint rules by ID: error, warning or off.
+	Rules map[string]string `yaml:"rules"`
+}
+
+// BotSettings configures `n8nctl bot`, which runs n8nctl commands asked for
+// in chat.
+type BotSettings struct {
+	// Commands are the commands the bot accepts, replacing the built-in
+	// ones when set.
+	Commands []BotCommand `yaml:"commands"`
+	// Users may ask for commands, by chat user ID. Anyone may when empty,
+	// which only commands without approval allow.
+	Users []string `yaml:"users"`
+	// Approvers may approve commands that need approval, by chat user ID.
+	// Any user but the requester may when empty.
+	Approvers []string `yaml:"approvers"`
+	// ApprovalReaction is the Slack reaction that approves a command,
+	// white_check_mark by default.
+	ApprovalReaction string `yaml:"approval_reaction"`
+}
+
+// BotCommand maps chat messages matching Pattern, such as
+// "deploy {workflow} to {profile}", to the n8nctl arguments of Run, in which
+// the {placeholders} of the pattern are replaced by the words they matched.
+type BotCommand struct {
+	Pattern string   `yaml:"pattern"`
+	Run     []string `yaml:"run"`
+	// Approval makes the command wait for an approver before it runs.
+	// Commands without it run with --read-only.
+	Approval bool `yaml:"approval"`
+}
+
+// AISettings configures the language model behind the experimental AI
+// commands, such as workflows generate. Nothing is sent to a model unless an
+// endpoint is configured, here or with N8NCTL_AI_ENDPOINT.
+type AISettings struct {
+	// Provider is openai, for the OpenAI API and the servers compatible with
+	// it such as Ollama or llama.cpp, or azure for Azure OpenAI.
+	Provider string `yaml:"provider"`
+	// Endpoint is the base URL of the API, e.g. https://api.openai.com/v1,
+	// http://localhost:11434/v1 or https://<resource>.openai.azure.com.
+	Endpoint string `yaml:"endpoint"`
+	// Model is the model to use, or the deployment on Azure.
+	Model string `yaml:"model"`
+	// APIKeyEnv names the environment variable holding the API key,
+	// N8NCTL_AI_API_KEY by default. Local servers need none.
+	APIKeyEnv string `yaml:"api_key_env"`
+	// APIVersion is the Azure OpenAI API version.
+	APIVersion string `yaml:"api_version"`
+}
+
+// Conventions are the naming and tagging conventions of the workflows of a
+// project.
+type Conventions struct {
+	Naming NamingConvention `yaml:"naming"`
+	Tags   TagConvention    `yaml:"tags"`
+}
+
+// NamingConvention is how workflow names are written.
+type NamingConvention struct {
+	// Case is title, sentence, lower, kebab or snake.
+	Case string `yaml:"case"`
+	// Prefix starts every name, such as the team's "[Growth] ".
+	Prefix string `yaml:"prefix"`
+}
+
+// TagConvention lists the tags every workflow carries.
+type TagConvention struct {
+	Required []string `yaml:"required"`
+}
+
+// LoadProject reads .n8nctl.yaml, returning an empty project if it does not exist.
+func LoadProject() (Project, error) {
+	var project Project
+	data, err := os.ReadFile(ProjectFile)
+	if os.IsNotExist(err) {
+		return project, nil
+	}
+	if err != nil {
+		return project, err
+	}
+	if err := yaml.Unmarshal(data, &project); err != nil {
+		return project, fmt.Errorf("failed to parse %s: %w", ProjectFile, err)
+	}
+	return project, nil
+}
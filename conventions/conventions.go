@@ -0,0 +1,125 @@
+// Package conventions applies the naming and tagging conventions of
+// .n8nctl.yaml to workflows.
+package conventions
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/brandon-kyle-bailey/n8nctl/config"
+)
+
+// Cases are the case styles names can be written in.
+var Cases = []string{"title", "sentence", "lower", "kebab", "snake"}
+
+// Check reports conventions that cannot be applied, such as an unknown case.
+func Check(c config.Conventions) error {
+	if c.Naming.Case != "" && !slices.Contains(Cases, c.Naming.Case) {
+		return fmt.Errorf("unknown conventions.naming.case %q, use one of %s", c.Naming.Case, strings.Join(Cases, ", "))
+	}
+	return nil
+}
+
+// Name returns name written by the convention: in its case, after its
+// prefix. A prefix name already has, in any case, is not repeated.
+func Name(name string, c config.NamingConvention) string {
+	rest := strings.TrimSpace(name)
+	prefix := c.Prefix
+	if trimmed := strings.TrimSpace(prefix); trimmed != "" && len(rest) >= len(trimmed) && strings.EqualFold(rest[:len(trimmed)], trimmed) {
+		rest = strings.TrimSpace(rest[len(trimmed):])
+	}
+	return prefix + applyCase(rest, c.Case)
+}
+
+// MissingTags returns the required tags that tags lack.
+func MissingTags(tags []string, c config.TagConvention) []string {
+	var missing []string
+	for _, tag := range c.Required {
+		if !slices.Contains(tags, tag) {
+			missing = append(missing, tag)
+		}
+	}
+	return missing
+}
+
+func applyCase(s, style string) string {
+	switch style {
+	case "title":
+		words := strings.Fields(s)
+		for i, word := range words {
+			words[i] = upperFirst(word)
+		}
+		return strings.Join(words, " ")
+	case "sentence":
+		words := strings.Fields(s)
+		for i, word := range words {
+			// Acronyms such as API keep their case.
+			if !isUpper(word) {
+				word = strings.ToLower(word)
+			}
+			if i == 0 {
+				word = upperFirst(word)
+			}
+			words[i] = word
+		}
+		return strings.Join(words, " ")
+	case "lower":
+		return strings.Join(strings.Fields(strings.ToLower(s)), " ")
+	case "kebab":
+		return strings.Join(splitWords(s), "-")
+	case "snake":
+		return strings.Join(splitWords(s), "_")
+	}
+	return s
+}
+
+// splitWords splits s into lower case words at spaces, punctuation and the
+// humps of camelCase.
+func splitWords(s string) []string {
+	var words []string
+	var word []rune
+	flush := func() {
+		if len(word) > 0 {
+			words = append(words, strings.ToLower(string(word)))
+			word = word[:0]
+		}
+	}
+	runes := []rune(s)
+	for i, r := range runes {
+		switch {
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			flush()
+			continue
+		case unicode.IsUpper(r) && i > 0 && len(word) > 0:
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				flush()
+			}
+		}
+		word = append(word, r)
+	}
+	flush()
+	return words
+}
+
+func upperFirst(word string) string {
+	r, size := utf8.DecodeRuneInString(word)
+	return string(unicode.ToUpper(r)) + word[size:]
+}
+
+func isUpper(word string) bool {
+	letters := 0
+	for _, r := range word {
+		if unicode.IsLetter(r) {
+			if !unicode.IsUpper(r) {
+				return false
+			}
+			letters++
+		}
+	}
+	return letters > 1
+}
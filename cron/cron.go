@@ -0,0 +1,192 @@
+// Package cron parses cron expressions and runs n8nctl commands on them.
+package cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule is a parsed cron expression, with a set of allowed values per
+// field.
+type Schedule struct {
+	seconds, minutes, hours, days, months, weekdays map[int]bool
+	// anyDay and anyWeekday record unrestricted fields, as cron runs on a
+	// day matching either when both day fields are restricted.
+	anyDay, anyWeekday bool
+}
+
+var (
+	cronMonthNames   = []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}
+	cronWeekdayNames = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+)
+
+// descriptors are the shorthands Parse accepts for common schedules.
+var descriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// Parse parses a cron expression of five fields, or six with leading
+// seconds as n8n accepts, or a descriptor such as @daily.
+func Parse(expr string) (*Schedule, error) {
+	if d, ok := descriptors[strings.ToLower(strings.TrimSpace(expr))]; ok {
+		expr = d
+	}
+	fields := strings.Fields(expr)
+	if len(fields) == 5 {
+		fields = append([]string{"0"}, fields...)
+	}
+	if len(fields) != 6 {
+		return nil, fmt.Errorf("invalid cron expression %q: expected 5 or 6 fields", expr)
+	}
+	s := &Schedule{anyDay: unrestricted(fields[3]), anyWeekday: unrestricted(fields[5])}
+	var err error
+	parse := func(field string, lo, hi int, names []string, offset int) map[int]bool {
+		if err != nil {
+			return nil
+		}
+		var set map[int]bool
+		set, err = parseCronField(field, lo, hi, names, offset)
+		if err != nil {
+			err = fmt.Errorf("invalid cron expression %q: %w", expr, err)
+		}
+		return set
+	}
+	s.seconds = parse(fields[0], 0, 59, nil, 0)
+	s.minutes = parse(fields[1], 0, 59, nil, 0)
+	s.hours = parse(fields[2], 0, 23, nil, 0)
+	s.days = parse(fields[3], 1, 31, nil, 0)
+	s.months = parse(fields[4], 1, 12, cronMonthNames, 1)
+	s.weekdays = parse(fields[5], 0, 7, cronWeekdayNames, 0)
+	if err != nil {
+		return nil, err
+	}
+	if s.weekdays[7] {
+		s.weekdays[0] = true
+	}
+	return s, nil
+}
+
+// unrestricted reports whether a day field leaves the day to the other one.
+// Like cron, any field starting with *, such as */1 or */2, does.
+func unrestricted(field string) bool {
+	return strings.HasPrefix(field, "*") || field == "?"
+}
+
+// parseCronField parses one field: *, values, ranges and steps separated by
+// commas. names, if given, are accepted for the values from offset on.
+func parseCronField(field string, lo, hi int, names []string, offset int) (map[int]bool, error) {
+	value := func(s string) (int, error) {
+		for i, name := range names {
+			if strings.EqualFold(s, name) {
+				return i + offset, nil
+			}
+		}
+		n, err := strconv.Atoi(s)
+		if err != nil || n < lo || n > hi {
+			return 0, fmt.Errorf("%q is not a value between %d and %d", s, lo, hi)
+		}
+		return n, nil
+	}
+	set := map[int]bool{}
+	for part := range strings.SplitSeq(field, ",") {
+		rng, step := part, 1
+		if r, s, ok := strings.Cut(part, "/"); ok {
+			n, err := strconv.Atoi(s)
+			if err != nil || n <= 0 {
+				return nil, fmt.Errorf("invalid step in %q", part)
+			}
+			rng, step = r, n
+		}
+		from, to := lo, hi
+		switch {
+		case rng == "*" || rng == "?":
+		case strings.Contains(rng, "-"):
+			a, b, _ := strings.Cut(rng, "-")
+			var err error
+			if from, err = value(a); err != nil {
+				return nil, err
+			}
+			if to, err = value(b); err != nil {
+				return nil, err
+			}
+		default:
+			n, err := value(rng)
+			if err != nil {
+				return nil, err
+			}
+			from, to = n, n
+			if step > 1 {
+				to = hi
+			}
+		}
+		if from > to {
+			return nil, fmt.Errorf("invalid range %q", rng)
+		}
+		for n := from; n <= to; n += step {
+			set[n] = true
+		}
+	}
+	return set, nil
+}
+
+// RunsBetween counts the times the schedule fires from start until end.
+func (s *Schedule) RunsBetween(start, end time.Time) int {
+	runs := 0
+	for t := start.Truncate(time.Minute); t.Before(end); t = t.Add(time.Minute) {
+		if s.matches(t) {
+			runs += len(s.seconds)
+		}
+	}
+	return runs
+}
+
+// Next returns the first time after t that the schedule fires, in the
+// location of t, or the zero time if it never fires within five years, as
+// for February 30.
+func (s *Schedule) Next(t time.Time) time.Time {
+	limit := t.AddDate(5, 0, 0)
+	minute := t.Truncate(time.Minute)
+	for m := minute; m.Before(limit); {
+		if !s.dayMatches(m) {
+			// Skip to the start of the next day, which DST may move.
+			y, mo, d := m.Date()
+			m = time.Date(y, mo, d+1, 0, 0, 0, 0, m.Location())
+			continue
+		}
+		if s.matches(m) {
+			for second := range 60 {
+				if at := m.Add(time.Duration(second) * time.Second); s.seconds[second] && at.After(t) {
+					return at
+				}
+			}
+		}
+		m = m.Add(time.Minute)
+	}
+	return time.Time{}
+}
+
+// matches reports whether the schedule fires in the minute of t.
+func (s *Schedule) matches(t time.Time) bool {
+	return s.dayMatches(t) && s.hours[t.Hour()] && s.minutes[t.Minute()]
+}
+
+// dayMatches reports whether the schedule fires on the day of t. Cron runs
+// on a day matching either day field when both are restricted.
+func (s *Schedule) dayMatches(t time.Time) bool {
+	if !s.months[int(t.Month())] {
+		return false
+	}
+	day, weekday := s.days[t.Day()], s.weekdays[int(t.Weekday())]
+	if !s.anyDay && !s.anyWeekday {
+		return day || weekday
+	}
+	return day && weekday
+}
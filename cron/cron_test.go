@@ -0,0 +1,34 @@
+package cron
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNextWithStarStepDayField(t *testing.T) {
+	// 2024-01-01 is a Monday.
+	from := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		expr string
+		want time.Time
+	}{
+		// */1 restricts no day, so only the weekday counts: the next Friday.
+		{"0 9 */1 * 5", time.Date(2024, 1, 5, 9, 0, 0, 0, time.UTC)},
+		{"0 9 * * 5", time.Date(2024, 1, 5, 9, 0, 0, 0, time.UTC)},
+		// Both day fields restricted: either matching runs, the 3rd first.
+		{"0 9 3 * 5", time.Date(2024, 1, 3, 9, 0, 0, 0, time.UTC)},
+		// A weekday starting with * counts as unrestricted even when it
+		// steps, so both fields must match: an odd day on Sun, Tue, Thu or
+		// Sat.
+		{"0 9 1-31/2 * */2", time.Date(2024, 1, 7, 9, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		s, err := Parse(tt.expr)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", tt.expr, err)
+		}
+		if got := s.Next(from); !got.Equal(tt.want) {
+			t.Errorf("Next(%q) = %s, want %s", tt.expr, got, tt.want)
+		}
+	}
+}
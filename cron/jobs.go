@@ -0,0 +1,191 @@
+package cron
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/brandon-kyle-bailey/n8nctl/daemon"
+)
+
+// File is a schedule file: n8nctl commands to run on cron expressions.
+type File struct {
+	// Timezone is the IANA name of the zone the schedules are in, the local
+	// one by default.
+	Timezone string `yaml:"timezone"`
+	Jobs     []Job  `yaml:"jobs"`
+}
+
+// Job is a command of a schedule file.
+type Job struct {
+	Name     string   `yaml:"name"`
+	Schedule string   `yaml:"schedule"`
+	Run      []string `yaml:"run"`
+	// Profile runs the command with --profile.
+	Profile string `yaml:"profile"`
+	// Timeout stops the command when it runs longer, e.g. 30m.
+	Timeout time.Duration `yaml:"timeout"`
+
+	schedule *Schedule
+}
+
+// Load reads and checks a schedule file.
+func Load(path string) (*File, *time.Location, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	var f File
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	loc := time.Local
+	if f.Timezone != "" {
+		if loc, err = time.LoadLocation(f.Timezone); err != nil {
+			return nil, nil, fmt.Errorf("%s: unknown timezone %q", path, f.Timezone)
+		}
+	}
+	if len(f.Jobs) == 0 {
+		return nil, nil, fmt.Errorf("%s has no jobs", path)
+	}
+	names := map[string]bool{}
+	for i := range f.Jobs {
+		job := &f.Jobs[i]
+		if job.Name == "" {
+			job.Name = strings.Join(job.Run, " ")
+		}
+		if names[job.Name] {
+			return nil, nil, fmt.Errorf("%s: job %q is defined twice", path, job.Name)
+		}
+		names[job.Name] = true
+		if len(job.Run) == 0 {
+			return nil, nil, fmt.Errorf("%s: job %q has nothing to run", path, job.Name)
+		}
+		if job.schedule, err = Parse(job.Schedule); err != nil {
+			return nil, nil, fmt.Errorf("%s: job %q: %w", path, job.Name, err)
+		}
+	}
+	return &f, loc, nil
+}
+
+// Next returns when the job runs next after t.
+func (j *Job) Next(t time.Time) time.Time {
+	return j.schedule.Next(t)
+}
+
+// Runner runs the jobs of a schedule file when they are due, from the
+// directory it is started in. A job still running when it is due again is
+// skipped rather than run twice at once.
+type Runner struct {
+	Jobs     []Job
+	Location *time.Location
+	// Dir is the absolute path of the directory the jobs run in.
+	Dir string
+	// Log gets a line when a job starts and finishes, followed by its output.
+	Log io.Writer
+
+	mu      sync.Mutex
+	running map[string]bool
+}
+
+// Run runs the jobs until ctx is done, then waits for the running ones.
+func (r *Runner) Run(ctx context.Context) {
+	r.running = map[string]bool{}
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	for {
+		now := time.Now().In(r.Location)
+		var next time.Time
+		var due []*Job
+		for i := range r.Jobs {
+			at := r.Jobs[i].Next(now)
+			switch {
+			case at.IsZero():
+			case next.IsZero() || at.Before(next):
+				next, due = at, []*Job{&r.Jobs[i]}
+			case at.Equal(next):
+				due = append(due, &r.Jobs[i])
+			}
+		}
+		if next.IsZero() {
+			r.logf("No job runs within five years.")
+			<-ctx.Done()
+			return
+		}
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		for _, job := range due {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				r.RunJob(ctx, job)
+			}()
+		}
+	}
+}
+
+// RunJob runs job now, unless it is running already, and reports whether
+// it succeeded.
+func (r *Runner) RunJob(ctx context.Context, job *Job) bool {
+	r.mu.Lock()
+	if r.running == nil {
+		r.running = map[string]bool{}
+	}
+	if r.running[job.Name] {
+		r.mu.Unlock()
+		r.logf("%s: still running, skipped", job.Name)
+		return false
+	}
+	r.running[job.Name] = true
+	r.mu.Unlock()
+	defer func() {
+		r.mu.Lock()
+		delete(r.running, job.Name)
+		r.mu.Unlock()
+	}()
+
+	if job.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, job.Timeout)
+		defer cancel()
+	}
+	r.logf("%s: started", job.Name)
+	start := time.Now()
+	result, err := daemon.Run(ctx, daemon.RunParams{Args: job.Run, Dir: r.Dir, Profile: job.Profile})
+	elapsed := time.Since(start).Round(time.Millisecond)
+	switch {
+	case ctx.Err() == context.DeadlineExceeded:
+		r.logf("%s: timed out after %s", job.Name, job.Timeout)
+	case err != nil:
+		r.logf("%s: failed to start: %v", job.Name, err)
+	case result.ExitCode != 0:
+		r.logf("%s: failed with exit code %d after %s", job.Name, result.ExitCode, elapsed)
+	default:
+		r.logf("%s: finished after %s", job.Name, elapsed)
+	}
+	r.mu.Lock()
+	for line := range strings.SplitSeq(strings.TrimRight(result.Stdout+result.Stderr, "\n"), "\n") {
+		if line != "" {
+			fmt.Fprintf(r.Log, "  %s | %s\n", job.Name, line)
+		}
+	}
+	r.mu.Unlock()
+	return err == nil && ctx.Err() == nil && result.ExitCode == 0
+}
+
+func (r *Runner) logf(format string, args ...any) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	fmt.Fprintf(r.Log, "%s %s\n", time.Now().In(r.Location).Format(time.DateTime), fmt.Sprintf(format, args...))
+}
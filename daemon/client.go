@@ -0,0 +1,107 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Running reports whether a daemon answers on socket.
+func Running(socket string) bool {
+	conn, err := net.DialTimeout("unix", socket, 200*time.Millisecond)
+	if err != nil {
+		return false
+	}
+	conn.Close()
+	return true
+}
+
+// socketClient returns an HTTP client that connects to the daemon at socket.
+func socketClient(socket string) *http.Client {
+	var dialer net.Dialer
+	return &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return dialer.DialContext(ctx, "unix", socket)
+		},
+	}}
+}
+
+// Transport returns a round tripper that sends requests through the daemon
+// at socket with the given settings. Requests go out through direct when
+// the daemon cannot be reached, e.g. after it stopped for being idle.
+func Transport(socket string, settings Settings, direct http.RoundTripper) http.RoundTripper {
+	encoded, _ := json.Marshal(settings)
+	return &transport{
+		socket:   socketClient(socket).Transport,
+		settings: string(encoded),
+		direct:   direct,
+	}
+}
+
+type transport struct {
+	socket   http.RoundTripper
+	settings string
+	direct   http.RoundTripper
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	out := req.Clone(req.Context())
+	out.URL = &url.URL{Scheme: "http", Host: "n8nctl-daemon", Path: "/forward"}
+	out.Host = ""
+	out.Header.Set(forwardHeader, req.URL.String())
+	out.Header.Set(transportHeader, t.settings)
+	resp, err := t.socket.RoundTrip(out)
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return t.direct.RoundTrip(req)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if msg := resp.Header.Get(errorHeader); msg != "" {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%s %s: %s", req.Method, req.URL.Redacted(), msg)
+	}
+	resp.Request = req
+	return resp, nil
+}
+
+// GetStatus asks the daemon at socket about itself.
+func GetStatus(ctx context.Context, socket string) (Status, error) {
+	var status Status
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://n8nctl-daemon/status", nil)
+	if err != nil {
+		return status, err
+	}
+	resp, err := socketClient(socket).Do(req)
+	if err != nil {
+		return status, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return status, fmt.Errorf("daemon answered %s", resp.Status)
+	}
+	return status, json.NewDecoder(resp.Body).Decode(&status)
+}
+
+// Stop asks the daemon at socket to stop.
+func Stop(ctx context.Context, socket string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "http://n8nctl-daemon/stop", nil)
+	if err != nil {
+		return err
+	}
+	resp, err := socketClient(socket).Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		return fmt.Errorf("daemon answered %s", resp.Status)
+	}
+	return nil
+}
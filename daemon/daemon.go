@@ -0,0 +1,306 @@
+// Package daemon keeps connections to n8n instances and their node type
+// catalogs warm between invocations of n8nctl, which send their API requests
+// through it over a local socket instead of connecting afresh every time.
+// Tools drive n8nctl through the same socket with JSON-RPC, see rpc.go.
+package daemon
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/brandon-kyle-bailey/n8nctl/config"
+	"github.com/brandon-kyle-bailey/n8nctl/pkg/n8n"
+)
+
+const (
+	// forwardHeader carries the URL a request is for.
+	forwardHeader = "X-N8nctl-Forward"
+	// transportHeader carries the Settings to send it with.
+	transportHeader = "X-N8nctl-Transport"
+	// errorHeader reports why the daemon could not send a request, which
+	// the client returns as the error of the request.
+	errorHeader = "X-N8nctl-Error"
+)
+
+// SocketPath returns where the daemon listens: the N8NCTL_DAEMON_SOCKET
+// environment variable, or daemon.sock in ~/.n8nctl.
+func SocketPath() (string, error) {
+	if path := os.Getenv("N8NCTL_DAEMON_SOCKET"); path != "" {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".n8nctl", "daemon.sock"), nil
+}
+
+// Settings are the transport settings of a profile. The daemon keeps a
+// transport, and so a pool of connections, for each set of settings.
+type Settings struct {
+	ProxyURL           string `json:"proxy_url,omitempty"`
+	CACert             string `json:"ca_cert,omitempty"`
+	ClientCert         string `json:"client_cert,omitempty"`
+	ClientKey          string `json:"client_key,omitempty"`
+	InsecureSkipVerify bool   `json:"insecure_skip_verify,omitempty"`
+}
+
+// SettingsOf returns the transport settings of a profile, with the paths
+// of its certificates made absolute for the daemon.
+func SettingsOf(cfg config.Config) Settings {
+	abs := func(path string) string {
+		if path == "" {
+			return ""
+		}
+		if p, err := filepath.Abs(path); err == nil {
+			return p
+		}
+		return path
+	}
+	return Settings{
+		ProxyURL:           cfg.ProxyURL,
+		CACert:             abs(cfg.CACert),
+		ClientCert:         abs(cfg.ClientCert),
+		ClientKey:          abs(cfg.ClientKey),
+		InsecureSkipVerify: cfg.InsecureSkipVerify,
+	}
+}
+
+// Status is what a running daemon reports about itself.
+type Status struct {
+	PID        int       `json:"pid"`
+	Started    time.Time `json:"started"`
+	LastUsed   time.Time `json:"last_used"`
+	Requests   int64     `json:"requests"`
+	Transports int       `json:"transports"`
+	NodeTypes  int       `json:"node_types"`
+}
+
+// Server forwards the requests of n8nctl to n8n instances, holding their
+// connections open, and answers repeated requests for node type catalogs
+// from memory.
+type Server struct {
+	// Log, when set, gets a line for every request forwarded.
+	Log io.Writer
+	// NodeTypesTTL is how long a fetched node type catalog is served
+	// before it is fetched again.
+	NodeTypesTTL time.Duration
+
+	started    time.Time
+	lastUsed   atomic.Int64
+	requests   atomic.Int64
+	stop       chan struct{}
+	stopOnce   sync.Once
+	mu         sync.Mutex
+	transports map[string]*http.Transport
+	nodeTypes  map[string]cachedResponse
+}
+
+// cachedResponse is a response kept to answer the same request again.
+type cachedResponse struct {
+	header  http.Header
+	body    []byte
+	fetched time.Time
+}
+
+// NewServer returns a server that has not forwarded anything yet.
+func NewServer() *Server {
+	s := &Server{
+		NodeTypesTTL: 10 * time.Minute,
+		started:      time.Now(),
+		stop:         make(chan struct{}),
+		transports:   map[string]*http.Transport{},
+		nodeTypes:    map[string]cachedResponse{},
+	}
+	s.lastUsed.Store(s.started.UnixNano())
+	return s
+}
+
+// Stopped is closed when a client asks the daemon to stop.
+func (s *Server) Stopped() <-chan struct{} {
+	return s.stop
+}
+
+// Idle returns how long ago the daemon last forwarded a request.
+func (s *Server) Idle() time.Duration {
+	return time.Since(time.Unix(0, s.lastUsed.Load()))
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.Header.Get(forwardHeader) != "":
+		s.forward(w, r)
+	case r.URL.Path == "/status":
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(s.status())
+	case r.URL.Path == "/rpc":
+		s.serveRPC(w, r)
+	case r.URL.Path == "/stop" && r.Method == http.MethodPost:
+		s.stopOnce.Do(func() { close(s.stop) })
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (s *Server) status() Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return Status{
+		PID:        os.Getpid(),
+		Started:    s.started,
+		LastUsed:   time.Unix(0, s.lastUsed.Load()),
+		Requests:   s.requests.Load(),
+		Transports: len(s.transports),
+		NodeTypes:  len(s.nodeTypes),
+	}
+}
+
+func (s *Server) forward(w http.ResponseWriter, r *http.Request) {
+	s.requests.Add(1)
+	s.lastUsed.Store(time.Now().UnixNano())
+	fail := func(err error) {
+		w.Header().Set(errorHeader, err.Error())
+		w.WriteHeader(http.StatusBadGateway)
+	}
+	target, err := url.Parse(r.Header.Get(forwardHeader))
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") {
+		fail(fmt.Errorf("invalid URL %q", r.Header.Get(forwardHeader)))
+		return
+	}
+	transport, err := s.transport(r.Header.Get(transportHeader))
+	if err != nil {
+		fail(err)
+		return
+	}
+
+	// Node type catalogs are large and only change when the instance is
+	// upgraded, so one fetch serves every check for a while.
+	cacheKey := ""
+	if r.Method == http.MethodGet && strings.HasSuffix(target.Path, "/types/nodes.json") {
+		sum := sha256.Sum256([]byte(r.Header.Get("X-N8N-API-KEY")))
+		cacheKey = target.String() + " " + hex.EncodeToString(sum[:])
+		s.mu.Lock()
+		cached, ok := s.nodeTypes[cacheKey]
+		s.mu.Unlock()
+		if ok && time.Since(cached.fetched) < s.NodeTypesTTL {
+			s.log(r.Method, target, "cached")
+			copyHeader(w.Header(), cached.header)
+			w.Header().Set(n8n.CacheHeader, "hit")
+			w.Write(cached.body)
+			return
+		}
+	}
+
+	out, err := http.NewRequestWithContext(r.Context(), r.Method, target.String(), r.Body)
+	if err != nil {
+		fail(err)
+		return
+	}
+	out.ContentLength = r.ContentLength
+	copyHeader(out.Header, r.Header)
+	out.Header.Del(forwardHeader)
+	out.Header.Del(transportHeader)
+	resp, err := transport.RoundTrip(out)
+	if err != nil {
+		fail(err)
+		return
+	}
+	defer resp.Body.Close()
+	s.log(r.Method, target, resp.Status)
+
+	if cacheKey != "" && resp.StatusCode == http.StatusOK {
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			fail(err)
+			return
+		}
+		s.mu.Lock()
+		s.nodeTypes[cacheKey] = cachedResponse{header: resp.Header.Clone(), body: body, fetched: time.Now()}
+		s.mu.Unlock()
+		copyHeader(w.Header(), resp.Header)
+		w.Write(body)
+		return
+	}
+	copyHeader(w.Header(), resp.Header)
+	w.WriteHeader(resp.StatusCode)
+	io.Copy(w, resp.Body)
+}
+
+// transport returns the transport for the settings encoded in header,
+// creating it on first use.
+func (s *Server) transport(header string) (*http.Transport, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if t, ok := s.transports[header]; ok {
+		return t, nil
+	}
+	var settings Settings
+	if header != "" {
+		if err := json.Unmarshal([]byte(header), &settings); err != nil {
+			return nil, fmt.Errorf("invalid transport settings: %w", err)
+		}
+	}
+	cfg := config.Config{
+		ProxyURL:           settings.ProxyURL,
+		CACert:             settings.CACert,
+		ClientCert:         settings.ClientCert,
+		ClientKey:          settings.ClientKey,
+		InsecureSkipVerify: settings.InsecureSkipVerify,
+	}
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	proxy, err := cfg.Proxy()
+	if err != nil {
+		return nil, err
+	}
+	if proxy != nil {
+		t.Proxy = http.ProxyURL(proxy)
+	}
+	if t.TLSClientConfig, err = cfg.TLSConfig(); err != nil {
+		return nil, err
+	}
+	// Keep connections around for as long as the daemon idles.
+	t.IdleConnTimeout = 0
+	t.MaxIdleConnsPerHost = 16
+	s.transports[header] = t
+	return t, nil
+}
+
+// Close closes the idle connections of every transport.
+func (s *Server) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, t := range s.transports {
+		t.CloseIdleConnections()
+	}
+}
+
+func (s *Server) log(method string, target *url.URL, result string) {
+	if s.Log != nil {
+		fmt.Fprintf(s.Log, "%s %s %s %s\n", time.Now().Format("15:04:05"), method, target.Redacted(), result)
+	}
+}
+
+// hopHeaders are the headers of a single connection, which are not
+// forwarded.
+var hopHeaders = []string{"Connection", "Keep-Alive", "Proxy-Connection", "Te", "Trailer", "Transfer-Encoding", "Upgrade"}
+
+func copyHeader(dst, src http.Header) {
+	for name, values := range src {
+		dst[name] = append([]string(nil), values...)
+	}
+	for _, name := range hopHeaders {
+		dst.Del(name)
+	}
+}
@@ -0,0 +1,241 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// method is a JSON-RPC method of the daemon. Params and Result are JSON
+// Schemas, published by rpc.discover.
+type method struct {
+	summary string
+	params  []param
+	result  map[string]any
+	call    func(ctx context.Context, params json.RawMessage) (any, error)
+}
+
+type param struct {
+	name     string
+	summary  string
+	schema   map[string]any
+	required bool
+}
+
+var (
+	stringSchema = map[string]any{"type": "string"}
+	boolSchema   = map[string]any{"type": "boolean"}
+	objectSchema = map[string]any{"type": "object"}
+
+	dirParam     = param{name: "dir", summary: "Absolute path of the project directory to run in", schema: stringSchema}
+	profileParam = param{name: "profile", summary: "Config profile to use", schema: stringSchema}
+)
+
+// methods returns the methods the daemon answers over /rpc.
+func (s *Server) methods() map[string]method {
+	return map[string]method{
+		"rpc.discover": {
+			summary: "Describe the methods of this interface as an OpenRPC document",
+			result:  objectSchema,
+			call: func(context.Context, json.RawMessage) (any, error) {
+				return OpenRPC(), nil
+			},
+		},
+		"daemon.status": {
+			summary: "Report the state of the daemon",
+			result: map[string]any{"type": "object", "properties": map[string]any{
+				"pid": map[string]any{"type": "integer"}, "started": stringSchema, "last_used": stringSchema,
+				"requests": map[string]any{"type": "integer"}, "transports": map[string]any{"type": "integer"},
+				"node_types": map[string]any{"type": "integer"},
+			}},
+			call: func(context.Context, json.RawMessage) (any, error) {
+				return s.status(), nil
+			},
+		},
+		"n8nctl.run": {
+			summary: "Run n8nctl with arguments, as on the command line, and return its exit code and output",
+			params: []param{
+				{name: "args", summary: "Arguments, e.g. [\"workflows\", \"deploy\", \"--dir\", \"workflows\"]", schema: map[string]any{"type": "array", "items": stringSchema}, required: true},
+				dirParam,
+				profileParam,
+				{name: "env", summary: "Environment variables to set", schema: map[string]any{"type": "object", "additionalProperties": stringSchema}},
+			},
+			result: map[string]any{"type": "object", "properties": map[string]any{
+				"exit_code": map[string]any{"type": "integer"}, "stdout": stringSchema, "stderr": stringSchema,
+			}},
+			call: func(ctx context.Context, raw json.RawMessage) (any, error) {
+				var params RunParams
+				if err := decodeParams(raw, &params); err != nil {
+					return nil, err
+				}
+				if len(params.Args) == 0 {
+					return nil, &RPCError{Code: rpcInvalidParams, Message: "args is required"}
+				}
+				return Run(ctx, params)
+			},
+		},
+		"workflows.list": {
+			summary: "List the workflows of the instance",
+			params:  []param{profileParam},
+			result:  map[string]any{"type": "array", "items": objectSchema},
+			call: func(ctx context.Context, raw json.RawMessage) (any, error) {
+				var params struct {
+					Profile string `json:"profile"`
+				}
+				if err := decodeParams(raw, &params); err != nil {
+					return nil, err
+				}
+				return runJSON(ctx, RunParams{Args: []string{"workflows", "list"}, Profile: params.Profile})
+			},
+		},
+		"workflows.get": {
+			summary: "Get a workflow of the instance",
+			params:  []param{{name: "id", summary: "ID of the workflow", schema: stringSchema, required: true}, profileParam},
+			result:  objectSchema,
+			call: func(ctx context.Context, raw json.RawMessage) (any, error) {
+				var params struct {
+					ID      string `json:"id"`
+					Profile string `json:"profile"`
+				}
+				if err := decodeParams(raw, &params); err != nil {
+					return nil, err
+				}
+				if params.ID == "" {
+					return nil, &RPCError{Code: rpcInvalidParams, Message: "id is required"}
+				}
+				return runJSON(ctx, RunParams{Args: []string{"workflows", "get", params.ID}, Profile: params.Profile})
+			},
+		},
+		"workflows.lint": {
+			summary: "Lint, or only validate, the workflow files of a project and return the findings",
+			params: []param{
+				dirParam,
+				{name: "files_dir", summary: "Directory of workflow YAML files within the project (default workflows/ or workflow.yaml)", schema: stringSchema},
+				{name: "validate", summary: "Only validate the files, as workflows validate does", schema: boolSchema},
+				{name: "node_types", summary: "Also check node types against those installed on the instance", schema: boolSchema},
+				profileParam,
+			},
+			result: map[string]any{"type": "object", "properties": map[string]any{
+				"files": map[string]any{"type": "array", "items": stringSchema}, "errors": map[string]any{"type": "integer"},
+				"warnings": map[string]any{"type": "integer"}, "findings": map[string]any{"type": "array", "items": objectSchema},
+			}},
+			call: lint,
+		},
+		"workflows.graph": {
+			summary: "Draw a workflow file, or a workflow of the instance, as a diagram",
+			params: []param{
+				dirParam,
+				{name: "workflow", summary: "Workflow file, relative to dir, or ID (default workflow.yaml)", schema: stringSchema},
+				{name: "format", summary: "Diagram format", schema: map[string]any{"type": "string", "enum": []string{"dot", "mermaid", "ascii"}}},
+				profileParam,
+			},
+			result: map[string]any{"type": "object", "properties": map[string]any{"diagram": stringSchema}},
+			call: func(ctx context.Context, raw json.RawMessage) (any, error) {
+				var params struct {
+					Dir      string `json:"dir"`
+					Workflow string `json:"workflow"`
+					Format   string `json:"format"`
+					Profile  string `json:"profile"`
+				}
+				if err := decodeParams(raw, &params); err != nil {
+					return nil, err
+				}
+				args := []string{"workflows", "graph"}
+				if params.Format != "" {
+					args = append(args, "--format", params.Format)
+				}
+				if params.Workflow != "" {
+					args = append(args, params.Workflow)
+				}
+				result, err := Run(ctx, RunParams{Args: args, Dir: params.Dir, Profile: params.Profile})
+				if err != nil {
+					return nil, err
+				}
+				if result.ExitCode != 0 {
+					return nil, runFailed(result)
+				}
+				return map[string]string{"diagram": result.Stdout}, nil
+			},
+		},
+	}
+}
+
+// lint runs workflows lint with a JSON report and returns the report.
+// Findings are the result of a call, so errors among them do not fail it.
+func lint(ctx context.Context, raw json.RawMessage) (any, error) {
+	var params struct {
+		Dir       string `json:"dir"`
+		FilesDir  string `json:"files_dir"`
+		Validate  bool   `json:"validate"`
+		NodeTypes bool   `json:"node_types"`
+		Profile   string `json:"profile"`
+	}
+	if err := decodeParams(raw, &params); err != nil {
+		return nil, err
+	}
+	tmp, err := os.MkdirTemp("", "n8nctl-rpc-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+	report := filepath.Join(tmp, "report.json")
+
+	action := "lint"
+	if params.Validate {
+		action = "validate"
+	}
+	args := []string{"workflows", action, "--report", "json", "--out", report}
+	if params.FilesDir != "" {
+		args = append(args, "--dir", params.FilesDir)
+	}
+	if params.NodeTypes {
+		args = append(args, "--node-types")
+	}
+	result, err := Run(ctx, RunParams{Args: args, Dir: params.Dir, Profile: params.Profile})
+	if err != nil {
+		return nil, err
+	}
+	data, readErr := os.ReadFile(report)
+	if readErr != nil {
+		// No report was written, so the files could not be checked at all.
+		return nil, runFailed(result)
+	}
+	return json.RawMessage(data), nil
+}
+
+// OpenRPC returns the OpenRPC document describing the methods of the
+// daemon, which `n8nctl schema export --for rpc` publishes.
+func OpenRPC() map[string]any {
+	methods := (&Server{}).methods()
+	var docs []map[string]any
+	for _, name := range slices.Sorted(maps.Keys(methods)) {
+		m := methods[name]
+		params := []map[string]any{}
+		for _, p := range m.params {
+			params = append(params, map[string]any{
+				"name": p.name, "summary": p.summary, "schema": p.schema, "required": p.required,
+			})
+		}
+		docs = append(docs, map[string]any{
+			"name":           name,
+			"summary":        m.summary,
+			"paramStructure": "by-name",
+			"params":         params,
+			"result":         map[string]any{"name": strings.ReplaceAll(name, ".", "_") + "_result", "schema": m.result},
+		})
+	}
+	return map[string]any{
+		"openrpc": "1.2.6",
+		"info": map[string]any{
+			"title":       "n8nctl daemon",
+			"version":     "1",
+			"description": "JSON-RPC 2.0 over HTTP POST to /rpc on the daemon's Unix socket. Command failures have code -32000 with the exit code and output as data.",
+		},
+		"servers": []map[string]any{{"name": "daemon", "url": "unix:~/.n8nctl/daemon.sock:/rpc"}},
+		"methods": docs,
+	}
+}
@@ -0,0 +1,227 @@
+package daemon
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// JSON-RPC 2.0 error codes.
+const (
+	rpcParseError     = -32700
+	rpcInvalidRequest = -32600
+	rpcMethodNotFound = -32601
+	rpcInvalidParams  = -32602
+	// rpcCommandFailed is returned when the n8nctl command behind a method
+	// fails; its data holds the exit code and output.
+	rpcCommandFailed = -32000
+)
+
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *RPCError       `json:"error,omitempty"`
+}
+
+// RPCError is the error of a JSON-RPC call.
+type RPCError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    any    `json:"data,omitempty"`
+}
+
+func (e *RPCError) Error() string {
+	return e.Message
+}
+
+// serveRPC answers JSON-RPC 2.0 requests, single or batched, posted to /rpc.
+func (s *Server) serveRPC(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "JSON-RPC requests are POSTed", http.StatusMethodNotAllowed)
+		return
+	}
+	s.lastUsed.Store(time.Now().UnixNano())
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return
+	}
+	body = bytes.TrimSpace(body)
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+
+	if len(body) > 0 && body[0] == '[' {
+		var batch []json.RawMessage
+		if err := json.Unmarshal(body, &batch); err != nil || len(batch) == 0 {
+			enc.Encode(rpcResponse{JSONRPC: "2.0", ID: json.RawMessage("null"), Error: &RPCError{Code: rpcInvalidRequest, Message: "invalid batch"}})
+			return
+		}
+		var responses []rpcResponse
+		for _, item := range batch {
+			if resp, ok := s.call(r.Context(), item); ok {
+				responses = append(responses, resp)
+			}
+		}
+		if len(responses) == 0 {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		enc.Encode(responses)
+		return
+	}
+	if resp, ok := s.call(r.Context(), body); ok {
+		enc.Encode(resp)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// call runs one request. Notifications, which have no ID, get no response.
+func (s *Server) call(ctx context.Context, data []byte) (rpcResponse, bool) {
+	resp := rpcResponse{JSONRPC: "2.0", ID: json.RawMessage("null")}
+	var req rpcRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		resp.Error = &RPCError{Code: rpcParseError, Message: err.Error()}
+		return resp, true
+	}
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		resp.Error = &RPCError{Code: rpcInvalidRequest, Message: `requests need "jsonrpc": "2.0" and a method`}
+		return resp, true
+	}
+	if len(req.ID) > 0 {
+		resp.ID = req.ID
+	}
+	m, ok := s.methods()[req.Method]
+	if !ok {
+		resp.Error = &RPCError{Code: rpcMethodNotFound, Message: fmt.Sprintf("unknown method %q, see rpc.discover", req.Method)}
+		return resp, len(req.ID) > 0
+	}
+	params := req.Params
+	if len(params) == 0 || string(params) == "null" {
+		params = json.RawMessage("{}")
+	}
+	result, err := m.call(ctx, params)
+	var rpcErr *RPCError
+	switch {
+	case errors.As(err, &rpcErr):
+		resp.Error = rpcErr
+	case err != nil:
+		resp.Error = &RPCError{Code: rpcCommandFailed, Message: err.Error()}
+	default:
+		resp.Result = result
+	}
+	return resp, len(req.ID) > 0
+}
+
+// decodeParams decodes the named parameters of a call, rejecting unknown
+// ones so typos do not go unnoticed.
+func decodeParams(params json.RawMessage, v any) error {
+	dec := json.NewDecoder(bytes.NewReader(params))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return &RPCError{Code: rpcInvalidParams, Message: fmt.Sprintf("invalid params: %v", err)}
+	}
+	return nil
+}
+
+// RunParams are the parameters of n8nctl.run, and of the methods built on
+// it: the directory of the project and the profile to work with.
+type RunParams struct {
+	Args    []string          `json:"args"`
+	Dir     string            `json:"dir,omitempty"`
+	Profile string            `json:"profile,omitempty"`
+	Env     map[string]string `json:"env,omitempty"`
+}
+
+// RunResult is the outcome of n8nctl.run.
+type RunResult struct {
+	ExitCode int    `json:"exit_code"`
+	Stdout   string `json:"stdout"`
+	Stderr   string `json:"stderr"`
+}
+
+// Run runs n8nctl with params, never prompting. Its API requests go through
+// the daemon, when one runs, like those of any other invocation.
+func Run(ctx context.Context, params RunParams) (RunResult, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return RunResult{}, err
+	}
+	if params.Dir != "" && !filepath.IsAbs(params.Dir) {
+		return RunResult{}, &RPCError{Code: rpcInvalidParams, Message: "dir must be an absolute path"}
+	}
+	args := []string{"--non-interactive"}
+	if params.Profile != "" {
+		args = append(args, "--profile", params.Profile)
+	}
+	cmd := exec.CommandContext(ctx, exe, append(args, params.Args...)...)
+	cmd.Dir = params.Dir
+	cmd.Env = append(os.Environ(), "NO_COLOR=1")
+	for name, value := range params.Env {
+		cmd.Env = append(cmd.Env, name+"="+value)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	err = cmd.Run()
+	result := RunResult{Stdout: stdout.String(), Stderr: stderr.String()}
+	var exitErr *exec.ExitError
+	switch {
+	case errors.As(err, &exitErr):
+		result.ExitCode = exitErr.ExitCode()
+	case err != nil:
+		return result, err
+	}
+	return result, nil
+}
+
+// runFailed is the error of a method whose command failed, carrying the
+// command's result so callers can show its output.
+func runFailed(result RunResult) error {
+	message := strings.TrimSpace(result.Stderr)
+	if message == "" {
+		message = strings.TrimSpace(result.Stdout)
+	}
+	// Commands report their failure on a line of its own, followed by
+	// details such as the body of an API error.
+	for _, line := range strings.Split(message, "\n") {
+		if after, ok := strings.CutPrefix(line, "Error: "); ok {
+			message = after
+			break
+		}
+	}
+	return &RPCError{Code: rpcCommandFailed, Message: fmt.Sprintf("n8nctl exited with %d: %s", result.ExitCode, message), Data: result}
+}
+
+// runJSON runs a command that prints JSON and returns what it printed.
+func runJSON(ctx context.Context, params RunParams) (json.RawMessage, error) {
+	result, err := Run(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	if result.ExitCode != 0 {
+		return nil, runFailed(result)
+	}
+	out := json.RawMessage(strings.TrimSpace(result.Stdout))
+	if !json.Valid(out) {
+		return nil, &RPCError{Code: rpcCommandFailed, Message: "n8nctl did not print JSON", Data: result}
+	}
+	return out, nil
+}
@@ -0,0 +1,95 @@
+// Package devenv runs throwaway n8n instances in Docker for local development
+// and CI.
+package devenv
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// Image is the Docker image n8n publishes.
+const Image = "docker.n8n.io/n8nio/n8n"
+
+// Instance is an n8n container published on a local port.
+type Instance struct {
+	Name    string
+	Version string
+	Port    int
+}
+
+// BaseURL is the address the instance is reachable at from the host.
+func (i Instance) BaseURL() string {
+	return fmt.Sprintf("http://localhost:%d", i.Port)
+}
+
+func docker(ctx context.Context, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "docker", args...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if _, ok := err.(*exec.Error); ok {
+			return "", fmt.Errorf("docker is required: %w", err)
+		}
+		return "", fmt.Errorf("docker %s failed: %s", args[0], strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// Start runs the container, or starts it again if it already exists. It
+// reports whether a new container was created.
+func (i Instance) Start(ctx context.Context) (bool, error) {
+	running, err := docker(ctx, "container", "inspect", "--format", "{{.State.Running}}", i.Name)
+	if err == nil {
+		if running != "true" {
+			if _, err := docker(ctx, "start", i.Name); err != nil {
+				return false, err
+			}
+		}
+		return false, nil
+	}
+	_, err = docker(ctx, "run", "--detach",
+		"--name", i.Name,
+		"--publish", fmt.Sprintf("%d:5678", i.Port),
+		"--env", "N8N_DIAGNOSTICS_ENABLED=false",
+		"--env", "N8N_PERSONALIZATION_ENABLED=false",
+		"--env", "N8N_SECURE_COOKIE=false",
+		Image+":"+i.Version)
+	return err == nil, err
+}
+
+// Remove stops the container and deletes it along with its data.
+func (i Instance) Remove(ctx context.Context) error {
+	_, err := docker(ctx, "rm", "--force", "--volumes", i.Name)
+	return err
+}
+
+// WaitReady polls the instance until its REST API answers, which happens
+// only after database migrations have finished.
+func (i Instance) WaitReady(ctx context.Context, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	client := &http.Client{Timeout: 5 * time.Second}
+	for {
+		req, err := http.NewRequestWithContext(ctx, "GET", i.BaseURL()+"/rest/settings", nil)
+		if err != nil {
+			return err
+		}
+		if resp, err := client.Do(req); err == nil {
+			resp.Body.Close()
+			if resp.StatusCode == http.StatusOK {
+				return nil
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("n8n at %s was not ready after %s", i.BaseURL(), timeout)
+		case <-time.After(time.Second):
+		}
+	}
+}
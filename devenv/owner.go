@@ -0,0 +1,122 @@
+package devenv
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"time"
+)
+
+// Owner is the account created on a fresh instance. Dev instances only listen
+// on localhost, so fixed credentials keep them easy to sign in to.
+type Owner struct {
+	Email     string
+	FirstName string
+	LastName  string
+	Password  string
+}
+
+// DefaultOwner is the owner account of instances started by n8nctl.
+var DefaultOwner = Owner{
+	Email:     "dev@n8nctl.local",
+	FirstName: "n8nctl",
+	LastName:  "Dev",
+	Password:  "N8nctl-dev-password1",
+}
+
+// Bootstrap sets up the owner account on a fresh instance, or signs in to it
+// if the instance was set up before, and creates an API key for n8nctl.
+// These steps go through the internal REST API the editor uses, as the
+// public API needs the very key being created.
+func Bootstrap(ctx context.Context, baseURL string, owner Owner) (string, error) {
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return "", err
+	}
+	s := &session{baseURL: baseURL, client: &http.Client{Jar: jar, Timeout: 30 * time.Second}}
+
+	status, body, err := s.post(ctx, "/rest/owner/setup", map[string]string{
+		"email":     owner.Email,
+		"firstName": owner.FirstName,
+		"lastName":  owner.LastName,
+		"password":  owner.Password,
+	})
+	if err != nil {
+		return "", err
+	}
+	// A 400 means the owner exists already, e.g. the container was restarted.
+	if status != http.StatusOK && status != http.StatusBadRequest {
+		return "", fmt.Errorf("owner setup failed: %d %s", status, body)
+	}
+
+	status, body, err = s.post(ctx, "/rest/login", map[string]string{
+		"emailOrLdapLoginId": owner.Email,
+		"email":              owner.Email,
+		"password":           owner.Password,
+	})
+	if err != nil {
+		return "", err
+	}
+	if status != http.StatusOK {
+		return "", fmt.Errorf("sign in as %s failed: %d %s", owner.Email, status, body)
+	}
+
+	status, body, err = s.post(ctx, "/rest/api-keys", map[string]any{
+		"label":     fmt.Sprintf("n8nctl %s", time.Now().UTC().Format(time.DateTime)),
+		"expiresAt": nil,
+	})
+	if err != nil {
+		return "", err
+	}
+	if status != http.StatusOK {
+		return "", fmt.Errorf("creating an API key failed: %d %s", status, body)
+	}
+	// Recent releases return the key once as rawApiKey and a masked apiKey,
+	// older ones only the plain apiKey.
+	var created struct {
+		Data struct {
+			RawAPIKey string `json:"rawApiKey"`
+			APIKey    string `json:"apiKey"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &created); err != nil {
+		return "", fmt.Errorf("failed to parse API key response: %w", err)
+	}
+	if created.Data.RawAPIKey != "" {
+		return created.Data.RawAPIKey, nil
+	}
+	if created.Data.APIKey == "" {
+		return "", fmt.Errorf("n8n returned no API key")
+	}
+	return created.Data.APIKey, nil
+}
+
+type session struct {
+	baseURL string
+	client  *http.Client
+}
+
+func (s *session) post(ctx context.Context, path string, payload any) (int, []byte, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return 0, nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", s.baseURL+path, bytes.NewReader(data))
+	if err != nil {
+		return 0, nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	// The auth cookie is bound to the browser ID it was issued for.
+	req.Header.Set("browser-id", "n8nctl")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	return resp.StatusCode, body, err
+}
@@ -0,0 +1,144 @@
+package entities
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/brandon-kyle-bailey/n8nctl/manifest"
+	"github.com/brandon-kyle-bailey/n8nctl/pkg/n8n"
+	"github.com/brandon-kyle-bailey/n8nctl/state"
+	"github.com/brandon-kyle-bailey/n8nctl/utils"
+	"github.com/brandon-kyle-bailey/n8nctl/workflows"
+)
+
+// adoptWorkflow brings a workflow built in the editor under management: it
+// writes the remote workflow as YAML into the project and records it in the
+// state file as deployed from there, without deploying anything.
+func adoptWorkflow(client *n8n.Client, basePath string, params []string) error {
+	fs := flag.NewFlagSet("adopt", flag.ContinueOnError)
+	file := fs.String("file", "", "YAML file to write (default workflows/<name>.yaml, or workflow.yaml in projects without workflows/)")
+	args, err := utils.ParseFlags(fs, params)
+	if err != nil {
+		return err
+	}
+	if len(args) != 1 {
+		return fmt.Errorf("adopt requires a workflow ID")
+	}
+	id := args[0]
+
+	st, err := state.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load %s: %w", state.Path, err)
+	}
+	for managed, entry := range st.Workflows {
+		if entry.WorkflowID == id {
+			return fmt.Errorf("workflow %s is managed by %s already", id, managed)
+		}
+	}
+	remote, err := n8nAPIRequest(client, "GET", fmt.Sprintf("%s/%s", basePath, id), "")
+	if err != nil {
+		return err
+	}
+	name, err := workflowName(remote)
+	if err != nil {
+		return err
+	}
+	data, err := workflowYAML(remote)
+	if err != nil {
+		return err
+	}
+
+	path := *file
+	if path == "" {
+		path = adoptPath(name)
+	}
+	if _, err := os.Stat(path); err == nil {
+		return fmt.Errorf("%s exists already, choose another file with --file", path)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create %s directory: %w", filepath.Dir(path), err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return err
+	}
+
+	// Record the file as rendered, so the next deploy finds it unchanged.
+	body, err := workflows.RenderWorkflowFile(path)
+	if err != nil {
+		return fmt.Errorf("wrote %s, but failed to render it: %w", path, err)
+	}
+	var wf remoteWorkflow
+	if err := json.Unmarshal(remote, &wf); err != nil {
+		return fmt.Errorf("failed to parse workflow %s: %w", id, err)
+	}
+	st.Record(path, id, body, wf.UpdatedAt)
+	if err := st.Save(); err != nil {
+		return fmt.Errorf("wrote %s, but failed to save %s: %w", path, state.Path, err)
+	}
+	fmt.Printf("Adopted %q (%s) as %s\n", name, id, path)
+	return nil
+}
+
+// workflowYAML converts a remote workflow to the YAML of a workflow file,
+// keeping only the fields deploys manage.
+func workflowYAML(remote []byte) ([]byte, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(remote, &fields); err != nil {
+		return nil, fmt.Errorf("failed to parse workflow: %w", err)
+	}
+	doc := &yaml.Node{Kind: yaml.MappingNode}
+	for _, field := range comparedFields {
+		value, ok := fields[field]
+		if !ok {
+			continue
+		}
+		node, err := manifest.JSONToYAML(value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert %s: %w", field, err)
+		}
+		doc.Content = append(doc.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: field}, node)
+	}
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(doc); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// adoptPath names the file of an adopted workflow after it, in workflows/
+// unless the project is a single workflow.yaml.
+func adoptPath(name string) string {
+	if _, err := os.Stat(workflows.WorkflowFile); err == nil {
+		if info, err := os.Stat(workflows.ProjectDir); err != nil || !info.IsDir() {
+			return workflows.WorkflowFile
+		}
+	}
+	var slug strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(name) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			slug.WriteRune(r)
+			dash = false
+		} else if !dash && slug.Len() > 0 {
+			slug.WriteByte('-')
+			dash = true
+		}
+	}
+	base := strings.TrimSuffix(slug.String(), "-")
+	if base == "" {
+		base = "workflow"
+	}
+	return filepath.Join(workflows.ProjectDir, base+".yaml")
+}
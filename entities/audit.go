@@ -0,0 +1,58 @@
+package entities
+
+import (
+	"flag"
+	neturl "net/url"
+	"time"
+
+	"github.com/brandon-kyle-bailey/n8nctl/activity"
+	"github.com/brandon-kyle-bailey/n8nctl/config"
+	"github.com/brandon-kyle-bailey/n8nctl/pkg/n8n"
+)
+
+// auditTail prints or forwards the activity on the instance. n8n has no event
+// log in its public API, so the events are derived from polling workflows,
+// credentials and executions.
+func auditTail(client *n8n.Client, params []string, cfg config.Config) error {
+	fs := flag.NewFlagSet("tail", flag.ContinueOnError)
+	follow := fs.Bool("follow", false, "Keep forwarding events as they happen")
+	fs.BoolVar(follow, "f", false, "Shorthand for --follow")
+	recent := fs.Int("n", 20, "Number of past events to send first")
+	interval := fs.Duration("interval", 10*time.Second, "How often to poll when following")
+	forward := fs.String("forward", "", "Where to send events: syslog://host:514, syslog+tcp://host:port, an http(s) URL or a file (default stdout)")
+	if err := fs.Parse(params); err != nil {
+		return err
+	}
+	sink, err := activity.OpenSink(*forward)
+	if err != nil {
+		return err
+	}
+	defer sink.Close()
+
+	listCredentials := true
+	return activity.Tail(Context, activity.TailOptions{
+		Recent:   *recent,
+		Follow:   *follow,
+		Interval: *interval,
+		Sink:     sink,
+		Fetch: func() (activity.Snapshot, error) {
+			var s activity.Snapshot
+			var err error
+			if s.Workflows, err = fetchAll[activity.Workflow](client, client.APIURL("workflows"), cfg); err != nil {
+				return s, err
+			}
+			if listCredentials {
+				s.Credentials, err = fetchAll[activity.Credential](client, client.APIURL("credentials"), cfg)
+				if unlisted(err) {
+					listCredentials = false
+					s.Credentials, err = nil, nil
+				}
+				if err != nil {
+					return s, err
+				}
+			}
+			s.Executions, err = listExecutions(client, client.APIURL("executions"), neturl.Values{}, 100, cfg)
+			return s, err
+		},
+	})
+}
@@ -0,0 +1,145 @@
+package entities
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/brandon-kyle-bailey/n8nctl/backup"
+	"github.com/brandon-kyle-bailey/n8nctl/config"
+	"github.com/brandon-kyle-bailey/n8nctl/pkg/n8n"
+	"github.com/brandon-kyle-bailey/n8nctl/prompt"
+	"github.com/brandon-kyle-bailey/n8nctl/telemetry"
+)
+
+// HandleBackup writes every workflow, tag and variable of the instance, and
+// optionally its credentials, to a single archive.
+func HandleBackup(args []string, cfg config.Config) {
+	fs := flag.NewFlagSet("backup", flag.ContinueOnError)
+	out := fs.String("out", "", "Archive to write (default n8nctl-backup-<timestamp>.tar.gz)")
+	fs.StringVar(out, "o", "", "Shorthand for --out")
+	withCredentials := fs.Bool("credentials", false, "Include credentials with their secrets, encrypted with a passphrase (N8NCTL_BACKUP_PASSPHRASE or a prompt); needs the account sign-in of share")
+	if err := fs.Parse(args); err != nil {
+		telemetry.Exit(1)
+	}
+	if *out == "" {
+		*out = fmt.Sprintf("n8nctl-backup-%s.tar.gz", time.Now().Format("20060102-150405"))
+	}
+	if err := backupInstance(newClient(cfg), cfg, *out, *withCredentials); err != nil {
+		fmt.Printf("Error: %s\n", config.Redact(err.Error(), cfg.APIToken))
+		telemetry.Exit(1)
+	}
+}
+
+func backupInstance(client *n8n.Client, cfg config.Config, out string, withCredentials bool) error {
+	b := &backup.Backup{Manifest: backup.Manifest{CreatedAt: time.Now().UTC(), Instance: client.BaseURL()}}
+	var err error
+	// Ask for the passphrase before the slow part.
+	var passphrase string
+	if withCredentials {
+		if passphrase, err = backupPassphrase(true); err != nil {
+			return err
+		}
+	}
+
+	if b.Workflows, err = fetchAll[json.RawMessage](client, client.APIURL("workflows"), cfg); err != nil {
+		return fmt.Errorf("failed to list workflows: %w", err)
+	}
+	if b.Tags, err = fetchAll[json.RawMessage](client, client.APIURL("tags"), cfg); err != nil {
+		return fmt.Errorf("failed to list tags: %w", err)
+	}
+	b.Variables, err = fetchAll[json.RawMessage](client, client.APIURL("variables"), cfg)
+	if unlisted(err) {
+		// Variables require a license tier many instances do not have.
+		fmt.Fprintln(os.Stderr, "Warning: variables are not available on this instance, skipping them")
+	} else if err != nil {
+		return fmt.Errorf("failed to list variables: %w", err)
+	}
+
+	if withCredentials {
+		credentials, err := fetchCredentialsWithData(client, cfg)
+		if err != nil {
+			return err
+		}
+		plaintext, err := json.Marshal(credentials)
+		if err != nil {
+			return err
+		}
+		if b.Credentials, err = backup.Seal(plaintext, passphrase); err != nil {
+			return err
+		}
+		b.Manifest.Credentials = len(credentials)
+	}
+
+	// Write next to the target and rename, so a failed backup never
+	// replaces a good one.
+	tmp, err := os.CreateTemp(filepath.Dir(out), ".n8nctl-backup-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := backup.Write(tmp, b); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write %s: %w", out, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), out); err != nil {
+		return err
+	}
+	fmt.Printf("Backed up %d workflows, %d tags, %d variables and %d credentials to %s\n",
+		b.Manifest.Workflows, b.Manifest.Tags, b.Manifest.Variables, b.Manifest.Credentials, out)
+	return nil
+}
+
+// fetchCredentialsWithData returns every credential the account can read,
+// with its secrets. The public API never returns credential data, so this
+// signs in to the REST API the editor uses.
+func fetchCredentialsWithData(client *n8n.Client, cfg config.Config) ([]json.RawMessage, error) {
+	s, err := signIn(client, cfg)
+	if err != nil {
+		return nil, err
+	}
+	var list []struct {
+		ID string `json:"id"`
+	}
+	if err := s.do("GET", "/rest/credentials", nil, &list); err != nil {
+		return nil, fmt.Errorf("failed to list credentials: %w", err)
+	}
+	credentials := make([]json.RawMessage, 0, len(list))
+	for _, c := range list {
+		var credential json.RawMessage
+		if err := s.do("GET", "/rest/credentials/"+c.ID+"?includeData=true", nil, &credential); err != nil {
+			return nil, fmt.Errorf("failed to read credential %s: %w", c.ID, err)
+		}
+		credentials = append(credentials, credential)
+	}
+	return credentials, nil
+}
+
+// backupPassphrase returns the passphrase of backup credentials from
+// N8NCTL_BACKUP_PASSPHRASE, or asks for it, twice when confirm is set.
+func backupPassphrase(confirm bool) (string, error) {
+	if passphrase := os.Getenv("N8NCTL_BACKUP_PASSPHRASE"); passphrase != "" {
+		return passphrase, nil
+	}
+	passphrase, err := prompt.Secret("Passphrase for the credentials")
+	if err != nil {
+		return "", err
+	}
+	if !confirm {
+		return passphrase, nil
+	}
+	again, err := prompt.Secret("Repeat the passphrase")
+	if err != nil {
+		return "", err
+	}
+	if again != passphrase {
+		return "", fmt.Errorf("the passphrases do not match")
+	}
+	return passphrase, nil
+}
@@ -0,0 +1,111 @@
+package entities
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/brandon-kyle-bailey/n8nctl/pkg/n8n"
+	"github.com/brandon-kyle-bailey/n8nctl/utils"
+)
+
+// getFlags parses the flags of get, returning the IDs to fetch from the
+// arguments and --ids-file, in order, and whether to print them as an array.
+func getFlags(params []string) (ids []string, many bool, concurrency int, err error) {
+	fs := flag.NewFlagSet("get", flag.ContinueOnError)
+	idsFile := fs.String("ids-file", "", "File with one ID per line to fetch as well (- reads stdin)")
+	fs.IntVar(&concurrency, "concurrency", 4, "Number of resources to fetch at once")
+	ids, err = utils.ParseFlags(fs, params)
+	if err != nil {
+		return nil, false, 0, err
+	}
+	if *idsFile != "" {
+		more, err := readIDs(*idsFile)
+		if err != nil {
+			return nil, false, 0, err
+		}
+		ids = append(ids, more...)
+	}
+	if len(ids) == 0 {
+		return nil, false, 0, fmt.Errorf("get requires an ID")
+	}
+	if concurrency < 1 {
+		return nil, false, 0, fmt.Errorf("--concurrency must be at least 1")
+	}
+	return ids, len(ids) > 1 || *idsFile != "", concurrency, nil
+}
+
+// readIDs reads one ID per line from path, or stdin for "-", skipping blank
+// lines and # comments.
+func readIDs(path string) ([]string, error) {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+	var ids []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" && !strings.HasPrefix(line, "#") {
+			ids = append(ids, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return ids, nil
+}
+
+// getMany fetches the resources with the given IDs, at most concurrency at a
+// time, and prints them as one array in the order of ids. Resources that
+// could not be fetched are left out and reported, failing the command.
+func getMany(client *n8n.Client, basePath string, ids []string, concurrency int) error {
+	results := make([]json.RawMessage, len(ids))
+	errs := make([]error, len(ids))
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, concurrency)
+	for i, id := range ids {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+			data, err := n8nAPIRequest(client, "GET", fmt.Sprintf("%s/%s", basePath, id), "")
+			if err == nil && !json.Valid(data) {
+				err = fmt.Errorf("invalid JSON response")
+			}
+			results[i], errs[i] = data, err
+		}()
+	}
+	wg.Wait()
+
+	found := make([]json.RawMessage, 0, len(ids))
+	var failed []error
+	for i, data := range results {
+		if errs[i] != nil {
+			failed = append(failed, fmt.Errorf("%s: %w", ids[i], errs[i]))
+			continue
+		}
+		found = append(found, data)
+	}
+	out, err := json.Marshal(found)
+	if err != nil {
+		return err
+	}
+	utils.PrintJSONResponse(out)
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to get %d of %d: %w", len(failed), len(ids), errors.Join(failed...))
+	}
+	return nil
+}
@@ -0,0 +1,225 @@
+package entities
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+
+	"github.com/brandon-kyle-bailey/n8nctl/config"
+	"github.com/brandon-kyle-bailey/n8nctl/pkg/n8n"
+	"github.com/brandon-kyle-bailey/n8nctl/utils"
+	"github.com/brandon-kyle-bailey/n8nctl/workflows"
+)
+
+// bisectSkip is the exit code of a test command that cannot tell whether a
+// revision is good, as for git bisect run.
+const bisectSkip = 125
+
+type bisectResult int
+
+const (
+	bisectUntested bisectResult = iota
+	bisectGood
+	bisectBad
+	bisectSkipped
+)
+
+// bisectWorkflow finds the commit that broke a workflow file: it deploys
+// revisions of the file between a good and a bad commit to the instance and
+// runs the test command against each, halving the range every time. The
+// current version of the file is deployed again at the end. Each revision
+// is rendered with the values and partials of that revision, see
+// renderRevision.
+func bisectWorkflow(client *n8n.Client, basePath string, params []string, cfg config.Config) error {
+	fs := flag.NewFlagSet("bisect", flag.ContinueOnError)
+	good := fs.String("good", "", "Commit where the workflow worked")
+	bad := fs.String("bad", "HEAD", "Commit where the workflow is broken")
+	test := fs.String("test", "", "Shell command that exits 0 when the deployed workflow works, 125 when it cannot tell, e.g. 'n8nctl workflows test'")
+	args, err := utils.ParseFlags(fs, params)
+	if err != nil {
+		return err
+	}
+	if len(args) != 1 || *good == "" || *test == "" {
+		return fmt.Errorf("bisect requires a workflow file, --good <ref> and --test <command>")
+	}
+	file := args[0]
+	if filepath.IsAbs(file) {
+		return fmt.Errorf("bisect takes the workflow file relative to the working directory")
+	}
+
+	ctx, stop := signal.NotifyContext(Context, os.Interrupt)
+	defer stop()
+	commits, err := workflows.CommitsBetween(ctx, file, *good, *bad)
+	if err != nil {
+		return err
+	}
+	if len(commits) == 0 {
+		return fmt.Errorf("no commits changed %s between %s and %s", file, *good, *bad)
+	}
+	worktree, err := workflows.AddWorktree(ctx, commits[0].Hash)
+	if err != nil {
+		return err
+	}
+	defer worktree.Remove(context.Background())
+
+	d, err := newDeployer(client, basePath, cfg)
+	if err != nil {
+		return err
+	}
+	// Revisions under test are not versions anyone deployed on purpose.
+	d.skipHistory, d.force = true, true
+	defer func() {
+		// Runs after Ctrl-C too, so the instance is not left on an old revision.
+		defer detachContext()()
+		body, err := workflows.RenderWorkflowFile(file)
+		if err == nil {
+			_, _, err = d.deploy(file, body, body)
+		}
+		if err != nil {
+			fmt.Printf("%s deploying the current %s again: %v\n", utils.Colorize("FAIL", utils.ColorRed), file, err)
+			return
+		}
+		fmt.Printf("Deployed the current %s again.\n", file)
+	}()
+
+	results := make([]bisectResult, len(commits))
+	try := func(i int) (bisectResult, error) {
+		c := commits[i]
+		fmt.Printf("\n%s %s %s\n", utils.Colorize("bisect", utils.ColorYellow), c.Short(), c.Subject)
+		if err := worktree.Checkout(ctx, c.Hash); err != nil {
+			return 0, err
+		}
+		body, err := renderRevision(worktree, file)
+		if err != nil {
+			fmt.Printf("  skipped, %s does not render: %v\n", file, err)
+			return bisectSkipped, nil
+		}
+		_, deployed, err := d.deploy(file, body, body)
+		if err != nil {
+			return 0, fmt.Errorf("failed to deploy %s at %s: %w", file, c.Short(), err)
+		}
+		fmt.Printf("  deployed: %s\n", deployed)
+		result := runBisectTest(ctx, *test)
+		if err := ctx.Err(); err != nil {
+			return 0, err
+		}
+		fmt.Printf("  %s\n", [...]string{"", "good", "bad", "skipped, the test cannot tell"}[result])
+		return result, nil
+	}
+
+	// The last commit is assumed bad, but a test that passes there would
+	// blame it wrongly.
+	last := len(commits) - 1
+	if results[last], err = try(last); err != nil {
+		return err
+	}
+	if results[last] == bisectGood {
+		return fmt.Errorf("the test passes at %s, is --bad right?", commits[last].Short())
+	}
+	results[last] = bisectBad
+
+	steps := 1
+	for {
+		// The first bad commit is after the last good one and at or before
+		// the first bad one after it.
+		lo, hi := -1, last
+		for i, r := range results {
+			if r == bisectGood {
+				lo = i
+			}
+		}
+		for i := lo + 1; i <= last; i++ {
+			if results[i] == bisectBad {
+				hi = i
+				break
+			}
+		}
+		var untested []int
+		for i := lo + 1; i < hi; i++ {
+			if results[i] == bisectUntested {
+				untested = append(untested, i)
+			}
+		}
+		if len(untested) == 0 {
+			return reportBisect(commits[lo+1:hi+1], results[lo+1:hi+1], steps)
+		}
+		next := untested[len(untested)/2]
+		if results[next], err = try(next); err != nil {
+			return err
+		}
+		steps++
+	}
+}
+
+// renderRevision renders file as checked out in worktree. The values files,
+// and the partials, overlays and included files next to it, are those of
+// the revision too, but the env files are the current ones: they hold
+// secrets and are rarely committed.
+func renderRevision(worktree *workflows.Worktree, file string) ([]byte, error) {
+	wd, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	envFiles := workflows.EnvFiles
+	defer func() { workflows.EnvFiles = envFiles }()
+	workflows.EnvFiles = make([]string, len(envFiles))
+	for i, path := range envFiles {
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(wd, path)
+		}
+		workflows.EnvFiles[i] = path
+	}
+	// Values files are read from the working directory.
+	if err := os.Chdir(worktree.Path(".")); err != nil {
+		return nil, err
+	}
+	defer os.Chdir(wd)
+	return workflows.RenderWorkflowFile(file)
+}
+
+// runBisectTest runs the test command through the shell, passing its output
+// through.
+func runBisectTest(ctx context.Context, command string) bisectResult {
+	shell, arg := "sh", "-c"
+	if runtime.GOOS == "windows" {
+		shell, arg = "cmd", "/C"
+	}
+	cmd := exec.CommandContext(ctx, shell, arg, command)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		return bisectGood
+	case errors.As(err, &exitErr) && exitErr.ExitCode() == bisectSkip:
+		return bisectSkipped
+	}
+	return bisectBad
+}
+
+// reportBisect prints the commit that broke the workflow, or the commits
+// that could have when skipped ones are in the way.
+func reportBisect(suspects []workflows.Commit, results []bisectResult, steps int) error {
+	fmt.Println()
+	if len(suspects) == 1 {
+		c := suspects[0]
+		fmt.Printf("%s is the first bad commit (%d steps):\n", c.Hash, steps)
+		fmt.Printf("  %s\n  %s, %s\n", c.Subject, c.Author, c.Time.Local().Format("2006-01-02 15:04"))
+		return nil
+	}
+	fmt.Printf("The first bad commit could be any of these, some could not be tested (%d steps):\n", steps)
+	for i, c := range suspects {
+		note := ""
+		if results[i] == bisectSkipped {
+			note = " (skipped)"
+		}
+		fmt.Printf("  %s %s%s\n", c.Short(), c.Subject, note)
+	}
+	return nil
+}
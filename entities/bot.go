@@ -0,0 +1,79 @@
+package entities
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/brandon-kyle-bailey/n8nctl/bot"
+	"github.com/brandon-kyle-bailey/n8nctl/config"
+	"github.com/brandon-kyle-bailey/n8nctl/telemetry"
+)
+
+// HandleBot serves chat commands for the project in the working directory:
+// Slack events on /slack/events, and the commands of any other chat
+// integration on /webhook.
+func HandleBot(args []string) {
+	fs := flag.NewFlagSet("bot", flag.ContinueOnError)
+	port := fs.Int("port", 8090, "Port to listen on")
+	slackToken := fs.String("slack-token", os.Getenv("SLACK_BOT_TOKEN"), "Bot token of the Slack app (default from SLACK_BOT_TOKEN)")
+	signingSecret := fs.String("slack-signing-secret", os.Getenv("SLACK_SIGNING_SECRET"), "Signing secret of the Slack app (default from SLACK_SIGNING_SECRET)")
+	webhookSecret := fs.String("webhook-secret", os.Getenv("N8NCTL_BOT_SECRET"), "Bearer token of /webhook requests (default from N8NCTL_BOT_SECRET)")
+	quiet := fs.Bool("quiet", false, "Do not log commands")
+	if err := fs.Parse(args); err != nil {
+		telemetry.Exit(1)
+	}
+	if *slackToken == "" && *webhookSecret == "" {
+		fmt.Println("Usage: n8nctl bot --slack-token <xoxb-...> --slack-signing-secret <secret> | --webhook-secret <secret> [--port 8090]")
+		telemetry.Exit(1)
+	}
+	if *slackToken != "" && *signingSecret == "" {
+		fmt.Println("Error: --slack-token needs --slack-signing-secret to verify the events Slack sends")
+		telemetry.Exit(1)
+	}
+
+	project, err := config.LoadProject()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		telemetry.Exit(1)
+	}
+	dir, err := os.Getwd()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		telemetry.Exit(1)
+	}
+	b, err := bot.New(project.Bot, dir)
+	if err != nil {
+		fmt.Printf("Error: %s: %v\n", config.ProjectFile, err)
+		telemetry.Exit(1)
+	}
+	if !*quiet {
+		b.Log = os.Stderr
+	}
+
+	mux := http.NewServeMux()
+	if *slackToken != "" {
+		reaction := project.Bot.ApprovalReaction
+		if reaction == "" {
+			reaction = "white_check_mark"
+		}
+		mux.Handle("/slack/events", &bot.Slack{Bot: b, Token: *slackToken, SigningSecret: *signingSecret, Reaction: reaction, Context: Context})
+		fmt.Fprintf(os.Stderr, "Slack events: http://localhost:%d/slack/events\n", *port)
+	}
+	if *webhookSecret != "" {
+		mux.Handle("/webhook", &bot.Webhook{Bot: b, Secret: *webhookSecret})
+		fmt.Fprintf(os.Stderr, "Webhook: http://localhost:%d/webhook\n", *port)
+	}
+	server := &http.Server{Addr: fmt.Sprintf(":%d", *port), Handler: mux}
+	go func() {
+		<-Context.Done()
+		server.Close()
+	}()
+	fmt.Fprintf(os.Stderr, "Serving %d commands for %s (Ctrl+C to stop)\n", len(b.Commands), dir)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		fmt.Printf("Error: %v\n", err)
+		telemetry.Exit(1)
+	}
+}
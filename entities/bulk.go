@@ -0,0 +1,127 @@
+package entities
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/brandon-kyle-bailey/n8nctl/config"
+	"github.com/brandon-kyle-bailey/n8nctl/manifest"
+	"github.com/brandon-kyle-bailey/n8nctl/pkg/n8n"
+	"github.com/brandon-kyle-bailey/n8nctl/prompt"
+	"github.com/brandon-kyle-bailey/n8nctl/utils"
+)
+
+// bulkSelector parses the flags of a workflow action that takes either an ID
+// or selectors. It returns nil when an ID was given, leaving the action to
+// its single form.
+func bulkSelector(action string, params []string) (*workflowSelector, error) {
+	fs := flag.NewFlagSet(action, flag.ContinueOnError)
+	var sel workflowSelector
+	sel.register(fs, true)
+	args, err := utils.ParseFlags(fs, params)
+	if err != nil {
+		return nil, err
+	}
+	if !sel.given() {
+		return nil, nil
+	}
+	if len(args) > 0 {
+		return nil, fmt.Errorf("%s takes a workflow ID or selectors, not both", action)
+	}
+	if err := sel.validate(true); err != nil {
+		return nil, err
+	}
+	return &sel, nil
+}
+
+// bulkWorkflows activates, deactivates or deletes the selected workflows,
+// skipping those already in the wanted state.
+func bulkWorkflows(client *n8n.Client, basePath, action string, sel *workflowSelector, cfg config.Config) error {
+	selected, err := sel.selectWorkflows(client, basePath, cfg)
+	if err != nil {
+		return err
+	}
+	var targets []remoteWorkflow
+	for _, wf := range selected {
+		if (action == "activate" && wf.Active) || (action == "deactivate" && !wf.Active) {
+			continue
+		}
+		targets = append(targets, wf)
+	}
+	if len(targets) == 0 {
+		fmt.Printf("No workflows to %s: %d selected, all already in that state.\n", action, len(selected))
+		return nil
+	}
+	return confirmBulk(client, basePath, action, targets)
+}
+
+// confirmBulk activates, deactivates or deletes targets after confirming
+// them item by item. Activation changes are recorded in an undo file.
+func confirmBulk(client *n8n.Client, basePath, action string, targets []remoteWorkflow) error {
+	// Review the workflows one by one, as a broad selector may catch more
+	// than intended.
+	verb := strings.ToUpper(action[:1]) + action[1:]
+	rows := make([][]string, len(targets))
+	for i, wf := range targets {
+		rows[i] = []string{wf.ID, wf.Name, tagNames(wf.Tags), activeLabel(wf.Active)}
+	}
+	keep, err := prompt.ConfirmItems(fmt.Sprintf("%s these workflows?", verb), []string{"ID", "NAME", "TAGS", "STATE"}, rows)
+	if err != nil {
+		return err
+	}
+	var chosen []remoteWorkflow
+	for i, ok := range keep {
+		if ok {
+			chosen = append(chosen, targets[i])
+		}
+	}
+	if len(chosen) == 0 {
+		fmt.Printf("%s aborted by user.\n", verb)
+		return nil
+	}
+	targets = chosen
+
+	// Record the earlier activation state first, so even a change that fails
+	// halfway can be undone.
+	if action != "delete" {
+		path, err := saveUndo(client, action, targets)
+		if err != nil {
+			return err
+		}
+		defer fmt.Printf("Undo with: n8nctl undo %s\n", path)
+	}
+
+	failed := 0
+	for _, wf := range targets {
+		method, url := "POST", fmt.Sprintf("%s/%s/%s", basePath, wf.ID, action)
+		if action == "delete" {
+			method, url = "DELETE", fmt.Sprintf("%s/%s", basePath, wf.ID)
+		}
+		if _, err := n8nAPIRequest(client, method, url, ""); err != nil {
+			failed++
+			fmt.Printf("%s %s %q: %v\n", utils.Colorize("FAIL", utils.ColorRed), wf.ID, wf.Name, err)
+			continue
+		}
+		fmt.Printf("%s   %s %q: %sd\n", utils.Colorize("OK", utils.ColorGreen), wf.ID, wf.Name, action)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d workflows failed to %s", failed, len(targets), action)
+	}
+	return nil
+}
+
+func tagNames(tags []manifest.Tag) string {
+	names := make([]string, len(tags))
+	for i, tag := range tags {
+		names[i] = tag.Name
+	}
+	return strings.Join(names, ",")
+}
+
+func activeLabel(active bool) string {
+	if active {
+		return "active"
+	}
+	return "inactive"
+}
@@ -0,0 +1,169 @@
+package entities
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/brandon-kyle-bailey/n8nctl/config"
+	"github.com/brandon-kyle-bailey/n8nctl/lint"
+	"github.com/brandon-kyle-bailey/n8nctl/pkg/n8n"
+	"github.com/brandon-kyle-bailey/n8nctl/report"
+	"github.com/brandon-kyle-bailey/n8nctl/state"
+	"github.com/brandon-kyle-bailey/n8nctl/utils"
+	"github.com/brandon-kyle-bailey/n8nctl/workflows"
+)
+
+// checkFiles renders every project workflow file and runs check on it,
+// printing the findings and a summary. Files whose findings are in cache
+// are not checked again; a nil cache checks every file.
+func checkFiles(dir string, check func(file string, body []byte) []lint.Finding, cache *checkCache) ([]string, []lint.Finding, error) {
+	files, err := workflows.ProjectFiles(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	// Files are checked in parallel as they are rendered, and their findings
+	// kept in the order of the files.
+	perFile := make([][]lint.Finding, len(files))
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, max(workflows.RenderWorkers, 1))
+	for i, rendered := range workflows.RenderFiles(files) {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+			perFile[i] = checkRendered(rendered, check, cache)
+		}()
+	}
+	wg.Wait()
+	var findings []lint.Finding
+	for _, f := range perFile {
+		findings = append(findings, f...)
+	}
+
+	errors, warnings := 0, 0
+	for _, f := range findings {
+		label := utils.Colorize("warning", utils.ColorYellow)
+		if f.Severity == lint.SeverityError {
+			label = utils.Colorize("error", utils.ColorRed)
+			errors++
+		} else {
+			warnings++
+		}
+		fmt.Printf("%s: %s: %s (%s)\n", f.Position(), label, f.Message, f.Rule)
+	}
+	if len(findings) > 0 {
+		fmt.Println()
+	}
+	fmt.Printf("%d files checked, %d errors, %d warnings\n", len(files), errors, warnings)
+	return files, findings, nil
+}
+
+// checkRendered runs check on a rendered file, or reports why it failed to
+// render.
+func checkRendered(rendered workflows.Rendered, check func(file string, body []byte) []lint.Finding, cache *checkCache) []lint.Finding {
+	file, err := rendered.File, rendered.Err
+	if err != nil {
+		finding := lint.Finding{File: file, Rule: "render", Severity: lint.SeverityError, Message: err.Error()}
+		var srcErr *workflows.SourceError
+		if errors.As(err, &srcErr) {
+			finding.Line, finding.Column, finding.Message = srcErr.Line, srcErr.Column, srcErr.Message
+		}
+		return []lint.Finding{finding}
+	}
+	findings, cached := []lint.Finding(nil), false
+	if cache != nil {
+		findings, cached = cache.get(file, rendered.Body)
+	}
+	if !cached {
+		findings = check(file, rendered.Body)
+		if cache != nil {
+			cache.put(file, rendered.Body, findings)
+		}
+	}
+	return locate(file, findings)
+}
+
+// fetchNodeTypes fetches the descriptions of the node types installed on
+// the instance, which the editor loads from /types/nodes.json, and returns
+// them with their hash.
+func fetchNodeTypes(client *n8n.Client) (lint.NodeTypes, string, error) {
+	data, err := n8nAPIRequest(client, "GET", client.BaseURL()+"/types/nodes.json", "")
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to fetch the node types of the instance: %w", err)
+	}
+	types, err := lint.ParseNodeTypes(data)
+	return types, state.Hash(data), err
+}
+
+// withNodeTypeChecks extends check with the checks against the node types
+// of the instance, for workflows that are valid otherwise.
+func withNodeTypeChecks(check func(file string, body []byte) []lint.Finding, types lint.NodeTypes) func(file string, body []byte) []lint.Finding {
+	return func(file string, body []byte) []lint.Finding {
+		findings := check(file, body)
+		if lint.HasErrors(lint.Validate(file, body)) {
+			return findings
+		}
+		return append(findings, lint.CheckNodeTypes(file, body, types)...)
+	}
+}
+
+// lintOverrides applies the rule severities of the lint section of
+// .n8nctl.yaml.
+func lintOverrides() error {
+	project, err := config.LoadProject()
+	if err != nil {
+		return err
+	}
+	for id, severity := range project.Lint.Rules {
+		lint.Overrides[id] = lint.Severity(severity)
+	}
+	if err := lint.CheckOverrides(lint.Overrides); err != nil {
+		return fmt.Errorf("%s: %w", config.ProjectFile, err)
+	}
+	return nil
+}
+
+// locate sets the source position of findings from their path. Findings
+// keep working without positions if the source cannot be indexed.
+func locate(file string, findings []lint.Finding) []lint.Finding {
+	if len(findings) == 0 {
+		return findings
+	}
+	positions, err := workflows.SourcePositions(file)
+	if err != nil {
+		return findings
+	}
+	for i, f := range findings {
+		if pos, ok := positions.Lookup(f.Path); ok {
+			findings[i].Line, findings[i].Column = pos.Line, pos.Column
+		}
+	}
+	return findings
+}
+
+// findingsReport reports each file as a test case that fails on errors.
+// Warnings are listed in the case output.
+func findingsReport(name string, files []string, findings []lint.Finding) []report.Suite {
+	suite := report.Suite{Name: name}
+	for _, file := range files {
+		c := report.Case{Name: file, ClassName: name}
+		var errs, warns []string
+		for _, f := range findings {
+			if f.File != file {
+				continue
+			}
+			if f.Severity == lint.SeverityError {
+				errs = append(errs, f.String())
+			} else {
+				warns = append(warns, f.String())
+			}
+		}
+		c.Failure = strings.Join(errs, "\n")
+		c.Output = strings.Join(warns, "\n")
+		suite.Cases = append(suite.Cases, c)
+	}
+	return []report.Suite{suite}
+}
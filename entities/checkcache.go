@@ -0,0 +1,75 @@
+package entities
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/brandon-kyle-bailey/n8nctl/lint"
+	"github.com/brandon-kyle-bailey/n8nctl/state"
+	"github.com/brandon-kyle-bailey/n8nctl/workflows"
+)
+
+// checkCacheDir keeps the findings of validate and lint for files that
+// have not changed since they were checked.
+var checkCacheDir = filepath.Join(workflows.CacheDir, "check")
+
+// checkCache looks up the findings of a file by the hash of its rendered
+// JSON. Everything else findings depend on is in key: the check, the rule
+// severities, the node types of the instance and the n8nctl binary itself.
+type checkCache struct {
+	key string
+}
+
+// newCheckCache returns the cache of a check. nodeTypes is the hash of the
+// node types checked against, which change with the version of the
+// instance and the community nodes installed, or "" without them.
+func newCheckCache(action, nodeTypes string) *checkCache {
+	var overrides []string
+	for _, id := range slices.Sorted(maps.Keys(lint.Overrides)) {
+		overrides = append(overrides, id+"="+string(lint.Overrides[id]))
+	}
+	// A new build of n8nctl may check differently.
+	binary := ""
+	if exe, err := os.Executable(); err == nil {
+		if info, err := os.Stat(exe); err == nil {
+			binary = fmt.Sprintf("%s %d %d", exe, info.Size(), info.ModTime().UnixNano())
+		}
+	}
+	return &checkCache{key: strings.Join([]string{action, strings.Join(overrides, ","), nodeTypes, binary}, "\x00")}
+}
+
+func (c *checkCache) path(file string, body []byte) string {
+	key := strings.Join([]string{c.key, filepath.ToSlash(filepath.Clean(file)), state.Hash(body)}, "\x00")
+	return filepath.Join(checkCacheDir, state.Hash([]byte(key))[:16]+".json")
+}
+
+func (c *checkCache) get(file string, body []byte) ([]lint.Finding, bool) {
+	data, err := os.ReadFile(c.path(file, body))
+	if err != nil {
+		return nil, false
+	}
+	var findings []lint.Finding
+	if json.Unmarshal(data, &findings) != nil {
+		return nil, false
+	}
+	if Stats != nil {
+		Stats.CacheHits.Add(1)
+	}
+	return findings, true
+}
+
+// put stores the findings of a file before they are located, as positions
+// change with the formatting of the YAML but findings do not.
+func (c *checkCache) put(file string, body []byte, findings []lint.Finding) {
+	if findings == nil {
+		findings = []lint.Finding{}
+	}
+	if data, err := json.Marshal(findings); err == nil {
+		workflows.WriteCacheFile(c.path(file, body), data)
+	}
+}
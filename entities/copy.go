@@ -0,0 +1,171 @@
+package entities
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/brandon-kyle-bailey/n8nctl/config"
+	"github.com/brandon-kyle-bailey/n8nctl/manifest"
+	"github.com/brandon-kyle-bailey/n8nctl/pkg/n8n"
+	"github.com/brandon-kyle-bailey/n8nctl/utils"
+)
+
+// copyWorkflow creates a copy of a workflow of this instance on the instance
+// of another profile. Credential IDs differ between instances, so the nodes
+// are pointed at the target's credentials of the same name and type, or
+// their credentials are stripped.
+func copyWorkflow(client *n8n.Client, basePath string, params []string) error {
+	fs := flag.NewFlagSet("copy", flag.ContinueOnError)
+	to := fs.String("to", "", "Profile of the instance to copy the workflow to")
+	credentials := fs.String("credentials", "map", "What to do with node credentials: map (to those with the same name and type on the target), strip, or keep (the IDs as they are)")
+	name := fs.String("name", "", "Name of the copy (default: the workflow's name)")
+	args, err := utils.ParseFlags(fs, params)
+	if err != nil {
+		return err
+	}
+	if len(args) != 1 {
+		return fmt.Errorf("copy requires exactly one workflow ID")
+	}
+	if *to == "" {
+		return fmt.Errorf("copy requires --to <profile>")
+	}
+	if *credentials != "map" && *credentials != "strip" && *credentials != "keep" {
+		return fmt.Errorf("unknown --credentials %q, expected map, strip or keep", *credentials)
+	}
+	target, err := config.LoadProfile(*to)
+	if err != nil {
+		return err
+	}
+	if target.ReadOnly {
+		return fmt.Errorf("profile %s is read-only, cannot copy a workflow to it", *to)
+	}
+	targetClient := newClient(target)
+	targetPath := target.APIBase() + "/workflows"
+
+	data, err := n8nAPIRequest(client, "GET", fmt.Sprintf("%s/%s", basePath, args[0]), "")
+	if err != nil {
+		return err
+	}
+	var wf manifest.Workflow
+	if err := json.Unmarshal(data, &wf); err != nil {
+		return fmt.Errorf("failed to parse workflow %s: %w", args[0], err)
+	}
+	if *name != "" {
+		wf.Name = *name
+	}
+	existing, err := findWorkflowByName(targetClient, targetPath, wf.Name, target)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		return fmt.Errorf("profile %s already has a workflow named %q (%s), choose another --name", *to, wf.Name, existing.ID)
+	}
+
+	remap := func(string, map[string]any) bool { return *credentials == "keep" }
+	if *credentials == "map" {
+		if remap, err = credentialsByName(targetClient, target, wf); err != nil {
+			return err
+		}
+	}
+	body, err := workflowPayload(wf, remap)
+	if err != nil {
+		return err
+	}
+	scoped, err := scopeCreate(targetClient, target, "workflows", string(body))
+	if err != nil {
+		return err
+	}
+	resp, err := n8nAPIRequest(targetClient, "POST", targetPath, scoped)
+	if err != nil {
+		return fmt.Errorf("failed to create the workflow on profile %s: %w", *to, err)
+	}
+	var created remoteWorkflow
+	if err := json.Unmarshal(resp, &created); err != nil || created.ID == "" {
+		return fmt.Errorf("unexpected response creating the workflow: %s", resp)
+	}
+	fmt.Fprintf(os.Stderr, "Copied %q (%s) to profile %s as %s, inactive\n", wf.Name, args[0], *to, created.ID)
+	fmt.Println(created.ID)
+	return nil
+}
+
+// credentialsByName returns a remapping of the credentials of wf's nodes to
+// the target's credentials with the same name and type, failing if any of
+// them has no counterpart there.
+func credentialsByName(client *n8n.Client, cfg config.Config, wf manifest.Workflow) (func(string, map[string]any) bool, error) {
+	list, err := fetchAll[struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+		Type string `json:"type"`
+	}](client, client.APIURL("credentials"), cfg)
+	if unlisted(err) {
+		return nil, fmt.Errorf("the target instance does not list credentials, use --credentials strip or keep")
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to list credentials of the target: %w", err)
+	}
+	ids := map[string]string{}
+	for _, c := range list {
+		ids[c.Type+"/"+c.Name] = c.ID
+	}
+
+	missing := map[string]bool{}
+	remap := func(credType string, ref map[string]any) bool {
+		name, _ := ref["name"].(string)
+		id, ok := ids[credType+"/"+name]
+		if !ok {
+			missing[fmt.Sprintf("%s (%s)", name, credType)] = true
+			return false
+		}
+		ref["id"] = id
+		return true
+	}
+	// A dry run finds every missing credential before anything is created.
+	if _, err := workflowPayload(wf, remap); err != nil {
+		return nil, err
+	}
+	if len(missing) > 0 {
+		names := make([]string, 0, len(missing))
+		for name := range missing {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("the target has no credentials named %s, create them or use --credentials strip", strings.Join(names, ", "))
+	}
+	return remap, nil
+}
+
+// workflowPayload returns the fields of wf the API accepts on create and
+// update. credential is called with the type and reference of every
+// credential of its nodes, and may change the reference, or return false to
+// remove it.
+func workflowPayload(wf manifest.Workflow, credential func(credType string, ref map[string]any) bool) ([]byte, error) {
+	var nodes []map[string]any
+	if err := json.Unmarshal(wf.Nodes, &nodes); err != nil {
+		return nil, fmt.Errorf("invalid nodes: %w", err)
+	}
+	for _, node := range nodes {
+		credentials, _ := node["credentials"].(map[string]any)
+		for credType, ref := range credentials {
+			ref, ok := ref.(map[string]any)
+			if ok && !credential(credType, ref) {
+				delete(credentials, credType)
+			}
+		}
+		if credentials != nil && len(credentials) == 0 {
+			delete(node, "credentials")
+		}
+	}
+	settings := wf.Settings
+	if len(settings) == 0 || string(settings) == "null" {
+		settings = json.RawMessage("{}")
+	}
+	return json.Marshal(map[string]any{
+		"name":        wf.Name,
+		"nodes":       nodes,
+		"connections": wf.Connections,
+		"settings":    settings,
+	})
+}
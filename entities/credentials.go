@@ -0,0 +1,140 @@
+package entities
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	neturl "net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/brandon-kyle-bailey/n8nctl/pkg/n8n"
+	"github.com/brandon-kyle-bailey/n8nctl/utils"
+)
+
+// credentialSchema prints the JSON schema of the data a credential type
+// expects, which is what credentials create needs in its "data" field.
+func credentialSchema(client *n8n.Client, basePath, typeName string) error {
+	resp, err := n8nAPIRequest(client, "GET", fmt.Sprintf("%s/schema/%s", basePath, neturl.PathEscape(typeName)), "")
+	var apiErr *n8n.APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("credential type %q not found, types are named like httpHeaderAuth or githubApi", typeName)
+	}
+	if err != nil {
+		return err
+	}
+	utils.PrintJSONResponse(resp)
+	return nil
+}
+
+// envCredential builds the payload of credentials create from environment
+// variables, so CI can provision credentials without writing their secrets
+// to disk: --type httpHeaderAuth --from-env NAME=HDR_NAME,VALUE=HDR_TOKEN.
+type envCredential struct {
+	typeName string
+	name     string
+	fields   listFlag
+}
+
+func (e *envCredential) register(fs *flag.FlagSet) {
+	fs.StringVar(&e.typeName, "type", "", "Credential type of --from-env, e.g. httpHeaderAuth")
+	fs.StringVar(&e.name, "name", "", "Credential name of --from-env (default: the type)")
+	fs.Var(&e.fields, "from-env", "Build the credential data from environment variables: FIELD=VAR (repeatable, or comma-separated)")
+}
+
+func (e *envCredential) given() bool {
+	return len(e.fields) > 0
+}
+
+// payload reads the environment variables into the credential's data. Fields
+// are matched to those of the type's schema regardless of case and converted
+// to the schema's type; without a schema they are taken as given.
+func (e *envCredential) payload(client *n8n.Client, basePath string) (string, error) {
+	if e.typeName == "" {
+		return "", fmt.Errorf("--from-env requires the credential --type")
+	}
+	var schema struct {
+		Properties map[string]struct {
+			Type string `json:"type"`
+		} `json:"properties"`
+		Required []string `json:"required"`
+	}
+	resp, err := n8nAPIRequest(client, "GET", fmt.Sprintf("%s/schema/%s", basePath, neturl.PathEscape(e.typeName)), "")
+	var apiErr *n8n.APIError
+	switch {
+	case errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound:
+		return "", fmt.Errorf("credential type %q not found, types are named like httpHeaderAuth or githubApi", e.typeName)
+	case err != nil:
+		fmt.Fprintf(os.Stderr, "Warning: could not fetch the schema of %s, using the fields as given: %v\n", e.typeName, err)
+	default:
+		if err := json.Unmarshal(resp, &schema); err != nil {
+			return "", fmt.Errorf("failed to parse the schema of %s: %w", e.typeName, err)
+		}
+	}
+
+	data := map[string]any{}
+	for _, mapping := range e.fields {
+		field, variable, ok := strings.Cut(mapping, "=")
+		if !ok || field == "" || variable == "" {
+			return "", fmt.Errorf("invalid --from-env %q, expected FIELD=VAR", mapping)
+		}
+		value, ok := os.LookupEnv(variable)
+		if !ok {
+			return "", fmt.Errorf("environment variable %s of field %s is not set", variable, field)
+		}
+		if schema.Properties == nil {
+			data[field] = value
+			continue
+		}
+		var typed any
+		var known bool
+		for name, prop := range schema.Properties {
+			if !strings.EqualFold(name, field) {
+				continue
+			}
+			field, known = name, true
+			if typed, err = envValue(value, prop.Type); err != nil {
+				return "", fmt.Errorf("field %s from %s: %w", name, variable, err)
+			}
+		}
+		if !known {
+			return "", fmt.Errorf("%s has no field %s, see credentials schema %s", e.typeName, field, e.typeName)
+		}
+		data[field] = typed
+	}
+	for _, name := range schema.Required {
+		if _, ok := data[name]; !ok {
+			return "", fmt.Errorf("%s requires the field %s, add it to --from-env", e.typeName, name)
+		}
+	}
+
+	name := e.name
+	if name == "" {
+		name = e.typeName
+	}
+	body, err := json.Marshal(map[string]any{"name": name, "type": e.typeName, "data": data})
+	return string(body), err
+}
+
+// envValue converts the value of an environment variable to the JSON type of
+// a credential field.
+func envValue(value, jsonType string) (any, error) {
+	switch jsonType {
+	case "number", "integer":
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("expected a number")
+		}
+		return n, nil
+	case "boolean":
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("expected true or false")
+		}
+		return b, nil
+	}
+	return value, nil
+}
@@ -0,0 +1,150 @@
+package entities
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"regexp"
+
+	"github.com/brandon-kyle-bailey/n8nctl/config"
+	"github.com/brandon-kyle-bailey/n8nctl/pkg/n8n"
+	"github.com/brandon-kyle-bailey/n8nctl/utils"
+	"github.com/brandon-kyle-bailey/n8nctl/workflows"
+)
+
+// credentialManifest lists the credentials to provision, with their secrets
+// given as placeholders such as ${{env:GITHUB_TOKEN}} or
+// ${{vault:secret/data/github#token}}:
+//
+//	credentials:
+//	  - name: GitHub
+//	    type: httpHeaderAuth
+//	    data:
+//	      name: Authorization
+//	      value: Bearer ${{env:GITHUB_TOKEN}}
+type credentialManifest struct {
+	Credentials []struct {
+		Name string         `json:"name"`
+		Type string         `json:"type"`
+		Data map[string]any `json:"data"`
+	} `json:"credentials"`
+}
+
+var placeholderPattern = regexp.MustCompile(`\${{[^}]*}}`)
+
+// applyCredentials creates the credentials of a manifest that do not exist
+// yet, by name and type, and updates those that do, so bootstrapping an
+// environment can run again safely.
+func applyCredentials(client *n8n.Client, basePath string, params []string, cfg config.Config) error {
+	fs := flag.NewFlagSet("apply", flag.ContinueOnError)
+	file := fs.String("file", "", "Credentials manifest (YAML) to apply")
+	fs.StringVar(file, "f", "", "Shorthand for --file")
+	dryRun := fs.Bool("dry-run", false, "Show which credentials would be created or updated without resolving secrets or changing anything")
+	if _, err := utils.ParseFlags(fs, params); err != nil {
+		return err
+	}
+	if *file == "" {
+		return fmt.Errorf("apply requires a credentials manifest, e.g. -f credentials.yaml")
+	}
+
+	// Manifests are rendered like workflow files, resolving .env variables
+	// and secret stores, except for a dry run which needs no secrets.
+	render := workflows.RenderWorkflowFile
+	if *dryRun {
+		render = workflows.RenderTemplate
+	} else {
+		useSecretStores(cfg)
+	}
+	body, err := render(*file)
+	if err != nil {
+		return err
+	}
+	if !*dryRun {
+		if unresolved := placeholderPattern.FindAll(body, -1); len(unresolved) > 0 {
+			return fmt.Errorf("%s has unresolved placeholders, such as %s", *file, unresolved[0])
+		}
+	}
+	var m credentialManifest
+	if err := json.Unmarshal(body, &m); err != nil {
+		return fmt.Errorf("invalid credentials manifest %s: %w", *file, err)
+	}
+	if len(m.Credentials) == 0 {
+		return fmt.Errorf("%s lists no credentials", *file)
+	}
+	seen := map[string]bool{}
+	for i, c := range m.Credentials {
+		if c.Name == "" || c.Type == "" {
+			return fmt.Errorf("credential %d of %s needs a name and a type", i+1, *file)
+		}
+		if seen[c.Type+"/"+c.Name] {
+			return fmt.Errorf("%s lists %s (%s) twice", *file, c.Name, c.Type)
+		}
+		seen[c.Type+"/"+c.Name] = true
+	}
+
+	listURL, err := scopeList(client, cfg, "credentials", basePath)
+	if err != nil {
+		return err
+	}
+	existing, err := fetchAll[struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+		Type string `json:"type"`
+	}](client, listURL, cfg)
+	if unlisted(err) {
+		return fmt.Errorf("this instance does not list credentials, so existing ones cannot be matched: %w", err)
+	} else if err != nil {
+		return fmt.Errorf("failed to list credentials: %w", err)
+	}
+	ids := map[string]string{}
+	for _, c := range existing {
+		ids[c.Type+"/"+c.Name] = c.ID
+	}
+
+	created, updated, failed := 0, 0, 0
+	for _, c := range m.Credentials {
+		label := fmt.Sprintf("%s (%s)", c.Name, c.Type)
+		id, exists := ids[c.Type+"/"+c.Name]
+		if *dryRun {
+			if exists {
+				fmt.Printf("  update  %s %s\n", label, id)
+			} else {
+				fmt.Printf("  create  %s\n", label)
+			}
+			continue
+		}
+		payload, err := json.Marshal(map[string]any{"name": c.Name, "type": c.Type, "data": c.Data})
+		if err != nil {
+			return err
+		}
+		outcome := "created"
+		if exists {
+			_, err = n8nAPIRequest(client, "PATCH", fmt.Sprintf("%s/%s", basePath, id), string(payload))
+			outcome = "updated " + id
+		} else {
+			var body string
+			if body, err = scopeCreate(client, cfg, "credentials", string(payload)); err == nil {
+				_, err = n8nAPIRequest(client, "POST", basePath, body)
+			}
+		}
+		if err != nil {
+			failed++
+			fmt.Printf("%s %s: %s\n", utils.Colorize("FAIL", utils.ColorRed), label, config.Redact(err.Error(), cfg.APIToken))
+			continue
+		}
+		if exists {
+			updated++
+		} else {
+			created++
+		}
+		fmt.Printf("%s   %s: %s\n", utils.Colorize("OK", utils.ColorGreen), label, outcome)
+	}
+	if *dryRun {
+		return nil
+	}
+	fmt.Printf("\n%d created, %d updated, %d failed\n", created, updated, failed)
+	if failed > 0 {
+		return fmt.Errorf("%d of %d credentials failed to apply", failed, len(m.Credentials))
+	}
+	return nil
+}
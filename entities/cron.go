@@ -0,0 +1,62 @@
+package entities
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/brandon-kyle-bailey/n8nctl/cron"
+	"github.com/brandon-kyle-bailey/n8nctl/telemetry"
+)
+
+// HandleCron runs the n8nctl commands of a schedule file on their cron
+// expressions until interrupted, lists when they run next, or runs one of
+// them right away.
+func HandleCron(args []string) {
+	fs := flag.NewFlagSet("cron", flag.ContinueOnError)
+	file := fs.String("file", "schedule.yaml", "Schedule file of the jobs to run")
+	fs.StringVar(file, "f", "schedule.yaml", "Shorthand for --file")
+	list := fs.Bool("list", false, "List the jobs and when they run next, then exit")
+	runNow := fs.String("run", "", "Run this job once now, then exit")
+	if err := fs.Parse(args); err != nil {
+		telemetry.Exit(1)
+	}
+	schedule, loc, err := cron.Load(*file)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		telemetry.Exit(1)
+	}
+	dir, err := os.Getwd()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		telemetry.Exit(1)
+	}
+	runner := &cron.Runner{Jobs: schedule.Jobs, Location: loc, Dir: dir, Log: os.Stderr}
+
+	switch {
+	case *list:
+		now := time.Now().In(loc)
+		for _, job := range schedule.Jobs {
+			next := "never"
+			if at := job.Next(now); !at.IsZero() {
+				next = at.Format("2006-01-02 15:04:05 MST")
+			}
+			fmt.Printf("%-20s %-16s next %s\n", job.Name, job.Schedule, next)
+		}
+	case *runNow != "":
+		for i := range schedule.Jobs {
+			if schedule.Jobs[i].Name == *runNow {
+				if !runner.RunJob(Context, &schedule.Jobs[i]) {
+					telemetry.Exit(1)
+				}
+				return
+			}
+		}
+		fmt.Printf("Error: %s has no job %q\n", *file, *runNow)
+		telemetry.Exit(1)
+	default:
+		fmt.Fprintf(os.Stderr, "Running %d jobs of %s in %s (Ctrl+C to stop)\n", len(schedule.Jobs), *file, loc)
+		runner.Run(Context)
+	}
+}
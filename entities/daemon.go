@@ -0,0 +1,132 @@
+package entities
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/brandon-kyle-bailey/n8nctl/daemon"
+	"github.com/brandon-kyle-bailey/n8nctl/telemetry"
+)
+
+// HandleDaemon runs the daemon that keeps connections and node type
+// catalogs warm for other invocations, until it is stopped, interrupted or
+// idle for too long, or reports on or stops a running daemon.
+func HandleDaemon(args []string) {
+	socket, err := daemon.SocketPath()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		telemetry.Exit(1)
+	}
+	if len(args) > 0 && (args[0] == "status" || args[0] == "stop") {
+		if err := daemonCommand(args[0], socket); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			telemetry.Exit(1)
+		}
+		return
+	}
+
+	fs := flag.NewFlagSet("daemon", flag.ContinueOnError)
+	idle := fs.Duration("idle", 30*time.Minute, "Stop after this long without requests, 0 to run until stopped")
+	nodeTypesTTL := fs.Duration("node-types-ttl", 10*time.Minute, "How long to serve a fetched node type catalog before fetching it again")
+	quiet := fs.Bool("quiet", false, "Do not log requests")
+	if err := fs.Parse(args); err != nil {
+		telemetry.Exit(1)
+	}
+	if daemon.Running(socket) {
+		fmt.Printf("A daemon is already running on %s, see n8nctl daemon status.\n", socket)
+		telemetry.Exit(1)
+	}
+	// A daemon that did not shut down cleanly leaves its socket behind.
+	os.Remove(socket)
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		telemetry.Exit(1)
+	}
+	// The socket hands out the API access of every profile used through it.
+	if err := os.Chmod(socket, 0600); err != nil {
+		listener.Close()
+		fmt.Printf("Error: %v\n", err)
+		telemetry.Exit(1)
+	}
+
+	handler := daemon.NewServer()
+	handler.NodeTypesTTL = *nodeTypesTTL
+	if !*quiet {
+		handler.Log = os.Stderr
+	}
+	server := &http.Server{Handler: handler}
+	go func() {
+		var idleCheck <-chan time.Time
+		if *idle > 0 {
+			ticker := time.NewTicker(min(*idle, time.Minute))
+			defer ticker.Stop()
+			idleCheck = ticker.C
+		}
+		for {
+			select {
+			case <-Context.Done():
+			case <-handler.Stopped():
+				fmt.Fprintln(os.Stderr, "Stopped on request.")
+			case <-idleCheck:
+				if handler.Idle() < *idle {
+					continue
+				}
+				fmt.Fprintf(os.Stderr, "Stopped after %s without requests.\n", *idle)
+			}
+			server.Close()
+			return
+		}
+	}()
+	fmt.Fprintf(os.Stderr, "Listening on %s (Ctrl+C or n8nctl daemon stop to stop)\n", socket)
+	err = server.Serve(listener)
+	handler.Close()
+	os.Remove(socket)
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		fmt.Printf("Error: %v\n", err)
+		telemetry.Exit(1)
+	}
+}
+
+func daemonCommand(command, socket string) error {
+	if !daemon.Running(socket) {
+		fmt.Printf("No daemon is running on %s.\n", socket)
+		return nil
+	}
+	if command == "stop" {
+		if err := daemon.Stop(Context, socket); err != nil {
+			return err
+		}
+		fmt.Println("Daemon stopped.")
+		return nil
+	}
+	status, err := daemon.GetStatus(Context, socket)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Daemon running on %s (pid %d)\n", socket, status.PID)
+	fmt.Printf("  started:    %s\n", status.Started.Local().Format(time.RFC1123))
+	fmt.Printf("  last used:  %s\n", status.LastUsed.Local().Format(time.RFC1123))
+	fmt.Printf("  requests:   %d\n", status.Requests)
+	fmt.Printf("  transports: %d\n", status.Transports)
+	fmt.Printf("  node type catalogs cached: %d\n", status.NodeTypes)
+	return nil
+}
+
+// daemonSocket returns the socket of a running daemon to send API requests
+// through, or "" to send them directly. N8NCTL_NO_DAEMON turns it off.
+func daemonSocket() string {
+	if os.Getenv("N8NCTL_NO_DAEMON") != "" {
+		return ""
+	}
+	socket, err := daemon.SocketPath()
+	if err != nil || !daemon.Running(socket) {
+		return ""
+	}
+	return socket
+}
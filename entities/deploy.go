@@ -0,0 +1,285 @@
+package entities
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/brandon-kyle-bailey/n8nctl/config"
+	"github.com/brandon-kyle-bailey/n8nctl/manifest"
+	"github.com/brandon-kyle-bailey/n8nctl/pkg/n8n"
+	"github.com/brandon-kyle-bailey/n8nctl/state"
+	"github.com/brandon-kyle-bailey/n8nctl/telemetry"
+	"github.com/brandon-kyle-bailey/n8nctl/utils"
+	"github.com/brandon-kyle-bailey/n8nctl/workflows"
+)
+
+// deployMode controls whether a deploy may create, update, or both.
+type deployMode int
+
+const (
+	deployUpsert deployMode = iota
+	deployCreateOnly
+	deployUpdateOnly
+)
+
+type remoteWorkflow struct {
+	ID        string         `json:"id"`
+	Name      string         `json:"name"`
+	Active    bool           `json:"active"`
+	UpdatedAt time.Time      `json:"updatedAt"`
+	Tags      []manifest.Tag `json:"tags,omitempty"`
+}
+
+type workflowListResponse struct {
+	Data       []remoteWorkflow `json:"data"`
+	NextCursor string           `json:"nextCursor"`
+}
+
+// deployer deploys rendered workflow JSON, consulting and updating the
+// project state so re-deploys and renames update the same remote workflow.
+type deployer struct {
+	client   *n8n.Client
+	basePath string
+	cfg      config.Config
+	mode     deployMode
+	force    bool
+	// skipHistory leaves the rollback history alone, for deploys to
+	// throwaway instances.
+	skipHistory bool
+	// rollbackTo is the earlier deployment a rollback deploys again, whose
+	// commit the deploy log records instead of the file's current one.
+	rollbackTo *workflows.Deployment
+	state      *state.State
+}
+
+func newDeployer(client *n8n.Client, basePath string, cfg config.Config) (*deployer, error) {
+	st, err := state.Load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load %s: %w", state.Path, err)
+	}
+	useSecretStores(cfg)
+	return &deployer{client: client, basePath: basePath, cfg: cfg, state: st}, nil
+}
+
+// deploy creates or updates the remote workflow for a local file. The remote
+// workflow is taken from the state file first, falling back to a workflow
+// with the same name. It returns the API response (nil when the deploy was
+// skipped) and a short description of what happened. snapshot is what the
+// rollback history keeps of body, see push.
+func (d *deployer) deploy(file string, body, snapshot []byte) ([]byte, string, error) {
+	name, err := workflowName(body)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var existing *remoteWorkflow
+	if d.mode != deployCreateOnly {
+		found, err := d.findExisting(file, name)
+		if err != nil {
+			return nil, "", err
+		}
+		existing = found
+	}
+
+	if existing != nil && !d.force {
+		if entry, ok := d.state.Get(file); ok && entry.WorkflowID == existing.ID && entry.Hash == state.Hash(body) {
+			return nil, fmt.Sprintf("unchanged since last deploy (%s)", existing.ID), nil
+		}
+	}
+	if existing == nil && d.mode == deployUpdateOnly {
+		return nil, "", fmt.Errorf("no remote workflow found for %s (--update-only)", file)
+	}
+	return d.push(file, name, body, snapshot, existing)
+}
+
+// push creates the workflow, or updates existing when it is not nil, and
+// records the resulting workflow ID in the state file. Created workflows
+// are moved into the project of the config, as creations cannot name one.
+// The history keeps snapshot rather than body, which is the render with its
+// placeholders when resolving at deploy, so no variable or secret is saved.
+func (d *deployer) push(file, name string, body, snapshot []byte, existing *remoteWorkflow) ([]byte, string, error) {
+	var resp []byte
+	var result string
+	var err error
+	if existing != nil {
+		resp, err = n8nAPIRequest(d.client, "PUT", fmt.Sprintf("%s/%s", d.basePath, existing.ID), string(body))
+		result = fmt.Sprintf("updated %q (%s)", name, existing.ID)
+	} else {
+		resp, err = n8nAPIRequest(d.client, "POST", d.basePath, string(body))
+		result = fmt.Sprintf("created %q", name)
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	var deployed remoteWorkflow
+	if err := json.Unmarshal(resp, &deployed); err != nil || deployed.ID == "" {
+		return resp, result, fmt.Errorf("deployed, but could not read the workflow ID from the response")
+	}
+	d.state.Record(file, deployed.ID, body, deployed.UpdatedAt)
+	if err := d.state.Save(); err != nil {
+		return resp, result, fmt.Errorf("deployed, but failed to save %s: %w", state.Path, err)
+	}
+	if existing == nil {
+		if err := moveCreated(d.client, d.cfg, "workflows", d.basePath, resp); err != nil {
+			return resp, result, err
+		}
+	}
+	if d.skipHistory {
+		return resp, result, nil
+	}
+	saved, err := workflows.SaveSnapshot(file, snapshot)
+	if err != nil {
+		return resp, result, fmt.Errorf("deployed, but failed to save history snapshot: %w", err)
+	}
+	deployment := workflows.Deployment{
+		Snapshot:   saved.Timestamp,
+		Instance:   d.client.BaseURL(),
+		Profile:    config.Profile,
+		WorkflowID: deployed.ID,
+	}
+	if d.rollbackTo != nil {
+		deployment.Commit, deployment.Dirty = d.rollbackTo.Commit, d.rollbackTo.Dirty
+		deployment.RollbackTo = d.rollbackTo.Snapshot
+	} else {
+		// Outside a git repository deployments are logged without a commit.
+		deployment.Commit, deployment.Dirty, _ = workflows.FileVersion(Context, file)
+	}
+	if err := workflows.RecordDeployment(file, deployment); err != nil {
+		return resp, result, fmt.Errorf("deployed, but failed to log the deployment: %w", err)
+	}
+	return resp, result, nil
+}
+
+// workflowName returns the name of a rendered workflow, which is required to
+// match it against remote workflows.
+func workflowName(body []byte) (string, error) {
+	var wf struct {
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal(body, &wf); err != nil {
+		return "", fmt.Errorf("invalid workflow JSON: %w", err)
+	}
+	if wf.Name == "" {
+		return "", fmt.Errorf("workflow JSON has no name, cannot match against remote workflows")
+	}
+	return wf.Name, nil
+}
+
+// findExisting returns the remote workflow recorded for file in the state, or
+// failing that the remote workflow with the given name.
+func (d *deployer) findExisting(file, name string) (*remoteWorkflow, error) {
+	if entry, ok := d.state.Get(file); ok {
+		data, err := n8nAPIRequest(d.client, "GET", fmt.Sprintf("%s/%s", d.basePath, entry.WorkflowID), "")
+		var apiErr *n8n.APIError
+		switch {
+		case err == nil:
+			var wf remoteWorkflow
+			if err := json.Unmarshal(data, &wf); err != nil {
+				return nil, fmt.Errorf("failed to parse workflow %s: %w", entry.WorkflowID, err)
+			}
+			return &wf, nil
+		case errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound:
+			// Deleted remotely since the last deploy, forget the stale mapping.
+			d.state.Forget(file)
+		default:
+			return nil, err
+		}
+	}
+	return findWorkflowByName(d.client, d.basePath, name, d.cfg)
+}
+
+// deployDir renders and deploys every workflow file in dir, reporting
+// the outcome of each file and failing if any of them could not be deployed.
+func (d *deployer) deployDir(dir string) error {
+	files, err := workflows.WorkflowFiles(dir)
+	if err != nil {
+		return err
+	}
+	return d.deployFiles(files)
+}
+
+func (d *deployer) deployFiles(files []string) error {
+	failed := 0
+	for _, rendered := range workflows.RenderFiles(files) {
+		file := rendered.File
+		_, span := telemetry.Start(Context, "deploy "+file)
+		result, err := d.deployFile(rendered)
+		span.SetAttribute("n8nctl.result", result)
+		span.SetError(err)
+		span.End()
+		if err != nil {
+			failed++
+			fmt.Printf("%s %s: %v\n", utils.Colorize("FAIL", utils.ColorRed), file, err)
+			continue
+		}
+		fmt.Printf("%s   %s: %s\n", utils.Colorize("OK", utils.ColorGreen), file, result)
+	}
+
+	fmt.Printf("\n%d deployed, %d failed\n", len(files)-failed, failed)
+	if failed > 0 {
+		return fmt.Errorf("%d of %d workflows failed to deploy", failed, len(files))
+	}
+	return nil
+}
+
+func (d *deployer) deployFile(rendered workflows.Rendered) (string, error) {
+	file, body, err := rendered.File, rendered.Body, rendered.Err
+	if err != nil {
+		return "", err
+	}
+	output := body
+	if workflows.ResolveAt == workflows.ResolveAtDeploy {
+		if output, err = workflows.RenderTemplate(file); err != nil {
+			return "", err
+		}
+	}
+	if err := workflows.WriteOutput(workflows.OutputPathFor(file), output); err != nil {
+		return "", err
+	}
+	_, result, err := d.deploy(file, body, output)
+	return result, err
+}
+
+// findWorkflowByName pages through the remote workflows filtered by name and
+// returns the exact match, nil if there is none, or an error if the name is ambiguous.
+func findWorkflowByName(client *n8n.Client, basePath, name string, cfg config.Config) (*remoteWorkflow, error) {
+	var matches []remoteWorkflow
+	cursor := ""
+	for {
+		query := url.Values{"name": {name}}
+		if cursor != "" {
+			query.Set("cursor", cursor)
+		}
+		data, err := n8nAPIRequest(client, "GET", basePath+"?"+query.Encode(), "")
+		if err != nil {
+			return nil, fmt.Errorf("failed to look up workflow %q: %w", name, err)
+		}
+		var page workflowListResponse
+		if err := json.Unmarshal(data, &page); err != nil {
+			return nil, fmt.Errorf("failed to parse workflow list: %w", err)
+		}
+		for _, wf := range page.Data {
+			if wf.Name == name {
+				matches = append(matches, wf)
+			}
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		cursor = page.NextCursor
+	}
+
+	switch len(matches) {
+	case 0:
+		return nil, nil
+	case 1:
+		return &matches[0], nil
+	default:
+		return nil, fmt.Errorf("found %d remote workflows named %q, refusing to guess which to update", len(matches), name)
+	}
+}
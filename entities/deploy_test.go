@@ -0,0 +1,96 @@
+package entities
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/brandon-kyle-bailey/n8nctl/config"
+	"github.com/brandon-kyle-bailey/n8nctl/pkg/n8n"
+	"github.com/brandon-kyle-bailey/n8nctl/workflows"
+)
+
+const deployTemplate = `name: Notify
+nodes:
+  - name: Post
+    type: n8n-nodes-base.httpRequest
+    parameters:
+      token: ${{ API_TOKEN }}
+      password: ${{ env:DEPLOY_TEST_PASSWORD }}
+connections: {}
+`
+
+func TestDeployResolvedAtDeployKeepsSecretsOutOfHistory(t *testing.T) {
+	t.Chdir(t.TempDir())
+	t.Setenv("DEPLOY_TEST_PASSWORD", "hunter2-password")
+	if err := os.WriteFile("workflow.yaml", []byte(deployTemplate), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(".env", []byte("API_TOKEN=tok-from-dotenv\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	resolveAt := workflows.ResolveAt
+	workflows.ResolveAt = workflows.ResolveAtDeploy
+	t.Cleanup(func() { workflows.ResolveAt = resolveAt })
+
+	var deployed string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			io.WriteString(w, `{"data":[]}`)
+		case http.MethodPost:
+			body, _ := io.ReadAll(r.Body)
+			deployed = string(body)
+			io.WriteString(w, `{"id":"wf1","name":"Notify"}`)
+		default:
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	d, err := newDeployer(n8n.New(server.URL, "key"), server.URL+"/api/v1/workflows", config.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := d.deployFiles([]string{"workflow.yaml"}); err != nil {
+		t.Fatal(err)
+	}
+
+	secrets := []string{"tok-from-dotenv", "hunter2-password"}
+	for _, secret := range secrets {
+		if !strings.Contains(deployed, secret) {
+			t.Errorf("deployed workflow lacks %q:\n%s", secret, deployed)
+		}
+	}
+
+	snapshots, err := workflows.Snapshots("workflow.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snapshots) != 1 {
+		t.Fatalf("got %d snapshots, want 1", len(snapshots))
+	}
+	snapshot, err := os.ReadFile(snapshots[0].Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range secrets {
+		if strings.Contains(string(snapshot), secret) {
+			t.Errorf("history snapshot contains %q:\n%s", secret, snapshot)
+		}
+	}
+
+	// Rolling back resolves the placeholders of the snapshot again.
+	resolved, err := workflows.ResolveJSON(snapshots[0].Path, snapshot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range secrets {
+		if !strings.Contains(string(resolved), secret) {
+			t.Errorf("resolved snapshot lacks %q:\n%s", secret, resolved)
+		}
+	}
+}
@@ -0,0 +1,245 @@
+package entities
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/brandon-kyle-bailey/n8nctl/config"
+	"github.com/brandon-kyle-bailey/n8nctl/devenv"
+	"github.com/brandon-kyle-bailey/n8nctl/state"
+	"github.com/brandon-kyle-bailey/n8nctl/telemetry"
+	"github.com/brandon-kyle-bailey/n8nctl/testsuite"
+	"github.com/brandon-kyle-bailey/n8nctl/workflows"
+)
+
+// devProfile is the config profile `dev up` logs in to the local instance.
+const devProfile = "dev"
+
+const devContainer = "n8nctl-dev"
+
+// HandleDev manages the local n8n sandbox.
+func HandleDev(args []string) {
+	if len(args) == 0 || args[0] == "--help" || args[0] == "-h" {
+		printDevHelp()
+		if len(args) == 0 {
+			telemetry.Exit(1)
+		}
+		return
+	}
+
+	var err error
+	switch args[0] {
+	case "up":
+		err = devUp(Context, args[1:])
+	case "test":
+		err = devTest(Context, args[1:])
+	case "down":
+		err = devenv.Instance{Name: devContainer}.Remove(Context)
+		if err == nil {
+			fmt.Println("Removed the dev instance.")
+		}
+	default:
+		fmt.Printf("Unknown action for dev: %s\n\n", args[0])
+		printDevHelp()
+		telemetry.Exit(1)
+	}
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		telemetry.Exit(1)
+	}
+}
+
+func printDevHelp() {
+	fmt.Println(`Available actions for dev:
+  up    Start a local n8n container, create its owner and an API key, log in the dev profile and deploy the project's workflows
+  test  Run the workflow tests against a throwaway n8n container and write a JUnit report
+  down  Stop and remove the local n8n container and its data
+
+Usage:
+  n8nctl dev up [--version <tag>] [--port 5678] [--ready-timeout 2m] [--no-seed]
+  n8nctl dev test [--version <tag>] [--dir tests] [--out test-results.xml] [--keep]
+  n8nctl dev down
+
+The n8n version and port of dev up default to dev.version and dev.port in .n8nctl.yaml.
+Use the instance with "n8nctl --profile dev <entity> <action>".`)
+}
+
+func devUp(ctx context.Context, args []string) error {
+	project, err := config.LoadProject()
+	if err != nil {
+		return err
+	}
+	fs := flag.NewFlagSet("dev up", flag.ContinueOnError)
+	version := fs.String("version", project.Dev.Version, "n8n image tag to run")
+	port := fs.Int("port", project.Dev.Port, "Local port to publish n8n on")
+	readyTimeout := fs.Duration("ready-timeout", 2*time.Minute, "How long to wait for n8n to start")
+	noSeed := fs.Bool("no-seed", false, "Do not deploy the project's workflows")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *version == "" {
+		fmt.Printf("No dev.version pinned in %s, using the latest n8n release.\n", config.ProjectFile)
+		*version = "latest"
+	}
+	if *port == 0 {
+		*port = 5678
+	}
+
+	instance := devenv.Instance{Name: devContainer, Version: *version, Port: *port}
+	created, err := instance.Start(ctx)
+	if err != nil {
+		return err
+	}
+	if created {
+		fmt.Printf("Started n8n %s as container %s.\n", instance.Version, instance.Name)
+	} else {
+		fmt.Printf("Reusing container %s.\n", instance.Name)
+	}
+	fmt.Printf("Waiting for %s ...\n", instance.BaseURL())
+	if err := instance.WaitReady(ctx, *readyTimeout); err != nil {
+		return err
+	}
+
+	apiKey, err := devenv.Bootstrap(ctx, instance.BaseURL(), devenv.DefaultOwner)
+	if err != nil {
+		return err
+	}
+	cfg := config.Config{APIToken: apiKey, BaseURL: instance.BaseURL()}
+	if err := config.SaveProfile(devProfile, cfg); err != nil {
+		return fmt.Errorf("failed to save the %s profile: %w", devProfile, err)
+	}
+	fmt.Printf("Logged in profile %q as %s (password %s).\n", devProfile, devenv.DefaultOwner.Email, devenv.DefaultOwner.Password)
+
+	if *noSeed {
+		return nil
+	}
+	files, err := workflows.ProjectFiles("")
+	if err != nil {
+		fmt.Println("No workflows to seed.")
+		return nil
+	}
+	if created {
+		// A new container has none of the workflows recorded for the last one.
+		state.UseProfile(devProfile)
+		if err := os.Remove(state.Path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return seedWorkflows(files, cfg)
+}
+
+// seedWorkflows deploys files to the dev instance, tracking them in the dev
+// profile's state file.
+func seedWorkflows(files []string, cfg config.Config) error {
+	state.UseProfile(devProfile)
+	basePath := cfg.APIBase() + "/workflows"
+	d, err := newDeployer(newClient(cfg), basePath, cfg)
+	if err != nil {
+		return err
+	}
+	d.force = true
+	d.skipHistory = true
+	fmt.Println()
+	return d.deployFiles(files)
+}
+
+// devTest deploys the project to a fresh instance, runs its tests there and
+// removes the instance again, whatever the outcome.
+func devTest(ctx context.Context, args []string) error {
+	project, err := config.LoadProject()
+	if err != nil {
+		return err
+	}
+	fs := flag.NewFlagSet("dev test", flag.ContinueOnError)
+	version := fs.String("version", project.Dev.Version, "n8n image tag to run")
+	dir := fs.String("dir", testsuite.Dir, "Directory with the test files")
+	out := fs.String("out", "test-results.xml", "Where to write the JUnit report")
+	readyTimeout := fs.Duration("ready-timeout", 2*time.Minute, "How long to wait for n8n to start")
+	keep := fs.Bool("keep", false, "Keep the instance running afterwards for debugging")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *version == "" {
+		*version = "latest"
+	}
+	// Check the project before paying for a container.
+	suites, err := testsuite.Load(*dir)
+	if err != nil {
+		return err
+	}
+	files, err := workflows.ProjectFiles("")
+	if err != nil {
+		return err
+	}
+
+	port, err := freePort()
+	if err != nil {
+		return err
+	}
+	instance := devenv.Instance{Name: fmt.Sprintf("n8nctl-test-%d", port), Version: *version, Port: port}
+	if _, err := instance.Start(ctx); err != nil {
+		return err
+	}
+	if *keep {
+		defer fmt.Printf("\nKept container %s at %s, remove it with: docker rm -f %s\n", instance.Name, instance.BaseURL(), instance.Name)
+	} else {
+		defer instance.Remove(context.Background())
+	}
+	fmt.Printf("Started n8n %s as container %s, waiting for %s ...\n", instance.Version, instance.Name, instance.BaseURL())
+	if err := instance.WaitReady(ctx, *readyTimeout); err != nil {
+		return err
+	}
+	apiKey, err := devenv.Bootstrap(ctx, instance.BaseURL(), devenv.DefaultOwner)
+	if err != nil {
+		return err
+	}
+	cfg := config.Config{APIToken: apiKey, BaseURL: instance.BaseURL()}
+
+	// Track the throwaway deploys apart from every real profile.
+	tmp, err := os.MkdirTemp("", "n8nctl-test-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+	state.Path = filepath.Join(tmp, "state.json")
+	d, err := newDeployer(newClient(cfg), fmt.Sprintf("%s/api/v1/workflows", cfg.BaseURL), cfg)
+	if err != nil {
+		return err
+	}
+	d.force = true
+	d.skipHistory = true
+	fmt.Println()
+	if err := d.deployFiles(files); err != nil {
+		return err
+	}
+	fmt.Println()
+
+	results, err := newTestRunner(newClient(cfg), cfg).Run(ctx, suites)
+	if err != nil {
+		return err
+	}
+	printTestResults(results)
+	junit := reportFlags{format: "junit", out: *out}
+	if err := junit.write(testReport(results), nil); err != nil {
+		return fmt.Errorf("failed to write %s: %w", *out, err)
+	}
+	if testsFailed(results) {
+		return fmt.Errorf("workflow tests failed")
+	}
+	return nil
+}
+
+// freePort asks the OS for a port nothing listens on.
+func freePort() (int, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port, nil
+}
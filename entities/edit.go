@@ -0,0 +1,223 @@
+package entities
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/brandon-kyle-bailey/n8nctl/pkg/n8n"
+	"github.com/brandon-kyle-bailey/n8nctl/prompt"
+	"github.com/brandon-kyle-bailey/n8nctl/state"
+	"github.com/brandon-kyle-bailey/n8nctl/utils"
+	"github.com/brandon-kyle-bailey/n8nctl/workflows"
+)
+
+// editWorkflow opens a remote workflow as YAML in the user's editor and,
+// once it is saved, shows the changes and updates the workflow with them.
+// Edits that fail to parse or are rejected by the instance are reopened with
+// the error on top, as kubectl edit does.
+func editWorkflow(client *n8n.Client, basePath string, params []string) error {
+	fs := flag.NewFlagSet("edit", flag.ContinueOnError)
+	args, err := utils.ParseFlags(fs, params)
+	if err != nil {
+		return err
+	}
+	if len(args) != 1 {
+		return fmt.Errorf("edit requires a workflow ID")
+	}
+	if prompt.NonInteractive {
+		return fmt.Errorf("edit opens an editor, which non-interactive mode does not allow")
+	}
+	id := args[0]
+
+	remote, err := n8nAPIRequest(client, "GET", fmt.Sprintf("%s/%s", basePath, id), "")
+	if err != nil {
+		return err
+	}
+	var original remoteWorkflow
+	if err := json.Unmarshal(remote, &original); err != nil {
+		return fmt.Errorf("failed to parse workflow: %w", err)
+	}
+	data, err := workflowYAML(remote)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp("", "n8nctl-edit-"+id+"-*.yaml")
+	if err != nil {
+		return err
+	}
+	path := f.Name()
+	f.Close()
+	keep := false
+	defer func() {
+		if !keep {
+			os.Remove(path)
+		}
+	}()
+
+	header := fmt.Sprintf("# Editing workflow %q (%s) on %s.\n"+
+		"# Saving shows the changes and asks to update the workflow with them.\n"+
+		"# Lines starting with '#' are ignored, and an empty file cancels the edit.\n#\n",
+		original.Name, id, client.BaseURL())
+	text := header + string(data)
+	failed := false
+	for {
+		if err := os.WriteFile(path, []byte(text), 0600); err != nil {
+			return err
+		}
+		if err := runEditor(path); err != nil {
+			keep = failed
+			return err
+		}
+		edited, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if string(edited) == text {
+			if failed {
+				keep = true
+				fmt.Printf("Edit cancelled, your changes are kept in %s\n", path)
+			} else {
+				fmt.Println("Edit cancelled, no changes made.")
+			}
+			return nil
+		}
+		body := stripHeader(string(edited))
+		if onlyComments(body) {
+			fmt.Println("Edit cancelled, the file was emptied.")
+			return nil
+		}
+
+		// Parse the file as saved, so the lines of errors match it.
+		updated, err := workflows.YAMLToJSON(edited)
+		if err == nil {
+			var done bool
+			done, err = applyEdit(client, basePath, original, remote, updated)
+			if err == nil {
+				if !done {
+					keep = true
+					fmt.Printf("Your changes are kept in %s\n", path)
+				}
+				return nil
+			}
+		}
+		// Reopen the edit with the error on top, so it can be fixed.
+		failed = true
+		var problem strings.Builder
+		for line := range strings.SplitSeq(err.Error(), "\n") {
+			problem.WriteString("# " + line + "\n")
+		}
+		text = header + "# Error: " + strings.TrimPrefix(problem.String(), "# ") + "#\n" + body
+	}
+}
+
+// applyEdit shows how updated changes the workflow and updates it after
+// confirmation. It reports false when the user declined.
+func applyEdit(client *n8n.Client, basePath string, original remoteWorkflow, remote, updated []byte) (bool, error) {
+	before, err := managedFields(remote)
+	if err != nil {
+		return false, err
+	}
+	after, err := managedFields(updated)
+	if err != nil {
+		return false, fmt.Errorf("the workflow is not a mapping: %w", err)
+	}
+	if before == after {
+		fmt.Println("No changes to the workflow.")
+		return true, nil
+	}
+	fmt.Printf("Changes to %q (%s):\n\n", original.Name, original.ID)
+	if err := utils.RunDiff([]byte(before), []byte(after)); err != nil {
+		return false, err
+	}
+	fmt.Println()
+	confirmed, err := prompt.Confirm(fmt.Sprintf("Update workflow %s?", original.ID), false)
+	if err != nil {
+		return false, err
+	}
+	if !confirmed {
+		fmt.Println("Edit aborted by user.")
+		return false, nil
+	}
+
+	// Do not overwrite what someone else saved in the meantime.
+	current, err := n8nAPIRequest(client, "GET", fmt.Sprintf("%s/%s", basePath, original.ID), "")
+	if err != nil {
+		return false, err
+	}
+	var latest remoteWorkflow
+	if err := json.Unmarshal(current, &latest); err == nil && !latest.UpdatedAt.Equal(original.UpdatedAt) {
+		fmt.Printf("Workflow %s changed on the instance while it was being edited.\n", original.ID)
+		return false, nil
+	}
+	if _, err := n8nAPIRequest(client, "PUT", fmt.Sprintf("%s/%s", basePath, original.ID), after); err != nil {
+		return false, err
+	}
+	name, _ := workflowName(updated)
+	fmt.Printf("Workflow updated %q (%s)\n", name, original.ID)
+
+	if st, err := state.Load(); err == nil {
+		for file, entry := range st.Workflows {
+			if entry.WorkflowID == original.ID {
+				fmt.Printf("Note: %s deploys this workflow, change it too or its next deploy reverts this edit.\n", file)
+			}
+		}
+	}
+	return true, nil
+}
+
+// stripHeader removes the comment lines at the top of an edit, where its
+// header and any error are. Comments further down are left to the YAML
+// parser, as the lines of block scalars, such as the content of sticky
+// notes, may start with '#' too.
+func stripHeader(text string) string {
+	for strings.HasPrefix(text, "#") {
+		_, rest, found := strings.Cut(text, "\n")
+		if !found {
+			return ""
+		}
+		text = rest
+	}
+	return text
+}
+
+// onlyComments reports whether text has nothing but comments and blank lines.
+func onlyComments(text string) bool {
+	for line := range strings.SplitSeq(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "#") {
+			return false
+		}
+	}
+	return true
+}
+
+// runEditor opens path in $VISUAL or $EDITOR, through the shell so editors
+// with arguments such as "code --wait" work, and waits for it to exit.
+func runEditor(path string) error {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		if editor == "" {
+			editor = "notepad"
+		}
+		cmd = exec.Command("cmd", "/C", editor+` "`+path+`"`)
+	} else {
+		if editor == "" {
+			editor = "vi"
+		}
+		cmd = exec.Command("sh", "-c", editor+` "$1"`, "sh", path)
+	}
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("editor %q failed: %w", editor, err)
+	}
+	return nil
+}
@@ -7,27 +7,65 @@ type Action struct {
 	Schema      string // Optional JSON schema or example payload
 }
 
+// readOnlyActions only read from the instance, so read-only profiles allow
+// them. Actions with read and write forms, such as tags, are left to the
+// client, which refuses the writes of read-only profiles. copy writes to the
+// instance of another profile only.
+var readOnlyActions = map[string]bool{
+	"list": true, "get": true, "watch": true, "schema": true, "list-shares": true,
+	"export": true, "preview": true, "diff": true, "plan": true, "validate": true,
+	"lint": true, "tags": true, "await-webhook": true, "relay": true,
+	"snapshot": true, "report": true, "slow": true, "profile": true, "estimate": true,
+	"copy": true, "adopt": true, "log": true, "graph": true, "vars": true,
+	"form-url": true, "chat-url": true, "generate": true,
+}
+
+// listColumns are the table columns list shows for each entity when
+// --columns is not given.
+var listColumns = map[string][]string{
+	"users":          {"id", "email", "firstName", "lastName", "role"},
+	"executions":     {"id", "workflowId", "status", "mode", "startedAt", "stoppedAt"},
+	"workflows":      {"id", "name", "active", "updatedAt"},
+	"credentials":    {"id", "name", "type", "updatedAt"},
+	"tags":           {"id", "name", "updatedAt"},
+	"source-control": {"id", "name"},
+	"variables":      {"id", "key", "value"},
+	"projects":       {"id", "name", "type"},
+}
+
 var Entities = map[string]map[string]Action{
 	"users": {
 		"list":   {Description: "List all users", NeedsID: false},
 		"create": {Description: "Create a new user", NeedsID: false},
-		"get":    {Description: "Get a user by ID", NeedsID: true},
+		"get":    {Description: "Get a user by ID, or several as a JSON array (id1 id2 ..., --ids-file, --concurrency)", NeedsID: true},
 		"update": {Description: "Update a user by ID", NeedsID: true},
 		"delete": {Description: "Delete a user by ID", NeedsID: true},
 	},
 	"audit": {
 		"create": {Description: "Create an audit log", NeedsID: false},
+		"tail":   {Description: "Print recent workflow, credential and execution activity, derived by polling, and new activity with --follow (--forward syslog://host:514|https://...|file, -n, --interval)", NeedsID: false},
 	},
 	"executions": {
-		"list":   {Description: "List executions", NeedsID: false},
-		"get":    {Description: "Get an execution by ID", NeedsID: true},
-		"delete": {Description: "Delete an execution by ID", NeedsID: true},
+		"list":    {Description: "List executions", NeedsID: false},
+		"get":     {Description: "Get an execution by ID, or several as a JSON array (id1 id2 ..., --ids-file, --concurrency)", NeedsID: true},
+		"delete":  {Description: "Delete an execution by ID", NeedsID: true},
+		"export":  {Description: "Write an execution to a file (<id> -o run.json), every matching one to --dir, or stream them into a warehouse (--sink bigquery|s3|postgres, --dsn, --table); --workflow, --status, --limit, --include-data, --scrub pii.yaml to redact personal data and tokens before sharing", NeedsID: false},
+		"prune":   {Description: "Delete the executions matching filters after confirming (--older-than 30d, --status, --workflow-id, --dry-run)", NeedsID: false},
+		"retry":   {Description: "Retry a failed execution from the failed node, printing the new execution ID (--load-workflow, --wait, --wait-timeout)", NeedsID: true},
+		"profile": {Description: "Show the time each node of an execution spent running, slowest first (--top n)", NeedsID: true},
+		"slow":    {Description: "Print the duration percentiles of a workflow's recent executions and list the slowest with links (--workflow <id>, --threshold p95|30s, --samples, --status)", NeedsID: false},
+		"watch":   {Description: "Print recent executions, then new ones as they start and finish with --follow (--workflow-id, -n, --interval)", NeedsID: false},
+		"schema":  {Description: "Infer a JSON Schema of a workflow's output from recent executions (--workflow <id>, --samples, --status)", NeedsID: false},
+		"await-webhook": {
+			Description: "Wait for n8n to call back on a local port (--port, --path, --match key=value, --timeout)",
+			NeedsID:     false,
+		},
 	},
 	"workflows": {
 		"list": {Description: "List workflow instances", NeedsID: false},
-		"get":  {Description: "Get a workflow instance by ID", NeedsID: true},
+		"get":  {Description: "Get a workflow instance by ID, or several as a JSON array (id1 id2 ..., --ids-file, --concurrency)", NeedsID: true},
 		"create": {
-			Description: "Create a workflow instance",
+			Description: "Create a workflow instance, or without a payload write a starter workflow.yaml (--template manual|form|chat)",
 			NeedsID:     false,
 			Schema: `{
   "name": "My Workflow",
@@ -44,19 +82,44 @@ var Entities = map[string]map[string]Action{
   "active": false
 }`,
 		},
-		"update":     {Description: "Update a workflow instance by ID", NeedsID: true},
-		"delete":     {Description: "Delete a workflow instance by ID", NeedsID: true},
-		"activate":   {Description: "Activate a workflow instance by ID", NeedsID: true},
-		"deactivate": {Description: "Deactivate a workflow instance by ID", NeedsID: true},
-		"preview":    {Description: "Preview a workflow template (with confirmation to save and show diff)", NeedsID: false},
-		"diff":       {Description: "Show diff between existing and new workflow templates", NeedsID: false},
-		"deploy":     {Description: "Deploy a workflow instance", NeedsID: false, Schema: "(No schema — uses .out/workflow.json from preview)"},
-		"rollback":   {Description: "Rollback a workflow instance", NeedsID: false},
+		"update":         {Description: "Update a workflow instance by ID", NeedsID: true},
+		"delete":         {Description: "Delete a workflow instance by ID, or every selected one after confirming (--tag, --name-glob, --all, --exclude-tag, --exclude-name-glob)", NeedsID: true},
+		"run":            {Description: "Start an active workflow through its webhook trigger (--input data.json, --wait to print the execution data and fail when it fails, --wait-timeout, --activate to activate an inactive one for the run)", NeedsID: true},
+		"invoke-webhook": {Description: "Call the workflow's webhook trigger and print the response (--test for the test URL, --method, --body '{\"a\":1}')", NeedsID: true},
+		"form-url":       {Description: "Print the public URL of a workflow's form trigger (--test for the test URL, --qr to also print a QR code)", NeedsID: true},
+		"chat-url":       {Description: "Print the URL of the hosted chat of a workflow's public chat trigger (--test for the test URL, --qr to also print a QR code)", NeedsID: true},
+		"copy":           {Description: "Create a copy of a workflow on the instance of another profile, pointing its nodes at the credentials with the same names there (--to <profile>, --credentials map|strip|keep, --name)", NeedsID: true},
+		"transfer":       {Description: "Move a workflow to another project (--project <id|name>)", NeedsID: true},
+		"share":          {Description: "Share a workflow with a project (--with-project <id|name>, --role editor)", NeedsID: true},
+		"unshare":        {Description: "Stop sharing a workflow with a project (--with-project <id|name>)", NeedsID: true},
+		"list-shares":    {Description: "List the projects that own or share a workflow", NeedsID: true},
+		"tags":           {Description: "List or replace a workflow's tags (list <id>, set <id> tag1,tag2 [--create-missing])", NeedsID: true},
+		"activate":       {Description: "Activate a workflow instance by ID, or every selected one after confirming (--tag, --name-glob, --all, --exclude-tag, --exclude-name-glob), writing an undo file for n8nctl undo", NeedsID: true},
+		"deactivate":     {Description: "Deactivate a workflow instance by ID, or every selected one after confirming, e.g. --all --exclude-tag heartbeat, writing an undo file for n8nctl undo", NeedsID: true},
+		"preview":        {Description: "Preview a workflow template with variables and secrets masked (with confirmation to save and show diff; --resolve-at deploy keeps placeholders in .out)", NeedsID: false},
+		"diff":           {Description: "Show diff between existing and new workflow templates, with variables and secrets masked (--resolve-at deploy, --explain to have the configured language model summarize the change)", NeedsID: false},
+		"deploy":         {Description: "Deploy a workflow instance, updating the remote workflow recorded in .n8nctl/state.json or with the same name ([file.yaml ...] --create-only, --update-only, --force, --dir <dir>, --resolve-at deploy, --all-envs)", NeedsID: false, Schema: "(No schema — uses .out/workflow.json from preview)"},
+		"generate":       {Description: "Experimental: draft a workflow file from a description with the language model of the ai settings of .n8nctl.yaml, for review (--prompt \"when a Stripe payment fails, alert Slack\", --out <file.yaml>, --node-types, --print-prompt)", NeedsID: false},
+		"watch":          {Description: "Render workflow files again whenever they, the files they include or the env files change, writing .out like preview, and deploy them too with --deploy ([file...] --dir, --poll, --interval 500ms, --resolve-at deploy)", NeedsID: false},
+		"log":            {Description: "Show the git commits and deployments of a workflow file, and which commit each instance runs ([file] --limit 20)", NeedsID: false},
+		"bisect":         {Description: "Find the commit that broke a workflow file by deploying its revisions and running a test command on each (<file> --good <ref> [--bad HEAD] --test 'n8nctl workflows test')", NeedsID: false},
+		"rollback":       {Description: "Re-deploy an earlier snapshot from .out/history/ ([file] --to <n|timestamp>, --list)", NeedsID: false},
+		"test":           {Description: "Run the workflow tests in tests/ against the instance (--dir, --report junit, --out)", NeedsID: false},
+		"validate":       {Description: "Check that local workflow files render to valid workflows (--dir, --no-cache, --node-types to check nodes against those installed on the instance, --report junit|sarif|json, --out)", NeedsID: false},
+		"lint":           {Description: "Check local workflow files for mistakes such as duplicate node names, disconnected or unreachable nodes, endless loops, connections into triggers, a missing trigger or malformed ={{ }} expressions; set rule severities under lint.rules in .n8nctl.yaml (--dir, --no-cache, --node-types, --report junit|sarif|json, --out)", NeedsID: false},
+		"vars":           {Description: "List the ${{VAR}} variables of workflow files, where each is used and whether the env files define it, or write them to .env.example ([file...|--all], --example)", NeedsID: false},
+		"graph":          {Description: "Print a workflow's nodes and connections as a diagram for docs and reviews, or draw it in the terminal ([file|id] --format dot|mermaid|ascii, --ascii)", NeedsID: false},
+		"estimate":       {Description: "Estimate a workflow's executions a month from its trigger schedules and recent webhook rate, failing above the plan's quota (<file|id> --quota, --window 7d, --events-per-day)", NeedsID: true},
+		"plan":           {Description: "Show what deploying all local workflow files would create, update or delete (--dir, --diff, --detailed-exitcode; scope with --tag, --name-glob, --exclude-tag, --exclude-name-glob)", NeedsID: false},
+		"apply":          {Description: "Create, update and delete remote workflows to match all local workflow files (--dir; scope with --tag, --name-glob, --exclude-tag, --exclude-name-glob)", NeedsID: false},
+		"edit":           {Description: "Open a remote workflow as YAML in $EDITOR and, on save, show the changes and update the workflow with them (<id>)", NeedsID: true},
+		"adopt":          {Description: "Write a remote workflow as YAML into the project and record it in .n8nctl/state.json, without deploying (<id> --file <path.yaml>)", NeedsID: true},
+		"prune":          {Description: "Delete, after confirming, the remote workflows neither in .n8nctl/state.json nor named like a local workflow file (--dir, --deactivate, --dry-run; scope with --tag, --name-glob, --exclude-tag, --exclude-name-glob)", NeedsID: false},
 	},
 	"credentials": {
 		"list": {Description: "List credentials", NeedsID: false},
 		"create": {
-			Description: "Create a credential",
+			Description: "Create a credential (see credentials schema <type> for the fields of its data), or build it from environment variables with --type httpHeaderAuth --from-env NAME=HDR_NAME,VALUE=HDR_TOKEN [--name]",
 			NeedsID:     false,
 			Schema: `{
   "name": "Joe's GitHub Credentials",
@@ -72,33 +135,46 @@ var Entities = map[string]map[string]Action{
   ]
 }`,
 		},
-		"get":    {Description: "Get a credential by ID", NeedsID: true},
-		"update": {Description: "Update a credential by ID", NeedsID: true},
-		"delete": {Description: "Delete a credential by ID", NeedsID: true},
+		"apply":       {Description: "Create or update, matched by name and type, the credentials of a YAML manifest whose secrets are ${{env:VAR}} or ${{vault:path#key}} placeholders (-f credentials.yaml, --dry-run)", NeedsID: false},
+		"get":         {Description: "Get a credential by ID, or several as a JSON array (id1 id2 ..., --ids-file, --concurrency)", NeedsID: true},
+		"update":      {Description: "Update a credential by ID", NeedsID: true},
+		"delete":      {Description: "Delete a credential by ID", NeedsID: true},
+		"transfer":    {Description: "Move a credential to another project (--project <id|name>)", NeedsID: true},
+		"schema":      {Description: "Show the data fields a credential type expects, e.g. schema httpHeaderAuth", NeedsID: true},
+		"share":       {Description: "Share a credential with a project (--with-project <id|name>, --role user)", NeedsID: true},
+		"unshare":     {Description: "Stop sharing a credential with a project (--with-project <id|name>)", NeedsID: true},
+		"list-shares": {Description: "List the projects that own or share a credential", NeedsID: true},
 	},
 	"tags": {
 		"list":   {Description: "List tags", NeedsID: false},
 		"create": {Description: "Create a tag", NeedsID: false},
-		"get":    {Description: "Get a tag by ID", NeedsID: true},
+		"get":    {Description: "Get a tag by ID, or several as a JSON array (id1 id2 ..., --ids-file, --concurrency)", NeedsID: true},
 		"update": {Description: "Update a tag by ID", NeedsID: true},
 		"delete": {Description: "Delete a tag by ID", NeedsID: true},
 	},
 	"source-control": {
 		"list":   {Description: "List source control configs", NeedsID: false},
-		"get":    {Description: "Get a source control config by ID", NeedsID: true},
+		"get":    {Description: "Get a source control config by ID, or several as a JSON array (id1 id2 ..., --ids-file, --concurrency)", NeedsID: true},
 		"update": {Description: "Update a source control config by ID", NeedsID: true},
 	},
 	"variables": {
 		"list":   {Description: "List variables", NeedsID: false},
 		"create": {Description: "Create a variable", NeedsID: false},
-		"get":    {Description: "Get a variable by ID", NeedsID: true},
+		"get":    {Description: "Get a variable by ID, or several as a JSON array (id1 id2 ..., --ids-file, --concurrency)", NeedsID: true},
 		"update": {Description: "Update a variable by ID", NeedsID: true},
 		"delete": {Description: "Delete a variable by ID", NeedsID: true},
 	},
+	"webhooks": {
+		"relay": {Description: "Replay requests received by a webhook workflow to a local server (--workflow <id>, --to <url>, --interval)", NeedsID: false},
+	},
+	"usage": {
+		"snapshot": {Description: "Record the number of workflows, active workflows, credentials and executions of the last day in ~/.n8nctl/usage, e.g. daily from cron", NeedsID: false},
+		"report":   {Description: "Chart the recorded usage as sparklines, or print it with --csv (--since 90d)", NeedsID: false},
+	},
 	"projects": {
 		"list":   {Description: "List projects", NeedsID: false},
 		"create": {Description: "Create a project", NeedsID: false},
-		"get":    {Description: "Get a project by ID", NeedsID: true},
+		"get":    {Description: "Get a project by ID, or several as a JSON array (id1 id2 ..., --ids-file, --concurrency)", NeedsID: true},
 		"update": {Description: "Update a project by ID", NeedsID: true},
 		"delete": {Description: "Delete a project by ID", NeedsID: true},
 	},
@@ -0,0 +1,171 @@
+package entities
+
+import (
+	"cmp"
+	"flag"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/brandon-kyle-bailey/n8nctl/config"
+	"github.com/brandon-kyle-bailey/n8nctl/envstore"
+	"github.com/brandon-kyle-bailey/n8nctl/prompt"
+	"github.com/brandon-kyle-bailey/n8nctl/telemetry"
+	"github.com/brandon-kyle-bailey/n8nctl/utils"
+	"github.com/brandon-kyle-bailey/n8nctl/workflows"
+)
+
+// HandleEnv manages the variables the workflow templates of the project in
+// the working directory are rendered with, and those stored encrypted for
+// the selected profile.
+func HandleEnv(args []string) {
+	if len(args) == 0 || args[0] == "--help" || args[0] == "-h" {
+		fmt.Println("Usage: n8nctl env check [file...] [--documented-only] | set KEY=value|KEY... | unset KEY... | list")
+		if len(args) == 0 {
+			telemetry.Exit(1)
+		}
+		return
+	}
+	switch args[0] {
+	case "check":
+		ok, err := checkEnv(args[1:])
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			telemetry.Exit(1)
+		}
+		if !ok {
+			telemetry.Exit(1)
+		}
+	case "set", "unset", "list":
+		if err := editEnvStore(args[0], args[1:]); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			telemetry.Exit(1)
+		}
+	default:
+		fmt.Printf("Error: unknown env command %q, expected check, set, unset or list\n", args[0])
+		telemetry.Exit(1)
+	}
+}
+
+// checkEnv compares the variables the workflow files use with those
+// .env.example documents and the env files define, and reports whether
+// every variable used is both. Variables documented but no longer used are
+// only warned about.
+func checkEnv(params []string) (bool, error) {
+	fs := flag.NewFlagSet("env check", flag.ContinueOnError)
+	documentedOnly := fs.Bool("documented-only", false, "Only check that "+envExampleFile+" documents the variables, e.g. in CI without the env files")
+	files, err := utils.ParseFlags(fs, params)
+	if err != nil {
+		return false, err
+	}
+	if len(files) == 0 {
+		if files, err = workflows.ProjectFiles(""); err != nil {
+			return false, err
+		}
+	}
+	usages, err := workflows.Variables(files)
+	if err != nil {
+		return false, err
+	}
+	documented, err := utils.LoadDotEnv(envExampleFile)
+	if os.IsNotExist(err) {
+		return false, fmt.Errorf("no %s found, write one with n8nctl workflows vars --all --example", envExampleFile)
+	} else if err != nil {
+		return false, fmt.Errorf("failed to read %s: %w", envExampleFile, err)
+	}
+	defined, err := workflows.EnvNames()
+	if err != nil {
+		return false, err
+	}
+
+	errs, warnings := 0, 0
+	names := variableNames(usages)
+	for _, name := range names {
+		var used []string
+		for _, usage := range usages {
+			if usage.Name == name {
+				used = append(used, fmt.Sprintf("%s (%s)", usage.File, usage.Where))
+			}
+		}
+		if _, ok := documented[name]; !ok {
+			fmt.Printf("error: %s is used by %s but not documented in %s\n", name, strings.Join(used, ", "), envExampleFile)
+			errs++
+		}
+		if _, ok := defined[name]; !ok && !*documentedOnly {
+			fmt.Printf("error: %s is used by %s but not defined in %s\n", name, strings.Join(used, ", "), strings.Join(workflows.EnvFiles, ", "))
+			errs++
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(documented)) {
+		if !slices.Contains(names, name) {
+			fmt.Printf("warning: %s is documented in %s but no workflow uses it\n", name, envExampleFile)
+			warnings++
+		}
+	}
+	if errs == 0 && warnings == 0 {
+		fmt.Printf("%d variables of %d files, all documented", len(names), len(files))
+		if !*documentedOnly {
+			fmt.Print(" and defined")
+		}
+		fmt.Println(".")
+	} else {
+		fmt.Printf("\n%d errors, %d warnings\n", errs, warnings)
+	}
+	return errs == 0, nil
+}
+
+// editEnvStore sets, unsets or lists the variables stored for the selected
+// profile. Variables given without a value are asked for, so secrets stay
+// out of the shell history.
+func editEnvStore(command string, args []string) error {
+	store, err := envstore.Open(config.Profile)
+	if err != nil {
+		return err
+	}
+	profile := cmp.Or(config.Profile, config.DefaultProfile)
+	switch command {
+	case "list":
+		if len(args) > 0 {
+			return fmt.Errorf("env list takes no arguments")
+		}
+		if len(store.Vars) == 0 {
+			fmt.Printf("No variables stored for profile %s.\n", profile)
+		}
+		for _, name := range slices.Sorted(maps.Keys(store.Vars)) {
+			fmt.Println(name)
+		}
+		return nil
+	case "set":
+		if len(args) == 0 {
+			return fmt.Errorf("env set requires KEY=value or KEY")
+		}
+		for _, arg := range args {
+			name, value, ok := strings.Cut(arg, "=")
+			if !ok {
+				if value, err = prompt.Secret("Value of " + name); err != nil {
+					return err
+				}
+			}
+			if err := store.Set(name, value); err != nil {
+				return err
+			}
+		}
+	case "unset":
+		if len(args) == 0 {
+			return fmt.Errorf("env unset requires KEY")
+		}
+		for _, name := range args {
+			if _, ok := store.Vars[name]; !ok {
+				return fmt.Errorf("no variable %s stored for profile %s", name, profile)
+			}
+			delete(store.Vars, name)
+		}
+	}
+	if err := store.Save(); err != nil {
+		return err
+	}
+	fmt.Printf("%d variables stored for profile %s in %s\n", len(store.Vars), profile, store.Path())
+	return nil
+}
@@ -0,0 +1,110 @@
+package entities
+
+import (
+	"cmp"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/brandon-kyle-bailey/n8nctl/config"
+	"github.com/brandon-kyle-bailey/n8nctl/daemon"
+	"github.com/brandon-kyle-bailey/n8nctl/prompt"
+	"github.com/brandon-kyle-bailey/n8nctl/telemetry"
+	"github.com/brandon-kyle-bailey/n8nctl/utils"
+	"github.com/brandon-kyle-bailey/n8nctl/workflows"
+)
+
+// AllEnvsFlag makes workflows deploy deploy to every environment of
+// environments.yaml.
+const AllEnvsFlag = "--all-envs"
+
+// HandleDeployAllEnvs runs workflows deploy with args, less --all-envs, for
+// each environment of environments.yaml in the order they are declared,
+// then reports how each went. A failed environment does not stop the
+// others.
+func HandleDeployAllEnvs(args []string) {
+	if workflows.Env != "" {
+		fmt.Println("Error: deploy to one environment with --env, or to every one with --all-envs, not both")
+		telemetry.Exit(1)
+	}
+	envs, err := config.LoadEnvironments()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		telemetry.Exit(1)
+	}
+	if len(envs) == 0 {
+		fmt.Printf("Error: --all-envs deploys to the environments of %s, found none\n", config.EnvironmentsFile)
+		telemetry.Exit(1)
+	}
+	dir, err := os.Getwd()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		telemetry.Exit(1)
+	}
+	var names []string
+	for _, env := range envs {
+		names = append(names, env.Name)
+	}
+	confirmed, err := prompt.Confirm(fmt.Sprintf("Deploy to %s?", strings.Join(names, ", ")), false)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		telemetry.Exit(1)
+	}
+	if !confirmed {
+		fmt.Println("Deploy aborted by user.")
+		return
+	}
+
+	args = slices.DeleteFunc(slices.Clone(args), func(arg string) bool {
+		return arg == AllEnvsFlag || arg == AllEnvsFlag[1:] || strings.HasPrefix(arg, AllEnvsFlag+"=") || strings.HasPrefix(arg, AllEnvsFlag[1:]+"=")
+	})
+	var rows []map[string]any
+	failed := false
+	for _, env := range envs {
+		fmt.Printf("==> %s\n", env.Name)
+		// Each environment deploys in a process of its own, as the profile,
+		// project and variables it selects are global to a run.
+		result, err := daemon.Run(Context, daemon.RunParams{
+			Args: append([]string{"--env", env.Name, "--yes", "workflows", "deploy"}, args...),
+			Dir:  dir,
+		})
+		status := "deployed"
+		switch {
+		case err != nil:
+			status = fmt.Sprintf("failed to start: %v", err)
+		case result.ExitCode != 0:
+			status = "failed: " + lastErrorLine(result.Stdout+result.Stderr, result.ExitCode)
+		}
+		if err != nil || result.ExitCode != 0 {
+			failed = true
+		}
+		fmt.Print(result.Stdout)
+		fmt.Fprint(os.Stderr, result.Stderr)
+		fmt.Println()
+		profile := env.Profile
+		if profile == "" {
+			profile = cmp.Or(config.Profile, config.DefaultProfile)
+		}
+		rows = append(rows, map[string]any{"env": env.Name, "profile": profile, "result": status})
+		if Context.Err() != nil {
+			break
+		}
+	}
+	utils.PrintTable(os.Stdout, rows, []string{"env", "profile", "result"}, true)
+	if failed {
+		telemetry.Exit(1)
+	}
+}
+
+// lastErrorLine returns the message of the last "Error: " line of output,
+// where commands report why they failed.
+func lastErrorLine(output string, exitCode int) string {
+	message := fmt.Sprintf("exit code %d", exitCode)
+	for line := range strings.SplitSeq(output, "\n") {
+		if after, ok := strings.CutPrefix(line, "Error: "); ok {
+			message = after
+		}
+	}
+	return message
+}
@@ -0,0 +1,197 @@
+package entities
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	neturl "net/url"
+	"os"
+	"time"
+
+	"github.com/brandon-kyle-bailey/n8nctl/config"
+	"github.com/brandon-kyle-bailey/n8nctl/executions"
+	"github.com/brandon-kyle-bailey/n8nctl/pkg/n8n"
+	"github.com/brandon-kyle-bailey/n8nctl/state"
+	"github.com/brandon-kyle-bailey/n8nctl/usage"
+	"github.com/brandon-kyle-bailey/n8nctl/utils"
+	"github.com/brandon-kyle-bailey/n8nctl/workflows"
+)
+
+// estimateWorkflow estimates the executions a month of a local workflow file
+// or remote workflow from its trigger schedules and the recent rate of its
+// event triggers, and fails when they would exceed the plan's quota, so
+// workflows can be checked before they are activated.
+func estimateWorkflow(client *n8n.Client, basePath string, params []string, cfg config.Config) error {
+	fs := flag.NewFlagSet("estimate", flag.ContinueOnError)
+	quota := fs.Int("quota", cfg.ExecutionQuota, "Executions a month the plan allows (default: the profile's \"execution_quota\" setting)")
+	window := fs.String("window", "7d", "How far back to count executions of event triggers such as webhooks")
+	eventsPerDay := fs.Float64("events-per-day", -1, "Expected executions a day from event triggers, instead of counting recent ones")
+	args, err := utils.ParseFlags(fs, params)
+	if err != nil {
+		return err
+	}
+	if len(args) != 1 {
+		return fmt.Errorf("estimate requires a workflow file or ID")
+	}
+	age, err := utils.ParseAge(*window)
+	if err != nil {
+		return err
+	}
+
+	// A file is estimated as rendered, and its event rate taken from the
+	// workflow it was last deployed to.
+	target, id := args[0], args[0]
+	var body []byte
+	if _, err := os.Stat(target); err == nil {
+		if body, err = workflows.RenderTemplate(target); err != nil {
+			return err
+		}
+		id = ""
+		if st, err := state.Load(); err == nil {
+			if entry, ok := st.Get(target); ok {
+				id = entry.WorkflowID
+			}
+		}
+	} else if body, err = n8nAPIRequest(client, "GET", fmt.Sprintf("%s/%s", basePath, id), ""); err != nil {
+		return err
+	}
+	name, err := workflowName(body)
+	if err != nil {
+		return err
+	}
+	triggers, err := workflows.Triggers(body)
+	if err != nil {
+		return err
+	}
+	if len(triggers) == 0 {
+		fmt.Printf("%q has no triggers besides the manual one, it only runs when started by hand or the API.\n", name)
+		return nil
+	}
+
+	var total float64
+	scheduled, events := false, false
+	fmt.Printf("Estimated executions a month of %q:\n", name)
+	for _, t := range triggers {
+		if !t.Scheduled {
+			events = true
+			continue
+		}
+		scheduled = true
+		total += t.PerMonth
+		estimate := fmt.Sprintf("%.0f", t.PerMonth)
+		if t.Polls {
+			// Polls only start an execution when they find new data.
+			estimate = "up to " + estimate
+		}
+		fmt.Printf("  %-24s %-14s %s\n", t.Node, estimate, t.Schedule)
+	}
+
+	if events {
+		rate, basis, err := eventRate(client, id, *window, age, scheduled, *eventsPerDay, cfg)
+		if err != nil {
+			return err
+		}
+		if rate < 0 {
+			fmt.Printf("  %-24s %-14s %s\n", "event triggers", "unknown", basis)
+		} else {
+			monthly := rate * float64(workflows.Month) / float64(24*time.Hour)
+			total += monthly
+			fmt.Printf("  %-24s %-14.0f %s\n", "event triggers", monthly, basis)
+		}
+	}
+	fmt.Printf("  %-24s %.0f\n", "total", total)
+
+	if *quota <= 0 {
+		fmt.Fprintln(os.Stderr, "\nSet --quota or the profile's \"execution_quota\" to compare the estimate to your plan.")
+		return nil
+	}
+	baseline := instanceExecutions()
+	fmt.Println()
+	if baseline > 0 {
+		fmt.Printf("The instance runs about %.0f executions a month already (last usage snapshot).\n", baseline)
+		if remoteActive(body) {
+			// The snapshot counts the executions of an active workflow.
+			total = 0
+			fmt.Println("The workflow is active, so these include its executions.")
+		}
+	}
+	share := (baseline + total) / float64(*quota) * 100
+	fmt.Printf("With this workflow: %.0f of %d executions a month (%.0f%%)\n", baseline+total, *quota, share)
+	switch {
+	case share > 100:
+		return fmt.Errorf("%q would exceed the plan's quota of %d executions a month", name, *quota)
+	case share >= 80:
+		fmt.Fprintln(os.Stderr, utils.Colorize("Warning: this leaves little headroom in the plan's execution quota", utils.ColorYellow))
+	}
+	return nil
+}
+
+// eventRate returns the executions a day that the event triggers of the
+// workflow id started within the last age, and what the rate is based on.
+// Executions of scheduled triggers, which have the "trigger" mode, are left
+// out when the schedules were estimated already. The rate is negative when
+// unknown.
+func eventRate(client *n8n.Client, id, window string, age time.Duration, scheduled bool, given float64, cfg config.Config) (float64, string, error) {
+	if given >= 0 {
+		return given, fmt.Sprintf("%g a day (--events-per-day)", given), nil
+	}
+	if id == "" {
+		return -1, "not deployed yet, pass --events-per-day", nil
+	}
+	since := time.Now().Add(-age)
+	count := 0
+	oldest := time.Now()
+	err := forEachExecution(client, client.APIURL("executions"), neturl.Values{"workflowId": {id}}, 0, cfg, func(exec executions.Execution) error {
+		if exec.StartedAt.Before(since) {
+			return errEnoughExecutions
+		}
+		oldest = exec.StartedAt
+		switch {
+		case exec.Mode == "manual" || exec.Mode == "retry":
+		case exec.Mode == "trigger" && scheduled:
+		default:
+			count++
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errEnoughExecutions) {
+		return 0, "", err
+	}
+	basis := fmt.Sprintf("%d executions in the last %s", count, window)
+	if errors.Is(err, errEnoughExecutions) {
+		oldest = since
+	} else if count > 0 {
+		// The workflow is younger than the window, rate it over its life.
+		basis = fmt.Sprintf("%d executions since %s", count, oldest.Local().Format("2006-01-02 15:04"))
+	}
+	days := time.Since(oldest).Hours() / 24
+	if count == 0 || days <= 0 {
+		return 0, fmt.Sprintf("no executions in the last %s", window), nil
+	}
+	return float64(count) / days, basis, nil
+}
+
+// remoteActive reports whether a workflow fetched from the instance is
+// active. Local files carry no active state.
+func remoteActive(body []byte) bool {
+	var wf struct {
+		ID     string `json:"id"`
+		Active bool   `json:"active"`
+	}
+	return json.Unmarshal(body, &wf) == nil && wf.ID != "" && wf.Active
+}
+
+// instanceExecutions returns the executions a month of the whole instance
+// from the last usage snapshot, or 0 if none was taken.
+func instanceExecutions() float64 {
+	path, err := usage.Path(config.Profile)
+	if err != nil {
+		return 0
+	}
+	samples, err := usage.Load(path, time.Time{})
+	if err != nil || len(samples) == 0 {
+		return 0
+	}
+	return float64(samples[len(samples)-1].Executions) * float64(workflows.Month) / float64(24*time.Hour)
+}
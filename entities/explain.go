@@ -0,0 +1,59 @@
+package entities
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/brandon-kyle-bailey/n8nctl/ai"
+	"github.com/brandon-kyle-bailey/n8nctl/config"
+	"github.com/brandon-kyle-bailey/n8nctl/workflows"
+)
+
+// maxExplainedDiff bounds the diff sent to the model, whose context is
+// limited; the summary of nodes at its top survives the cut.
+const maxExplainedDiff = 60000
+
+// explainInstructions ask for a summary fit for a pull request.
+const explainInstructions = `You review changes to n8n workflows. You get a summary of
+the nodes added, removed and changed, then a unified diff of the workflow
+JSON. Values shown as ${{...}} or **** are redacted variables and secrets.
+
+Explain in plain English, in a few short bullet points, what the change does
+to the behavior of the workflow, as for a pull request description. Mention
+risks such as removed error handling, new external calls or changed triggers.
+Do not restate the diff line by line.`
+
+// explainDiff prints a summary of the changes of the workflow file path
+// since its last preview, written by the configured language model from the
+// diff with variables and secrets redacted. Nothing is sent unless a model
+// is configured.
+func explainDiff(path string) error {
+	project, err := config.LoadProject()
+	if err != nil {
+		return err
+	}
+	model, err := ai.New(project.AI)
+	if err != nil {
+		return err
+	}
+	diff, err := workflows.RedactedDiff(path)
+	if err != nil {
+		return err
+	}
+	if diff == "" {
+		return nil
+	}
+	if len(diff) > maxExplainedDiff {
+		diff = diff[:maxExplainedDiff] + "\n[diff truncated]\n"
+	}
+	fmt.Fprintf(os.Stderr, "\nAsking %s to explain the change...\n", model.Model)
+	reply, err := model.Complete(Context, []ai.Message{
+		{Role: "system", Content: explainInstructions},
+		{Role: "user", Content: diff},
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("\nExplanation (generated, check it against the diff):\n%s\n", reply)
+	return nil
+}
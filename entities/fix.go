@@ -0,0 +1,350 @@
+package entities
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/brandon-kyle-bailey/n8nctl/config"
+	"github.com/brandon-kyle-bailey/n8nctl/conventions"
+	"github.com/brandon-kyle-bailey/n8nctl/pkg/n8n"
+	"github.com/brandon-kyle-bailey/n8nctl/prompt"
+	"github.com/brandon-kyle-bailey/n8nctl/telemetry"
+	"github.com/brandon-kyle-bailey/n8nctl/utils"
+	"github.com/brandon-kyle-bailey/n8nctl/workflows"
+)
+
+// fixRules are the conventions fix can apply.
+var fixRules = []string{"naming", "tags"}
+
+// HandleFix applies the conventions of .n8nctl.yaml to the names of local
+// workflow files and, with --remote, to the names and tags of the remote
+// workflows. Every change is shown, then all are applied at once after
+// confirming. The config is only loaded for --remote.
+func HandleFix(args []string, loadConfig func() config.Config) {
+	fs := flag.NewFlagSet("fix", flag.ContinueOnError)
+	rules := fs.String("rules", strings.Join(fixRules, ","), "Conventions to apply: naming, tags")
+	local := fs.Bool("local", false, "Fix the local workflow files (the default without --remote)")
+	remote := fs.Bool("remote", false, "Fix the remote workflows")
+	dir := fs.String("dir", "", "Fix the workflow files in this directory")
+	dryRun := fs.Bool("dry-run", false, "Show the changes without applying them")
+	var sel workflowSelector
+	sel.register(fs, false)
+	args, err := utils.ParseFlags(fs, args)
+	if err == nil && len(args) > 0 {
+		err = fmt.Errorf("unexpected arguments: %s", strings.Join(args, " "))
+	}
+	if err == nil {
+		err = sel.validate(false)
+	}
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		telemetry.Exit(1)
+	}
+	if !*local && !*remote {
+		*local = true
+	}
+
+	project, err := config.LoadProject()
+	if err == nil {
+		err = conventions.Check(project.Conventions)
+	}
+	var apply []string
+	for rule := range strings.SplitSeq(*rules, ",") {
+		if rule = strings.TrimSpace(rule); rule == "" {
+			continue
+		}
+		if !slices.Contains(fixRules, rule) && err == nil {
+			err = fmt.Errorf("unknown rule %q, use %s", rule, strings.Join(fixRules, ", "))
+		}
+		apply = append(apply, rule)
+	}
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		telemetry.Exit(1)
+	}
+	c := project.Conventions
+	if !slices.Contains(apply, "naming") {
+		c.Naming = config.NamingConvention{}
+	}
+	if !slices.Contains(apply, "tags") {
+		c.Tags = config.TagConvention{}
+	}
+	if c.Naming == (config.NamingConvention{}) && len(c.Tags.Required) == 0 {
+		fmt.Printf("Error: no conventions to apply, set conventions.naming or conventions.tags in %s\n", config.ProjectFile)
+		telemetry.Exit(1)
+	}
+
+	if *local {
+		if err := fixLocal(*dir, c, *dryRun); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			telemetry.Exit(1)
+		}
+	}
+	if *remote {
+		cfg := loadConfig()
+		if cfg.ReadOnly && !*dryRun {
+			fmt.Println("Error: fix --remote changes the instance, which is not allowed in read-only mode")
+			telemetry.Exit(1)
+		}
+		client := newClient(cfg)
+		if err := fixRemote(client, client.APIURL("workflows"), &sel, c, *dryRun, cfg); err != nil {
+			fmt.Printf("Error: %s\n", config.Redact(err.Error(), cfg.APIToken))
+			telemetry.Exit(1)
+		}
+	}
+}
+
+// localFix is the new content of a workflow file.
+type localFix struct {
+	file, oldName, newName string
+	content                string
+}
+
+// fixLocal renames the workflows of the files in dir that do not follow
+// c. Tags are not part of workflow files, so only names are fixed.
+func fixLocal(dir string, c config.Conventions, dryRun bool) error {
+	if c.Naming == (config.NamingConvention{}) {
+		return nil
+	}
+	files, err := workflows.ProjectFiles(dir)
+	if err != nil {
+		return err
+	}
+	var fixes []localFix
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		fix, ok, err := fixFileName(file, string(data), c.Naming)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+			continue
+		}
+		if ok {
+			fixes = append(fixes, fix)
+			fmt.Print(utils.UnifiedDiff(file, file, string(data), fix.content, 1))
+		}
+	}
+	if len(fixes) == 0 {
+		fmt.Printf("%d workflow files follow the naming convention.\n", len(files))
+		return nil
+	}
+	if dryRun {
+		fmt.Printf("\n%d workflow files to rename (dry run).\n", len(fixes))
+		return nil
+	}
+	rows := make([][]string, len(fixes))
+	for i, fix := range fixes {
+		rows[i] = []string{fix.file, fix.oldName, fix.newName}
+	}
+	keep, err := prompt.ConfirmItems("Rename the workflows of these files?", []string{"FILE", "NAME", "NEW NAME"}, rows)
+	if err != nil {
+		return err
+	}
+	if keep == nil {
+		fmt.Println("Fix aborted by user.")
+		return nil
+	}
+	renamed := 0
+	for i, fix := range fixes {
+		if !keep[i] {
+			continue
+		}
+		if err := os.WriteFile(fix.file, []byte(fix.content), 0644); err != nil {
+			return err
+		}
+		renamed++
+	}
+	fmt.Printf("Renamed %d workflows, deploy them to rename them on the instance too.\n", renamed)
+	return nil
+}
+
+// fixFileName returns src, the workflow file file, with the name of its
+// workflow following c. Only the line of the name changes, keeping the rest
+// of the file as it is.
+func fixFileName(file, src string, c config.NamingConvention) (localFix, bool, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(src), &doc); err != nil {
+		return localFix{}, false, fmt.Errorf("%s: invalid YAML: %w", file, err)
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return localFix{}, false, fmt.Errorf("%s is not a workflow file", file)
+	}
+	root := doc.Content[0]
+	var name *yaml.Node
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value == "name" {
+			name = root.Content[i+1]
+		}
+	}
+	if name == nil || name.Kind != yaml.ScalarNode {
+		return localFix{}, false, fmt.Errorf("%s has no workflow name", file)
+	}
+	if strings.Contains(name.Value, "${{") || strings.Contains(name.Value, "{%") || name.Style&(yaml.LiteralStyle|yaml.FoldedStyle) != 0 {
+		return localFix{}, false, fmt.Errorf("%s: the name %q is built from variables, fix it by hand", file, name.Value)
+	}
+	fixed := conventions.Name(name.Value, c)
+	if fixed == name.Value {
+		return localFix{}, false, nil
+	}
+
+	lines := strings.SplitAfter(src, "\n")
+	line := lines[name.Line-1]
+	ending := line[len(strings.TrimRight(line, "\r\n")):]
+	// Keep double quotes, whose escapes JSON strings are a subset of.
+	value, err := yaml.Marshal(fixed)
+	if name.Style == yaml.DoubleQuotedStyle {
+		value, err = json.Marshal(fixed)
+	}
+	if err != nil {
+		return localFix{}, false, err
+	}
+	replaced := line[:name.Column-1] + strings.TrimSuffix(string(value), "\n")
+	if name.LineComment != "" {
+		replaced += " " + name.LineComment
+	}
+	lines[name.Line-1] = replaced + ending
+	return localFix{file: file, oldName: name.Value, newName: fixed, content: strings.Join(lines, "")}, true, nil
+}
+
+// remoteFix is a change to a remote workflow.
+type remoteFix struct {
+	wf          remoteWorkflow
+	newName     string
+	missingTags []string
+}
+
+// fixRemote renames and tags the remote workflows sel selects, all of
+// them by default, that do not follow c.
+func fixRemote(client *n8n.Client, basePath string, sel *workflowSelector, c config.Conventions, dryRun bool, cfg config.Config) error {
+	selected, err := sel.selectWorkflows(client, basePath, cfg)
+	if err != nil {
+		return err
+	}
+	var fixes []remoteFix
+	for _, wf := range selected {
+		fix := remoteFix{wf: wf, newName: wf.Name}
+		if c.Naming != (config.NamingConvention{}) {
+			fix.newName = conventions.Name(wf.Name, c.Naming)
+		}
+		var tags []string
+		for _, tag := range wf.Tags {
+			tags = append(tags, tag.Name)
+		}
+		fix.missingTags = conventions.MissingTags(tags, c.Tags)
+		if fix.newName == wf.Name && len(fix.missingTags) == 0 {
+			continue
+		}
+		fixes = append(fixes, fix)
+		fmt.Printf("%s (%s)\n", wf.Name, wf.ID)
+		if fix.newName != wf.Name {
+			fmt.Printf("%s\n%s\n", utils.Colorize("-  name: "+wf.Name, utils.ColorRed), utils.Colorize("+  name: "+fix.newName, utils.ColorGreen))
+		}
+		if len(fix.missingTags) > 0 {
+			fmt.Println(utils.Colorize("+  tags: "+strings.Join(fix.missingTags, ", "), utils.ColorGreen))
+		}
+	}
+	if len(fixes) == 0 {
+		fmt.Printf("%d remote workflows follow the conventions.\n", len(selected))
+		return nil
+	}
+	if dryRun {
+		fmt.Printf("\n%d remote workflows to fix (dry run).\n", len(fixes))
+		return nil
+	}
+	rows := make([][]string, len(fixes))
+	for i, fix := range fixes {
+		rows[i] = []string{fix.wf.ID, fix.wf.Name, fix.newName, strings.Join(fix.missingTags, ",")}
+	}
+	keep, err := prompt.ConfirmItems("Fix these workflows?", []string{"ID", "NAME", "NEW NAME", "ADD TAGS"}, rows)
+	if err != nil {
+		return err
+	}
+	if keep == nil {
+		fmt.Println("Fix aborted by user.")
+		return nil
+	}
+
+	// Look the tags up once for the whole batch, creating the missing ones.
+	var needed []string
+	for i, fix := range fixes {
+		if keep[i] {
+			for _, tag := range fix.missingTags {
+				if !slices.Contains(needed, tag) {
+					needed = append(needed, tag)
+				}
+			}
+		}
+	}
+	tagIDs := map[string]string{}
+	if len(needed) > 0 {
+		ids, err := resolveTags(client, cfg, needed, true)
+		if err != nil {
+			return err
+		}
+		for i, tag := range needed {
+			tagIDs[tag] = ids[i]
+		}
+	}
+
+	failed := 0
+	for i, fix := range fixes {
+		if !keep[i] {
+			continue
+		}
+		if err := applyRemoteFix(client, basePath, fix, tagIDs); err != nil {
+			failed++
+			fmt.Printf("%s %s: %v\n", utils.Colorize("FAIL", utils.ColorRed), fix.wf.ID, err)
+			continue
+		}
+		fmt.Printf("%s   %s: %q\n", utils.Colorize("OK", utils.ColorGreen), fix.wf.ID, fix.newName)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d workflows failed to update", failed)
+	}
+	return nil
+}
+
+func applyRemoteFix(client *n8n.Client, basePath string, fix remoteFix, tagIDs map[string]string) error {
+	path := fmt.Sprintf("%s/%s", basePath, fix.wf.ID)
+	if fix.newName != fix.wf.Name {
+		data, err := n8nAPIRequest(client, "GET", path, "")
+		if err != nil {
+			return err
+		}
+		fields, err := managedFields(data)
+		if err != nil {
+			return err
+		}
+		var body map[string]any
+		if err := json.Unmarshal([]byte(fields), &body); err != nil {
+			return err
+		}
+		body["name"] = fix.newName
+		payload, _ := json.Marshal(body)
+		if _, err := n8nAPIRequest(client, "PUT", path, string(payload)); err != nil {
+			return err
+		}
+	}
+	if len(fix.missingTags) > 0 {
+		refs := []map[string]string{}
+		for _, tag := range fix.wf.Tags {
+			refs = append(refs, map[string]string{"id": tag.ID})
+		}
+		for _, tag := range fix.missingTags {
+			refs = append(refs, map[string]string{"id": tagIDs[tag]})
+		}
+		payload, _ := json.Marshal(refs)
+		if _, err := n8nAPIRequest(client, "PUT", path+"/tags", string(payload)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package entities
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/brandon-kyle-bailey/n8nctl/pkg/n8n"
+	"github.com/brandon-kyle-bailey/n8nctl/qr"
+	"github.com/brandon-kyle-bailey/n8nctl/utils"
+)
+
+// The triggers of workflows people use through pages n8n serves itself.
+const (
+	formTriggerType = "n8n-nodes-base.formTrigger"
+	chatTriggerType = "@n8n/n8n-nodes-langchain.chatTrigger"
+)
+
+// publicURL prints the URL of the form or chat page of a workflow, action
+// being form-url or chat-url, and with --qr a QR code of it to open it on a
+// phone.
+func publicURL(client *n8n.Client, basePath, action string, params []string) error {
+	fs := flag.NewFlagSet(action, flag.ContinueOnError)
+	test := fs.Bool("test", false, "Print the test URL, which works while the editor waits for a test event")
+	showQR := fs.Bool("qr", false, "Also print the URL as a QR code")
+	args, err := utils.ParseFlags(fs, params)
+	if err != nil {
+		return err
+	}
+	if len(args) != 1 {
+		return fmt.Errorf("%s requires exactly one workflow ID", action)
+	}
+	id := args[0]
+
+	nodeType, kind := formTriggerType, "form"
+	if action == "chat-url" {
+		nodeType, kind = chatTriggerType, "chat"
+	}
+	trigger, active, err := fetchTrigger(client, basePath, id, nodeType)
+	if err != nil {
+		return err
+	}
+	if trigger == nil {
+		return fmt.Errorf("workflow %s has no enabled %s trigger", id, kind)
+	}
+
+	var url string
+	if kind == "form" {
+		// Forms are served below /form/, at the path of the trigger.
+		prefix := "form"
+		if *test {
+			prefix = "form-test"
+		}
+		url = client.BaseURL() + "/" + prefix + "/" + trigger.path()
+	} else {
+		if !trigger.Parameters.Public {
+			return fmt.Errorf("the chat trigger of workflow %s is not public, turn on \"Make Chat Publicly Available\" to get a URL", id)
+		}
+		// The hosted chat is a page of the trigger's webhook.
+		prefix := productionWebhook
+		if *test {
+			prefix = testWebhook
+		}
+		url = client.BaseURL() + "/" + prefix + "/" + trigger.WebhookID + "/chat"
+		if trigger.Parameters.Mode == "webhook" {
+			fmt.Fprintf(os.Stderr, "Note: the chat of workflow %s is embedded, this URL is the webhook the chat widget calls, not a page\n", id)
+		}
+	}
+	if !*test && !active {
+		fmt.Fprintf(os.Stderr, "Warning: workflow %s is not active, its %s is not served until it is\n", id, kind)
+	}
+
+	fmt.Println(url)
+	if *showQR {
+		code, err := qr.Encode(url)
+		if err != nil {
+			return err
+		}
+		fmt.Print(code)
+	}
+	return nil
+}
@@ -0,0 +1,221 @@
+package entities
+
+import (
+	"cmp"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/brandon-kyle-bailey/n8nctl/ai"
+	"github.com/brandon-kyle-bailey/n8nctl/config"
+	"github.com/brandon-kyle-bailey/n8nctl/lint"
+	"github.com/brandon-kyle-bailey/n8nctl/pkg/n8n"
+	"github.com/brandon-kyle-bailey/n8nctl/prompt"
+	"github.com/brandon-kyle-bailey/n8nctl/utils"
+	"github.com/brandon-kyle-bailey/n8nctl/workflows"
+)
+
+// catalogEntry is a node type as the model is told about it.
+type catalogEntry struct {
+	Type        string
+	Version     float64
+	Description string
+}
+
+// builtinCatalog is the catalog of node types used without --node-types:
+// the triggers and nodes most workflows are built from.
+var builtinCatalog = []catalogEntry{
+	{"n8n-nodes-base.manualTrigger", 1, "Starts the workflow when clicking Test workflow"},
+	{"n8n-nodes-base.scheduleTrigger", 1.2, "Starts the workflow on a schedule (parameters.rule.interval)"},
+	{"n8n-nodes-base.webhook", 2, "Starts the workflow on an HTTP request (parameters.path, httpMethod)"},
+	{"n8n-nodes-base.formTrigger", 2.2, "Starts the workflow when a form n8n hosts is submitted"},
+	{"@n8n/n8n-nodes-langchain.chatTrigger", 1.1, "Starts the workflow on a chat message"},
+	{"n8n-nodes-base.errorTrigger", 1, "Starts the workflow when another workflow fails"},
+	{"n8n-nodes-base.stripeTrigger", 1, "Starts the workflow on Stripe events (parameters.events)"},
+	{"n8n-nodes-base.githubTrigger", 1, "Starts the workflow on GitHub repository events"},
+	{"n8n-nodes-base.slackTrigger", 1, "Starts the workflow on Slack events"},
+	{"n8n-nodes-base.httpRequest", 4.2, "Calls any HTTP API (parameters.method, url, sendBody, jsonBody)"},
+	{"n8n-nodes-base.set", 3.4, "Sets fields of the items (parameters.assignments)"},
+	{"n8n-nodes-base.if", 2, "Routes items to output true or false by conditions"},
+	{"n8n-nodes-base.switch", 3, "Routes items to one of several outputs by rules"},
+	{"n8n-nodes-base.merge", 3, "Merges the items of two inputs"},
+	{"n8n-nodes-base.filter", 2, "Keeps the items matching conditions"},
+	{"n8n-nodes-base.code", 2, "Runs JavaScript or Python code (parameters.jsCode)"},
+	{"n8n-nodes-base.wait", 1.1, "Waits for an amount of time or a webhook call"},
+	{"n8n-nodes-base.splitInBatches", 3, "Loops over the items in batches"},
+	{"n8n-nodes-base.respondToWebhook", 1.1, "Responds to the request of a webhook trigger"},
+	{"n8n-nodes-base.executeWorkflow", 1.1, "Runs another workflow"},
+	{"n8n-nodes-base.slack", 2.2, "Sends and manages Slack messages (parameters.resource, operation, channelId, text)"},
+	{"n8n-nodes-base.jira", 1, "Creates and updates Jira issues (parameters.resource, operation, project, issueType, summary)"},
+	{"n8n-nodes-base.github", 1, "Manages GitHub issues, files and releases"},
+	{"n8n-nodes-base.stripe", 1, "Reads and manages Stripe customers, charges and sources"},
+	{"n8n-nodes-base.gmail", 2.1, "Sends and reads Gmail email"},
+	{"n8n-nodes-base.emailSend", 2.1, "Sends email over SMTP"},
+	{"n8n-nodes-base.googleSheets", 4.5, "Reads and appends Google Sheets rows"},
+	{"n8n-nodes-base.postgres", 2.5, "Runs Postgres queries"},
+	{"n8n-nodes-base.notion", 2.2, "Reads and creates Notion pages and database items"},
+	{"n8n-nodes-base.airtable", 2.1, "Reads and creates Airtable records"},
+	{"n8n-nodes-base.telegram", 1.2, "Sends Telegram messages"},
+	{"n8n-nodes-base.discord", 2, "Sends Discord messages"},
+	{"n8n-nodes-base.microsoftTeams", 2, "Sends Microsoft Teams messages"},
+	{"n8n-nodes-base.pagerDuty", 1, "Creates and resolves PagerDuty incidents"},
+	{"n8n-nodes-base.noOp", 1, "Does nothing, ends a branch"},
+	{"n8n-nodes-base.stickyNote", 1, "A note on the canvas (parameters.content)"},
+}
+
+// generateInstructions tell the model how n8nctl workflow files look.
+const generateInstructions = `You write n8n workflows as n8nctl workflow files: YAML with
+- name: the workflow name
+- nodes: a list of nodes, each with id (a quoted number), name (unique), type,
+  typeVersion, position ([x, y], 200 apart from left to right) and parameters
+- connections: by source node name, main: a list of outputs, each a list of
+  {node: <target name>, type: main, index: 0}
+- settings: {}
+
+Rules:
+- Use only the node types of the catalog below, at the version given.
+- Start with exactly one trigger.
+- Never write secrets or credential IDs. Reference credentials by type with
+  a placeholder, e.g. credentials: {slackApi: {id: "CREDENTIAL_ID", name: "Slack"}},
+  and write values that differ between environments, such as channels or
+  project keys, as ${{VAR_NAME}} placeholders.
+- Reply with the YAML only, no explanations.
+
+Catalog (type, typeVersion: purpose):
+`
+
+// generateWorkflow asks the configured language model for a draft workflow
+// file doing what --prompt describes, lints it and writes it for review.
+// It is experimental, and nothing leaves the machine unless a model is
+// configured.
+func generateWorkflow(client *n8n.Client, params []string) error {
+	fs := flag.NewFlagSet("generate", flag.ContinueOnError)
+	description := fs.String("prompt", "", "What the workflow should do, e.g. \"when a Stripe payment fails, alert Slack\"")
+	out := fs.String("out", "", "Write the draft to this file instead of printing it")
+	withNodeTypes := fs.Bool("node-types", false, "Offer the model the node types installed on the instance instead of the built-in catalog, and check the draft against them")
+	printPrompt := fs.Bool("print-prompt", false, "Print the messages that would be sent to the model, without sending them")
+	args, err := utils.ParseFlags(fs, params)
+	if err != nil {
+		return err
+	}
+	if len(args) > 0 {
+		return fmt.Errorf("unexpected arguments: %s", strings.Join(args, " "))
+	}
+	if strings.TrimSpace(*description) == "" {
+		return fmt.Errorf("generate requires --prompt describing the workflow")
+	}
+
+	catalog := builtinCatalog
+	var types lint.NodeTypes
+	if *withNodeTypes {
+		if catalog, types, err = instanceCatalog(client); err != nil {
+			return err
+		}
+	}
+	var system strings.Builder
+	system.WriteString(generateInstructions)
+	for _, entry := range catalog {
+		fmt.Fprintf(&system, "- %s, %s: %s\n", entry.Type, strconv.FormatFloat(entry.Version, 'f', -1, 64), entry.Description)
+	}
+	messages := []ai.Message{
+		{Role: "system", Content: system.String()},
+		{Role: "user", Content: *description},
+	}
+	if *printPrompt {
+		for _, m := range messages {
+			fmt.Printf("--- %s\n%s\n", m.Role, strings.TrimSpace(m.Content))
+		}
+		return nil
+	}
+
+	project, err := config.LoadProject()
+	if err != nil {
+		return err
+	}
+	model, err := ai.New(project.AI)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Experimental: asking %s for a draft, review it before deploying.\n", model.Model)
+	reply, err := model.Complete(Context, messages)
+	if err != nil {
+		return err
+	}
+	draft := ai.StripFences(reply)
+
+	name := cmp.Or(*out, "draft")
+	body, err := workflows.YAMLToJSON([]byte(draft))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: the draft is not a valid workflow file: %v\n", err)
+	} else {
+		findings := lint.Lint(name, body)
+		if types != nil && !lint.HasErrors(findings) {
+			findings = append(findings, lint.CheckNodeTypes(name, body, types)...)
+		}
+		for _, f := range findings {
+			fmt.Fprintf(os.Stderr, "Warning: %s\n", f)
+		}
+	}
+
+	content := fmt.Sprintf("# Draft generated by n8nctl workflows generate (experimental), review before deploying.\n# Prompt: %s\n%s\n",
+		strings.Join(strings.Fields(*description), " "), draft)
+	if *out == "" {
+		fmt.Print(content)
+		return nil
+	}
+	if _, err := os.Stat(*out); err == nil {
+		confirmed, err := prompt.Confirm(fmt.Sprintf("Overwrite %s?", *out), false)
+		if err != nil {
+			return err
+		}
+		if !confirmed {
+			fmt.Println("Draft discarded.")
+			return nil
+		}
+	}
+	if err := os.WriteFile(*out, []byte(content), 0644); err != nil {
+		return err
+	}
+	fmt.Printf("Wrote draft %s, check it with n8nctl workflows preview %s\n", *out, *out)
+	return nil
+}
+
+// instanceCatalog returns the catalog of the node types installed on the
+// instance, at their latest version, and the node types to check drafts
+// against.
+func instanceCatalog(client *n8n.Client) ([]catalogEntry, lint.NodeTypes, error) {
+	data, err := n8nAPIRequest(client, "GET", client.BaseURL()+"/types/nodes.json", "")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch the node types of the instance: %w", err)
+	}
+	types, err := lint.ParseNodeTypes(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	var list []struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
+		Hidden      bool   `json:"hidden"`
+	}
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, nil, fmt.Errorf("invalid node types: %w", err)
+	}
+	var catalog []catalogEntry
+	seen := map[string]bool{}
+	for _, item := range list {
+		if item.Hidden || seen[item.Name] {
+			continue
+		}
+		seen[item.Name] = true
+		var latest float64
+		for _, nt := range types[item.Name] {
+			latest = max(latest, slices.Max(append([]float64{0}, nt.Versions...)))
+		}
+		catalog = append(catalog, catalogEntry{item.Name, latest, item.Description})
+	}
+	return catalog, types, nil
+}
@@ -0,0 +1,59 @@
+package entities
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/brandon-kyle-bailey/n8nctl/pkg/n8n"
+	"github.com/brandon-kyle-bailey/n8nctl/utils"
+	"github.com/brandon-kyle-bailey/n8nctl/workflows"
+)
+
+// graphWorkflow prints the nodes and connections of a local workflow file or
+// remote workflow as a Graphviz or Mermaid diagram, or draws it in the
+// terminal.
+func graphWorkflow(client *n8n.Client, basePath string, params []string) error {
+	fs := flag.NewFlagSet("graph", flag.ContinueOnError)
+	format := fs.String("format", "dot", "Diagram format: dot, mermaid or ascii")
+	ascii := fs.Bool("ascii", false, "Draw the diagram in the terminal, same as --format ascii")
+	args, err := utils.ParseFlags(fs, params)
+	if err != nil {
+		return err
+	}
+	if *ascii {
+		*format = "ascii"
+	}
+	if *format != "dot" && *format != "mermaid" && *format != "ascii" {
+		return fmt.Errorf("unknown format %q, use dot, mermaid or ascii", *format)
+	}
+	target := workflows.WorkflowFile
+	if len(args) > 0 {
+		target = args[0]
+	}
+
+	// Files are drawn as templates, so the diagram holds no secrets.
+	var body []byte
+	if _, err := os.Stat(target); err == nil {
+		if body, err = workflows.RenderTemplate(target); err != nil {
+			return err
+		}
+	} else if len(args) == 0 {
+		return fmt.Errorf("graph requires a workflow file or ID, no %s found", workflows.WorkflowFile)
+	} else if body, err = n8nAPIRequest(client, "GET", fmt.Sprintf("%s/%s", basePath, target), ""); err != nil {
+		return err
+	}
+	g, err := workflows.ParseGraph(body)
+	if err != nil {
+		return err
+	}
+	switch *format {
+	case "mermaid":
+		fmt.Print(g.Mermaid())
+	case "ascii":
+		fmt.Print(g.ASCII())
+	default:
+		fmt.Print(g.DOT())
+	}
+	return nil
+}
@@ -2,15 +2,27 @@
 package entities
 
 import (
-	"bufio"
+	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"io"
 	"net/http"
+	neturl "net/url"
 	"os"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/brandon-kyle-bailey/n8nctl/config"
+	"github.com/brandon-kyle-bailey/n8nctl/daemon"
+	"github.com/brandon-kyle-bailey/n8nctl/executions"
+	"github.com/brandon-kyle-bailey/n8nctl/lint"
+	"github.com/brandon-kyle-bailey/n8nctl/pkg/n8n"
+	"github.com/brandon-kyle-bailey/n8nctl/prompt"
+	"github.com/brandon-kyle-bailey/n8nctl/secrets"
+	"github.com/brandon-kyle-bailey/n8nctl/telemetry"
+	"github.com/brandon-kyle-bailey/n8nctl/testsuite"
 	"github.com/brandon-kyle-bailey/n8nctl/utils"
 	"github.com/brandon-kyle-bailey/n8nctl/workflows"
 )
@@ -29,19 +41,118 @@ Entities:`)
 	fmt.Println(`
 Special commands:
 	login:	Login and store your API token and base URL
+	dev:	Run a local n8n sandbox in Docker (dev up, dev down)
+	export:	Export workflows, variables and tags for other tooling
+		(--format terraform|terraform-json|k8s, --output <file>)
+	backup:	Write all workflows, tags and variables to one archive
+		(--out <file.tar.gz> [--credentials])
+	restore:	Recreate the resources of a backup archive, matching existing ones by name
+		(<file.tar.gz> [--skip credentials,variables] [--dry-run])
+	undo:	Restore the activation state recorded before a bulk activate or deactivate
+		(<.n8nctl/undo/file.json>)
+	daemon:	Keep API connections and node type catalogs warm for later commands, which
+		send their requests through it while it runs ([--idle 30m] [--node-types-ttl 10m],
+		daemon status, daemon stop). Tools drive n8nctl through its socket with JSON-RPC 2.0:
+		curl --unix-socket ~/.n8nctl/daemon.sock -d '{"jsonrpc":"2.0","id":1,"method":"rpc.discover"}' http://n8nctl/rpc
+	cron:	Run n8nctl commands, such as drift checks, prunes and backups, on the cron
+		expressions of a schedule file from one process ([-f schedule.yaml] [--list] [--run <job>])
+	bot:	Run allowlisted commands asked for in Slack or through a webhook, such as
+		"deploy orders to prod" after an approver reacts in Slack; the webhook cannot tell
+		users apart and refuses commands needing approval ([--slack-token, --slack-signing-secret]
+		[--webhook-secret] [--port 8090], commands in the bot section of .n8nctl.yaml)
+	env:	Check that .env.example documents, and the env files define, every ${{VAR}}
+		the workflow files use, failing otherwise (env check [file...] [--documented-only]),
+		or store variables encrypted for the profile in ~/.n8nctl/env/<profile>.enc,
+		which rendering uses over the env files (env set KEY=value|KEY, env unset KEY, env list)
+	fix:	Apply the conventions of .n8nctl.yaml (naming: case title|sentence|lower|kebab|snake
+		and prefix, tags: required) to the names of local workflow files and, with --remote,
+		to the names and tags of remote workflows, showing each change before applying them
+		([--rules naming,tags] [--local] [--remote [--tag, --name-glob ...]] [--dir <dir>] [--dry-run])
+	mock-server:	Serve a fake n8n API from recorded fixtures
+		(--fixtures <dir> [--port 8080] [--api-key <key>])
+	schema:	Export a JSON Schema of workflow YAML for editor validation, or the OpenRPC
+		document of the daemon's methods (schema export --for workflow-yaml|rpc [--out <file>] [--from-instance])
 
 Config:
 	Config is stored in ~/.n8nctl/config.json
+	Profiles may set "ca_cert", "client_cert" and "client_key" (PEM files) for
+	instances behind a private CA or mutual TLS, and "project" to work within
+	one team project (see --project)
+	Project settings are read from .n8nctl.yaml
+	Scrub files, such as pii.yaml for executions export --scrub, list "paths" to redact
+	(e.g. "**.headers.authorization", * matching one key or index and ** any number) and
+	"patterns" to mask in every value: email, card, token, phone, or {name, regex, replace}
 
 Environment:
 	.env file can be used for environment variable injection. (use workflows preview to verify values)
+	.env.sops.yaml, .env.sops.json or .env itself may be encrypted with SOPS, and are
+	decrypted with the age keys in SOPS_AGE_KEY or SOPS_AGE_KEY_FILE, or with AWS or
+	Google Cloud KMS using the AWS_* variables or GOOGLE_APPLICATION_CREDENTIALS.
+	${{vault:secret/data/<path>#<field>}} placeholders are read from HashiCorp Vault at
+	preview and deploy, using the profile's "vault" settings (address, token or
+	role_id and secret_id) or VAULT_ADDR and VAULT_TOKEN.
+	${{aws-sm:<name>#<field>}} placeholders are read from AWS Secrets Manager, using the
+	profile's "aws" settings (region, access_key_id, secret_access_key) or the AWS_*
+	variables, and ${{gcp-sm:projects/<project>/secrets/<name>}} from Google Cloud Secret
+	Manager, using the profile's "gcp" credentials_file or GOOGLE_APPLICATION_CREDENTIALS.
+	Workflow files named *.tmpl.yaml are Go templates with {% ... %} actions and the
+	functions of sprig, rendered first with the values of values.yaml (and
+	values.<env>.yaml with --env) as .Values.
+	Variables stored with n8nctl env set override those of the env files at preview
+	and deploy; N8NCTL_ENV_PASSPHRASE holds their passphrase instead of asking for it.
+	N8NCTL_RECORD=<file> records every API interaction into a fixture file, and
+	N8NCTL_REPLAY=<file> answers requests from it without contacting the instance.
+	N8NCTL_DAEMON_SOCKET=<path> moves the socket of the daemon from ~/.n8nctl/daemon.sock,
+	and N8NCTL_NO_DAEMON=1 sends API requests directly even while a daemon runs.
+	SLACK_BOT_TOKEN, SLACK_SIGNING_SECRET and N8NCTL_BOT_SECRET are the defaults of the
+	secrets of n8nctl bot.
+	N8NCTL_AI_ENDPOINT, N8NCTL_AI_MODEL, N8NCTL_AI_PROVIDER (openai or azure) and
+	N8NCTL_AI_API_KEY select the language model of the experimental workflows generate
+	and diff --explain, overriding the ai settings of .n8nctl.yaml; local
+	OpenAI-compatible servers work too. Nothing is sent to a model unless one is set.
+	N8NCTL_EMAIL and N8NCTL_PASSWORD sign in to the account that share, unshare and
+	list-shares use, as sharing is not part of the public API.
 
 Flags:
 	--schema  Show JSON schema for an entity's action when used with --help or an action command
 
-Dependencies:
-	- yq: sudo apt install yq or brew install yq
-	- colordiff: sudo apt install colordiff or brew install colordiff
+Global flags (accepted anywhere):
+	--debug            Trace every API request and response, with timing, to stderr
+	--yes, -y          Answer yes to every confirmation prompt
+	--non-interactive  Never prompt, fail instead of waiting for input (default when CI is set)
+	--profile <name>   Use the named config profile (default from N8NCTL_PROFILE)
+	--env <name>       Patch workflow files with their overlays of this env when
+	                   rendering them, e.g. overlays/prod.yaml, and use the profile,
+	                   project and env_file environments.yaml declares for it,
+	                   else .env.<env> instead of .env if it exists
+	                   (default from N8NCTL_ENV)
+	--env-file <path>  Read ${{VAR}} values from this file instead of .env and
+	                   .env.sops.*, e.g. .env.staging (repeatable, later files win)
+	--proxy <url>      Send API requests through this proxy (default: the profile's
+	                   "proxy_url" setting, else HTTP_PROXY/HTTPS_PROXY/NO_PROXY)
+	--project <id|name>
+	                   Scope workflow, execution and variable lists and new workflows,
+	                   credentials and variables to a project (default: the profile's
+	                   "project" setting); also the destination of transfer
+	--read-only        Refuse every command that changes the instance (default: the
+	                   profile's "read_only" setting)
+	--insecure-skip-verify
+	                   Do not verify the instance's TLS certificate (for testing only;
+	                   prefer the profile's "ca_cert" setting for private CAs)
+	--timeout <dur>    Limit each API request, e.g. 30s (default: the profile's
+	                   "timeout" setting, else 60s). After executions await-webhook,
+	                   executions retry and workflows run, --timeout is their own
+	                   limit on waiting; put it before the command for requests
+	--otel-endpoint <url>
+	                   Export OpenTelemetry traces of the command and its API calls to
+	                   this OTLP/HTTP collector (default from OTEL_EXPORTER_OTLP_ENDPOINT)
+	--stats            Print the number of API calls, bytes transferred and wall time
+	                   to stderr when the command finishes
+	--query <expr>     Filter and shape JSON responses with a jq expression,
+	                   e.g. --query '.data[].name'
+
+Output:
+	Diffs are colored when writing to a terminal. Set NO_COLOR=1 to disable colors.
 
 Use "n8nctl <entity> --help" for available actions and usage details.
 Use "n8nctl <entity> <action> --schema" to see the JSON schema for that action.`)
@@ -50,7 +161,7 @@ Use "n8nctl <entity> <action> --schema" to see the JSON schema for that action.`
 func HandleEntityCommand(entity string, args []string, actions map[string]Action, cfg config.Config) {
 	if len(args) == 0 {
 		fmt.Printf("%s requires an action. Use --help for available actions.\n", entity)
-		os.Exit(1)
+		telemetry.Exit(1)
 	}
 
 	action := args[0]
@@ -74,7 +185,7 @@ func HandleEntityCommand(entity string, args []string, actions map[string]Action
 		a, ok := actions[action]
 		if !ok {
 			fmt.Printf("Unknown action for %s: %s\n", entity, action)
-			os.Exit(1)
+			telemetry.Exit(1)
 		}
 		if a.Schema == "" {
 			fmt.Printf("No schema available for action %s on entity %s\n", action, entity)
@@ -88,18 +199,22 @@ func HandleEntityCommand(entity string, args []string, actions map[string]Action
 	a, ok := actions[action]
 	if !ok {
 		fmt.Printf("Unknown action for %s: %s\n", entity, action)
-		os.Exit(1)
+		telemetry.Exit(1)
 	}
 	if a.NeedsID && len(args) < 2 {
 		fmt.Printf("Action '%s' requires an ID parameter\n", action)
-		os.Exit(1)
+		telemetry.Exit(1)
 	}
 	params := args[1:]
+	if cfg.ReadOnly && !(readOnlyActions[action] || entity == "audit") {
+		fmt.Printf("Error: %s %s changes the instance, which is not allowed in read-only mode\n", entity, action)
+		telemetry.Exit(1)
+	}
 
 	err := handleGenericEntityAction(entity, action, params, cfg)
 	if err != nil {
-		fmt.Printf("Error: %v\n", err)
-		os.Exit(1)
+		fmt.Printf("Error: %s\n", config.Redact(err.Error(), cfg.APIToken))
+		telemetry.Exit(1)
 	}
 }
 
@@ -117,51 +232,145 @@ func PrintEntityHelp(entity string, actions map[string]Action, showSchema bool)
 	fmt.Printf("\nUsage:\n  n8nctl %s <action> [id] [flags]\n", entity)
 	fmt.Println("\nFlags:")
 	fmt.Println("  --schema  Show JSON schema for the action (use with --help or an action)")
+	fmt.Println("  --data    JSON payload for create/update (- reads it from stdin)")
+	fmt.Println("  --file    File with the JSON payload for create/update (- reads it from stdin)")
+	fmt.Println("\nList flags:")
+	fmt.Println("  --output, -o  Output format: json (default) or table")
+	fmt.Println("  --columns     Comma-separated fields to show, e.g. name,id,active,updatedAt")
+	fmt.Println("  --no-headers  Omit the table header row")
 }
 
 func handleGenericEntityAction(entity, action string, params []string, cfg config.Config) error {
-	client := &http.Client{}
-	basePath := fmt.Sprintf("%s/api/v1/%s", strings.ToLower(cfg.BaseURL), entity)
+	client := newClient(cfg)
+	basePath := fmt.Sprintf("%s/%s", cfg.APIBase(), entity)
 	var url, method, body string
 
 	switch action {
 	case "list":
-		method = "GET"
-		url = basePath
+		fs := flag.NewFlagSet("list", flag.ContinueOnError)
+		output := fs.String("output", "json", "Output format: json or table")
+		fs.StringVar(output, "o", "json", "Shorthand for --output")
+		columns := fs.String("columns", "", "Comma-separated fields to show as table columns (implies --output table)")
+		noHeaders := fs.Bool("no-headers", false, "Omit the table header row (implies --output table)")
+		if err := fs.Parse(params); err != nil {
+			return err
+		}
+		listURL, err := scopeList(client, cfg, entity, basePath)
+		if err != nil {
+			return err
+		}
+		if *columns == "" && !*noHeaders && *output == "json" {
+			method = "GET"
+			url = listURL
+			break
+		}
+		if *output != "json" && *output != "table" {
+			return fmt.Errorf("unknown output format %q, expected json or table", *output)
+		}
+		cols := listColumns[entity]
+		if *columns != "" {
+			cols = nil
+			for col := range strings.SplitSeq(*columns, ",") {
+				if col = strings.TrimSpace(col); col != "" {
+					cols = append(cols, col)
+				}
+			}
+		}
+		items, err := fetchAll[map[string]any](client, listURL, cfg)
+		if err != nil {
+			return err
+		}
+		utils.PrintTable(os.Stdout, items, cols, !*noHeaders)
+		return nil
 	case "get":
+		ids, many, concurrency, err := getFlags(params)
+		if err != nil {
+			return err
+		}
+		if many {
+			return getMany(client, basePath, ids, concurrency)
+		}
 		method = "GET"
-		url = fmt.Sprintf("%s/%s", basePath, params[0])
+		url = fmt.Sprintf("%s/%s", basePath, ids[0])
 	case "create":
+		var payload payloadFlags
+		var fromEnv envCredential
+		var starter string
+		fs := flag.NewFlagSet("create", flag.ContinueOnError)
+		payload.register(fs)
+		if entity == "credentials" {
+			fromEnv.register(fs)
+		}
 		if entity == "workflows" {
-			return workflows.GenerateStarterWorkflowYAML()
+			fs.StringVar(&starter, "template", "manual", "Trigger of the starter workflow.yaml written without a payload: manual, form or chat")
 		}
-		method = "POST"
-		if len(params) >= 2 && params[0] == "--data" {
-			body = params[1]
+		args, err := utils.ParseFlags(fs, params)
+		if err != nil {
+			return err
+		}
+		if entity == "workflows" && !payload.given() && len(args) == 0 {
+			return workflows.GenerateStarterWorkflowYAML(starter)
+		}
+		if fromEnv.given() {
+			if payload.given() || len(args) > 0 {
+				return fmt.Errorf("--from-env builds the payload, use it without --data and --file")
+			}
+			body, err = fromEnv.payload(client, basePath)
 		} else {
-			fmt.Println("Enter JSON data for creation:")
-			body = utils.ReadStdin()
+			body, err = payload.read(args, "creation")
 		}
+		if err != nil {
+			return err
+		}
+		if body, err = scopeCreate(client, cfg, entity, body); err != nil {
+			return err
+		}
+		method = "POST"
 		url = basePath
 
 	case "update":
-		method = "PATCH"
-		if len(params) < 1 {
+		var payload payloadFlags
+		fs := flag.NewFlagSet("update", flag.ContinueOnError)
+		payload.register(fs)
+		args, err := utils.ParseFlags(fs, params)
+		if err != nil {
+			return err
+		}
+		if len(args) < 1 || args[0] == "-" {
 			return fmt.Errorf("missing ID for update")
 		}
-		id := params[0]
-		url = fmt.Sprintf("%s/%s", basePath, id)
-		if len(params) >= 3 && params[1] == "--data" {
-			body = params[2]
-		} else {
-			fmt.Println("Enter JSON data for update:")
-			body = utils.ReadStdin()
+		body, err = payload.read(args[1:], "update")
+		if err != nil {
+			return err
 		}
+		method = "PATCH"
+		url = fmt.Sprintf("%s/%s", basePath, args[0])
 	case "delete":
+		if entity == "workflows" {
+			sel, err := bulkSelector(action, params)
+			if err != nil {
+				return err
+			}
+			if sel != nil {
+				return bulkWorkflows(client, basePath, action, sel, cfg)
+			}
+		}
+		confirmed, err := prompt.Confirm(fmt.Sprintf("Delete %s %s?", entity, params[0]), false)
+		if err != nil {
+			return err
+		}
+		if !confirmed {
+			fmt.Println("Delete aborted by user.")
+			return nil
+		}
 		method = "DELETE"
 		url = fmt.Sprintf("%s/%s", basePath, params[0])
 	case "preview":
 		if entity == "workflows" {
+			if err := parseResolveAt("preview", params); err != nil {
+				return err
+			}
+			useSecretStores(cfg)
 			confirmed, err := workflows.PreviewWorkflowJSONWithPrompt()
 			if err != nil {
 				return err
@@ -174,11 +383,69 @@ func handleGenericEntityAction(entity, action string, params []string, cfg confi
 		return fmt.Errorf("preview not supported for %s", entity)
 	case "diff":
 		if entity == "workflows" {
-			return workflows.DiffWorkflowJSON()
+			fs := flag.NewFlagSet("diff", flag.ContinueOnError)
+			fs.StringVar(&workflows.ResolveAt, "resolve-at", workflows.ResolveAtPreview, "When to resolve variables and secrets: preview, writing them to .out, or deploy")
+			explain := fs.Bool("explain", false, "Also ask the language model of the ai settings of .n8nctl.yaml to summarize the change, sending it the diff with variables and secrets redacted")
+			if _, err := utils.ParseFlags(fs, params); err != nil {
+				return err
+			}
+			if err := checkResolveAt(); err != nil {
+				return err
+			}
+			useSecretStores(cfg)
+			if err := workflows.DiffWorkflowJSON(); err != nil {
+				return err
+			}
+			if *explain {
+				return explainDiff(workflows.WorkflowFile)
+			}
+			return nil
 		}
 		return fmt.Errorf("diff not supported for %s", entity)
 	case "deploy":
 		if entity == "workflows" {
+			fs := flag.NewFlagSet("deploy", flag.ContinueOnError)
+			createOnly := fs.Bool("create-only", false, "Always create a new workflow, never update")
+			updateOnly := fs.Bool("update-only", false, "Only update an existing workflow, fail if none matches")
+			dir := fs.String("dir", "", "Deploy every workflow YAML file in this directory")
+			force := fs.Bool("force", false, "Deploy even if the workflow is unchanged since the last deploy")
+			// The command line routes deploys with --all-envs to HandleDeployAllEnvs.
+			fs.Bool(AllEnvsFlag[2:], false, "Deploy to every environment of "+config.EnvironmentsFile+", one after the other")
+			fs.StringVar(&workflows.ResolveAt, "resolve-at", workflows.ResolveAtPreview, "When to resolve variables and secrets: preview, writing them to .out, or deploy")
+			files, err := utils.ParseFlags(fs, params)
+			if err != nil {
+				return err
+			}
+			if err := checkResolveAt(); err != nil {
+				return err
+			}
+			if len(files) > 0 && *dir != "" {
+				return fmt.Errorf("deploy either files or --dir, not both")
+			}
+			mode := deployUpsert
+			switch {
+			case *createOnly && *updateOnly:
+				return fmt.Errorf("--create-only and --update-only are mutually exclusive")
+			case *createOnly:
+				mode = deployCreateOnly
+			case *updateOnly:
+				mode = deployUpdateOnly
+			}
+
+			d, err := newDeployer(client, basePath, cfg)
+			if err != nil {
+				return err
+			}
+			d.mode = mode
+			d.force = *force
+
+			if *dir != "" {
+				return d.deployDir(*dir)
+			}
+			if len(files) > 0 {
+				return d.deployFiles(files)
+			}
+
 			confirmed, err := workflows.PreviewWorkflowJSONWithPrompt()
 			if err != nil {
 				return err
@@ -188,25 +455,425 @@ func handleGenericEntityAction(entity, action string, params []string, cfg confi
 				return nil
 			}
 
-			jsonPath := ".out/workflow.json"
-			jsonBytes, err := os.ReadFile(jsonPath)
+			jsonPath := workflows.OutputPath
+			output, err := os.ReadFile(jsonPath)
 			if err != nil {
 				return fmt.Errorf("could not read %s: %w", jsonPath, err)
 			}
-			url = basePath
-			method = "POST"
-			body = string(jsonBytes)
-		} else {
-			return fmt.Errorf("deploy not supported for %s", entity)
+			jsonBytes := output
+			if workflows.ResolveAt == workflows.ResolveAtDeploy {
+				// The saved JSON holds the placeholders.
+				if jsonBytes, err = workflows.RenderWorkflowFile(workflows.WorkflowFile); err != nil {
+					return err
+				}
+			}
+			resp, result, err := d.deploy(workflows.WorkflowFile, jsonBytes, output)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Workflow %s\n", result)
+			if resp != nil {
+				utils.PrintJSONResponse(resp)
+			}
+			return nil
+		}
+		return fmt.Errorf("deploy not supported for %s", entity)
+	case "bisect":
+		if entity != "workflows" {
+			return fmt.Errorf("bisect not supported for %s", entity)
+		}
+		return bisectWorkflow(client, basePath, params, cfg)
+	case "log":
+		if entity != "workflows" {
+			return fmt.Errorf("log not supported for %s", entity)
+		}
+		return workflowLog(params)
+	case "rollback":
+		if entity != "workflows" {
+			return fmt.Errorf("rollback not supported for %s", entity)
+		}
+		fs := flag.NewFlagSet("rollback", flag.ContinueOnError)
+		to := fs.String("to", "1", "Snapshot to restore: number of deployments back, or a timestamp")
+		list := fs.Bool("list", false, "List recorded deployments instead of rolling back")
+		args, err := utils.ParseFlags(fs, params)
+		if err != nil {
+			return err
+		}
+		file := workflows.WorkflowFile
+		if len(args) > 0 {
+			file = args[0]
+		}
+		if *list {
+			return printHistory(file)
+		}
+		d, err := newDeployer(client, basePath, cfg)
+		if err != nil {
+			return err
+		}
+		return d.rollback(file, *to)
+	case "relay":
+		if entity != "webhooks" {
+			return fmt.Errorf("relay not supported for %s", entity)
+		}
+		fs := flag.NewFlagSet("relay", flag.ContinueOnError)
+		workflowID := fs.String("workflow", "", "ID of the webhook workflow whose requests to relay")
+		target := fs.String("to", "", "Local URL to replay requests to, e.g. http://localhost:3000/hook")
+		interval := fs.Duration("interval", 2*time.Second, "How often to poll for new executions")
+		if err := fs.Parse(params); err != nil {
+			return err
+		}
+		if *workflowID == "" || *target == "" {
+			return fmt.Errorf("relay requires --workflow and --to")
+		}
+		executionsPath := cfg.APIBase() + "/executions"
+		return executions.Relay(Context, executions.RelayOptions{
+			Target:   *target,
+			Interval: *interval,
+			Fetch: func(query neturl.Values) ([]byte, error) {
+				query.Set("workflowId", *workflowID)
+				return n8nAPIRequest(client, "GET", executionsPath+"?"+query.Encode(), "")
+			},
+		})
+	case "retry":
+		if entity != "executions" {
+			return fmt.Errorf("retry not supported for %s", entity)
+		}
+		return retryExecution(client, basePath, params)
+	case "copy":
+		if entity != "workflows" {
+			return fmt.Errorf("copy not supported for %s", entity)
+		}
+		return copyWorkflow(client, basePath, params)
+	case "adopt":
+		if entity != "workflows" {
+			return fmt.Errorf("adopt not supported for %s", entity)
+		}
+		return adoptWorkflow(client, basePath, params)
+	case "edit":
+		if entity != "workflows" {
+			return fmt.Errorf("edit not supported for %s", entity)
+		}
+		return editWorkflow(client, basePath, params)
+	case "graph":
+		if entity != "workflows" {
+			return fmt.Errorf("graph not supported for %s", entity)
+		}
+		return graphWorkflow(client, basePath, params)
+	case "vars":
+		if entity != "workflows" {
+			return fmt.Errorf("vars not supported for %s", entity)
+		}
+		return workflowVars(params)
+	case "estimate":
+		if entity != "workflows" {
+			return fmt.Errorf("estimate not supported for %s", entity)
+		}
+		return estimateWorkflow(client, basePath, params, cfg)
+	case "profile":
+		if entity != "executions" {
+			return fmt.Errorf("profile not supported for %s", entity)
+		}
+		return profileExecution(client, basePath, params)
+	case "slow":
+		if entity != "executions" {
+			return fmt.Errorf("slow not supported for %s", entity)
+		}
+		return slowExecutions(client, basePath, params, cfg)
+	case "prune":
+		switch entity {
+		case "executions":
+			return pruneExecutions(client, basePath, params, cfg)
+		case "workflows":
+			return pruneWorkflows(client, basePath, params, cfg)
+		}
+		return fmt.Errorf("prune not supported for %s", entity)
+	case "snapshot":
+		if entity != "usage" {
+			return fmt.Errorf("snapshot not supported for %s", entity)
+		}
+		return usageSnapshot(client, cfg)
+	case "report":
+		if entity != "usage" {
+			return fmt.Errorf("report not supported for %s", entity)
+		}
+		return usageReport(params)
+	case "tail":
+		if entity != "audit" {
+			return fmt.Errorf("tail not supported for %s", entity)
+		}
+		return auditTail(client, params, cfg)
+	case "watch":
+		if entity == "workflows" {
+			return watchWorkflows(client, basePath, params, cfg)
+		}
+		if entity != "executions" {
+			return fmt.Errorf("watch not supported for %s", entity)
+		}
+		fs := flag.NewFlagSet("watch", flag.ContinueOnError)
+		workflowID := fs.String("workflow-id", "", "Only show executions of this workflow")
+		fs.StringVar(workflowID, "workflow", "", "Shorthand for --workflow-id")
+		follow := fs.Bool("follow", false, "Keep printing executions as they start and finish")
+		fs.BoolVar(follow, "f", false, "Shorthand for --follow")
+		recent := fs.Int("n", 10, "Number of recent executions to print first")
+		interval := fs.Duration("interval", 2*time.Second, "How often to poll when following")
+		if err := fs.Parse(params); err != nil {
+			return err
+		}
+		return executions.Watch(Context, executions.WatchOptions{
+			Follow:   *follow,
+			Interval: *interval,
+			Recent:   *recent,
+			Out:      os.Stdout,
+			Fetch: func(query neturl.Values) ([]byte, error) {
+				if *workflowID != "" {
+					query.Set("workflowId", *workflowID)
+				}
+				return n8nAPIRequest(client, "GET", basePath+"?"+query.Encode(), "")
+			},
+		})
+	case "schema":
+		if entity == "credentials" {
+			return credentialSchema(client, basePath, params[0])
+		}
+		if entity != "executions" {
+			return fmt.Errorf("schema not supported for %s", entity)
+		}
+		fs := flag.NewFlagSet("schema", flag.ContinueOnError)
+		workflowID := fs.String("workflow", "", "ID of the workflow whose output to describe")
+		samples := fs.Int("samples", 20, "Number of recent executions to sample")
+		status := fs.String("status", "success", "Only sample executions with this status")
+		if err := fs.Parse(params); err != nil {
+			return err
+		}
+		if *workflowID == "" {
+			return fmt.Errorf("schema requires --workflow")
+		}
+		query := neturl.Values{"workflowId": {*workflowID}, "includeData": {"true"}}
+		if *status != "" {
+			query.Set("status", *status)
+		}
+		list, err := listExecutions(client, basePath, query, *samples, cfg)
+		if err != nil {
+			return err
+		}
+		schema, sampled, err := executions.InferOutputSchema(list, fmt.Sprintf("Output of workflow %s", *workflowID))
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Inferred from %d items in %d executions\n", sampled, len(list))
+		fmt.Println(string(schema))
+		return nil
+	case "export":
+		if entity != "executions" {
+			return fmt.Errorf("export not supported for %s", entity)
+		}
+		return exportExecutions(client, basePath, params, cfg)
+	case "plan", "apply":
+		if entity == "credentials" && action == "apply" {
+			return applyCredentials(client, basePath, params, cfg)
+		}
+		if entity != "workflows" {
+			return fmt.Errorf("%s not supported for %s", action, entity)
+		}
+		fs := flag.NewFlagSet(action, flag.ContinueOnError)
+		dir := fs.String("dir", "", "Directory of workflow YAML files (default workflows/ or workflow.yaml)")
+		showDiff := fs.Bool("diff", false, "Show the changes of each workflow to update")
+		detailedExitCode := fs.Bool("detailed-exitcode", false, "Exit with 2 when the plan contains changes")
+		var sel workflowSelector
+		sel.register(fs, false)
+		if err := fs.Parse(params); err != nil {
+			return err
+		}
+		if err := sel.validate(false); err != nil {
+			return err
+		}
+		files, err := workflows.ProjectFiles(*dir)
+		if err != nil {
+			return err
+		}
+		d, err := newDeployer(client, basePath, cfg)
+		if err != nil {
+			return err
 		}
+		items := d.plan(files, *dir, *showDiff)
+		if sel.given() {
+			items = d.scope(items, &sel)
+		}
+		changed := printPlan(items)
+		if action == "apply" {
+			fmt.Println()
+			return d.apply(items)
+		}
+		for _, item := range items {
+			if item.Action == planError {
+				return fmt.Errorf("plan has errors")
+			}
+		}
+		if changed && *detailedExitCode {
+			telemetry.Exit(2)
+		}
+		return nil
+	case "await-webhook":
+		if entity != "executions" {
+			return fmt.Errorf("await-webhook not supported for %s", entity)
+		}
+		fs := flag.NewFlagSet("await-webhook", flag.ContinueOnError)
+		port := fs.Int("port", 8089, "Local port to listen on")
+		path := fs.String("path", "/", "URL path to accept callbacks on")
+		timeout := fs.Duration("timeout", 30*time.Minute, "Give up after this long (0 waits forever)")
+		fs.DurationVar(timeout, "wait-timeout", 30*time.Minute, "Same as --timeout")
+		var match utils.StringList
+		fs.Var(&match, "match", "Only accept callbacks where key=value (dotted paths, repeatable)")
+		if err := fs.Parse(params); err != nil {
+			return err
+		}
+		conditions, err := utils.ParseKeyValues(match)
+		if err != nil {
+			return fmt.Errorf("invalid --match: %w", err)
+		}
+		payload, err := executions.AwaitWebhook(Context, executions.AwaitOptions{
+			Port:    *port,
+			Path:    *path,
+			Match:   conditions,
+			Timeout: *timeout,
+		})
+		if err != nil {
+			return err
+		}
+		utils.PrintJSONResponse(payload)
+		return nil
+	case "test":
+		if entity != "workflows" {
+			return fmt.Errorf("test not supported for %s", entity)
+		}
+		reports := reportFlags{formats: []string{"junit"}}
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		dir := fs.String("dir", testsuite.Dir, "Directory with the test files")
+		reports.register(fs)
+		if err := fs.Parse(params); err != nil {
+			return err
+		}
+		if err := reports.check(); err != nil {
+			return err
+		}
+		results, err := runTests(Context, *dir, cfg)
+		if err != nil {
+			return err
+		}
+		if err := reports.write(testReport(results), nil); err != nil {
+			return err
+		}
+		if testsFailed(results) {
+			telemetry.Exit(1)
+		}
+		return nil
+	case "validate", "lint":
+		if entity != "workflows" {
+			return fmt.Errorf("%s not supported for %s", action, entity)
+		}
+		reports := reportFlags{formats: []string{"junit", "sarif", "json"}}
+		fs := flag.NewFlagSet(action, flag.ContinueOnError)
+		dir := fs.String("dir", "", "Check every workflow YAML file in this directory")
+		withNodeTypes := fs.Bool("node-types", false, "Also check node types, versions and parameters against those installed on the instance")
+		noCache := fs.Bool("no-cache", false, "Check every file, even those unchanged since they were last checked")
+		reports.register(fs)
+		if err := fs.Parse(params); err != nil {
+			return err
+		}
+		if err := reports.check(); err != nil {
+			return err
+		}
+		check := lint.Lint
+		if action == "validate" {
+			check = lint.Validate
+		} else if err := lintOverrides(); err != nil {
+			return err
+		}
+		nodeTypesHash := ""
+		if *withNodeTypes {
+			types, hash, err := fetchNodeTypes(client)
+			if err != nil {
+				return err
+			}
+			check, nodeTypesHash = withNodeTypeChecks(check, types), hash
+		}
+		var cache *checkCache
+		if !*noCache {
+			cache = newCheckCache(action, nodeTypesHash)
+		}
+		files, findings, err := checkFiles(*dir, check, cache)
+		if err != nil {
+			return err
+		}
+		reports.files = files
+		if err := reports.write(findingsReport(action, files, findings), findings); err != nil {
+			return err
+		}
+		if lint.HasErrors(findings) {
+			telemetry.Exit(1)
+		}
+		return nil
+	case "run":
+		if entity != "workflows" {
+			return fmt.Errorf("run not supported for %s", entity)
+		}
+		return runWorkflow(client, basePath, params, cfg)
+	case "share", "unshare", "list-shares":
+		if entity != "workflows" && entity != "credentials" {
+			return fmt.Errorf("%s not supported for %s", action, entity)
+		}
+		return shareResource(client, entity, action, params, cfg)
+	case "transfer":
+		if entity != "workflows" && entity != "credentials" {
+			return fmt.Errorf("transfer not supported for %s", entity)
+		}
+		return transferResource(client, entity, basePath, params, cfg)
+	case "tags":
+		if entity != "workflows" {
+			return fmt.Errorf("tags not supported for %s", entity)
+		}
+		return workflowTags(client, basePath, params, cfg)
+	case "invoke-webhook":
+		if entity != "workflows" {
+			return fmt.Errorf("invoke-webhook not supported for %s", entity)
+		}
+		return invokeWebhook(client, basePath, params)
+	case "generate":
+		if entity != "workflows" {
+			return fmt.Errorf("generate not supported for %s", entity)
+		}
+		return generateWorkflow(client, params)
+	case "form-url", "chat-url":
+		if entity != "workflows" {
+			return fmt.Errorf("%s not supported for %s", action, entity)
+		}
+		return publicURL(client, basePath, action, params)
 	case "activate", "deactivate":
+		sel, err := bulkSelector(action, params)
+		if err != nil {
+			return err
+		}
+		if sel != nil {
+			return bulkWorkflows(client, basePath, action, sel, cfg)
+		}
 		url = fmt.Sprintf("%s/%s/%s", basePath, params[0], action)
 		method = "POST"
 	default:
 		return fmt.Errorf("action %s not implemented for entity %s", action, entity)
 	}
 
-	resp, err := n8nAPIRequest(client, method, url, body, cfg.APIToken)
+	if method == "GET" {
+		// Executions with their data run to many megabytes, print them as
+		// they arrive.
+		resp, err := streamAPIRequest(client, method, url)
+		if err != nil {
+			return err
+		}
+		defer resp.Close()
+		return utils.PrintJSONStream(resp)
+	}
+
+	resp, err := n8nAPIRequest(client, method, url, body)
 	if err != nil {
 		return err
 	}
@@ -215,67 +882,255 @@ func handleGenericEntityAction(entity, action string, params []string, cfg confi
 		fmt.Printf("%s %s successful\n", entity, action)
 		return nil
 	}
+	if action == "create" {
+		utils.PrintJSONResponse(resp)
+		return moveCreated(client, cfg, entity, basePath, resp)
+	}
 
 	utils.PrintJSONResponse(resp)
 	return nil
 }
 
-func n8nAPIRequest(client *http.Client, method, url, body, apiKey string) ([]byte, error) {
-	var reqBody io.Reader
-	if body != "" {
-		reqBody = strings.NewReader(body)
+// listExecutions pages through the executions matching query until limit
+// executions have been collected or there are no more.
+func listExecutions(client *n8n.Client, basePath string, query neturl.Values, limit int, cfg config.Config) ([]executions.Execution, error) {
+	var all []executions.Execution
+	err := forEachExecution(client, basePath, query, limit, cfg, func(exec executions.Execution) error {
+		all = append(all, exec)
+		return nil
+	})
+	return all, err
+}
+
+// forEachExecution streams the executions matching query page by page,
+// stopping after limit executions (0 means all of them).
+func forEachExecution(client *n8n.Client, basePath string, query neturl.Values, limit int, cfg config.Config, fn func(executions.Execution) error) error {
+	seen := 0
+	for limit == 0 || seen < limit {
+		pageSize := 250
+		if limit > 0 {
+			pageSize = min(limit-seen, pageSize)
+		}
+		query.Set("limit", fmt.Sprint(pageSize))
+		data, err := n8nAPIRequest(client, "GET", basePath+"?"+query.Encode(), "")
+		if err != nil {
+			return err
+		}
+		var page executions.ExecutionList
+		if err := json.Unmarshal(data, &page); err != nil {
+			return fmt.Errorf("failed to parse executions: %w", err)
+		}
+		for _, exec := range page.Data {
+			if err := fn(exec); err != nil {
+				return err
+			}
+			seen++
+		}
+		if page.NextCursor == "" || len(page.Data) == 0 {
+			break
+		}
+		query.Set("cursor", page.NextCursor)
 	}
+	return nil
+}
 
-	req, err := http.NewRequest(method, url, reqBody)
-	if err != nil {
-		return nil, err
+// Context is cancelled when the user interrupts n8nctl, abandoning the API
+// requests in flight.
+var Context = context.Background()
+
+// Debug traces every API request and response to stderr.
+var Debug bool
+
+// Faults are injected into every API request, for resilience testing.
+var Faults n8n.Faults
+
+// Stats, when set, counts the API requests of every client.
+var Stats *n8n.Stats
+
+// countRetry counts a poll of a command waiting for something, such as an
+// execution to finish, in Stats.
+func countRetry() {
+	if Stats != nil {
+		Stats.Retries.Add(1)
 	}
-	req.Header.Set("X-N8N-API-KEY", apiKey)
-	req.Header.Set("Content-Type", "application/json")
+}
 
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, err
+// useSecretStores registers the secret stores of the profile, and enables
+// decrypting SOPS env files, for rendering workflow templates. Lint and
+// validate leave them out, to run without access to the stores.
+func useSecretStores(cfg config.Config) {
+	workflows.SecretStores["vault"] = cfg.VaultResolver()
+	workflows.SecretStores["aws-sm"] = cfg.AWSSecretsResolver()
+	workflows.SecretStores["gcp-sm"] = cfg.GCPSecretsResolver()
+	workflows.SecretStores["env"] = secrets.Env{}
+	workflows.DecryptEnv = true
+}
+
+// parseResolveAt parses the --resolve-at flag of preview and diff.
+func parseResolveAt(action string, params []string) error {
+	fs := flag.NewFlagSet(action, flag.ContinueOnError)
+	fs.StringVar(&workflows.ResolveAt, "resolve-at", workflows.ResolveAtPreview, "When to resolve variables and secrets: preview, writing them to .out, or deploy")
+	if _, err := utils.ParseFlags(fs, params); err != nil {
+		return err
 	}
-	defer resp.Body.Close()
+	return checkResolveAt()
+}
 
-	data, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
+func checkResolveAt() error {
+	if workflows.ResolveAt != workflows.ResolveAtPreview && workflows.ResolveAt != workflows.ResolveAtDeploy {
+		return fmt.Errorf("unknown --resolve-at %q, expected preview or deploy", workflows.ResolveAt)
 	}
+	return nil
+}
 
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return nil, fmt.Errorf("API error: %s\n%s", resp.Status, string(data))
+// newClient returns an API client for the instance cfg points at.
+func newClient(cfg config.Config) *n8n.Client {
+	opts := []n8n.Option{n8n.WithTimeout(cfg.RequestTimeout())}
+	// LoadConfig has validated the proxy URL and TLS files already.
+	if proxy, _ := cfg.Proxy(); proxy != nil {
+		opts = append(opts, n8n.WithProxy(proxy))
+	}
+	if tlsConfig, _ := cfg.TLSConfig(); tlsConfig != nil {
+		opts = append(opts, n8n.WithTLSConfig(tlsConfig))
+	}
+	opts = append(opts, n8n.WithMiddleware(telemetry.Middleware))
+	if cfg.ReadOnly {
+		opts = append(opts, n8n.WithMiddleware(n8n.InterceptRequest(refuseWrites)))
+	}
+	if Stats != nil {
+		opts = append(opts, n8n.WithMiddleware(Stats.Middleware))
+	}
+	if r := fixtureRecorder(); r != nil {
+		opts = append(opts, n8n.WithRecorder(r))
 	}
+	if Faults != (n8n.Faults{}) {
+		opts = append(opts, n8n.WithFaults(Faults))
+	}
+	if Debug {
+		opts = append(opts, n8n.WithDebug(os.Stderr))
+	}
+	if socket := daemonSocket(); socket != "" {
+		opts = append(opts, n8n.WithDelegate(func(direct *http.Transport) http.RoundTripper {
+			return daemon.Transport(socket, daemon.SettingsOf(cfg), direct)
+		}))
+	}
+	return n8n.New(cfg.BaseURL, cfg.APIToken, opts...)
+}
 
-	return data, nil
+// refuseWrites fails the requests of read-only profiles that could change
+// the instance, backing up the check of the action in HandleEntityCommand.
+func refuseWrites(req *http.Request) error {
+	// Generating a security audit and signing in to the REST API, which
+	// sharing uses, are POSTs that change nothing.
+	if req.Method == http.MethodGet || req.Method == http.MethodHead ||
+		strings.HasSuffix(req.URL.Path, "/api/v1/audit") || strings.HasSuffix(req.URL.Path, "/rest/login") {
+		return nil
+	}
+	return fmt.Errorf("read-only mode, refusing to send %s %s", req.Method, req.URL.Path)
+}
+
+var (
+	recorder     *n8n.Recorder
+	recorderOnce sync.Once
+)
+
+// fixtureRecorder returns the recorder selected by N8NCTL_RECORD or
+// N8NCTL_REPLAY, which name a fixture file, or nil when neither is set. It is
+// shared by every client of the command so one fixture covers all requests.
+func fixtureRecorder() *n8n.Recorder {
+	recorderOnce.Do(func() {
+		path, mode := os.Getenv("N8NCTL_RECORD"), n8n.Record
+		if replay := os.Getenv("N8NCTL_REPLAY"); replay != "" {
+			path, mode = replay, n8n.Replay
+		}
+		if path == "" {
+			return
+		}
+		var err error
+		if recorder, err = n8n.NewRecorder(path, mode); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			telemetry.Exit(1)
+		}
+	})
+	return recorder
+}
+
+func n8nAPIRequest(client *n8n.Client, method, url, body string) ([]byte, error) {
+	var reqBody io.Reader
+	if body != "" {
+		reqBody = strings.NewReader(body)
+	}
+	return client.Do(Context, method, url, reqBody)
+}
+
+// detachContext switches Context to one that Ctrl-C does not cancel, for the
+// cleanup a command does on its way out, and returns a function switching
+// it back.
+func detachContext() func() {
+	previous := Context
+	Context = context.WithoutCancel(previous)
+	return func() { Context = previous }
+}
+
+// streamAPIRequest sends a request without a body and returns the response
+// body unread, see n8n.Client.Stream.
+func streamAPIRequest(client *n8n.Client, method, url string) (io.ReadCloser, error) {
+	return client.Stream(Context, method, url, nil)
 }
 
 func HandleLogin(args []string) {
 	fs := flag.NewFlagSet("login", flag.ExitOnError)
 	baseURL := fs.String("base-url", "", "API base URL")
-	token := fs.String("token", "", "API access token (see <base-url>/settings/api)")
+	token := fs.String("token", "", "API access token (see <base-url>/settings/api); omit to enter it without echo")
 	fs.Parse(args)
-	reader := bufio.NewReader(os.Stdin)
+	if prompt.NonInteractive && (*baseURL == "" || *token == "") {
+		fmt.Println("Error: --base-url and --token are required in non-interactive mode")
+		telemetry.Exit(1)
+	}
 	if *baseURL == "" {
-		fmt.Print("Enter API base URL: ")
-		input, _ := reader.ReadString('\n')
-		*baseURL = strings.TrimSpace(input)
+		input, err := prompt.Input("Enter API base URL", "", validateBaseURL)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			telemetry.Exit(1)
+		}
+		*baseURL = input
+	}
+	normalized, err := config.NormalizeBaseURL(*baseURL)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		telemetry.Exit(1)
+	}
+	if normalized != strings.TrimRight(strings.TrimSpace(*baseURL), "/") {
+		fmt.Printf("Using base URL %s\n", normalized)
 	}
 	if *token == "" {
-		fmt.Printf("Enter API token (visit %s/settings/api to generate one): ", *baseURL)
-		input, _ := reader.ReadString('\n')
-		*token = strings.TrimSpace(input)
+		input, err := prompt.Secret(fmt.Sprintf("Enter API token (visit %s/settings/api to generate one)", normalized))
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			telemetry.Exit(1)
+		}
+		*token = input
 	}
-	if *token == "" || *baseURL == "" {
+	if *token == "" {
 		fmt.Println("Error: both token and base-url are required")
-		os.Exit(1)
+		telemetry.Exit(1)
 	}
-	cfg := config.Config{APIToken: *token, BaseURL: strings.TrimRight(*baseURL, "/")}
-	err := config.SaveConfig(cfg)
+	// Keep the other settings of the profile, such as its proxy, TLS and
+	// project. A profile that does not load, e.g. because it does not exist
+	// yet or has no base URL, starts empty.
+	cfg, err := config.LoadConfig()
 	if err != nil {
-		fmt.Printf("Failed to save config: %v\n", err)
-		os.Exit(1)
+		cfg = config.Config{}
+	}
+	cfg.APIToken, cfg.BaseURL = *token, normalized
+	if err := config.SaveConfig(cfg); err != nil {
+		fmt.Printf("Failed to save config: %s\n", config.Redact(err.Error(), cfg.APIToken))
+		telemetry.Exit(1)
 	}
 	fmt.Println("Login successful, credentials saved.")
 }
+
+func validateBaseURL(value string) error {
+	_, err := config.NormalizeBaseURL(value)
+	return err
+}
@@ -0,0 +1,121 @@
+package entities
+
+import (
+	"flag"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/brandon-kyle-bailey/n8nctl/config"
+	"github.com/brandon-kyle-bailey/n8nctl/utils"
+	"github.com/brandon-kyle-bailey/n8nctl/workflows"
+)
+
+// logEntry is a commit or a deployment in the history of a workflow file.
+type logEntry struct {
+	at         time.Time
+	commit     *workflows.Commit
+	deployment *workflows.Deployment
+}
+
+// workflowLog prints the git commits of a workflow file together with its
+// deployments, newest first, and which commit every instance runs now.
+func workflowLog(params []string) error {
+	fs := flag.NewFlagSet("log", flag.ContinueOnError)
+	limit := fs.Int("limit", 20, "Show at most this many entries, 0 for all")
+	args, err := utils.ParseFlags(fs, params)
+	if err != nil {
+		return err
+	}
+	file := workflows.WorkflowFile
+	if len(args) > 0 {
+		file = args[0]
+	}
+
+	commits, err := workflows.FileCommits(Context, file)
+	if err != nil {
+		fmt.Printf("No git history of %s: %v\n", file, err)
+	}
+	deployments, err := workflows.Deployments(file)
+	if err != nil {
+		return err
+	}
+	if len(commits) == 0 && len(deployments) == 0 {
+		fmt.Printf("No commits or deployments of %s recorded yet.\n", file)
+		return nil
+	}
+
+	var entries []logEntry
+	for i := range commits {
+		entries = append(entries, logEntry{at: commits[i].Time, commit: &commits[i]})
+	}
+	for i := range deployments {
+		entries = append(entries, logEntry{at: deployments[i].Time(), deployment: &deployments[i]})
+	}
+	slices.SortStableFunc(entries, func(a, b logEntry) int { return b.at.Compare(a.at) })
+
+	// The first deployment of each instance in the list is what it runs.
+	running := map[string]*workflows.Deployment{}
+	var instances []string
+	for _, entry := range entries {
+		if d := entry.deployment; d != nil && d.Instance != "" && running[d.Instance] == nil {
+			running[d.Instance] = d
+			instances = append(instances, d.Instance)
+		}
+	}
+	if len(instances) > 0 {
+		fmt.Printf("Running versions of %s:\n", file)
+		for _, instance := range instances {
+			d := running[instance]
+			fmt.Printf("  %-40s %-16s since %s (%s)\n", deploymentTarget(*d), deployedVersion(*d),
+				d.Time().Local().Format("2006-01-02 15:04"), d.WorkflowID)
+		}
+		fmt.Println()
+	}
+
+	fmt.Printf("History of %s (newest first):\n", file)
+	for i, entry := range entries {
+		if *limit > 0 && i == *limit {
+			fmt.Printf("  ... %d older entries, see --limit\n", len(entries)-i)
+			break
+		}
+		at := entry.at.Local().Format("2006-01-02 15:04")
+		if c := entry.commit; c != nil {
+			fmt.Printf("  %s  %s  %s  %s (%s)\n", at, utils.Colorize("commit", utils.ColorYellow), c.Short(), c.Subject, c.Author)
+			continue
+		}
+		d := entry.deployment
+		detail := deployedVersion(*d)
+		if d.RollbackTo != "" {
+			detail += ", rollback to " + d.RollbackTo
+		}
+		fmt.Printf("  %s  %s  %s  to %s\n", at, utils.Colorize("deploy", utils.ColorGreen), detail, deploymentTarget(*d))
+	}
+	return nil
+}
+
+// deploymentTarget names the instance of a deployment by profile and URL.
+func deploymentTarget(d workflows.Deployment) string {
+	switch {
+	case d.Instance == "":
+		return "an unrecorded instance"
+	case d.Profile == "":
+		return fmt.Sprintf("%s (%s)", config.DefaultProfile, d.Instance)
+	}
+	return fmt.Sprintf("%s (%s)", d.Profile, d.Instance)
+}
+
+// deployedVersion names the commit of a deployment, marking uncommitted
+// changes.
+func deployedVersion(d workflows.Deployment) string {
+	version := "unknown commit"
+	if d.Commit != "" {
+		version = d.Commit[:min(len(d.Commit), 7)]
+	} else if d.Dirty {
+		version = "uncommitted"
+	}
+	if d.Commit != "" && d.Dirty {
+		version += "+changes"
+	}
+	return version
+}
@@ -0,0 +1,54 @@
+package entities
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/brandon-kyle-bailey/n8nctl/mockserver"
+	"github.com/brandon-kyle-bailey/n8nctl/pkg/n8n"
+	"github.com/brandon-kyle-bailey/n8nctl/telemetry"
+)
+
+// HandleMockServer serves a fake n8n API from fixtures recorded with
+// N8NCTL_RECORD, until interrupted.
+func HandleMockServer(args []string) {
+	fs := flag.NewFlagSet("mock-server", flag.ContinueOnError)
+	port := fs.Int("port", 8080, "Port to listen on")
+	fixtures := fs.String("fixtures", "", "Directory of fixture files recorded with N8NCTL_RECORD")
+	apiKey := fs.String("api-key", "", "Require this API key, like a real instance")
+	quiet := fs.Bool("quiet", false, "Do not log requests")
+	if err := fs.Parse(args); err != nil {
+		telemetry.Exit(1)
+	}
+	if *fixtures == "" {
+		fmt.Println("Usage: n8nctl mock-server --fixtures <dir> [--port 8080] [--api-key <key>]")
+		telemetry.Exit(1)
+	}
+	interactions, err := mockserver.LoadDir(*fixtures)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		telemetry.Exit(1)
+	}
+
+	handler := mockserver.New(interactions)
+	handler.APIKey = *apiKey
+	// The global --inject-latency and --inject-error-rate flags make the
+	// server itself slow or flaky rather than the client.
+	handler.Faults, Faults = Faults, n8n.Faults{}
+	if !*quiet {
+		handler.Log = os.Stderr
+	}
+	server := &http.Server{Addr: fmt.Sprintf(":%d", *port), Handler: handler}
+	go func() {
+		<-Context.Done()
+		server.Close()
+	}()
+	fmt.Fprintf(os.Stderr, "Serving %d recorded interactions on http://localhost:%d (Ctrl+C to stop)\n", len(interactions), *port)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		fmt.Printf("Error: %v\n", err)
+		telemetry.Exit(1)
+	}
+}
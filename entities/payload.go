@@ -0,0 +1,65 @@
+package entities
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/brandon-kyle-bailey/n8nctl/prompt"
+	"github.com/brandon-kyle-bailey/n8nctl/utils"
+)
+
+// payloadFlags are the ways create and update actions accept a JSON body:
+// --data '<json>', --file payload.json, or "-" for either to read stdin.
+type payloadFlags struct {
+	data string
+	file string
+}
+
+func (p *payloadFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&p.data, "data", "", "JSON payload, or - to read it from stdin")
+	fs.StringVar(&p.file, "file", "", "File containing the JSON payload, or - to read it from stdin")
+}
+
+func (p *payloadFlags) given() bool {
+	return p.data != "" || p.file != ""
+}
+
+// read returns the payload from the flags, from a lone "-" positional
+// argument, or otherwise by asking for it interactively.
+func (p *payloadFlags) read(positional []string, what string) (string, error) {
+	if p.data != "" && p.file != "" {
+		return "", fmt.Errorf("--data and --file are mutually exclusive")
+	}
+
+	var body string
+	switch {
+	case p.data == "-" || p.file == "-" || (len(positional) > 0 && positional[len(positional)-1] == "-"):
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return "", fmt.Errorf("failed to read stdin: %w", err)
+		}
+		body = string(data)
+	case p.data != "":
+		body = p.data
+	case p.file != "":
+		data, err := os.ReadFile(p.file)
+		if err != nil {
+			return "", fmt.Errorf("failed to read %s: %w", p.file, err)
+		}
+		body = string(data)
+	default:
+		if prompt.NonInteractive {
+			return "", fmt.Errorf("no JSON payload given, use --data, --file or - for stdin")
+		}
+		fmt.Printf("Enter JSON data for %s:\n", what)
+		body = utils.ReadStdin()
+	}
+
+	if !json.Valid([]byte(body)) {
+		return "", fmt.Errorf("payload is not valid JSON")
+	}
+	return body, nil
+}
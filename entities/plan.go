@@ -0,0 +1,280 @@
+package entities
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/brandon-kyle-bailey/n8nctl/manifest"
+	"github.com/brandon-kyle-bailey/n8nctl/pkg/n8n"
+	"github.com/brandon-kyle-bailey/n8nctl/state"
+	"github.com/brandon-kyle-bailey/n8nctl/utils"
+	"github.com/brandon-kyle-bailey/n8nctl/workflows"
+)
+
+type planAction string
+
+const (
+	planCreate planAction = "create"
+	planUpdate planAction = "update"
+	planNoop   planAction = "no-op"
+	planDelete planAction = "delete"
+	planError  planAction = "error"
+)
+
+// planItem is the change planned for a single local workflow file.
+type planItem struct {
+	File     string
+	Name     string
+	Action   planAction
+	Reason   string
+	Body     []byte
+	Existing *remoteWorkflow
+	Diff     string
+	Err      error
+}
+
+// comparedFields are the parts of a workflow that deploys manage; everything
+// else (ids, timestamps, activation) is owned by the instance.
+var comparedFields = []string{"name", "nodes", "connections", "settings"}
+
+// plan computes what deploying files would change on the remote instance,
+// including deleting workflows whose local file was removed.
+func (d *deployer) plan(files []string, dir string, withDiff bool) []planItem {
+	var items []planItem
+	planned := map[string]bool{}
+	dirs := map[string]bool{}
+	if dir != "" {
+		dirs[filepath.ToSlash(filepath.Clean(dir))] = true
+	}
+	for _, rendered := range workflows.RenderFiles(files) {
+		file := rendered.File
+		planned[filepath.ToSlash(filepath.Clean(file))] = true
+		dirs[filepath.ToSlash(filepath.Dir(file))] = true
+		items = append(items, d.planFile(rendered, withDiff))
+	}
+
+	for _, file := range slices.Sorted(maps.Keys(d.state.Workflows)) {
+		entry := d.state.Workflows[file]
+		if planned[file] || !dirs[filepath.ToSlash(filepath.Dir(file))] {
+			continue
+		}
+		if _, err := os.Stat(filepath.FromSlash(file)); err == nil {
+			continue
+		}
+		items = append(items, planItem{
+			File:     file,
+			Action:   planDelete,
+			Reason:   "local file removed",
+			Existing: &remoteWorkflow{ID: entry.WorkflowID},
+		})
+	}
+	return items
+}
+
+// scope keeps the items of the workflows sel selects. Tags are those of
+// the remote workflow, so workflows yet to be created only match by name.
+func (d *deployer) scope(items []planItem, sel *workflowSelector) []planItem {
+	var scoped []planItem
+	for _, item := range items {
+		name := item.Name
+		var tags []manifest.Tag
+		if item.Existing != nil {
+			tags = item.Existing.Tags
+			if name == "" {
+				// Deletions only know the ID recorded in the state file.
+				if data, err := n8nAPIRequest(d.client, "GET", fmt.Sprintf("%s/%s", d.basePath, item.Existing.ID), ""); err == nil {
+					var remote remoteWorkflow
+					if json.Unmarshal(data, &remote) == nil {
+						name, tags = remote.Name, remote.Tags
+						item.Name = remote.Name
+					}
+				}
+			}
+		}
+		if sel.matches(name, tags) {
+			scoped = append(scoped, item)
+		}
+	}
+	return scoped
+}
+
+func (d *deployer) planFile(rendered workflows.Rendered, withDiff bool) planItem {
+	file, body := rendered.File, rendered.Body
+	item := planItem{File: file}
+	fail := func(err error) planItem {
+		item.Action = planError
+		item.Err = err
+		return item
+	}
+
+	if rendered.Err != nil {
+		return fail(rendered.Err)
+	}
+	item.Body = body
+	name, err := workflowName(body)
+	if err != nil {
+		return fail(err)
+	}
+	item.Name = name
+
+	existing, err := d.findExisting(file, name)
+	if err != nil {
+		return fail(err)
+	}
+	item.Existing = existing
+	if existing == nil {
+		item.Action = planCreate
+		return item
+	}
+
+	entry, deployed := d.state.Get(file)
+	switch {
+	case !deployed || entry.WorkflowID != existing.ID:
+		item.Action = planUpdate
+		item.Reason = "not deployed from this file yet"
+	case entry.Hash != state.Hash(body):
+		item.Action = planUpdate
+		item.Reason = "local changes"
+	case entry.ChangedRemotely(existing.UpdatedAt):
+		item.Action = planUpdate
+		item.Reason = "changed remotely since last deploy"
+	default:
+		item.Action = planNoop
+		return item
+	}
+
+	if withDiff {
+		remote, err := n8nAPIRequest(d.client, "GET", fmt.Sprintf("%s/%s", d.basePath, existing.ID), "")
+		if err != nil {
+			return fail(err)
+		}
+		item.Diff, err = workflowDiff(remote, body)
+		if err != nil {
+			return fail(err)
+		}
+	}
+	return item
+}
+
+// workflowDiff diffs the deploy-managed fields of a remote and a local workflow.
+func workflowDiff(remote, local []byte) (string, error) {
+	remoteFields, err := managedFields(remote)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse remote workflow: %w", err)
+	}
+	localFields, err := managedFields(local)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse local workflow: %w", err)
+	}
+	return utils.UnifiedDiff("remote", "local", remoteFields, localFields, 3), nil
+}
+
+func managedFields(data []byte) (string, error) {
+	var wf map[string]any
+	if err := json.Unmarshal(data, &wf); err != nil {
+		return "", err
+	}
+	subset := map[string]any{}
+	for _, field := range comparedFields {
+		if value, ok := wf[field]; ok {
+			subset[field] = value
+		}
+	}
+	var out strings.Builder
+	enc := json.NewEncoder(&out)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	err := enc.Encode(subset)
+	return out.String(), err
+}
+
+// printPlan prints the planned changes and a summary line, returning whether
+// anything would change.
+func printPlan(items []planItem) bool {
+	counts := map[planAction]int{}
+	for _, item := range items {
+		counts[item.Action]++
+		label := item.File
+		if item.Name != "" {
+			label += fmt.Sprintf(" %q", item.Name)
+		}
+		if item.Existing != nil && item.Existing.ID != "" {
+			label += fmt.Sprintf(" (%s)", item.Existing.ID)
+		}
+		switch item.Action {
+		case planCreate:
+			fmt.Printf("%s %s\n", utils.Colorize("  + create", utils.ColorGreen), label)
+		case planUpdate:
+			fmt.Printf("%s %s: %s\n", utils.Colorize("  ~ update", utils.ColorYellow), label, item.Reason)
+		case planDelete:
+			fmt.Printf("%s %s: %s\n", utils.Colorize("  - delete", utils.ColorRed), label, item.Reason)
+		case planNoop:
+			fmt.Printf("    no-op  %s\n", label)
+		case planError:
+			fmt.Printf("%s %s: %v\n", utils.Colorize("  ! error ", utils.ColorRed), label, item.Err)
+		}
+		if item.Diff != "" {
+			for line := range strings.SplitSeq(strings.TrimRight(item.Diff, "\n"), "\n") {
+				fmt.Printf("        %s\n", line)
+			}
+		}
+	}
+	fmt.Printf("\nPlan: %d to create, %d to update, %d to delete, %d unchanged",
+		counts[planCreate], counts[planUpdate], counts[planDelete], counts[planNoop])
+	if counts[planError] > 0 {
+		fmt.Printf(", %d errors", counts[planError])
+	}
+	fmt.Println(".")
+	return counts[planCreate]+counts[planUpdate]+counts[planDelete] > 0
+}
+
+// apply executes a plan, continuing past failures and reporting each change.
+func (d *deployer) apply(items []planItem) error {
+	failed := 0
+	for _, item := range items {
+		var result string
+		var err error
+		switch item.Action {
+		case planNoop:
+			continue
+		case planError:
+			err = item.Err
+		case planCreate, planUpdate:
+			_, result, err = d.push(item.File, item.Name, item.Body, item.Body, item.Existing)
+		case planDelete:
+			result, err = d.remove(item.File, item.Existing.ID)
+		}
+		if err != nil {
+			failed++
+			fmt.Printf("%s %s: %v\n", utils.Colorize("FAIL", utils.ColorRed), item.File, err)
+			continue
+		}
+		fmt.Printf("%s   %s: %s\n", utils.Colorize("OK", utils.ColorGreen), item.File, result)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d changes failed to apply", failed)
+	}
+	return nil
+}
+
+// remove deletes the remote workflow deployed from a removed local file and
+// forgets it in the state file.
+func (d *deployer) remove(file, id string) (string, error) {
+	_, err := n8nAPIRequest(d.client, "DELETE", fmt.Sprintf("%s/%s", d.basePath, id), "")
+	var apiErr *n8n.APIError
+	if err != nil && !(errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound) {
+		return "", err
+	}
+	d.state.Forget(file)
+	if err := d.state.Save(); err != nil {
+		return "", fmt.Errorf("deleted, but failed to save %s: %w", state.Path, err)
+	}
+	return fmt.Sprintf("deleted %s", id), nil
+}
@@ -0,0 +1,76 @@
+package entities
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/brandon-kyle-bailey/n8nctl/executions"
+	"github.com/brandon-kyle-bailey/n8nctl/pkg/n8n"
+	"github.com/brandon-kyle-bailey/n8nctl/utils"
+)
+
+// profileExecution prints the time each node of an execution spent running,
+// slowest first, to show which node dominates the workflow's latency.
+func profileExecution(client *n8n.Client, basePath string, params []string) error {
+	fs := flag.NewFlagSet("profile", flag.ContinueOnError)
+	top := fs.Int("top", 0, "Only list the n slowest nodes")
+	args, err := utils.ParseFlags(fs, params)
+	if err != nil {
+		return err
+	}
+	if len(args) != 1 {
+		return fmt.Errorf("profile requires exactly one execution ID")
+	}
+	data, err := n8nAPIRequest(client, "GET", fmt.Sprintf("%s/%s?includeData=true", basePath, args[0]), "")
+	if err != nil {
+		return err
+	}
+	var exec executions.Execution
+	if err := json.Unmarshal(data, &exec); err != nil {
+		return fmt.Errorf("failed to parse execution %s: %w", args[0], err)
+	}
+	timings, err := executions.NodeTimings(exec.Data)
+	if err != nil {
+		return fmt.Errorf("execution %s: %w", args[0], err)
+	}
+
+	var inNodes time.Duration
+	for _, t := range timings {
+		inNodes += t.Total
+	}
+	header := fmt.Sprintf("Execution %s of workflow %s (%s)", exec.ID, exec.WorkflowID, exec.Status)
+	if d, ok := exec.Duration(); ok {
+		// The gap to the node total is spent outside nodes, e.g. starting up
+		// and saving the execution.
+		header += fmt.Sprintf(": %s end to end, %s in nodes", roundDuration(d), roundDuration(inNodes))
+	}
+	fmt.Println(header)
+	if *top > 0 && len(timings) > *top {
+		timings = timings[:*top]
+	}
+
+	width := len("NODE")
+	for _, t := range timings {
+		width = max(width, len(t.Node))
+	}
+	fmt.Printf("  %-*s  %4s  %8s  %8s  %6s\n", width, "NODE", "RUNS", "TIME", "MAX", "SHARE")
+	for _, t := range timings {
+		share := 0.0
+		if inNodes > 0 {
+			share = float64(t.Total) / float64(inNodes) * 100
+		}
+		line := fmt.Sprintf("  %-*s  %4d  %8s  %8s  %5.1f%%", width, t.Node, t.Runs,
+			roundDuration(t.Total), roundDuration(t.Max), share)
+		if bar := strings.Repeat("█", int(share/5+0.5)); bar != "" {
+			line += "  " + bar
+		}
+		if t.Status != "success" {
+			line += "  " + t.Status
+		}
+		fmt.Println(line)
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package entities
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	neturl "net/url"
+	"os"
+	"strings"
+
+	"github.com/brandon-kyle-bailey/n8nctl/config"
+	"github.com/brandon-kyle-bailey/n8nctl/pkg/n8n"
+)
+
+// Project is the --project flag, which overrides the profile's default
+// project and names the destination of transfer.
+var Project string
+
+type project struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+func (p project) String() string {
+	if p.Name == "" {
+		return p.ID
+	}
+	return fmt.Sprintf("%s (%s)", p.Name, p.ID)
+}
+
+// The entities the project of the profile or --project scopes. Lists are
+// filtered by a projectId query parameter. Creations set a projectId field
+// where the API accepts one, and are otherwise transferred into the project
+// right after.
+var (
+	projectScopedLists    = map[string]bool{"workflows": true, "executions": true, "variables": true}
+	projectScopedCreates  = map[string]bool{"variables": true}
+	projectTransferCreate = map[string]bool{"workflows": true, "credentials": true}
+)
+
+// resolveProject finds the project ref names, by ID or else by name. When
+// the instance does not allow listing projects, ref is taken to be an ID.
+func resolveProject(client *n8n.Client, cfg config.Config, ref string) (project, error) {
+	projects, err := fetchAll[project](client, cfg.APIBase()+"/projects", cfg)
+	var apiErr *n8n.APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusForbidden {
+		fmt.Fprintf(os.Stderr, "Warning: cannot list projects on this instance, using %q as a project ID\n", ref)
+		return project{ID: ref}, nil
+	}
+	if err != nil {
+		return project{}, fmt.Errorf("failed to list projects: %w", err)
+	}
+	var byName []project
+	for _, p := range projects {
+		if p.ID == ref {
+			return p, nil
+		}
+		if strings.EqualFold(p.Name, ref) {
+			byName = append(byName, p)
+		}
+	}
+	switch len(byName) {
+	case 0:
+		return project{}, fmt.Errorf("project %q not found", ref)
+	case 1:
+		return byName[0], nil
+	}
+	ids := make([]string, len(byName))
+	for i, p := range byName {
+		ids[i] = p.ID
+	}
+	return project{}, fmt.Errorf("several projects are named %q, use one of their IDs: %s", ref, strings.Join(ids, ", "))
+}
+
+// scopeList adds the projectId filter of cfg's project to a list URL.
+func scopeList(client *n8n.Client, cfg config.Config, entity, url string) (string, error) {
+	if cfg.Project == "" || !projectScopedLists[entity] {
+		return url, nil
+	}
+	p, err := resolveProject(client, cfg, cfg.Project)
+	if err != nil {
+		return "", err
+	}
+	return url + "?" + neturl.Values{"projectId": {p.ID}}.Encode(), nil
+}
+
+// scopeCreate sets the projectId field of cfg's project in a creation body
+// that does not set one itself.
+func scopeCreate(client *n8n.Client, cfg config.Config, entity, body string) (string, error) {
+	if cfg.Project == "" || !projectScopedCreates[entity] {
+		return body, nil
+	}
+	var fields map[string]any
+	if err := json.Unmarshal([]byte(body), &fields); err != nil {
+		return "", fmt.Errorf("invalid JSON payload: %w", err)
+	}
+	if _, ok := fields["projectId"]; ok {
+		return body, nil
+	}
+	p, err := resolveProject(client, cfg, cfg.Project)
+	if err != nil {
+		return "", err
+	}
+	fields["projectId"] = p.ID
+	scoped, err := json.Marshal(fields)
+	return string(scoped), err
+}
+
+// moveCreated transfers a resource just created into cfg's project, for the
+// entities whose creation cannot name a project.
+func moveCreated(client *n8n.Client, cfg config.Config, entity, basePath string, created []byte) error {
+	if cfg.Project == "" || !projectTransferCreate[entity] {
+		return nil
+	}
+	var resource struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(created, &resource); err != nil || resource.ID == "" {
+		return fmt.Errorf("created, but could not read the ID to move it into project %s", cfg.Project)
+	}
+	p, err := resolveProject(client, cfg, cfg.Project)
+	if err != nil {
+		return err
+	}
+	if err := transfer(client, basePath, resource.ID, p.ID); err != nil {
+		return fmt.Errorf("created %s, but failed to move it into project %s: %w", resource.ID, p, err)
+	}
+	fmt.Fprintf(os.Stderr, "Moved %s %s into project %s\n", strings.TrimSuffix(entity, "s"), resource.ID, p)
+	return nil
+}
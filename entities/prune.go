@@ -0,0 +1,142 @@
+package entities
+
+import (
+	"flag"
+	"fmt"
+	neturl "net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/brandon-kyle-bailey/n8nctl/config"
+	"github.com/brandon-kyle-bailey/n8nctl/executions"
+	"github.com/brandon-kyle-bailey/n8nctl/pkg/n8n"
+	"github.com/brandon-kyle-bailey/n8nctl/prompt"
+	"github.com/brandon-kyle-bailey/n8nctl/utils"
+)
+
+// pruneExecutions deletes the executions matching the filters after
+// confirming them, one by one when there are few enough to review.
+func pruneExecutions(client *n8n.Client, basePath string, params []string, cfg config.Config) error {
+	fs := flag.NewFlagSet("prune", flag.ContinueOnError)
+	olderThan := fs.String("older-than", "", "Only delete executions started longer ago than this, e.g. 30d, 2w or 12h")
+	status := fs.String("status", "", "Only delete executions with this status, e.g. error")
+	workflowID := fs.String("workflow-id", "", "Only delete executions of this workflow")
+	fs.StringVar(workflowID, "workflow", "", "Shorthand for --workflow-id")
+	dryRun := fs.Bool("dry-run", false, "Only count the executions that would be deleted")
+	if err := fs.Parse(params); err != nil {
+		return err
+	}
+	if *olderThan == "" && *status == "" && *workflowID == "" {
+		return fmt.Errorf("prune requires at least one of --older-than, --status and --workflow-id")
+	}
+
+	var cutoff time.Time
+	if *olderThan != "" {
+		age, err := utils.ParseAge(*olderThan)
+		if err != nil {
+			return err
+		}
+		cutoff = time.Now().Add(-age)
+	}
+	query := neturl.Values{}
+	var filters []string
+	if *status != "" {
+		query.Set("status", *status)
+		filters = append(filters, "status "+*status)
+	}
+	if *workflowID != "" {
+		query.Set("workflowId", *workflowID)
+		filters = append(filters, "workflow "+*workflowID)
+	}
+	if !cutoff.IsZero() {
+		filters = append(filters, "started before "+cutoff.Local().Format("2006-01-02 15:04"))
+	}
+
+	// Collect first, deleting while paging would shift the cursor.
+	var matched []executions.Execution
+	err := forEachExecution(client, basePath, query, 0, cfg, func(exec executions.Execution) error {
+		if err := Context.Err(); err != nil {
+			return err
+		}
+		if cutoff.IsZero() || exec.StartedAt.Before(cutoff) {
+			matched = append(matched, exec)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	summary := fmt.Sprintf("%d executions (%s)", len(matched), strings.Join(filters, ", "))
+	if len(matched) == 0 || *dryRun {
+		fmt.Printf("Would delete %s\n", summary)
+		return nil
+	}
+	ids, err := confirmPrune(matched, summary)
+	if err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		fmt.Println("Prune aborted by user.")
+		return nil
+	}
+
+	deleted, failed := 0, 0
+	for _, id := range ids {
+		if Context.Err() != nil {
+			break
+		}
+		if _, err := n8nAPIRequest(client, "DELETE", fmt.Sprintf("%s/%s", basePath, id), ""); err != nil {
+			failed++
+			fmt.Fprintf(os.Stderr, "\rFailed to delete execution %s: %s\n", id, config.Redact(err.Error(), cfg.APIToken))
+		} else {
+			deleted++
+		}
+		fmt.Fprintf(os.Stderr, "\rDeleted %d/%d", deleted, len(ids))
+	}
+	fmt.Fprintln(os.Stderr)
+
+	fmt.Printf("Deleted %d of %d executions\n", deleted, len(ids))
+	if failed > 0 || deleted < len(ids) {
+		return fmt.Errorf("%d executions were not deleted", len(ids)-deleted)
+	}
+	return nil
+}
+
+// maxItemizedPrune is the most executions prune lists one by one for review;
+// larger prunes are confirmed by their count.
+const maxItemizedPrune = 100
+
+// confirmPrune asks to confirm deleting the matched executions, listing them
+// for review when there are few enough, and returns the IDs to delete.
+func confirmPrune(matched []executions.Execution, summary string) ([]executions.ID, error) {
+	var ids []executions.ID
+	if len(matched) > maxItemizedPrune {
+		confirmed, err := prompt.Confirm(fmt.Sprintf("Delete %s?", summary), false)
+		if err != nil || !confirmed {
+			return nil, err
+		}
+		for _, exec := range matched {
+			ids = append(ids, exec.ID)
+		}
+		return ids, nil
+	}
+	rows := make([][]string, len(matched))
+	for i, exec := range matched {
+		started := ""
+		if !exec.StartedAt.IsZero() {
+			started = exec.StartedAt.Local().Format("2006-01-02 15:04:05")
+		}
+		rows[i] = []string{string(exec.ID), exec.WorkflowID, exec.Status, started}
+	}
+	keep, err := prompt.ConfirmItems(fmt.Sprintf("Delete %s?", summary), []string{"ID", "WORKFLOW", "STATUS", "STARTED"}, rows)
+	if err != nil {
+		return nil, err
+	}
+	for i, ok := range keep {
+		if ok {
+			ids = append(ids, matched[i].ID)
+		}
+	}
+	return ids, nil
+}
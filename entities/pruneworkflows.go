@@ -0,0 +1,113 @@
+package entities
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/brandon-kyle-bailey/n8nctl/config"
+	"github.com/brandon-kyle-bailey/n8nctl/pkg/n8n"
+	"github.com/brandon-kyle-bailey/n8nctl/state"
+	"github.com/brandon-kyle-bailey/n8nctl/utils"
+	"github.com/brandon-kyle-bailey/n8nctl/workflows"
+)
+
+// pruneWorkflows deletes, or deactivates, the remote workflows the project
+// does not manage: those neither recorded in the state file nor named like
+// one of the local workflow files. This makes the project the single source
+// of truth of the instance.
+func pruneWorkflows(client *n8n.Client, basePath string, params []string, cfg config.Config) error {
+	fs := flag.NewFlagSet("prune", flag.ContinueOnError)
+	dir := fs.String("dir", "", "Directory of workflow YAML files (default workflows/ or workflow.yaml)")
+	deactivate := fs.Bool("deactivate", false, "Deactivate the unmanaged workflows instead of deleting them")
+	dryRun := fs.Bool("dry-run", false, "Only list the workflows that would be pruned")
+	var sel workflowSelector
+	sel.register(fs, false)
+	args, err := utils.ParseFlags(fs, params)
+	if err != nil {
+		return err
+	}
+	if len(args) > 0 {
+		return fmt.Errorf("prune takes no arguments, select workflows with --tag, --name-glob and the exclusions")
+	}
+	if err := sel.validate(false); err != nil {
+		return err
+	}
+
+	ids, names, err := managedWorkflows(*dir, cfg)
+	if err != nil {
+		return err
+	}
+	selected, err := sel.selectWorkflows(client, basePath, cfg)
+	if err != nil {
+		return err
+	}
+	action := "delete"
+	if *deactivate {
+		action = "deactivate"
+	}
+	var targets []remoteWorkflow
+	for _, wf := range selected {
+		if ids[wf.ID] || names[wf.Name] || (*deactivate && !wf.Active) {
+			continue
+		}
+		targets = append(targets, wf)
+	}
+	if len(targets) == 0 {
+		fmt.Printf("No unmanaged workflows to %s.\n", action)
+		return nil
+	}
+	if *dryRun {
+		fmt.Printf("Would %s %d unmanaged workflows:\n", action, len(targets))
+		for _, wf := range targets {
+			fmt.Printf("  %s %q [%s] %s\n", wf.ID, wf.Name, tagNames(wf.Tags), activeLabel(wf.Active))
+		}
+		return nil
+	}
+	return confirmBulk(client, basePath, action, targets)
+}
+
+// managedWorkflows returns the IDs of the workflows recorded in the state
+// file and the names of the local workflow files in dir. A file that fails
+// to render is an error, as the workflow it manages could not be told apart.
+// Names with ${{...}} placeholders are rendered with their variables and
+// secrets resolved, as deployed, and are an error if they still have any.
+func managedWorkflows(dir string, cfg config.Config) (map[string]bool, map[string]bool, error) {
+	st, err := state.Load()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load %s: %w", state.Path, err)
+	}
+	ids := map[string]bool{}
+	for _, entry := range st.Workflows {
+		ids[entry.WorkflowID] = true
+	}
+	files, err := workflows.ProjectFiles(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	names := map[string]bool{}
+	for _, file := range files {
+		body, err := workflows.RenderTemplate(file)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", file, err)
+		}
+		name, err := workflowName(body)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", file, err)
+		}
+		if strings.Contains(name, "${{") {
+			useSecretStores(cfg)
+			if body, err = workflows.RenderWorkflowFile(file); err != nil {
+				return nil, nil, fmt.Errorf("%s: %w", file, err)
+			}
+			if name, err = workflowName(body); err != nil {
+				return nil, nil, fmt.Errorf("%s: %w", file, err)
+			}
+		}
+		if strings.Contains(name, "${{") {
+			return nil, nil, fmt.Errorf("%s: the workflow name %q has unresolved placeholders, refusing to prune workflows it could be the name of", file, name)
+		}
+		names[name] = true
+	}
+	return ids, names, nil
+}
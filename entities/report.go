@@ -0,0 +1,81 @@
+package entities
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/brandon-kyle-bailey/n8nctl/lint"
+	"github.com/brandon-kyle-bailey/n8nctl/report"
+)
+
+// reportOut is the default --out of each report format.
+var reportOut = map[string]string{
+	"junit": "report.xml",
+	"sarif": "report.sarif",
+	"json":  "report.json",
+}
+
+// reportFlags are the --report and --out flags of the commands that can
+// write their results for CI systems. formats lists the formats the command
+// supports; SARIF and JSON need findings with file positions, so only checks
+// offer them.
+type reportFlags struct {
+	formats []string
+	format  string
+	out     string
+	// files are the files checked, for the JSON report.
+	files []string
+}
+
+func (r *reportFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&r.format, "report", "", "Also write the results as a report: "+strings.Join(r.formats, " or "))
+	fs.StringVar(&r.out, "out", "", "Where to write the report (default report.xml, or report.sarif for sarif, report.json for json)")
+}
+
+// check rejects flag combinations write cannot honour, so they fail before
+// any work is done.
+func (r *reportFlags) check() error {
+	switch {
+	case r.format == "" && r.out != "":
+		return fmt.Errorf("--out needs --report")
+	case r.format != "" && !slices.Contains(r.formats, r.format):
+		return fmt.Errorf("unknown report format %q, expected %s", r.format, strings.Join(r.formats, " or "))
+	}
+	return nil
+}
+
+// write writes the report in the requested format, if any. Check results
+// pass their findings, for other results findings is nil.
+func (r *reportFlags) write(suites []report.Suite, findings []lint.Finding) error {
+	if r.format == "" {
+		return nil
+	}
+	out := r.out
+	if out == "" {
+		out = reportOut[r.format]
+	}
+	f, err := os.Create(out)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	switch r.format {
+	case "sarif":
+		err = report.WriteSARIF(f, findings)
+	case "json":
+		err = report.WriteJSON(f, r.files, findings)
+	default:
+		err = report.WriteJUnit(f, suites)
+	}
+	if err != nil {
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	fmt.Printf("Wrote %s report to %s\n", r.format, out)
+	return nil
+}
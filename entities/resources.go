@@ -0,0 +1,121 @@
+package entities
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	neturl "net/url"
+	"os"
+	"strings"
+
+	"github.com/brandon-kyle-bailey/n8nctl/config"
+	"github.com/brandon-kyle-bailey/n8nctl/manifest"
+	"github.com/brandon-kyle-bailey/n8nctl/pkg/n8n"
+	"github.com/brandon-kyle-bailey/n8nctl/telemetry"
+)
+
+// exportFormats maps each --format of `n8nctl export` to its renderer.
+var exportFormats = map[string]func(manifest.Resources) ([]byte, error){
+	"terraform":      manifest.Terraform,
+	"terraform-json": manifest.TerraformJSON,
+	"k8s":            manifest.Kubernetes,
+}
+
+// HandleExport writes the workflows, variables and tags of the instance in a
+// format other infrastructure tooling can take over.
+func HandleExport(args []string, cfg config.Config) {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	format := fs.String("format", "", "Output format: terraform, terraform-json or k8s")
+	output := fs.String("output", "", "Write to this file instead of stdout")
+	fs.StringVar(output, "o", "", "Shorthand for --output")
+	if err := fs.Parse(args); err != nil {
+		telemetry.Exit(1)
+	}
+	render, ok := exportFormats[*format]
+	if !ok {
+		fmt.Println("Usage: n8nctl export --format terraform|terraform-json|k8s [--output file]")
+		telemetry.Exit(1)
+	}
+
+	if err := exportResources(render, *output, cfg); err != nil {
+		fmt.Printf("Error: %s\n", config.Redact(err.Error(), cfg.APIToken))
+		telemetry.Exit(1)
+	}
+}
+
+func exportResources(render func(manifest.Resources) ([]byte, error), output string, cfg config.Config) error {
+	client := newClient(cfg)
+	apiBase := cfg.APIBase()
+
+	var res manifest.Resources
+	var err error
+	if res.Workflows, err = fetchAll[manifest.Workflow](client, apiBase+"/workflows", cfg); err != nil {
+		return fmt.Errorf("failed to list workflows: %w", err)
+	}
+	if res.Tags, err = fetchAll[manifest.Tag](client, apiBase+"/tags", cfg); err != nil {
+		return fmt.Errorf("failed to list tags: %w", err)
+	}
+	res.Variables, err = fetchAll[manifest.Variable](client, apiBase+"/variables", cfg)
+	var apiErr *n8n.APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusForbidden {
+		// Variables require a license tier many instances do not have.
+		fmt.Fprintln(os.Stderr, "Warning: variables are not available on this instance, skipping them")
+	} else if err != nil {
+		return fmt.Errorf("failed to list variables: %w", err)
+	}
+
+	data, err := render(res)
+	if err != nil {
+		return err
+	}
+	if output == "" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	if err := os.WriteFile(output, data, 0644); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Exported %d workflows, %d variables and %d tags to %s\n",
+		len(res.Workflows), len(res.Variables), len(res.Tags), output)
+	return nil
+}
+
+// unlisted reports whether err means the instance cannot list an endpoint at
+// all, as older instances and member API keys cannot list credentials.
+func unlisted(err error) bool {
+	var apiErr *n8n.APIError
+	return errors.As(err, &apiErr) && (apiErr.StatusCode == http.StatusNotFound ||
+		apiErr.StatusCode == http.StatusMethodNotAllowed || apiErr.StatusCode == http.StatusForbidden)
+}
+
+// fetchAll pages through a list endpoint, which may carry a query such as
+// the project filter of scopeList, and decodes every item.
+func fetchAll[T any](client *n8n.Client, endpoint string, cfg config.Config) ([]T, error) {
+	var all []T
+	endpoint, rawQuery, _ := strings.Cut(endpoint, "?")
+	query, err := neturl.ParseQuery(rawQuery)
+	if err != nil {
+		return nil, fmt.Errorf("invalid query of %s: %w", endpoint, err)
+	}
+	query.Set("limit", "250")
+	for {
+		data, err := n8nAPIRequest(client, "GET", endpoint+"?"+query.Encode(), "")
+		if err != nil {
+			return nil, err
+		}
+		var page struct {
+			Data       []T    `json:"data"`
+			NextCursor string `json:"nextCursor"`
+		}
+		if err := json.Unmarshal(data, &page); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", endpoint, err)
+		}
+		all = append(all, page.Data...)
+		if page.NextCursor == "" || len(page.Data) == 0 {
+			return all, nil
+		}
+		query.Set("cursor", page.NextCursor)
+	}
+}
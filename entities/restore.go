@@ -0,0 +1,415 @@
+package entities
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/brandon-kyle-bailey/n8nctl/backup"
+	"github.com/brandon-kyle-bailey/n8nctl/config"
+	"github.com/brandon-kyle-bailey/n8nctl/manifest"
+	"github.com/brandon-kyle-bailey/n8nctl/pkg/n8n"
+	"github.com/brandon-kyle-bailey/n8nctl/telemetry"
+	"github.com/brandon-kyle-bailey/n8nctl/utils"
+)
+
+// restoreKinds are the kinds of resources restore recreates, in the order it
+// restores them: workflows refer to tags and credentials.
+var restoreKinds = []string{"tags", "variables", "credentials", "workflows"}
+
+// HandleRestore recreates the resources of a backup archive on the
+// instance, matching existing ones by name.
+func HandleRestore(args []string, cfg config.Config) {
+	fs := flag.NewFlagSet("restore", flag.ContinueOnError)
+	skip := fs.String("skip", "", "Comma-separated kinds of resources not to restore: "+strings.Join(restoreKinds, ", "))
+	dryRun := fs.Bool("dry-run", false, "Show what would be created, updated and skipped without changing anything")
+	rest, err := utils.ParseFlags(fs, args)
+	if err != nil {
+		telemetry.Exit(1)
+	}
+	if len(rest) != 1 {
+		fmt.Println("Usage: n8nctl restore <backup.tar.gz> [--skip credentials,variables] [--dry-run]")
+		telemetry.Exit(1)
+	}
+	if cfg.ReadOnly && !*dryRun {
+		fmt.Println("Error: restore changes the instance, which is not allowed in read-only mode")
+		telemetry.Exit(1)
+	}
+	skipped := map[string]bool{}
+	for _, kind := range strings.Split(*skip, ",") {
+		if kind = strings.TrimSpace(kind); kind == "" {
+			continue
+		}
+		if !slices.Contains(restoreKinds, kind) {
+			fmt.Printf("Error: cannot skip %q, expected one of %s\n", kind, strings.Join(restoreKinds, ", "))
+			telemetry.Exit(1)
+		}
+		skipped[kind] = true
+	}
+
+	r := &restorer{client: newClient(cfg), cfg: cfg, dryRun: *dryRun, skip: skipped, counts: map[string]*restoreCounts{}}
+	if err := r.restore(rest[0]); err != nil {
+		fmt.Printf("Error: %s\n", config.Redact(err.Error(), cfg.APIToken))
+		telemetry.Exit(1)
+	}
+}
+
+// restoreCounts counts the outcomes of restoring one kind of resource.
+type restoreCounts struct {
+	created, updated, skipped, failed int
+}
+
+// restorer recreates the resources of a backup, remembering the IDs the
+// tags and credentials got on the instance to fix up the workflows that
+// refer to them.
+type restorer struct {
+	client *n8n.Client
+	cfg    config.Config
+	dryRun bool
+	skip   map[string]bool
+	counts map[string]*restoreCounts
+	// tagIDs maps tag names to their ID on the instance.
+	tagIDs map[string]string
+	// credentialIDs maps credential IDs in the backup to those on the
+	// instance.
+	credentialIDs map[string]string
+}
+
+func (r *restorer) restore(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	b, err := backup.Read(f)
+	f.Close()
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	fmt.Printf("Restoring the backup of %s from %s to %s\n", b.Manifest.Instance,
+		b.Manifest.CreatedAt.Local().Format("2006-01-02 15:04"), r.client.BaseURL())
+	if b.Credentials == nil && !r.skip["credentials"] {
+		fmt.Fprintln(os.Stderr, "Note: the backup has no credentials, it was made without --credentials")
+		r.skip["credentials"] = true
+	}
+
+	steps := map[string]func(*backup.Backup) error{
+		"tags":        r.restoreTags,
+		"variables":   r.restoreVariables,
+		"credentials": r.restoreCredentials,
+		"workflows":   r.restoreWorkflows,
+	}
+	for _, kind := range restoreKinds {
+		if r.skip[kind] {
+			continue
+		}
+		r.counts[kind] = &restoreCounts{}
+		if err := steps[kind](b); err != nil {
+			return fmt.Errorf("failed to restore %s: %w", kind, err)
+		}
+	}
+	return r.summarize()
+}
+
+// report prints the outcome of restoring one resource and counts it.
+func (r *restorer) report(kind, name, outcome string, err error) {
+	counts := r.counts[kind]
+	if err != nil {
+		counts.failed++
+		fmt.Printf("%s %s %q: %v\n", utils.Colorize("FAIL", utils.ColorRed), strings.TrimSuffix(kind, "s"), name, err)
+		return
+	}
+	switch outcome {
+	case "created":
+		counts.created++
+	case "updated":
+		counts.updated++
+	default:
+		counts.skipped++
+	}
+	if r.dryRun && outcome != "skipped" {
+		outcome = "would be " + outcome
+	}
+	fmt.Printf("%s   %s %q: %s\n", utils.Colorize("OK", utils.ColorGreen), strings.TrimSuffix(kind, "s"), name, outcome)
+}
+
+// summarize prints the outcomes per kind, failing if any resource failed.
+func (r *restorer) summarize() error {
+	var rows [][]string
+	failed := 0
+	for _, kind := range restoreKinds {
+		c, ok := r.counts[kind]
+		if !ok {
+			rows = append(rows, []string{kind, "-", "-", "-", "-"})
+			continue
+		}
+		failed += c.failed
+		rows = append(rows, []string{kind, fmt.Sprint(c.created), fmt.Sprint(c.updated), fmt.Sprint(c.skipped), fmt.Sprint(c.failed)})
+	}
+	fmt.Println()
+	if r.dryRun {
+		fmt.Println("Dry run, nothing was changed.")
+	}
+	fmt.Printf("%-12s %8s %8s %8s %8s\n", "KIND", "CREATED", "UPDATED", "SKIPPED", "FAILED")
+	for _, row := range rows {
+		fmt.Printf("%-12s %8s %8s %8s %8s\n", row[0], row[1], row[2], row[3], row[4])
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d resources failed to restore", failed)
+	}
+	return nil
+}
+
+// restoreTags creates the tags missing on the instance. Tags have nothing
+// but a name, so existing ones are left alone.
+func (r *restorer) restoreTags(b *backup.Backup) error {
+	tagsPath := r.client.APIURL("tags")
+	existing, err := fetchAll[manifest.Tag](r.client, tagsPath, r.cfg)
+	if err != nil {
+		return err
+	}
+	r.tagIDs = map[string]string{}
+	for _, tag := range existing {
+		r.tagIDs[tag.Name] = tag.ID
+	}
+	for _, raw := range b.Tags {
+		var tag manifest.Tag
+		if err := json.Unmarshal(raw, &tag); err != nil || tag.Name == "" {
+			r.report("tags", tag.ID, "", fmt.Errorf("invalid tag in the backup: %s", raw))
+			continue
+		}
+		if _, ok := r.tagIDs[tag.Name]; ok {
+			r.report("tags", tag.Name, "skipped", nil)
+			continue
+		}
+		if r.dryRun {
+			r.report("tags", tag.Name, "created", nil)
+			continue
+		}
+		payload, _ := json.Marshal(map[string]string{"name": tag.Name})
+		resp, err := n8nAPIRequest(r.client, "POST", tagsPath, string(payload))
+		var created manifest.Tag
+		if err == nil {
+			if err = json.Unmarshal(resp, &created); err == nil && created.ID == "" {
+				err = fmt.Errorf("unexpected response: %s", resp)
+			}
+		}
+		if err == nil {
+			r.tagIDs[tag.Name] = created.ID
+		}
+		r.report("tags", tag.Name, "created", err)
+	}
+	return nil
+}
+
+// restoreVariables creates the missing variables and updates those whose
+// value differs, matching them by key.
+func (r *restorer) restoreVariables(b *backup.Backup) error {
+	variablesPath := r.client.APIURL("variables")
+	existing, err := fetchAll[manifest.Variable](r.client, variablesPath, r.cfg)
+	if unlisted(err) {
+		if len(b.Variables) > 0 {
+			fmt.Fprintln(os.Stderr, "Warning: variables are not available on this instance, skipping them")
+		}
+		delete(r.counts, "variables")
+		return nil
+	} else if err != nil {
+		return err
+	}
+	byKey := map[string]manifest.Variable{}
+	for _, v := range existing {
+		byKey[v.Key] = v
+	}
+	for _, raw := range b.Variables {
+		var v manifest.Variable
+		if err := json.Unmarshal(raw, &v); err != nil || v.Key == "" {
+			r.report("variables", v.ID, "", fmt.Errorf("invalid variable in the backup: %s", raw))
+			continue
+		}
+		current, ok := byKey[v.Key]
+		if ok && current.Value == v.Value {
+			r.report("variables", v.Key, "skipped", nil)
+			continue
+		}
+		method, url, outcome := "POST", variablesPath, "created"
+		if ok {
+			method, url, outcome = "PUT", variablesPath+"/"+current.ID, "updated"
+		}
+		if r.dryRun {
+			r.report("variables", v.Key, outcome, nil)
+			continue
+		}
+		payload, _ := json.Marshal(map[string]string{"key": v.Key, "value": v.Value})
+		_, err := n8nAPIRequest(r.client, method, url, string(payload))
+		r.report("variables", v.Key, outcome, err)
+	}
+	return nil
+}
+
+// backupCredential is a credential as the REST API returns it with its data.
+type backupCredential struct {
+	ID   string          `json:"id"`
+	Name string          `json:"name"`
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data"`
+}
+
+// restoreCredentials creates the credentials missing on the instance,
+// matching them by name and type. Existing credentials keep their secrets.
+// Like backup it signs in to the REST API, as only it lists credentials on
+// every version of n8n.
+func (r *restorer) restoreCredentials(b *backup.Backup) error {
+	passphrase, err := backupPassphrase(false)
+	if err != nil {
+		return err
+	}
+	plaintext, err := backup.Open(b.Credentials, passphrase)
+	if err != nil {
+		return err
+	}
+	var credentials []backupCredential
+	if err := json.Unmarshal(plaintext, &credentials); err != nil {
+		return fmt.Errorf("failed to parse the credentials of the backup: %w", err)
+	}
+	s, err := signIn(r.client, r.cfg)
+	if err != nil {
+		return err
+	}
+	var existing []backupCredential
+	if err := s.do("GET", "/rest/credentials", nil, &existing); err != nil {
+		return fmt.Errorf("failed to list credentials: %w", err)
+	}
+	byName := map[string]string{}
+	for _, c := range existing {
+		byName[c.Type+"/"+c.Name] = c.ID
+	}
+
+	r.credentialIDs = map[string]string{}
+	for _, c := range credentials {
+		if id, ok := byName[c.Type+"/"+c.Name]; ok {
+			r.credentialIDs[c.ID] = id
+			r.report("credentials", c.Name, "skipped", nil)
+			continue
+		}
+		if r.dryRun {
+			r.report("credentials", c.Name, "created", nil)
+			continue
+		}
+		var created backupCredential
+		err := s.do("POST", "/rest/credentials", map[string]any{"name": c.Name, "type": c.Type, "data": c.Data}, &created)
+		if err == nil && created.ID == "" {
+			err = fmt.Errorf("unexpected response without an ID")
+		}
+		if err == nil {
+			r.credentialIDs[c.ID] = created.ID
+		}
+		r.report("credentials", c.Name, "created", err)
+	}
+	return nil
+}
+
+// restoreWorkflows creates the workflows missing on the instance and
+// updates those that differ, matching them by name. Their tags and the
+// credentials of their nodes are pointed at the restored ones, and
+// workflows that were active are activated.
+func (r *restorer) restoreWorkflows(b *backup.Backup) error {
+	workflowsPath := r.client.APIURL("workflows")
+	existing, err := fetchAll[json.RawMessage](r.client, workflowsPath, r.cfg)
+	if err != nil {
+		return err
+	}
+	byName := map[string][]json.RawMessage{}
+	for _, raw := range existing {
+		var wf remoteWorkflow
+		if err := json.Unmarshal(raw, &wf); err == nil {
+			byName[wf.Name] = append(byName[wf.Name], raw)
+		}
+	}
+
+	for _, raw := range b.Workflows {
+		var wf manifest.Workflow
+		if err := json.Unmarshal(raw, &wf); err != nil || wf.Name == "" {
+			r.report("workflows", wf.ID, "", fmt.Errorf("invalid workflow in the backup"))
+			continue
+		}
+		body, err := r.workflowBody(wf)
+		if err != nil {
+			r.report("workflows", wf.Name, "", err)
+			continue
+		}
+		matches := byName[wf.Name]
+		if len(matches) > 1 {
+			r.report("workflows", wf.Name, "", fmt.Errorf("%d workflows on the instance have this name", len(matches)))
+			continue
+		}
+
+		var remote remoteWorkflow
+		method, url, outcome := "POST", workflowsPath, "created"
+		if len(matches) == 1 {
+			json.Unmarshal(matches[0], &remote)
+			remoteFields, err1 := managedFields(matches[0])
+			localFields, err2 := managedFields(body)
+			if err1 == nil && err2 == nil && remoteFields == localFields && remote.Active == wf.Active {
+				r.report("workflows", wf.Name, "skipped", nil)
+				continue
+			}
+			method, url, outcome = "PUT", workflowsPath+"/"+remote.ID, "updated"
+		}
+		if r.dryRun {
+			r.report("workflows", wf.Name, outcome, nil)
+			continue
+		}
+		resp, err := n8nAPIRequest(r.client, method, url, string(body))
+		if err == nil && remote.ID == "" {
+			if err = json.Unmarshal(resp, &remote); err == nil && remote.ID == "" {
+				err = fmt.Errorf("unexpected response without an ID")
+			}
+		}
+		if err == nil {
+			err = r.finishWorkflow(wf, remote)
+		}
+		r.report("workflows", wf.Name, outcome, err)
+	}
+	return nil
+}
+
+// workflowBody returns the fields of a backed up workflow the API accepts
+// on create and update, with the credentials of its nodes pointed at those
+// restored.
+func (r *restorer) workflowBody(wf manifest.Workflow) ([]byte, error) {
+	return workflowPayload(wf, func(_ string, ref map[string]any) bool {
+		if id, ok := ref["id"].(string); ok && r.credentialIDs[id] != "" {
+			ref["id"] = r.credentialIDs[id]
+		}
+		return true
+	})
+}
+
+// finishWorkflow sets the tags of a restored workflow and activates it if
+// it was active when backed up.
+func (r *restorer) finishWorkflow(wf manifest.Workflow, remote remoteWorkflow) error {
+	if len(wf.Tags) > 0 && !r.skip["tags"] {
+		var ids []map[string]string
+		for _, tag := range wf.Tags {
+			if id, ok := r.tagIDs[tag.Name]; ok {
+				ids = append(ids, map[string]string{"id": id})
+			}
+		}
+		payload, _ := json.Marshal(ids)
+		if _, err := n8nAPIRequest(r.client, "PUT", r.client.APIURL("workflows/"+remote.ID+"/tags"), string(payload)); err != nil {
+			return fmt.Errorf("restored as %s, but failed to set its tags: %w", remote.ID, err)
+		}
+	}
+	if wf.Active != remote.Active {
+		action := "deactivate"
+		if wf.Active {
+			action = "activate"
+		}
+		if _, err := n8nAPIRequest(r.client, "POST", r.client.APIURL("workflows/"+remote.ID+"/"+action), ""); err != nil {
+			return fmt.Errorf("restored as %s, but failed to %s it: %w", remote.ID, action, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package entities
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/brandon-kyle-bailey/n8nctl/executions"
+	"github.com/brandon-kyle-bailey/n8nctl/pkg/n8n"
+	"github.com/brandon-kyle-bailey/n8nctl/telemetry"
+	"github.com/brandon-kyle-bailey/n8nctl/utils"
+)
+
+// retryExecution retries a failed execution and, with --wait, waits for the
+// new execution to finish, exiting non-zero when it fails again.
+func retryExecution(client *n8n.Client, basePath string, params []string) error {
+	fs := flag.NewFlagSet("retry", flag.ContinueOnError)
+	loadWorkflow := fs.Bool("load-workflow", false, "Retry with the currently saved workflow instead of the version that ran")
+	fromFailedNode := fs.Bool("from-failed-node", true, "Resume from the node that failed, reusing the output of the nodes before it")
+	wait := fs.Bool("wait", false, "Wait for the new execution to finish")
+	waitTimeout := fs.Duration("wait-timeout", 10*time.Minute, "Give up waiting after this long (0 waits forever)")
+	fs.DurationVar(waitTimeout, "timeout", 10*time.Minute, "Same as --wait-timeout")
+	args, err := utils.ParseFlags(fs, params)
+	if err != nil {
+		return err
+	}
+	if len(args) != 1 {
+		return fmt.Errorf("retry requires exactly one execution ID")
+	}
+	if !*fromFailedNode {
+		// The API has no way to rerun from the start; the trigger data is only
+		// replayed by re-triggering the workflow.
+		return fmt.Errorf("the n8n API only retries from the failed node, trigger the workflow again to rerun it from the start")
+	}
+
+	payload, _ := json.Marshal(map[string]bool{"loadWorkflow": *loadWorkflow})
+	resp, err := n8nAPIRequest(client, "POST", fmt.Sprintf("%s/%s/retry", basePath, args[0]), string(payload))
+	if err != nil {
+		return err
+	}
+	var exec executions.Execution
+	if err := json.Unmarshal(resp, &exec); err != nil || exec.ID == "" {
+		return fmt.Errorf("unexpected retry response: %s", resp)
+	}
+	fmt.Fprintf(os.Stderr, "Retried execution %s as %s\n", args[0], exec.ID)
+	if !*wait {
+		fmt.Println(exec.ID)
+		return nil
+	}
+
+	ctx := Context
+	if *waitTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *waitTimeout)
+		defer cancel()
+	}
+	for exec.StoppedAt == nil || exec.Status == "running" || exec.Status == "new" || exec.Status == "waiting" {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("execution %s did not finish within %s", exec.ID, *waitTimeout)
+		case <-time.After(time.Second):
+		}
+		countRetry()
+		resp, err := n8nAPIRequest(client, "GET", fmt.Sprintf("%s/%s", basePath, exec.ID), "")
+		if err != nil {
+			return err
+		}
+		if err := json.Unmarshal(resp, &exec); err != nil {
+			return fmt.Errorf("failed to parse execution %s: %w", exec.ID, err)
+		}
+	}
+
+	fmt.Println(exec.ID)
+	if exec.Status != "success" {
+		fmt.Fprintf(os.Stderr, "Execution %s finished with status %s\n", exec.ID, exec.Status)
+		telemetry.Exit(1)
+	}
+	fmt.Fprintf(os.Stderr, "Execution %s succeeded\n", exec.ID)
+	return nil
+}
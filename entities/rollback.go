@@ -0,0 +1,115 @@
+package entities
+
+import (
+	"cmp"
+	"fmt"
+	"os"
+
+	"github.com/brandon-kyle-bailey/n8nctl/config"
+	"github.com/brandon-kyle-bailey/n8nctl/prompt"
+	"github.com/brandon-kyle-bailey/n8nctl/utils"
+	"github.com/brandon-kyle-bailey/n8nctl/workflows"
+)
+
+// printHistory lists the deployment snapshots of a workflow file.
+func printHistory(file string) error {
+	snapshots, err := workflows.Snapshots(file)
+	if err != nil {
+		return err
+	}
+	if len(snapshots) == 0 {
+		fmt.Printf("No deployments of %s recorded yet.\n", file)
+		return nil
+	}
+	fmt.Printf("Deployments of %s (newest first):\n", file)
+	for i := len(snapshots) - 1; i >= 0; i-- {
+		back := len(snapshots) - 1 - i
+		marker := fmt.Sprintf("%d", back)
+		if back == 0 {
+			marker = "current"
+		}
+		fmt.Printf("  %-8s %s\n", marker, snapshots[i].Timestamp)
+	}
+	return nil
+}
+
+// rollback re-deploys an earlier snapshot of a workflow file after showing the
+// diff against the currently deployed version and asking for confirmation.
+func (d *deployer) rollback(file, to string) error {
+	snapshots, err := workflows.Snapshots(file)
+	if err != nil {
+		return err
+	}
+	target, err := workflows.SelectSnapshot(snapshots, to)
+	if err != nil {
+		return err
+	}
+	current := snapshots[len(snapshots)-1]
+
+	d.rollbackTo = &workflows.Deployment{Snapshot: target.Timestamp}
+	if deployments, err := workflows.Deployments(file); err == nil {
+		for _, deployment := range deployments {
+			if deployment.Snapshot == target.Timestamp {
+				d.rollbackTo = &deployment
+			}
+		}
+	}
+	// Snapshots deployed by --all-envs to other environments may share the
+	// history. Those logged before the deploy log was kept have no instance.
+	from, profile := d.rollbackTo, cmp.Or(config.Profile, config.DefaultProfile)
+	if from.Instance != "" && (from.Instance != d.client.BaseURL() || cmp.Or(from.Profile, config.DefaultProfile) != profile) {
+		return fmt.Errorf("snapshot %s was deployed to %s with profile %q, not to %s with profile %q, refusing to roll back to it",
+			target.Timestamp, from.Instance, cmp.Or(from.Profile, config.DefaultProfile), d.client.BaseURL(), profile)
+	}
+
+	currentBody, err := os.ReadFile(current.Path)
+	if err != nil {
+		return fmt.Errorf("failed to read snapshot: %w", err)
+	}
+	targetBody, err := os.ReadFile(target.Path)
+	if err != nil {
+		return fmt.Errorf("failed to read snapshot: %w", err)
+	}
+
+	fmt.Printf("Rolling back %s from %s to %s:\n\n", file, current.Timestamp, target.Timestamp)
+	if err := utils.RunDiff(currentBody, targetBody); err != nil {
+		return err
+	}
+
+	name, err := workflowName(targetBody)
+	if err != nil {
+		return err
+	}
+	existing, err := d.findExisting(file, name)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return fmt.Errorf("no remote workflow found for %s, deploy it first", file)
+	}
+	if from.WorkflowID != "" && from.WorkflowID != existing.ID {
+		return fmt.Errorf("snapshot %s was deployed to workflow %s, not %s, refusing to roll back to it", target.Timestamp, from.WorkflowID, existing.ID)
+	}
+
+	fmt.Println()
+	confirmed, err := prompt.Confirm(fmt.Sprintf("Re-deploy this version to workflow %s?", existing.ID), false)
+	if err != nil {
+		return err
+	}
+	if !confirmed {
+		fmt.Println("Rollback aborted by user.")
+		return nil
+	}
+
+	// Snapshots deployed with --resolve-at deploy hold the placeholders.
+	resolved, err := workflows.ResolveJSON(target.Path, targetBody)
+	if err != nil {
+		return err
+	}
+	_, result, err := d.push(file, name, resolved, targetBody, existing)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Workflow %s\n", result)
+	return nil
+}
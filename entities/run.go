@@ -0,0 +1,283 @@
+package entities
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	neturl "net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/brandon-kyle-bailey/n8nctl/config"
+	"github.com/brandon-kyle-bailey/n8nctl/executions"
+	"github.com/brandon-kyle-bailey/n8nctl/pkg/n8n"
+	"github.com/brandon-kyle-bailey/n8nctl/utils"
+)
+
+// runHeader carries a random ID with the webhook request of workflows run,
+// which identifies the execution it started among those of other callers.
+const runHeader = "X-N8nctl-Run"
+
+// webhookNodeType is the trigger through which the API lets n8nctl start a
+// workflow; the public API has no endpoint to run one directly.
+const webhookNodeType = "n8n-nodes-base.webhook"
+
+type webhookTrigger struct {
+	Name       string `json:"name"`
+	Type       string `json:"type"`
+	Disabled   bool   `json:"disabled"`
+	WebhookID  string `json:"webhookId"`
+	Parameters struct {
+		Path       string `json:"path"`
+		HTTPMethod string `json:"httpMethod"`
+		// Public and Mode are set on chat triggers.
+		Public bool   `json:"public"`
+		Mode   string `json:"mode"`
+	} `json:"parameters"`
+}
+
+// runWorkflow starts a workflow through its webhook trigger and, with
+// --wait, prints the data of the execution once it finishes, exiting
+// non-zero when it failed.
+func runWorkflow(client *n8n.Client, basePath string, params []string, cfg config.Config) error {
+	fs := flag.NewFlagSet("run", flag.ContinueOnError)
+	input := fs.String("input", "", "JSON file to send to the webhook, or - to read it from stdin")
+	wait := fs.Bool("wait", false, "Wait for the execution to finish and print its data")
+	waitTimeout := fs.Duration("wait-timeout", 10*time.Minute, "Give up waiting after this long (0 waits forever)")
+	fs.DurationVar(waitTimeout, "timeout", 10*time.Minute, "Same as --wait-timeout")
+	activate := fs.Bool("activate", false, "Activate an inactive workflow for the run, putting all of its triggers live until it is deactivated again")
+	args, err := utils.ParseFlags(fs, params)
+	if err != nil {
+		return err
+	}
+	if len(args) != 1 {
+		return fmt.Errorf("run requires exactly one workflow ID")
+	}
+	id := args[0]
+
+	var body []byte
+	switch *input {
+	case "":
+	case "-":
+		body, err = io.ReadAll(os.Stdin)
+	default:
+		body, err = os.ReadFile(*input)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read input: %w", err)
+	}
+	if len(body) > 0 && !json.Valid(body) {
+		return fmt.Errorf("input is not valid JSON")
+	}
+
+	trigger, active, err := fetchWebhookTrigger(client, basePath, id)
+	if err != nil {
+		return err
+	}
+	method, path := trigger.method(), trigger.path()
+	if len(body) > 0 && (method == http.MethodGet || method == http.MethodHead) {
+		return fmt.Errorf("the webhook of workflow %s accepts %s requests, which cannot carry --input", id, method)
+	}
+
+	if !active {
+		// Production webhooks only listen while the workflow is active, and
+		// activating it puts every other trigger live too.
+		if !*activate {
+			return fmt.Errorf("workflow %s is inactive, so its webhook does not listen; activate it, or pass --activate to activate it for the run", id)
+		}
+		fmt.Fprintf(os.Stderr, "Activating workflow %s for the run, its other triggers are live until it is deactivated\n", id)
+		if _, err := n8nAPIRequest(client, "POST", fmt.Sprintf("%s/%s/activate", basePath, id), ""); err != nil {
+			return fmt.Errorf("failed to activate workflow %s: %w", id, err)
+		}
+		defer func() {
+			defer detachContext()()
+			n8nAPIRequest(client, "POST", fmt.Sprintf("%s/%s/deactivate", basePath, id), "")
+		}()
+	}
+
+	executionsPath := cfg.APIBase() + "/executions"
+	query := neturl.Values{"workflowId": {id}}
+	previous, err := listExecutions(client, executionsPath, query, 1, cfg)
+	if err != nil {
+		return err
+	}
+	var last executions.ID
+	for _, exec := range previous {
+		if exec.ID.After(last) {
+			last = exec.ID
+		}
+	}
+	runID, err := newRunID()
+	if err != nil {
+		return err
+	}
+
+	ctx := Context
+	if *waitTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *waitTimeout)
+		defer cancel()
+	}
+	status, response, err := callWebhook(ctx, client, productionWebhook, method, path, body, runID)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Webhook %s /%s/%s responded %d\n", method, productionWebhook, path, status)
+	if !*wait {
+		fmt.Println(string(response))
+		if status >= 400 {
+			return fmt.Errorf("webhook call failed with status %d", status)
+		}
+		return nil
+	}
+
+	exec, err := awaitRunExecution(ctx, client, executionsPath, id, last, runID, cfg)
+	if err != nil {
+		if ctx.Err() != nil && Context.Err() == nil {
+			return fmt.Errorf("no finished execution of workflow %s within %s (n8n only saves the executions its settings keep)", id, *waitTimeout)
+		}
+		return err
+	}
+
+	data, err := n8nAPIRequest(client, "GET", fmt.Sprintf("%s/%s?includeData=true", executionsPath, exec.ID), "")
+	if err != nil {
+		return err
+	}
+	utils.PrintJSONResponse(data)
+	if exec.Status != "success" {
+		return fmt.Errorf("execution %s finished with status %s", exec.ID, exec.Status)
+	}
+	fmt.Fprintf(os.Stderr, "Execution %s succeeded\n", exec.ID)
+	return nil
+}
+
+// awaitRunExecution waits for the execution that the webhook request carrying
+// runID started to finish, looking only at executions newer than last.
+func awaitRunExecution(ctx context.Context, client *n8n.Client, executionsPath, id string, last executions.ID, runID string, cfg config.Config) (*executions.Execution, error) {
+	query := neturl.Values{"workflowId": {id}, "includeData": {"true"}}
+	errFound := errors.New("found")
+	for {
+		var exec *executions.Execution
+		err := forEachExecution(client, executionsPath, query, 0, cfg, func(e executions.Execution) error {
+			if !e.ID.After(last) {
+				return errFound
+			}
+			if value, ok := executions.WebhookHeader(e.Data, runHeader); ok && value == runID {
+				exec = &e
+				return errFound
+			}
+			return nil
+		})
+		if err != nil && !errors.Is(err, errFound) {
+			return nil, err
+		}
+		if exec != nil && exec.Done() {
+			return exec, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(time.Second):
+		}
+		countRetry()
+	}
+}
+
+// newRunID returns a random ID for runHeader.
+func newRunID() (string, error) {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// fetchWebhookTrigger returns the first enabled webhook trigger of workflow
+// id and whether the workflow is active.
+func fetchWebhookTrigger(client *n8n.Client, basePath, id string) (*webhookTrigger, bool, error) {
+	trigger, active, err := fetchTrigger(client, basePath, id, webhookNodeType)
+	if err == nil && trigger == nil {
+		err = fmt.Errorf("workflow %s has no webhook trigger, which the n8n API needs to start it", id)
+	}
+	return trigger, active, err
+}
+
+// fetchTrigger returns the first enabled node of nodeType in workflow id, or
+// nil if it has none, and whether the workflow is active.
+func fetchTrigger(client *n8n.Client, basePath, id, nodeType string) (*webhookTrigger, bool, error) {
+	data, err := n8nAPIRequest(client, "GET", fmt.Sprintf("%s/%s", basePath, id), "")
+	if err != nil {
+		return nil, false, err
+	}
+	var wf struct {
+		Active bool             `json:"active"`
+		Nodes  []webhookTrigger `json:"nodes"`
+	}
+	if err := json.Unmarshal(data, &wf); err != nil {
+		return nil, false, fmt.Errorf("failed to parse workflow %s: %w", id, err)
+	}
+	for i, node := range wf.Nodes {
+		if node.Type == nodeType && !node.Disabled {
+			return &wf.Nodes[i], wf.Active, nil
+		}
+	}
+	return nil, wf.Active, nil
+}
+
+// method returns the HTTP method the webhook listens for, GET by default.
+func (t *webhookTrigger) method() string {
+	if t.Parameters.HTTPMethod == "" {
+		return http.MethodGet
+	}
+	return strings.ToUpper(t.Parameters.HTTPMethod)
+}
+
+// path returns the path of the webhook below /webhook/, which defaults to
+// the node's webhook ID.
+func (t *webhookTrigger) path() string {
+	if t.Parameters.Path == "" {
+		return t.WebhookID
+	}
+	return strings.TrimLeft(t.Parameters.Path, "/")
+}
+
+// The URL prefixes of webhooks. Production webhooks listen while the workflow
+// is active, test webhooks while the editor listens for a test event.
+const (
+	productionWebhook = "webhook"
+	testWebhook       = "webhook-test"
+)
+
+// callWebhook sends body to the webhook at path below prefix, with runID in
+// runHeader unless it is empty.
+func callWebhook(ctx context.Context, client *n8n.Client, prefix, method, path string, body []byte, runID string) (int, []byte, error) {
+	endpoint := client.BaseURL() + "/" + prefix + "/" + path
+	var reqBody io.Reader
+	if len(body) > 0 {
+		reqBody = strings.NewReader(string(body))
+	}
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, reqBody)
+	if err != nil {
+		return 0, nil, err
+	}
+	if reqBody != nil && json.Valid(body) {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if runID != "" {
+		req.Header.Set(runHeader, runID)
+	}
+	resp, err := client.HTTPClient().Do(req)
+	if err != nil {
+		return 0, nil, fmt.Errorf("calling webhook %s failed: %w", path, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	return resp.StatusCode, data, err
+}
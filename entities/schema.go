@@ -0,0 +1,110 @@
+package entities
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/brandon-kyle-bailey/n8nctl/config"
+	"github.com/brandon-kyle-bailey/n8nctl/daemon"
+	"github.com/brandon-kyle-bailey/n8nctl/telemetry"
+	"github.com/brandon-kyle-bailey/n8nctl/workflows"
+)
+
+// schemaTargets maps each --for of `n8nctl schema export` to its generator.
+// The config is only loaded when the schema draws on the instance.
+var schemaTargets = map[string]func(fromInstance bool, loadConfig func() config.Config) ([]byte, error){
+	"workflow-yaml": workflowYAMLSchema,
+	"rpc":           rpcSchema,
+}
+
+// HandleSchema publishes JSON Schemas of the files n8nctl reads, for editors
+// to validate them as they are written.
+func HandleSchema(args []string, loadConfig func() config.Config) {
+	usage := "Usage: n8nctl schema export --for workflow-yaml|rpc [--out schema.json] [--from-instance]"
+	if len(args) == 0 || args[0] != "export" {
+		fmt.Println(usage)
+		telemetry.Exit(1)
+	}
+	fs := flag.NewFlagSet("schema export", flag.ContinueOnError)
+	target := fs.String("for", "", "File the schema describes: workflow-yaml, or rpc for the daemon's JSON-RPC methods")
+	out := fs.String("out", "", "Write to this file instead of stdout")
+	fs.StringVar(out, "o", "", "Shorthand for --out")
+	fromInstance := fs.Bool("from-instance", false, "Suggest the node types used by the instance's workflows")
+	if err := fs.Parse(args[1:]); err != nil {
+		telemetry.Exit(1)
+	}
+	generate, ok := schemaTargets[*target]
+	if !ok {
+		fmt.Println(usage)
+		telemetry.Exit(1)
+	}
+
+	data, err := generate(*fromInstance, loadConfig)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		telemetry.Exit(1)
+	}
+	if *out == "" {
+		os.Stdout.Write(data)
+		return
+	}
+	if err := os.WriteFile(*out, data, 0644); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		telemetry.Exit(1)
+	}
+	fmt.Fprintf(os.Stderr, "Wrote the %s schema to %s\n", *target, *out)
+	if *target != "workflow-yaml" {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "For VS Code's YAML extension, add to settings.json:\n  \"yaml.schemas\": {%q: [%q, %q]}\n",
+		*out, workflows.WorkflowFile, workflows.ProjectDir+"/**/*.yaml")
+}
+
+func workflowYAMLSchema(fromInstance bool, loadConfig func() config.Config) ([]byte, error) {
+	var nodeTypes []string
+	if fromInstance {
+		cfg := loadConfig()
+		var err error
+		if nodeTypes, err = instanceNodeTypes(cfg); err != nil {
+			return nil, fmt.Errorf("failed to list node types: %s", config.Redact(err.Error(), cfg.APIToken))
+		}
+	}
+	return workflows.JSONSchema(nodeTypes)
+}
+
+// instanceNodeTypes returns the node types used by the workflows of the
+// instance. The public API has no node type listing, so this is the closest
+// it offers to the types installed there.
+// rpcSchema is the OpenRPC document of the daemon's JSON-RPC interface.
+func rpcSchema(bool, func() config.Config) ([]byte, error) {
+	data, err := json.MarshalIndent(daemon.OpenRPC(), "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+func instanceNodeTypes(cfg config.Config) ([]string, error) {
+	type workflow struct {
+		Nodes []struct {
+			Type string `json:"type"`
+		} `json:"nodes"`
+	}
+	list, err := fetchAll[workflow](newClient(cfg), cfg.APIBase()+"/workflows", cfg)
+	if err != nil {
+		return nil, err
+	}
+	seen := map[string]bool{}
+	var types []string
+	for _, wf := range list {
+		for _, node := range wf.Nodes {
+			if node.Type != "" && !seen[node.Type] {
+				seen[node.Type] = true
+				types = append(types, node.Type)
+			}
+		}
+	}
+	return types, nil
+}
@@ -0,0 +1,124 @@
+package entities
+
+import (
+	"flag"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/brandon-kyle-bailey/n8nctl/config"
+	"github.com/brandon-kyle-bailey/n8nctl/manifest"
+	"github.com/brandon-kyle-bailey/n8nctl/pkg/n8n"
+)
+
+// listFlag is a flag that may be repeated or given comma-separated values.
+type listFlag []string
+
+func (l *listFlag) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *listFlag) Set(s string) error {
+	for value := range strings.SplitSeq(s, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			*l = append(*l, value)
+		}
+	}
+	return nil
+}
+
+// workflowSelector picks workflows by tag and name for bulk operations and
+// to scope plan and apply. A workflow is selected when it matches any of the
+// inclusion selectors, or there are none, and none of the exclusions.
+type workflowSelector struct {
+	all          bool
+	tags         listFlag
+	names        listFlag
+	excludeTags  listFlag
+	excludeNames listFlag
+}
+
+// register adds the selector flags to fs. withAll adds --all, which bulk
+// operations require to act on every workflow but the excluded ones.
+func (s *workflowSelector) register(fs *flag.FlagSet, withAll bool) {
+	if withAll {
+		fs.BoolVar(&s.all, "all", false, "Select every workflow, except those excluded")
+	}
+	fs.Var(&s.tags, "tag", "Select workflows with this tag (repeatable, or comma-separated)")
+	fs.Var(&s.names, "name-glob", "Select workflows whose name matches this glob, e.g. 'billing-*' (repeatable)")
+	fs.Var(&s.excludeTags, "exclude-tag", "Leave out workflows with this tag (repeatable, or comma-separated)")
+	fs.Var(&s.excludeNames, "exclude-name-glob", "Leave out workflows whose name matches this glob (repeatable)")
+}
+
+// given reports whether any selector flag was set.
+func (s *workflowSelector) given() bool {
+	return s.all || s.including() || len(s.excludeTags) > 0 || len(s.excludeNames) > 0
+}
+
+func (s *workflowSelector) including() bool {
+	return len(s.tags) > 0 || len(s.names) > 0
+}
+
+// validate checks the globs, and that a bulk operation selects workflows
+// explicitly rather than only excluding some of them.
+func (s *workflowSelector) validate(bulk bool) error {
+	for _, glob := range append(append([]string{}, s.names...), s.excludeNames...) {
+		if _, err := path.Match(glob, ""); err != nil {
+			return fmt.Errorf("invalid name glob %q: %w", glob, err)
+		}
+	}
+	if bulk && !s.all && !s.including() {
+		return fmt.Errorf("select workflows with --tag or --name-glob, or pass --all to act on every workflow but the excluded ones")
+	}
+	if s.all && s.including() {
+		return fmt.Errorf("--all selects every workflow, use it without --tag and --name-glob")
+	}
+	return nil
+}
+
+// matches reports whether the workflow named name with the given tags is
+// selected.
+func (s *workflowSelector) matches(name string, tags []manifest.Tag) bool {
+	hasTag := func(names []string) bool {
+		for _, tag := range tags {
+			for _, want := range names {
+				if strings.EqualFold(tag.Name, want) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	matchesGlob := func(globs []string) bool {
+		for _, glob := range globs {
+			if ok, _ := path.Match(glob, name); ok {
+				return true
+			}
+		}
+		return false
+	}
+	if hasTag(s.excludeTags) || matchesGlob(s.excludeNames) {
+		return false
+	}
+	return !s.including() || hasTag(s.tags) || matchesGlob(s.names)
+}
+
+// selectWorkflows lists the remote workflows, within cfg's project, and
+// returns those selected.
+func (s *workflowSelector) selectWorkflows(client *n8n.Client, basePath string, cfg config.Config) ([]remoteWorkflow, error) {
+	listURL, err := scopeList(client, cfg, "workflows", basePath)
+	if err != nil {
+		return nil, err
+	}
+	all, err := fetchAll[remoteWorkflow](client, listURL, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list workflows: %w", err)
+	}
+	var selected []remoteWorkflow
+	for _, wf := range all {
+		if s.matches(wf.Name, wf.Tags) {
+			selected = append(selected, wf)
+		}
+	}
+	return selected, nil
+}
@@ -0,0 +1,191 @@
+package entities
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/brandon-kyle-bailey/n8nctl/config"
+	"github.com/brandon-kyle-bailey/n8nctl/pkg/n8n"
+	"github.com/brandon-kyle-bailey/n8nctl/prompt"
+	"github.com/brandon-kyle-bailey/n8nctl/utils"
+)
+
+// shareRoles is the role sharing grants on each entity. The n8n API has no
+// choice of role, so --role only guards against expecting another one.
+var shareRoles = map[string]string{"workflows": "editor", "credentials": "user"}
+
+// shareResource handles share, unshare and list-shares for workflows and
+// credentials. Sharing is only exposed by the internal REST API the editor
+// uses, which API keys cannot call, so these commands sign in with an
+// account's email and password.
+func shareResource(client *n8n.Client, entity, action string, params []string, cfg config.Config) error {
+	fs := flag.NewFlagSet(action, flag.ContinueOnError)
+	withProject := fs.String("with-project", "", "ID or name of the project to share with")
+	role := fs.String("role", shareRoles[entity], "Role to grant, the API only supports "+shareRoles[entity])
+	args, err := utils.ParseFlags(fs, params)
+	if err != nil {
+		return err
+	}
+	if len(args) != 1 || (action != "list-shares" && *withProject == "") {
+		return fmt.Errorf("usage: %s %s <id> --with-project <project>", entity, action)
+	}
+	if *role != shareRoles[entity] {
+		return fmt.Errorf("n8n only shares %s with the %s role", entity, shareRoles[entity])
+	}
+	id := args[0]
+
+	s, err := signIn(client, cfg)
+	if err != nil {
+		return err
+	}
+	path := fmt.Sprintf("/rest/%s/%s", entity, id)
+	var resource struct {
+		HomeProject        *project  `json:"homeProject"`
+		SharedWithProjects []project `json:"sharedWithProjects"`
+	}
+	if err := s.do("GET", path, nil, &resource); err != nil {
+		return err
+	}
+
+	if action == "list-shares" {
+		var items []map[string]any
+		if p := resource.HomeProject; p != nil {
+			items = append(items, map[string]any{"id": p.ID, "name": p.Name, "type": p.Type, "role": "owner"})
+		}
+		for _, p := range resource.SharedWithProjects {
+			items = append(items, map[string]any{"id": p.ID, "name": p.Name, "type": p.Type, "role": shareRoles[entity]})
+		}
+		utils.PrintTable(os.Stdout, items, []string{"id", "name", "type", "role"}, true)
+		return nil
+	}
+
+	target, err := resolveProject(client, cfg, *withProject)
+	if err != nil {
+		return err
+	}
+	if resource.HomeProject != nil && resource.HomeProject.ID == target.ID {
+		return fmt.Errorf("project %s owns %s %s", target, strings.TrimSuffix(entity, "s"), id)
+	}
+	// The API replaces the whole list of projects shared with.
+	ids := make([]string, 0, len(resource.SharedWithProjects)+1)
+	for _, p := range resource.SharedWithProjects {
+		ids = append(ids, p.ID)
+	}
+	shared := slices.Contains(ids, target.ID)
+	switch {
+	case action == "share" && shared:
+		fmt.Printf("Already sharing %s %s with project %s\n", strings.TrimSuffix(entity, "s"), id, target)
+		return nil
+	case action == "share":
+		ids = append(ids, target.ID)
+	case !shared:
+		fmt.Printf("Not sharing %s %s with project %s\n", strings.TrimSuffix(entity, "s"), id, target)
+		return nil
+	default:
+		ids = slices.DeleteFunc(ids, func(id string) bool { return id == target.ID })
+	}
+	if err := s.do("PUT", path+"/share", map[string][]string{"shareWithIds": ids}, nil); err != nil {
+		return err
+	}
+	if action == "share" {
+		fmt.Printf("Shared %s %s with project %s as %s\n", strings.TrimSuffix(entity, "s"), id, target, *role)
+	} else {
+		fmt.Printf("Stopped sharing %s %s with project %s\n", strings.TrimSuffix(entity, "s"), id, target)
+	}
+	return nil
+}
+
+// restSession is signed in to the internal REST API.
+type restSession struct {
+	baseURL string
+	client  *http.Client
+}
+
+// signIn signs in to the internal REST API with the email and password from
+// N8NCTL_EMAIL and N8NCTL_PASSWORD, asking for those not set. The session
+// shares the client's proxy and TLS settings, but not its debug trace,
+// recorder, faults or telemetry: it carries the password and, for backups,
+// the secrets of credentials. Read-only profiles still refuse its writes.
+func signIn(client *n8n.Client, cfg config.Config) (*restSession, error) {
+	email, password := os.Getenv("N8NCTL_EMAIL"), os.Getenv("N8NCTL_PASSWORD")
+	var err error
+	if email == "" {
+		if email, err = prompt.Input("Email of your n8n account", "", nil); err != nil {
+			return nil, err
+		}
+	}
+	if password == "" {
+		if password, err = prompt.Secret("Password"); err != nil {
+			return nil, err
+		}
+	}
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return nil, err
+	}
+	hc := client.DirectHTTPClient()
+	hc.Jar = jar
+	if cfg.ReadOnly {
+		hc.Transport = n8n.InterceptRequest(refuseWrites)(hc.Transport)
+	}
+	s := &restSession{baseURL: client.BaseURL(), client: hc}
+	err = s.do("POST", "/rest/login", map[string]string{
+		"emailOrLdapLoginId": email,
+		"email":              email,
+		"password":           password,
+	}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("sign in as %s failed: %w", email, err)
+	}
+	return s, nil
+}
+
+// do sends a request and decodes the "data" the REST API wraps responses in
+// into out, when not nil.
+func (s *restSession) do(method, path string, payload, out any) error {
+	var body io.Reader
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(Context, method, s.baseURL+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	// The auth cookie is bound to the browser ID it was issued for.
+	req.Header.Set("browser-id", "n8nctl")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return &n8n.APIError{StatusCode: resp.StatusCode, Status: resp.Status, ContentType: resp.Header.Get("Content-Type"), Body: string(data)}
+	}
+	if out == nil {
+		return nil
+	}
+	wrapped := struct {
+		Data any `json:"data"`
+	}{Data: out}
+	if err := json.Unmarshal(data, &wrapped); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return nil
+}
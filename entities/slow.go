@@ -0,0 +1,100 @@
+package entities
+
+import (
+	"flag"
+	"fmt"
+	neturl "net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/brandon-kyle-bailey/n8nctl/config"
+	"github.com/brandon-kyle-bailey/n8nctl/executions"
+	"github.com/brandon-kyle-bailey/n8nctl/pkg/n8n"
+)
+
+// slowExecutions prints the duration percentiles of a workflow's recent
+// executions and lists those slower than the threshold, linked to the
+// editor, to track down performance regressions.
+func slowExecutions(client *n8n.Client, basePath string, params []string, cfg config.Config) error {
+	fs := flag.NewFlagSet("slow", flag.ContinueOnError)
+	workflowID := fs.String("workflow", "", "ID of the workflow whose executions to analyse")
+	threshold := fs.String("threshold", "p95", "Percentile (p90, p99) or duration (30s) above which executions count as slow")
+	samples := fs.Int("samples", 200, "Number of recent executions to analyse")
+	status := fs.String("status", "", "Only analyse executions with this status, e.g. success")
+	if err := fs.Parse(params); err != nil {
+		return err
+	}
+	if *workflowID == "" {
+		return fmt.Errorf("slow requires --workflow")
+	}
+	percentile, fixed, err := executions.ParseThreshold(*threshold)
+	if err != nil {
+		return err
+	}
+	query := neturl.Values{"workflowId": {*workflowID}}
+	if *status != "" {
+		query.Set("status", *status)
+	}
+	list, err := listExecutions(client, basePath, query, *samples, cfg)
+	if err != nil {
+		return err
+	}
+	durations := executions.SortedDurations(list)
+	if len(durations) == 0 {
+		return fmt.Errorf("workflow %s has no finished executions to analyse", *workflowID)
+	}
+
+	fmt.Printf("Durations of the last %d finished executions of workflow %s:\n", len(durations), *workflowID)
+	var stats []string
+	for _, p := range []float64{50, 90, 95, 99} {
+		stats = append(stats, fmt.Sprintf("p%g %s", p, roundDuration(executions.Percentile(durations, p))))
+	}
+	stats = append(stats, "max "+roundDuration(durations[len(durations)-1]))
+	fmt.Printf("  %s\n\n", strings.Join(stats, "  "))
+	if len(durations) < 20 && fixed == 0 {
+		fmt.Fprintf(os.Stderr, "Warning: %d executions are too few for reliable percentiles, raise --samples\n", len(durations))
+	}
+
+	limit, label := fixed, *threshold
+	if fixed == 0 {
+		limit = executions.Percentile(durations, percentile)
+		label = fmt.Sprintf("%s (%s)", *threshold, roundDuration(limit))
+	}
+	var slow []executions.Execution
+	for _, exec := range list {
+		if d, ok := exec.Duration(); ok && d > limit {
+			slow = append(slow, exec)
+		}
+	}
+	if len(slow) == 0 {
+		fmt.Printf("No executions slower than %s.\n", label)
+		return nil
+	}
+	sort.SliceStable(slow, func(i, j int) bool {
+		a, _ := slow[i].Duration()
+		b, _ := slow[j].Duration()
+		return a > b
+	})
+	fmt.Printf("%d executions slower than %s:\n", len(slow), label)
+	// Links are printed in full, a table would truncate them.
+	for _, exec := range slow {
+		d, _ := exec.Duration()
+		fmt.Printf("  %s  #%-8s %-8s %8s  %s\n", exec.StartedAt.Local().Format("2006-01-02 15:04:05"),
+			exec.ID, exec.Status, roundDuration(d), executionLink(cfg, *workflowID, exec.ID))
+	}
+	return nil
+}
+
+// executionLink returns the editor URL of an execution.
+func executionLink(cfg config.Config, workflowID string, id executions.ID) string {
+	return fmt.Sprintf("%s/workflow/%s/executions/%s", strings.TrimRight(cfg.BaseURL, "/"), workflowID, id)
+}
+
+func roundDuration(d time.Duration) string {
+	if d >= time.Second {
+		return d.Round(100 * time.Millisecond).String()
+	}
+	return d.Round(time.Millisecond).String()
+}
@@ -0,0 +1,117 @@
+package entities
+
+import (
+	"context"
+	"fmt"
+	neturl "net/url"
+	"strings"
+	"time"
+
+	"github.com/brandon-kyle-bailey/n8nctl/config"
+	"github.com/brandon-kyle-bailey/n8nctl/executions"
+	"github.com/brandon-kyle-bailey/n8nctl/pkg/n8n"
+	"github.com/brandon-kyle-bailey/n8nctl/report"
+	"github.com/brandon-kyle-bailey/n8nctl/testsuite"
+	"github.com/brandon-kyle-bailey/n8nctl/utils"
+)
+
+// newTestRunner returns a runner for the instance cfg points at.
+func newTestRunner(client *n8n.Client, cfg config.Config) *testsuite.Runner {
+	apiBase := cfg.APIBase()
+	return &testsuite.Runner{
+		BaseURL: cfg.BaseURL,
+		Client:  client.HTTPClient(),
+		Workflow: func(name string) (string, bool, error) {
+			wf, err := findWorkflowByName(client, apiBase+"/workflows", name, cfg)
+			if err != nil {
+				return "", false, err
+			}
+			if wf == nil {
+				return "", false, fmt.Errorf("not found on %s, deploy it first", cfg.BaseURL)
+			}
+			return wf.ID, wf.Active, nil
+		},
+		SetActive: func(id string, active bool) error {
+			action := "deactivate"
+			if active {
+				action = "activate"
+			}
+			_, err := n8nAPIRequest(client, "POST", fmt.Sprintf("%s/workflows/%s/%s", apiBase, id, action), "")
+			return err
+		},
+		Executions: func(workflowID string) ([]executions.Execution, error) {
+			return listExecutions(client, apiBase+"/executions", neturl.Values{"workflowId": {workflowID}}, 20, cfg)
+		},
+	}
+}
+
+// runTests runs the suites in dir against the instance cfg points at, prints
+// the outcome of each case and returns the results.
+func runTests(ctx context.Context, dir string, cfg config.Config) ([]testsuite.SuiteResult, error) {
+	suites, err := testsuite.Load(dir)
+	if err != nil {
+		return nil, err
+	}
+	results, err := newTestRunner(newClient(cfg), cfg).Run(ctx, suites)
+	if err != nil {
+		return nil, err
+	}
+	printTestResults(results)
+	return results, nil
+}
+
+func printTestResults(results []testsuite.SuiteResult) {
+	passed, failed := 0, 0
+	for _, suite := range results {
+		for _, c := range suite.Cases {
+			label := fmt.Sprintf("%s: %s", suite.Suite.File, c.Case.Name)
+			if c.Passed() {
+				passed++
+				fmt.Printf("%s %s (%s)\n", utils.Colorize("PASS", utils.ColorGreen), label, c.Duration.Round(time.Millisecond))
+				continue
+			}
+			failed++
+			fmt.Printf("%s %s (%s)\n", utils.Colorize("FAIL", utils.ColorRed), label, c.Duration.Round(time.Millisecond))
+			if c.Err != nil {
+				fmt.Printf("     %v\n", c.Err)
+			}
+			for _, failure := range c.Failures {
+				fmt.Printf("     %s\n", failure)
+			}
+		}
+	}
+	fmt.Printf("\n%d passed, %d failed\n", passed, failed)
+}
+
+// testsFailed reports whether any case did not pass.
+func testsFailed(results []testsuite.SuiteResult) bool {
+	for _, suite := range results {
+		for _, c := range suite.Cases {
+			if !c.Passed() {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// testReport converts results for report writers, one suite per test file.
+func testReport(results []testsuite.SuiteResult) []report.Suite {
+	var suites []report.Suite
+	for _, result := range results {
+		suite := report.Suite{Name: result.Suite.File}
+		for _, c := range result.Cases {
+			rc := report.Case{Name: c.Case.Name, ClassName: c.Case.Workflow, Time: c.Duration}
+			if c.Err != nil {
+				rc.Error = c.Err.Error()
+			}
+			rc.Failure = strings.Join(c.Failures, "\n")
+			if c.ExecutionID != "" {
+				rc.Output = fmt.Sprintf("execution %s: %s", c.ExecutionID, c.ExecutionStatus)
+			}
+			suite.Cases = append(suite.Cases, rc)
+		}
+		suites = append(suites, suite)
+	}
+	return suites
+}
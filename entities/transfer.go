@@ -0,0 +1,40 @@
+package entities
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/brandon-kyle-bailey/n8nctl/config"
+	"github.com/brandon-kyle-bailey/n8nctl/pkg/n8n"
+	"github.com/brandon-kyle-bailey/n8nctl/utils"
+)
+
+// transferResource moves a workflow or credential into another project. The
+// destination comes from the global --project flag, never from the profile's
+// default project, so a forgotten flag cannot move anything.
+func transferResource(client *n8n.Client, entity, basePath string, params []string, cfg config.Config) error {
+	args, err := utils.ParseFlags(flag.NewFlagSet("transfer", flag.ContinueOnError), params)
+	if err != nil {
+		return err
+	}
+	if len(args) != 1 || Project == "" {
+		return fmt.Errorf("usage: %s transfer <id> --project <project>", entity)
+	}
+	dest, err := resolveProject(client, cfg, Project)
+	if err != nil {
+		return err
+	}
+	if err := transfer(client, basePath, args[0], dest.ID); err != nil {
+		return err
+	}
+	fmt.Printf("Transferred %s %s to project %s\n", strings.TrimSuffix(entity, "s"), args[0], dest)
+	return nil
+}
+
+func transfer(client *n8n.Client, basePath, id, projectID string) error {
+	payload, _ := json.Marshal(map[string]string{"destinationProjectId": projectID})
+	_, err := n8nAPIRequest(client, "PUT", fmt.Sprintf("%s/%s/transfer", basePath, id), string(payload))
+	return err
+}
@@ -0,0 +1,156 @@
+package entities
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/brandon-kyle-bailey/n8nctl/config"
+	"github.com/brandon-kyle-bailey/n8nctl/pkg/n8n"
+	"github.com/brandon-kyle-bailey/n8nctl/prompt"
+	"github.com/brandon-kyle-bailey/n8nctl/telemetry"
+	"github.com/brandon-kyle-bailey/n8nctl/utils"
+)
+
+// undoDir keeps the undo files of bulk activation changes.
+var undoDir = filepath.Join(".n8nctl", "undo")
+
+// undoFile records the activation state of workflows before a bulk change,
+// so `n8nctl undo` can restore it.
+type undoFile struct {
+	Action    string         `json:"action"`
+	Instance  string         `json:"instance"`
+	CreatedAt time.Time      `json:"createdAt"`
+	Workflows []undoWorkflow `json:"workflows"`
+}
+
+type undoWorkflow struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Active bool   `json:"active"`
+}
+
+// saveUndo writes the activation state of targets before action to a
+// timestamped undo file and returns its path.
+func saveUndo(client *n8n.Client, action string, targets []remoteWorkflow) (string, error) {
+	u := undoFile{Action: action, Instance: client.BaseURL(), CreatedAt: time.Now().UTC()}
+	for _, wf := range targets {
+		u.Workflows = append(u.Workflows, undoWorkflow{ID: wf.ID, Name: wf.Name, Active: wf.Active})
+	}
+	data, err := json.MarshalIndent(u, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(undoDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create %s: %w", undoDir, err)
+	}
+	// Bulk changes may follow each other within a millisecond, so an existing
+	// file gets a numbered sibling rather than being overwritten.
+	stamp := u.CreatedAt.Format("20060102T150405.000Z")
+	path := filepath.Join(undoDir, fmt.Sprintf("%s-%s.json", stamp, action))
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	for n := 2; os.IsExist(err); n++ {
+		path = filepath.Join(undoDir, fmt.Sprintf("%s-%s-%d.json", stamp, action, n))
+		f, err = os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to write undo file: %w", err)
+	}
+	_, err = f.Write(append(data, '\n'))
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to write undo file: %w", err)
+	}
+	return path, nil
+}
+
+// HandleUndo restores the activation state recorded in an undo file.
+func HandleUndo(args []string, cfg config.Config) {
+	if len(args) != 1 || strings.HasPrefix(args[0], "-") {
+		fmt.Println("Usage: n8nctl undo <.n8nctl/undo/file.json>")
+		telemetry.Exit(1)
+	}
+	if cfg.ReadOnly {
+		fmt.Println("Error: undo changes the instance, which is not allowed in read-only mode")
+		telemetry.Exit(1)
+	}
+	if err := undo(newClient(cfg), args[0]); err != nil {
+		fmt.Printf("Error: %s\n", config.Redact(err.Error(), cfg.APIToken))
+		telemetry.Exit(1)
+	}
+}
+
+func undo(client *n8n.Client, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var u undoFile
+	if err := json.Unmarshal(data, &u); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if u.Instance != client.BaseURL() {
+		return fmt.Errorf("%s was written for %s, select its profile with --profile", path, u.Instance)
+	}
+
+	// Only touch the workflows whose state still differs, as some may have
+	// been changed back by hand since.
+	basePath := client.APIURL("workflows")
+	var changes []undoWorkflow
+	var rows [][]string
+	failed := 0
+	for _, wf := range u.Workflows {
+		body, err := n8nAPIRequest(client, "GET", fmt.Sprintf("%s/%s", basePath, wf.ID), "")
+		if err != nil {
+			failed++
+			fmt.Printf("%s %s %q: %v\n", utils.Colorize("FAIL", utils.ColorRed), wf.ID, wf.Name, err)
+			continue
+		}
+		if remoteActive(body) == wf.Active {
+			continue
+		}
+		changes = append(changes, wf)
+		rows = append(rows, []string{wf.ID, wf.Name, activeLabel(!wf.Active) + " -> " + activeLabel(wf.Active)})
+	}
+	if len(changes) == 0 && failed == 0 {
+		fmt.Printf("Nothing to undo: the %d workflows of %s are back in their earlier state.\n", len(u.Workflows), path)
+		return nil
+	}
+	var keep []bool
+	if len(changes) > 0 {
+		if keep, err = prompt.ConfirmItems(fmt.Sprintf("Undo the %s of these workflows?", u.Action), []string{"ID", "NAME", "STATE"}, rows); err != nil {
+			return err
+		}
+	}
+
+	done := 0
+	for i, ok := range keep {
+		if !ok {
+			continue
+		}
+		wf := changes[i]
+		action := "deactivate"
+		if wf.Active {
+			action = "activate"
+		}
+		done++
+		if _, err := n8nAPIRequest(client, "POST", fmt.Sprintf("%s/%s/%s", basePath, wf.ID, action), ""); err != nil {
+			failed++
+			fmt.Printf("%s %s %q: %v\n", utils.Colorize("FAIL", utils.ColorRed), wf.ID, wf.Name, err)
+			continue
+		}
+		fmt.Printf("%s   %s %q: %sd\n", utils.Colorize("OK", utils.ColorGreen), wf.ID, wf.Name, action)
+	}
+	if done == 0 && len(changes) > 0 {
+		fmt.Println("Undo aborted by user.")
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d workflows failed to be restored", failed, len(u.Workflows))
+	}
+	return nil
+}
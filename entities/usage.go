@@ -0,0 +1,166 @@
+package entities
+
+import (
+	"encoding/csv"
+	"errors"
+	"flag"
+	"fmt"
+	neturl "net/url"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/brandon-kyle-bailey/n8nctl/config"
+	"github.com/brandon-kyle-bailey/n8nctl/executions"
+	"github.com/brandon-kyle-bailey/n8nctl/pkg/n8n"
+	"github.com/brandon-kyle-bailey/n8nctl/usage"
+	"github.com/brandon-kyle-bailey/n8nctl/utils"
+)
+
+// errEnoughExecutions stops forEachExecution once the executions get older
+// than a snapshot counts.
+var errEnoughExecutions = errors.New("enough executions")
+
+// usageSnapshot records the current usage of the instance in the profile's
+// usage file. Run it on a schedule, e.g. daily from cron, to build a trend.
+func usageSnapshot(client *n8n.Client, cfg config.Config) error {
+	path, err := usage.Path(config.Profile)
+	if err != nil {
+		return err
+	}
+	sample := usage.Sample{Time: time.Now().UTC()}
+
+	workflows, err := fetchAll[struct {
+		Active bool `json:"active"`
+	}](client, client.APIURL("workflows"), cfg)
+	if err != nil {
+		return err
+	}
+	sample.Workflows = len(workflows)
+	for _, wf := range workflows {
+		if wf.Active {
+			sample.ActiveWorkflows++
+		}
+	}
+
+	credentials, err := fetchAll[struct{}](client, client.APIURL("credentials"), cfg)
+	switch {
+	case err == nil:
+		count := len(credentials)
+		sample.Credentials = &count
+	case !unlisted(err):
+		return err
+	}
+
+	// Executions are listed newest first, so stop at the first one older
+	// than a day.
+	dayAgo := sample.Time.Add(-24 * time.Hour)
+	err = forEachExecution(client, client.APIURL("executions"), neturl.Values{}, 0, cfg, func(exec executions.Execution) error {
+		if exec.StartedAt.Before(dayAgo) {
+			return errEnoughExecutions
+		}
+		sample.Executions++
+		if exec.Status == "error" || exec.Status == "crashed" {
+			sample.FailedExecutions++
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errEnoughExecutions) {
+		return err
+	}
+
+	if err := usage.Append(path, sample); err != nil {
+		return err
+	}
+	credentialCount := "unknown"
+	if sample.Credentials != nil {
+		credentialCount = strconv.Itoa(*sample.Credentials)
+	}
+	fmt.Printf("Recorded %d workflows (%d active), %s credentials and %d executions in the last day (%d failed) in %s\n",
+		sample.Workflows, sample.ActiveWorkflows, credentialCount, sample.Executions, sample.FailedExecutions, path)
+	return nil
+}
+
+// usageReport charts the recorded usage of the profile's instance.
+func usageReport(params []string) error {
+	fs := flag.NewFlagSet("report", flag.ContinueOnError)
+	since := fs.String("since", "", "Only report snapshots taken within this age, e.g. 30d or 12w")
+	asCSV := fs.Bool("csv", false, "Print the snapshots as CSV instead of a chart")
+	if err := fs.Parse(params); err != nil {
+		return err
+	}
+	var from time.Time
+	if *since != "" {
+		age, err := utils.ParseAge(*since)
+		if err != nil {
+			return err
+		}
+		from = time.Now().Add(-age)
+	}
+	path, err := usage.Path(config.Profile)
+	if err != nil {
+		return err
+	}
+	samples, err := usage.Load(path, from)
+	if os.IsNotExist(err) {
+		return fmt.Errorf("no usage recorded for this profile yet, record some with: n8nctl usage snapshot")
+	}
+	if err != nil {
+		return err
+	}
+	if len(samples) == 0 {
+		return fmt.Errorf("no usage recorded in the last %s", *since)
+	}
+
+	if *asCSV {
+		w := csv.NewWriter(os.Stdout)
+		header := []string{"time"}
+		for _, m := range usage.Metrics {
+			header = append(header, m.Name)
+		}
+		w.Write(header)
+		for _, s := range samples {
+			row := []string{s.Time.Format(time.RFC3339)}
+			for _, m := range usage.Metrics {
+				cell := ""
+				if v, ok := m.Value(s); ok {
+					cell = strconv.FormatFloat(v, 'f', -1, 64)
+				}
+				row = append(row, cell)
+			}
+			w.Write(row)
+		}
+		w.Flush()
+		return w.Error()
+	}
+
+	fmt.Printf("%d snapshots from %s to %s\n\n", len(samples),
+		samples[0].Time.Local().Format("2006-01-02 15:04"), samples[len(samples)-1].Time.Local().Format("2006-01-02 15:04"))
+	var rows []map[string]any
+	for _, m := range usage.Metrics {
+		var values []float64
+		for _, s := range samples {
+			if v, ok := m.Value(s); ok {
+				values = append(values, v)
+			}
+		}
+		if len(values) == 0 {
+			continue
+		}
+		low, high := values[0], values[0]
+		for _, v := range values {
+			low, high = min(low, v), max(high, v)
+		}
+		rows = append(rows, map[string]any{
+			"metric": m.Name,
+			"first":  values[0],
+			"last":   values[len(values)-1],
+			"change": fmt.Sprintf("%+g", values[len(values)-1]-values[0]),
+			"min":    low,
+			"max":    high,
+			"trend":  usage.Sparkline(values, 40),
+		})
+	}
+	utils.PrintTable(os.Stdout, rows, []string{"metric", "first", "last", "change", "min", "max", "trend"}, true)
+	return nil
+}
@@ -0,0 +1,133 @@
+package entities
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/brandon-kyle-bailey/n8nctl/prompt"
+	"github.com/brandon-kyle-bailey/n8nctl/utils"
+	"github.com/brandon-kyle-bailey/n8nctl/workflows"
+)
+
+// envExampleFile documents the variables a project's templates need.
+const envExampleFile = ".env.example"
+
+// workflowVars lists the ${{VAR_NAME}} variables of workflow files, where
+// they are used and whether the env files define them, or writes them to
+// .env.example.
+func workflowVars(params []string) error {
+	fs := flag.NewFlagSet("vars", flag.ContinueOnError)
+	all := fs.Bool("all", false, "Scan every workflow file of the project")
+	example := fs.Bool("example", false, "Write the variables to "+envExampleFile+", keeping the example values it has")
+	args, err := utils.ParseFlags(fs, params)
+	if err != nil {
+		return err
+	}
+	files := args
+	switch {
+	case *all && len(args) > 0:
+		return fmt.Errorf("vars takes workflow files or --all, not both")
+	case *all:
+		if files, err = workflows.ProjectFiles(""); err != nil {
+			return err
+		}
+	case len(files) == 0:
+		files = []string{workflows.WorkflowFile}
+	}
+	usages, err := workflows.Variables(files)
+	if err != nil {
+		return err
+	}
+	if *example {
+		return writeEnvExample(usages)
+	}
+
+	env, err := workflows.EnvNames()
+	if err != nil {
+		return err
+	}
+	if len(usages) == 0 {
+		fmt.Println("No variables used.")
+		return nil
+	}
+	slices.SortStableFunc(usages, func(a, b workflows.VarUsage) int { return strings.Compare(a.Name, b.Name) })
+	var rows []map[string]any
+	undefined := map[string]bool{}
+	for _, usage := range usages {
+		defined := "yes"
+		if _, ok := env[usage.Name]; !ok {
+			defined = "no"
+			undefined[usage.Name] = true
+		}
+		rows = append(rows, map[string]any{"variable": usage.Name, "defined": defined, "file": usage.File, "field": usage.Where})
+	}
+	utils.PrintTable(os.Stdout, rows, []string{"variable", "defined", "file", "field"}, true)
+	fmt.Printf("\n%d variables, %d undefined in %s\n", len(variableNames(usages)), len(undefined), strings.Join(workflows.EnvFiles, ", "))
+	return nil
+}
+
+// variableNames returns the names of the variables of usages in the order
+// they are first used.
+func variableNames(usages []workflows.VarUsage) []string {
+	var names []string
+	for _, usage := range usages {
+		if !slices.Contains(names, usage.Name) {
+			names = append(names, usage.Name)
+		}
+	}
+	return names
+}
+
+// writeEnvExample writes the variables of usages to .env.example, each
+// under a comment saying where it is used. Values the file has already are
+// kept as examples; values of the env files never end up in it.
+func writeEnvExample(usages []workflows.VarUsage) error {
+	examples := map[string]string{}
+	old, err := os.ReadFile(envExampleFile)
+	if err == nil {
+		if examples, err = utils.LoadDotEnv(envExampleFile); err != nil {
+			return fmt.Errorf("failed to read %s: %w", envExampleFile, err)
+		}
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("# Variables of the workflow templates, written by n8nctl workflows vars --example.\n")
+	buf.WriteString("# Copy this file to .env and fill in the values.\n")
+	for _, name := range slices.Sorted(slices.Values(variableNames(usages))) {
+		buf.WriteString("\n")
+		for _, usage := range usages {
+			if usage.Name == name {
+				fmt.Fprintf(&buf, "# %s: %s\n", usage.File, usage.Where)
+			}
+		}
+		fmt.Fprintf(&buf, "%s=%s\n", name, examples[name])
+	}
+	if bytes.Equal(old, buf.Bytes()) {
+		fmt.Printf("%s is up to date.\n", envExampleFile)
+		return nil
+	}
+	if old != nil {
+		if err := utils.RunDiff(old, buf.Bytes()); err != nil {
+			return err
+		}
+		confirmed, err := prompt.Confirm(fmt.Sprintf("Write these changes to %s?", envExampleFile), false)
+		if err != nil {
+			return err
+		}
+		if !confirmed {
+			fmt.Println("Aborted, no changes written.")
+			return nil
+		}
+	}
+	if err := os.WriteFile(envExampleFile, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", envExampleFile, err)
+	}
+	fmt.Printf("Wrote %d variables to %s\n", len(variableNames(usages)), envExampleFile)
+	return nil
+}
@@ -0,0 +1,186 @@
+package entities
+
+import (
+	"flag"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/brandon-kyle-bailey/n8nctl/config"
+	"github.com/brandon-kyle-bailey/n8nctl/lint"
+	"github.com/brandon-kyle-bailey/n8nctl/pkg/n8n"
+	"github.com/brandon-kyle-bailey/n8nctl/utils"
+	"github.com/brandon-kyle-bailey/n8nctl/workflows"
+	"github.com/fsnotify/fsnotify"
+)
+
+// watchWorkflows renders workflow files again whenever they, the files
+// they include or the env files change, writing their .out files like
+// preview, and with --deploy deploys them too, until interrupted. The
+// directories of the files are watched for change notifications; with
+// --poll, or where notifications are not available, the files are polled
+// instead, which also works on mounted volumes that do not report changes.
+func watchWorkflows(client *n8n.Client, basePath string, params []string, cfg config.Config) error {
+	fs := flag.NewFlagSet("watch", flag.ContinueOnError)
+	dir := fs.String("dir", "", "Watch every workflow YAML file in this directory")
+	deploy := fs.Bool("deploy", false, "Also deploy the workflows after each change")
+	poll := fs.Bool("poll", false, "Poll the files instead of waiting for change notifications, for mounted volumes that do not send them")
+	interval := fs.Duration("interval", 500*time.Millisecond, "How often to poll the files, and how long changes must settle before rendering")
+	fs.StringVar(&workflows.ResolveAt, "resolve-at", workflows.ResolveAtPreview, "When to resolve variables and secrets: preview, writing them to .out, or deploy")
+	files, err := utils.ParseFlags(fs, params)
+	if err != nil {
+		return err
+	}
+	if err := checkResolveAt(); err != nil {
+		return err
+	}
+	if len(files) > 0 && *dir != "" {
+		return fmt.Errorf("watch either files or --dir, not both")
+	}
+	if len(files) == 0 {
+		if files, err = workflows.ProjectFiles(*dir); err != nil {
+			return err
+		}
+	}
+	if *interval <= 0 {
+		return fmt.Errorf("--interval must be positive")
+	}
+	useSecretStores(cfg)
+	var d *deployer
+	if *deploy {
+		if d, err = newDeployer(client, basePath, cfg); err != nil {
+			return err
+		}
+	}
+
+	var watcher *fsnotify.Watcher
+	if !*poll {
+		if watcher, err = fsnotify.NewWatcher(); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: change notifications are not available (%v), polling instead\n", err)
+		} else {
+			defer watcher.Close()
+		}
+	}
+
+	inputs := watchInputs(files)
+	hashes := workflows.InputHashes(inputs)
+	rebuild := func() {
+		fmt.Printf("[%s] ", time.Now().Format(time.TimeOnly))
+		if d != nil {
+			// deployFiles reports the files that failed itself.
+			d.deployFiles(files)
+		} else {
+			previewFiles(files)
+		}
+		inputs = watchInputs(files)
+		hashes = workflows.InputHashes(inputs)
+		if watcher != nil {
+			if err := watchDirs(watcher, inputs); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: %v, polling instead\n", err)
+				watcher.Close()
+				watcher = nil
+			}
+		}
+		fmt.Printf("Watching %d files for changes, press Ctrl-C to stop.\n", len(inputs))
+	}
+	rebuild()
+
+	ticker := time.NewTicker(*interval)
+	defer ticker.Stop()
+	for {
+		// Nil channels never fire, leaving the ticker to poll without a
+		// watcher and only the watcher with one.
+		var tick <-chan time.Time
+		var events <-chan fsnotify.Event
+		var errs <-chan error
+		if watcher == nil {
+			tick = ticker.C
+		} else {
+			events, errs = watcher.Events, watcher.Errors
+		}
+		select {
+		case <-Context.Done():
+			return nil
+		case <-tick:
+		case <-events:
+		case err := <-errs:
+			fmt.Fprintf(os.Stderr, "Warning: %v, polling instead\n", err)
+			watcher.Close()
+			watcher = nil
+		}
+		if maps.Equal(hashes, workflows.InputHashes(inputs)) {
+			continue
+		}
+		// Editors write files in several steps, so wait for them to settle.
+		for {
+			current := workflows.InputHashes(inputs)
+			select {
+			case <-Context.Done():
+				return nil
+			case <-time.After(*interval):
+			}
+			if maps.Equal(current, workflows.InputHashes(inputs)) {
+				break
+			}
+		}
+		fmt.Println()
+		rebuild()
+	}
+}
+
+// watchDirs watches the directories of inputs. Editors often save by
+// replacing files, and inputs may not exist yet, so the directories are
+// watched rather than the files themselves.
+func watchDirs(watcher *fsnotify.Watcher, inputs []string) error {
+	dirs := map[string]bool{}
+	for _, input := range inputs {
+		dirs[filepath.Dir(input)] = true
+	}
+	for dir := range dirs {
+		if _, err := os.Stat(dir); os.IsNotExist(err) {
+			continue
+		}
+		if err := watcher.Add(dir); err != nil {
+			return fmt.Errorf("failed to watch %s: %w", dir, err)
+		}
+	}
+	return nil
+}
+
+// watchInputs returns the files rendering files reads.
+func watchInputs(files []string) []string {
+	var inputs []string
+	for _, file := range files {
+		inputs = append(inputs, workflows.Inputs(file)...)
+	}
+	return inputs
+}
+
+// previewFiles renders files and writes their .out files, as preview does
+// without asking, printing the problems lint finds.
+func previewFiles(files []string) {
+	failed := 0
+	for _, rendered := range workflows.RenderFiles(files) {
+		file := rendered.File
+		output, err := rendered.Body, rendered.Err
+		if err == nil && workflows.ResolveAt == workflows.ResolveAtDeploy {
+			output, err = workflows.RenderTemplate(file)
+		}
+		if err == nil {
+			err = workflows.WriteOutput(workflows.OutputPathFor(file), output)
+		}
+		if err != nil {
+			failed++
+			fmt.Printf("%s %s: %v\n", utils.Colorize("FAIL", utils.ColorRed), file, err)
+			continue
+		}
+		findings := lint.Lint(file, rendered.Body)
+		fmt.Printf("%s   %s: saved to %s\n", utils.Colorize("OK", utils.ColorGreen), file, workflows.OutputPathFor(file))
+		for _, f := range findings {
+			fmt.Printf("       %s\n", f)
+		}
+	}
+	fmt.Printf("%d rendered, %d failed\n", len(files)-failed, failed)
+}
@@ -0,0 +1,65 @@
+package entities
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/brandon-kyle-bailey/n8nctl/pkg/n8n"
+	"github.com/brandon-kyle-bailey/n8nctl/utils"
+)
+
+// invokeWebhook sends a request to the webhook trigger of a workflow and
+// prints the response, exiting non-zero on an error status. Unlike run, it
+// leaves the workflow as it is, so it can call the test webhook of a workflow
+// open in the editor.
+func invokeWebhook(client *n8n.Client, basePath string, params []string) error {
+	fs := flag.NewFlagSet("invoke-webhook", flag.ContinueOnError)
+	test := fs.Bool("test", false, "Call the test webhook, which listens while the editor waits for a test event")
+	method := fs.String("method", "", "HTTP method (default: the one the webhook listens for)")
+	body := fs.String("body", "", "Request body, JSON bodies are sent as application/json")
+	args, err := utils.ParseFlags(fs, params)
+	if err != nil {
+		return err
+	}
+	if len(args) != 1 {
+		return fmt.Errorf("invoke-webhook requires exactly one workflow ID")
+	}
+	id := args[0]
+
+	trigger, active, err := fetchWebhookTrigger(client, basePath, id)
+	if err != nil {
+		return err
+	}
+	if *method == "" {
+		*method = trigger.method()
+	}
+	*method = strings.ToUpper(*method)
+	if *method != trigger.method() {
+		fmt.Fprintf(os.Stderr, "Warning: the webhook of workflow %s listens for %s requests, not %s\n", id, trigger.method(), *method)
+	}
+	prefix := productionWebhook
+	if *test {
+		prefix = testWebhook
+	} else if !active {
+		fmt.Fprintf(os.Stderr, "Warning: workflow %s is not active, its production webhook is not listening\n", id)
+	}
+
+	status, response, err := callWebhook(Context, client, prefix, *method, trigger.path(), []byte(*body), "")
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "%s %s/%s/%s responded %d %s\n", *method, client.BaseURL(), prefix, trigger.path(), status, http.StatusText(status))
+	if len(response) > 0 {
+		utils.PrintJSONResponse(response)
+	}
+	if status == http.StatusNotFound && *test {
+		return fmt.Errorf("the test webhook is not listening, click \"Listen for test event\" in the editor first")
+	}
+	if status >= 400 {
+		return fmt.Errorf("webhook call failed with status %d", status)
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package entities
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/brandon-kyle-bailey/n8nctl/config"
+	"github.com/brandon-kyle-bailey/n8nctl/manifest"
+	"github.com/brandon-kyle-bailey/n8nctl/pkg/n8n"
+	"github.com/brandon-kyle-bailey/n8nctl/utils"
+)
+
+// workflowTags lists or replaces the tags of a workflow:
+// "tags list <workflow-id>" and "tags set <workflow-id> tag1,tag2".
+func workflowTags(client *n8n.Client, basePath string, params []string, cfg config.Config) error {
+	usage := fmt.Errorf("usage: workflows tags list <workflow-id> | workflows tags set <workflow-id> <tag1,tag2> [--create-missing]")
+	fs := flag.NewFlagSet("tags", flag.ContinueOnError)
+	createMissing := fs.Bool("create-missing", false, "Create the tags that do not exist yet instead of failing")
+	args, err := utils.ParseFlags(fs, params)
+	if err != nil {
+		return err
+	}
+	if len(args) < 2 {
+		return usage
+	}
+	subcommand, id := args[0], args[1]
+	tagsPath := fmt.Sprintf("%s/%s/tags", basePath, id)
+
+	switch {
+	case subcommand == "list" && len(args) == 2:
+		resp, err := n8nAPIRequest(client, "GET", tagsPath, "")
+		if err != nil {
+			return err
+		}
+		utils.PrintJSONResponse(resp)
+		return nil
+	case subcommand == "set" && len(args) == 3:
+	default:
+		return usage
+	}
+
+	var names []string
+	for name := range strings.SplitSeq(args[2], ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	ids, err := resolveTags(client, cfg, names, *createMissing)
+	if err != nil {
+		return err
+	}
+	refs := make([]map[string]string, 0, len(ids))
+	for _, tagID := range ids {
+		refs = append(refs, map[string]string{"id": tagID})
+	}
+	payload, _ := json.Marshal(refs)
+	resp, err := n8nAPIRequest(client, "PUT", tagsPath, string(payload))
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Set %d tags on workflow %s\n", len(ids), id)
+	utils.PrintJSONResponse(resp)
+	return nil
+}
+
+// resolveTags returns the IDs of the tags named names, creating the missing
+// ones when create is set.
+func resolveTags(client *n8n.Client, cfg config.Config, names []string, create bool) ([]string, error) {
+	tagsPath := client.APIURL("tags")
+	existing, err := fetchAll[manifest.Tag](client, tagsPath, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tags: %w", err)
+	}
+	byName := make(map[string]string, len(existing))
+	for _, tag := range existing {
+		byName[tag.Name] = tag.ID
+	}
+
+	var ids, missing []string
+	for _, name := range names {
+		if id, ok := byName[name]; ok {
+			ids = append(ids, id)
+			continue
+		}
+		if !create {
+			missing = append(missing, name)
+			continue
+		}
+		payload, _ := json.Marshal(map[string]string{"name": name})
+		resp, err := n8nAPIRequest(client, "POST", tagsPath, string(payload))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create tag %q: %w", name, err)
+		}
+		var tag manifest.Tag
+		if err := json.Unmarshal(resp, &tag); err != nil || tag.ID == "" {
+			return nil, fmt.Errorf("unexpected response creating tag %q: %s", name, resp)
+		}
+		fmt.Fprintf(os.Stderr, "Created tag %s (%s)\n", tag.Name, tag.ID)
+		byName[name] = tag.ID
+		ids = append(ids, tag.ID)
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("tags not found: %s (use --create-missing to create them)", strings.Join(missing, ", "))
+	}
+	return ids, nil
+}
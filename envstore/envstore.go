@@ -0,0 +1,173 @@
+// Package envstore keeps the variables of a config profile encrypted in
+// ~/.n8nctl/env/<profile>.enc, so the secrets workflow templates are rendered
+// with never live in plain text in project files. Stores are encrypted like
+// the credentials of backups, with a passphrase taken from
+// N8NCTL_ENV_PASSPHRASE or asked for.
+package envstore
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"github.com/brandon-kyle-bailey/n8nctl/backup"
+	"github.com/brandon-kyle-bailey/n8nctl/config"
+	"github.com/brandon-kyle-bailey/n8nctl/prompt"
+)
+
+// PassphraseEnv names the environment variable holding the passphrase.
+const PassphraseEnv = "N8NCTL_ENV_PASSPHRASE"
+
+// namePattern is what ${{VAR_NAME}} placeholders can name.
+var namePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Store is the variables stored for a profile.
+type Store struct {
+	Profile string
+	Vars    map[string]string
+
+	path       string
+	passphrase string
+}
+
+// Path returns where the variables of profile are stored.
+func Path(profile string) (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	if profile == "" {
+		profile = config.DefaultProfile
+	}
+	return filepath.Join(home, ".n8nctl", "env", profile+".enc"), nil
+}
+
+// Exists reports whether variables are stored for profile.
+func Exists(profile string) bool {
+	path, err := Path(profile)
+	if err != nil {
+		return false
+	}
+	_, err = os.Stat(path)
+	return err == nil
+}
+
+// Open decrypts the variables stored for profile, asking for the
+// passphrase, or returns an empty store if there are none.
+func Open(profile string) (*Store, error) {
+	path, err := Path(profile)
+	if err != nil {
+		return nil, err
+	}
+	s := &Store{Profile: profile, Vars: map[string]string{}, path: path}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if s.passphrase, err = passphrase(fmt.Sprintf("Passphrase of the variables of profile %s", s.name()), false); err != nil {
+		return nil, err
+	}
+	plaintext, err := backup.Open(data, s.passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt %s: %w", path, err)
+	}
+	if err := json.Unmarshal(plaintext, &s.Vars); err != nil {
+		return nil, fmt.Errorf("failed to decrypt %s: %w", path, err)
+	}
+	return s, nil
+}
+
+// Set stores value under name, which must be a valid variable name.
+func (s *Store) Set(name, value string) error {
+	if !namePattern.MatchString(name) {
+		return fmt.Errorf("invalid variable name %q: use letters, digits and underscores, not starting with a digit", name)
+	}
+	s.Vars[name] = value
+	return nil
+}
+
+// Save encrypts the variables back to their file, asking for a new
+// passphrase, twice, when the store did not exist yet. An emptied store is
+// removed.
+func (s *Store) Save() error {
+	if len(s.Vars) == 0 {
+		if err := os.Remove(s.path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	if s.passphrase == "" {
+		var err error
+		if s.passphrase, err = passphrase(fmt.Sprintf("New passphrase for the variables of profile %s", s.name()), true); err != nil {
+			return err
+		}
+	}
+	plaintext, err := json.Marshal(s.Vars)
+	if err != nil {
+		return err
+	}
+	data, err := backup.Seal(plaintext, s.passphrase)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
+
+// Path returns the file of the store.
+func (s *Store) Path() string {
+	return s.path
+}
+
+func (s *Store) name() string {
+	if s.Profile == "" {
+		return config.DefaultProfile
+	}
+	return s.Profile
+}
+
+// passphrase returns the passphrase from N8NCTL_ENV_PASSPHRASE, or asks for
+// it, twice when confirm is set.
+func passphrase(label string, confirm bool) (string, error) {
+	if value := os.Getenv(PassphraseEnv); value != "" {
+		return value, nil
+	}
+	value, err := prompt.Secret(label)
+	if err != nil {
+		return "", fmt.Errorf("%w (or set %s)", err, PassphraseEnv)
+	}
+	if value == "" {
+		return "", errors.New("an empty passphrase cannot protect variables")
+	}
+	if !confirm {
+		return value, nil
+	}
+	again, err := prompt.Secret("Repeat the passphrase")
+	if err != nil {
+		return "", err
+	}
+	if again != value {
+		return "", errors.New("the passphrases do not match")
+	}
+	return value, nil
+}
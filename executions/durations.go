@@ -0,0 +1,58 @@
+package executions
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Duration returns how long exec ran, or false if it has not finished.
+func (e Execution) Duration() (time.Duration, bool) {
+	if e.StoppedAt == nil {
+		return 0, false
+	}
+	return e.StoppedAt.Sub(e.StartedAt), true
+}
+
+// Percentile returns the p-th percentile (0-100) of durations by the
+// nearest-rank method. durations must be sorted.
+func Percentile(durations []time.Duration, p float64) time.Duration {
+	if len(durations) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(durations))))
+	return durations[min(max(rank, 1), len(durations))-1]
+}
+
+// SortedDurations returns the durations of the finished executions in list,
+// shortest first.
+func SortedDurations(list []Execution) []time.Duration {
+	var durations []time.Duration
+	for _, exec := range list {
+		if d, ok := exec.Duration(); ok {
+			durations = append(durations, d)
+		}
+	}
+	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+	return durations
+}
+
+// ParseThreshold parses a percentile such as p95, returning it as a number,
+// or a fixed duration such as 30s.
+func ParseThreshold(s string) (percentile float64, fixed time.Duration, err error) {
+	if n, ok := strings.CutPrefix(strings.ToLower(s), "p"); ok {
+		p, err := strconv.ParseFloat(n, 64)
+		if err != nil || p <= 0 || p >= 100 {
+			return 0, 0, fmt.Errorf("invalid percentile %q, expected e.g. p95 or p99.9", s)
+		}
+		return p, 0, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return 0, 0, fmt.Errorf("invalid threshold %q, expected a percentile such as p95 or a duration such as 30s", s)
+	}
+	return 0, d, nil
+}
@@ -0,0 +1,66 @@
+package executions
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// NodeTiming is the time one node of an execution spent running, summed over
+// its runs, as loops and retries run a node more than once.
+type NodeTiming struct {
+	Node   string
+	Runs   int
+	Total  time.Duration
+	Max    time.Duration
+	Status string
+}
+
+// NodeTimings returns the time spent in each node of an execution, parsed
+// from its run data, slowest first.
+func NodeTimings(data json.RawMessage) ([]NodeTiming, error) {
+	var run struct {
+		ResultData struct {
+			RunData map[string][]struct {
+				ExecutionTime   int64  `json:"executionTime"`
+				ExecutionStatus string `json:"executionStatus"`
+				Error           any    `json:"error"`
+			} `json:"runData"`
+		} `json:"resultData"`
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("execution has no data")
+	}
+	if err := json.Unmarshal(data, &run); err != nil {
+		return nil, fmt.Errorf("failed to parse execution data: %w", err)
+	}
+	if len(run.ResultData.RunData) == 0 {
+		return nil, fmt.Errorf("execution has no run data, it may not have started or its data was pruned")
+	}
+
+	var timings []NodeTiming
+	for node, runs := range run.ResultData.RunData {
+		t := NodeTiming{Node: node, Runs: len(runs), Status: "success"}
+		for _, r := range runs {
+			d := time.Duration(r.ExecutionTime) * time.Millisecond
+			t.Total += d
+			t.Max = max(t.Max, d)
+			// Older instances leave executionStatus out and only record errors.
+			switch {
+			case r.ExecutionStatus != "" && r.ExecutionStatus != "success":
+				t.Status = r.ExecutionStatus
+			case r.ExecutionStatus == "" && r.Error != nil:
+				t.Status = "error"
+			}
+		}
+		timings = append(timings, t)
+	}
+	sort.Slice(timings, func(i, j int) bool {
+		if timings[i].Total != timings[j].Total {
+			return timings[i].Total > timings[j].Total
+		}
+		return timings[i].Node < timings[j].Node
+	})
+	return timings, nil
+}
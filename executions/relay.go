@@ -0,0 +1,275 @@
+package executions
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/brandon-kyle-bailey/n8nctl/utils"
+)
+
+// ID is an execution ID, which the n8n API returns as either a number or a string.
+type ID string
+
+func (id *ID) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*id = ID(s)
+		return nil
+	}
+	var n json.Number
+	if err := json.Unmarshal(data, &n); err != nil {
+		return err
+	}
+	*id = ID(n.String())
+	return nil
+}
+
+// After reports whether id is newer than other. Numeric IDs are compared as
+// numbers, anything else lexically.
+func (id ID) After(other ID) bool {
+	a, errA := strconv.ParseInt(string(id), 10, 64)
+	b, errB := strconv.ParseInt(string(other), 10, 64)
+	if errA == nil && errB == nil {
+		return a > b
+	}
+	return string(id) > string(other)
+}
+
+type Execution struct {
+	ID         ID              `json:"id"`
+	WorkflowID string          `json:"workflowId"`
+	Status     string          `json:"status"`
+	Mode       string          `json:"mode"`
+	Finished   bool            `json:"finished"`
+	StartedAt  time.Time       `json:"startedAt"`
+	StoppedAt  *time.Time      `json:"stoppedAt"`
+	Data       json.RawMessage `json:"data,omitempty"`
+}
+
+// Done reports whether exec has stopped, successfully or not, rather than
+// still running or waiting.
+func (e Execution) Done() bool {
+	return e.StoppedAt != nil && e.Status != "running" && e.Status != "new" && e.Status != "waiting"
+}
+
+type ExecutionList struct {
+	Data       []Execution `json:"data"`
+	NextCursor string      `json:"nextCursor"`
+}
+
+type RelayOptions struct {
+	Target   string
+	Interval time.Duration
+	// Fetch lists the most recent executions of the relayed workflow with their data.
+	Fetch func(query url.Values) ([]byte, error)
+}
+
+// webhookRequest is the request a webhook trigger node received, as recorded in its output.
+type webhookRequest struct {
+	Headers map[string]any `json:"headers"`
+	Query   map[string]any `json:"query"`
+	Body    any            `json:"body"`
+}
+
+// hopHeaders are not forwarded when replaying a request.
+var hopHeaders = map[string]bool{
+	"host": true, "content-length": true, "connection": true, "accept-encoding": true,
+	"transfer-encoding": true, "keep-alive": true, "upgrade": true,
+	"x-forwarded-for": true, "x-forwarded-host": true, "x-forwarded-proto": true, "x-real-ip": true,
+}
+
+// Relay polls a webhook workflow's executions and replays the request that
+// triggered each new execution to a local target, until ctx is cancelled.
+func Relay(ctx context.Context, opts RelayOptions) error {
+	// Only relay executions that start after the relay, not the existing history.
+	latest, err := fetchExecutions(opts.Fetch, nil)
+	if err != nil {
+		return err
+	}
+	var lastSeen ID
+	for _, exec := range latest {
+		if exec.ID.After(lastSeen) {
+			lastSeen = exec.ID
+		}
+	}
+	// relayed holds the executions after lastSeen replayed already, while an
+	// earlier one still runs and keeps lastSeen from advancing past them.
+	relayed := map[ID]bool{}
+
+	fmt.Fprintf(os.Stderr, "Relaying new executions to %s (Ctrl+C to stop)\n", opts.Target)
+	client := &http.Client{Timeout: 30 * time.Second}
+	ticker := time.NewTicker(opts.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		executions, err := fetchExecutions(opts.Fetch, &lastSeen)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Polling failed: %v\n", err)
+			continue
+		}
+		// The API lists newest first, replay in the order they happened.
+		// Executions still running have no data to replay yet, so lastSeen
+		// only advances up to the first of them.
+		running := false
+		for i := len(executions) - 1; i >= 0; i-- {
+			exec := executions[i]
+			if !exec.ID.After(lastSeen) {
+				continue
+			}
+			if !exec.Done() {
+				running = true
+				continue
+			}
+			if !relayed[exec.ID] {
+				replay(ctx, client, opts.Target, exec)
+				relayed[exec.ID] = true
+			}
+			if !running {
+				lastSeen = exec.ID
+				delete(relayed, exec.ID)
+			}
+		}
+	}
+}
+
+// fetchExecutions lists the executions after since, newest first, paging
+// back until it reaches since so bursts between polls are not dropped.
+// With since nil it lists only the latest page.
+func fetchExecutions(fetch func(url.Values) ([]byte, error), since *ID) ([]Execution, error) {
+	query := url.Values{"includeData": {"true"}, "limit": {"20"}}
+	var all []Execution
+	for {
+		data, err := fetch(query)
+		if err != nil {
+			return nil, err
+		}
+		var list ExecutionList
+		if err := json.Unmarshal(data, &list); err != nil {
+			return nil, fmt.Errorf("failed to parse executions: %w", err)
+		}
+		all = append(all, list.Data...)
+		if since == nil || list.NextCursor == "" || len(list.Data) == 0 || !list.Data[len(list.Data)-1].ID.After(*since) {
+			return all, nil
+		}
+		query.Set("cursor", list.NextCursor)
+	}
+}
+
+func replay(ctx context.Context, client *http.Client, target string, exec Execution) {
+	request, ok := findWebhookRequest(exec.Data)
+	if !ok {
+		fmt.Printf("#%s: no webhook request found in execution data, skipping\n", exec.ID)
+		return
+	}
+
+	var body io.Reader
+	method := http.MethodGet
+	if request.Body != nil {
+		if m, isMap := request.Body.(map[string]any); !isMap || len(m) > 0 {
+			method = http.MethodPost
+			if s, isString := request.Body.(string); isString {
+				body = strings.NewReader(s)
+			} else {
+				encoded, _ := json.Marshal(request.Body)
+				body = bytes.NewReader(encoded)
+			}
+		}
+	}
+
+	u, err := url.Parse(target)
+	if err != nil {
+		fmt.Printf("#%s: invalid target: %v\n", exec.ID, err)
+		return
+	}
+	query := u.Query()
+	for key, value := range request.Query {
+		query.Set(key, fmt.Sprint(value))
+	}
+	u.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		fmt.Printf("#%s: %v\n", exec.ID, err)
+		return
+	}
+	for key, value := range request.Headers {
+		if !hopHeaders[strings.ToLower(key)] {
+			req.Header.Set(key, fmt.Sprint(value))
+		}
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		fmt.Printf("#%s: %s %s failed: %v\n", exec.ID, method, u, err)
+		return
+	}
+	resp.Body.Close()
+	status := utils.Colorize(resp.Status, utils.ColorGreen)
+	if resp.StatusCode >= 400 {
+		status = utils.Colorize(resp.Status, utils.ColorRed)
+	}
+	fmt.Printf("#%s: %s %s -> %s\n", exec.ID, method, u, status)
+}
+
+// WebhookHeader returns the named header of the webhook request that started an execution.
+func WebhookHeader(data json.RawMessage, name string) (string, bool) {
+	request, ok := findWebhookRequest(data)
+	if !ok {
+		return "", false
+	}
+	value, ok := request.Headers[strings.ToLower(name)].(string)
+	return value, ok
+}
+
+// findWebhookRequest looks through an execution's run data for the output of
+// a webhook trigger, which records the headers, query and body it received.
+func findWebhookRequest(data json.RawMessage) (webhookRequest, bool) {
+	var run struct {
+		ResultData struct {
+			RunData map[string][]struct {
+				Data struct {
+					Main [][]struct {
+						JSON json.RawMessage `json:"json"`
+					} `json:"main"`
+				} `json:"data"`
+			} `json:"runData"`
+		} `json:"resultData"`
+	}
+	if len(data) == 0 || json.Unmarshal(data, &run) != nil {
+		return webhookRequest{}, false
+	}
+	for _, runs := range run.ResultData.RunData {
+		if len(runs) == 0 || len(runs[0].Data.Main) == 0 || len(runs[0].Data.Main[0]) == 0 {
+			continue
+		}
+		raw := runs[0].Data.Main[0][0].JSON
+		var fields map[string]json.RawMessage
+		if json.Unmarshal(raw, &fields) != nil {
+			continue
+		}
+		_, hasHeaders := fields["headers"]
+		_, hasWebhookURL := fields["webhookUrl"]
+		if !hasHeaders || !hasWebhookURL {
+			continue
+		}
+		var request webhookRequest
+		if json.Unmarshal(raw, &request) == nil {
+			return request, true
+		}
+	}
+	return webhookRequest{}, false
+}
@@ -0,0 +1,172 @@
+package executions
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+)
+
+// schemaNode accumulates the shapes seen at one position of the output.
+type schemaNode struct {
+	types       map[string]bool
+	objects     int
+	properties  map[string]*schemaNode
+	seen        map[string]int
+	items       *schemaNode
+	strings     int
+	dateStrings int
+}
+
+func newSchemaNode() *schemaNode {
+	return &schemaNode{types: map[string]bool{}}
+}
+
+func (n *schemaNode) add(value any) {
+	switch v := value.(type) {
+	case nil:
+		n.types["null"] = true
+	case bool:
+		n.types["boolean"] = true
+	case json.Number:
+		if strings.ContainsAny(v.String(), ".eE") {
+			n.types["number"] = true
+		} else {
+			n.types["integer"] = true
+		}
+	case string:
+		n.types["string"] = true
+		n.strings++
+		if _, err := time.Parse(time.RFC3339, v); err == nil {
+			n.dateStrings++
+		}
+	case []any:
+		n.types["array"] = true
+		if n.items == nil {
+			n.items = newSchemaNode()
+		}
+		for _, item := range v {
+			n.items.add(item)
+		}
+	case map[string]any:
+		n.types["object"] = true
+		n.objects++
+		if n.properties == nil {
+			n.properties = map[string]*schemaNode{}
+			n.seen = map[string]int{}
+		}
+		for key, item := range v {
+			if n.properties[key] == nil {
+				n.properties[key] = newSchemaNode()
+			}
+			n.properties[key].add(item)
+			n.seen[key]++
+		}
+	}
+}
+
+func (n *schemaNode) schema() map[string]any {
+	out := map[string]any{}
+	types := slices.Sorted(maps.Keys(n.types))
+	if n.types["integer"] && n.types["number"] {
+		types = slices.DeleteFunc(types, func(t string) bool { return t == "integer" })
+	}
+	switch len(types) {
+	case 0:
+	case 1:
+		out["type"] = types[0]
+	default:
+		out["type"] = types
+	}
+	if n.strings > 0 && n.dateStrings == n.strings {
+		out["format"] = "date-time"
+	}
+	if n.items != nil {
+		out["items"] = n.items.schema()
+	}
+	if n.properties != nil {
+		properties := map[string]any{}
+		var required []string
+		for key, child := range n.properties {
+			properties[key] = child.schema()
+			if n.seen[key] == n.objects {
+				required = append(required, key)
+			}
+		}
+		out["properties"] = properties
+		if len(required) > 0 {
+			slices.Sort(required)
+			out["required"] = required
+		}
+	}
+	return out
+}
+
+// OutputItems returns the JSON of the items produced by the last node that
+// ran in an execution, i.e. the workflow's final output.
+func OutputItems(data json.RawMessage) ([]json.RawMessage, error) {
+	var run struct {
+		ResultData struct {
+			LastNodeExecuted string `json:"lastNodeExecuted"`
+			RunData          map[string][]struct {
+				Data struct {
+					Main [][]struct {
+						JSON json.RawMessage `json:"json"`
+					} `json:"main"`
+				} `json:"data"`
+			} `json:"runData"`
+		} `json:"resultData"`
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("execution has no data")
+	}
+	if err := json.Unmarshal(data, &run); err != nil {
+		return nil, fmt.Errorf("failed to parse execution data: %w", err)
+	}
+	runs := run.ResultData.RunData[run.ResultData.LastNodeExecuted]
+	if len(runs) == 0 {
+		return nil, fmt.Errorf("no run data for last node %q", run.ResultData.LastNodeExecuted)
+	}
+	var items []json.RawMessage
+	for _, output := range runs[len(runs)-1].Data.Main {
+		for _, item := range output {
+			items = append(items, item.JSON)
+		}
+	}
+	return items, nil
+}
+
+// InferOutputSchema builds a JSON Schema describing the output items of the
+// given executions. It returns the schema and the number of items sampled.
+func InferOutputSchema(executions []Execution, title string) ([]byte, int, error) {
+	root := newSchemaNode()
+	sampled := 0
+	for _, exec := range executions {
+		items, err := OutputItems(exec.Data)
+		if err != nil {
+			continue
+		}
+		for _, raw := range items {
+			decoder := json.NewDecoder(bytes.NewReader(raw))
+			decoder.UseNumber()
+			var value any
+			if err := decoder.Decode(&value); err != nil {
+				continue
+			}
+			root.add(value)
+			sampled++
+		}
+	}
+	if sampled == 0 {
+		return nil, 0, fmt.Errorf("no output items found in %d executions", len(executions))
+	}
+
+	schema := root.schema()
+	schema["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	schema["title"] = title
+	out, err := json.MarshalIndent(schema, "", "  ")
+	return out, sampled, err
+}
@@ -0,0 +1,283 @@
+package executions
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Redacted replaces the values at the paths of a scrub file.
+const Redacted = "[REDACTED]"
+
+// ScrubFile is a file of rules for scrubbing execution data before sharing
+// it, such as pii.yaml:
+//
+//	paths:
+//	  - "**.headers.authorization"
+//	  - "data.resultData.runData.*.*.data.main.*.*.json.customer"
+//	patterns:
+//	  - email
+//	  - card
+//	  - name: order-id
+//	    regex: 'ORD-\d{8}'
+//
+// Paths are dot-separated keys from the execution down, where * matches any
+// one key or list index and ** any number of them; the values they reach
+// are replaced whole. Patterns are masked in every string, and are either
+// the name of a built-in pattern or a name with a regex.
+type ScrubFile struct {
+	Paths    []string       `yaml:"paths"`
+	Patterns []ScrubPattern `yaml:"patterns"`
+}
+
+// ScrubPattern is a pattern of a scrub file. Written as a plain string it
+// names a built-in pattern.
+type ScrubPattern struct {
+	Name    string `yaml:"name"`
+	Regex   string `yaml:"regex"`
+	Replace string `yaml:"replace"`
+}
+
+func (p *ScrubPattern) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		p.Name = node.Value
+		return nil
+	}
+	type plain ScrubPattern
+	return node.Decode((*plain)(p))
+}
+
+// builtinPattern is a pattern scrub files can name. check, when set,
+// confirms a match, keeping false positives such as order numbers that
+// look like card numbers.
+type builtinPattern struct {
+	regex string
+	check func(string) bool
+}
+
+var builtinPatterns = map[string]builtinPattern{
+	"email": {regex: `[A-Za-z0-9._%+-]+@[A-Za-z0-9-]+(?:\.[A-Za-z0-9-]+)*\.[A-Za-z]{2,}`},
+	"card":  {regex: `\b\d(?:[ -]?\d){12,18}\b`, check: luhn},
+	"token": {regex: strings.Join([]string{
+		`(?i)\bbearer\s+[A-Za-z0-9._~+/=-]+`,
+		`\beyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`,
+		`\b[sprk]k_(?:live|test)_[A-Za-z0-9]+`,
+		`\bgh[pousr]_[A-Za-z0-9]{20,}`,
+		`\bxox[abposr]-[A-Za-z0-9-]+`,
+		`\bAKIA[0-9A-Z]{16}\b`,
+	}, "|")},
+	"phone": {regex: `\+\d(?:[ -]?\d){7,14}\b`},
+}
+
+// BuiltinPatterns returns the names of the built-in patterns.
+func BuiltinPatterns() []string {
+	return slices.Sorted(maps.Keys(builtinPatterns))
+}
+
+// Scrubber redacts execution data by the rules of a scrub file, counting
+// what it redacted.
+type Scrubber struct {
+	paths    [][]string
+	patterns []scrubPattern
+	// Redacted counts the values replaced per rule: "path" or the name of
+	// a pattern.
+	Redacted map[string]int
+}
+
+type scrubPattern struct {
+	name    string
+	re      *regexp.Regexp
+	check   func(string) bool
+	replace string
+}
+
+// LoadScrubber reads and checks a scrub file.
+func LoadScrubber(path string) (*Scrubber, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var f ScrubFile
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	s, err := NewScrubber(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return s, nil
+}
+
+// NewScrubber returns a scrubber applying the rules of f.
+func NewScrubber(f ScrubFile) (*Scrubber, error) {
+	if len(f.Paths) == 0 && len(f.Patterns) == 0 {
+		return nil, fmt.Errorf("no paths or patterns to scrub")
+	}
+	s := &Scrubber{Redacted: map[string]int{}}
+	for _, path := range f.Paths {
+		if strings.TrimSpace(path) == "" {
+			return nil, fmt.Errorf("empty path")
+		}
+		s.paths = append(s.paths, strings.Split(path, "."))
+	}
+	for _, p := range f.Patterns {
+		if p.Name == "" {
+			return nil, fmt.Errorf("pattern %q has no name", p.Regex)
+		}
+		compiled := scrubPattern{name: p.Name, replace: p.Replace}
+		expr := p.Regex
+		if expr == "" {
+			builtin, ok := builtinPatterns[p.Name]
+			if !ok {
+				return nil, fmt.Errorf("unknown pattern %q, give it a regex or use one of %s", p.Name, strings.Join(BuiltinPatterns(), ", "))
+			}
+			expr, compiled.check = builtin.regex, builtin.check
+		}
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("pattern %s: %w", p.Name, err)
+		}
+		compiled.re = re
+		if compiled.replace == "" {
+			compiled.replace = "[REDACTED:" + p.Name + "]"
+		}
+		s.patterns = append(s.patterns, compiled)
+	}
+	return s, nil
+}
+
+// ScrubJSON returns the execution data redacted and indented.
+func (s *Scrubber) ScrubJSON(data []byte) ([]byte, error) {
+	var v any
+	dec := json.NewDecoder(bytes.NewReader(data))
+	// Numbers are kept as written, and scrubbed like strings.
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		return nil, fmt.Errorf("invalid execution JSON: %w", err)
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(s.scrub(v, nil)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// ScrubData returns the data field of an execution redacted, matching
+// paths from the execution as ScrubJSON does.
+func (s *Scrubber) ScrubData(data json.RawMessage) (json.RawMessage, error) {
+	if len(data) == 0 {
+		return data, nil
+	}
+	var v any
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		return nil, fmt.Errorf("invalid execution data: %w", err)
+	}
+	return json.Marshal(s.scrub(v, []string{"data"}))
+}
+
+// Summary describes what was redacted, e.g. "12 values (email 10, path 2)".
+func (s *Scrubber) Summary() string {
+	total := 0
+	var parts []string
+	for _, rule := range slices.Sorted(maps.Keys(s.Redacted)) {
+		total += s.Redacted[rule]
+		parts = append(parts, fmt.Sprintf("%s %d", rule, s.Redacted[rule]))
+	}
+	if total == 0 {
+		return "0 values"
+	}
+	return fmt.Sprintf("%d values (%s)", total, strings.Join(parts, ", "))
+}
+
+func (s *Scrubber) scrub(v any, path []string) any {
+	for _, pattern := range s.paths {
+		if matchPath(pattern, path) {
+			s.Redacted["path"]++
+			return Redacted
+		}
+	}
+	switch t := v.(type) {
+	case map[string]any:
+		for key, value := range t {
+			t[key] = s.scrub(value, append(path[:len(path):len(path)], key))
+		}
+	case []any:
+		for i, value := range t {
+			t[i] = s.scrub(value, append(path[:len(path):len(path)], strconv.Itoa(i)))
+		}
+	case string:
+		return s.scrubString(t)
+	case json.Number:
+		if scrubbed := s.scrubString(t.String()); scrubbed != t.String() {
+			return scrubbed
+		}
+	}
+	return v
+}
+
+func (s *Scrubber) scrubString(value string) string {
+	for _, p := range s.patterns {
+		value = p.re.ReplaceAllStringFunc(value, func(match string) string {
+			if p.check != nil && !p.check(match) {
+				return match
+			}
+			s.Redacted[p.name]++
+			return p.replace
+		})
+	}
+	return value
+}
+
+// matchPath reports whether path matches pattern, whose * segments match
+// any one segment and ** segments any number.
+func matchPath(pattern, path []string) bool {
+	if len(pattern) == 0 {
+		return len(path) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(path); i++ {
+			if matchPath(pattern[1:], path[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(path) == 0 || (pattern[0] != "*" && pattern[0] != path[0]) {
+		return false
+	}
+	return matchPath(pattern[1:], path[1:])
+}
+
+// luhn reports whether the digits of number pass the Luhn check card
+// numbers carry.
+func luhn(number string) bool {
+	sum, digits := 0, 0
+	for i := len(number) - 1; i >= 0; i-- {
+		c := number[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if digits%2 == 1 {
+			if d *= 2; d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		digits++
+	}
+	return digits >= 13 && sum%10 == 0
+}
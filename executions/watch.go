@@ -0,0 +1,111 @@
+package executions
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/brandon-kyle-bailey/n8nctl/utils"
+)
+
+type WatchOptions struct {
+	// Follow keeps polling for new executions instead of exiting after
+	// printing the recent ones.
+	Follow   bool
+	Interval time.Duration
+	// Recent is how many past executions to print before following.
+	Recent int
+	// Fetch lists the most recent executions, newest first.
+	Fetch func(query url.Values) ([]byte, error)
+	Out   io.Writer
+}
+
+// Watch prints the most recent executions and, with Follow, keeps printing
+// executions as they start and again when they finish, until ctx is done.
+func Watch(ctx context.Context, opts WatchOptions) error {
+	list, err := fetchRecent(opts.Fetch, max(opts.Recent, 50))
+	if err != nil {
+		return err
+	}
+	// Remember the status of everything in view, so only changes print later.
+	printed := map[ID]string{}
+	for i, exec := range list {
+		if i >= len(list)-opts.Recent {
+			printExecution(opts.Out, exec)
+		}
+		printed[exec.ID] = exec.Status
+	}
+	if !opts.Follow {
+		return nil
+	}
+
+	fmt.Fprintln(os.Stderr, "Watching for new executions (Ctrl+C to stop)")
+	ticker := time.NewTicker(opts.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		list, err := fetchRecent(opts.Fetch, 50)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Polling failed: %v\n", err)
+			continue
+		}
+		for _, exec := range list {
+			if status, ok := printed[exec.ID]; ok && status == exec.Status {
+				continue
+			}
+			printExecution(opts.Out, exec)
+			printed[exec.ID] = exec.Status
+		}
+	}
+}
+
+// fetchRecent returns up to limit of the latest executions, oldest first.
+func fetchRecent(fetch func(url.Values) ([]byte, error), limit int) ([]Execution, error) {
+	data, err := fetch(url.Values{"limit": {fmt.Sprint(limit)}})
+	if err != nil {
+		return nil, err
+	}
+	var list ExecutionList
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("failed to parse executions: %w", err)
+	}
+	sort.SliceStable(list.Data, func(i, j int) bool { return list.Data[j].ID.After(list.Data[i].ID) })
+	return list.Data, nil
+}
+
+// statusColors marks how an execution ended, or that it has not yet.
+var statusColors = map[string]string{
+	"success":  utils.ColorGreen,
+	"error":    utils.ColorRed,
+	"crashed":  utils.ColorRed,
+	"running":  utils.ColorCyan,
+	"new":      utils.ColorCyan,
+	"waiting":  utils.ColorYellow,
+	"canceled": utils.ColorYellow,
+}
+
+func printExecution(w io.Writer, exec Execution) {
+	status := fmt.Sprintf("%-8s", exec.Status)
+	if color, ok := statusColors[exec.Status]; ok {
+		status = utils.Colorize(status, color)
+	}
+	duration := "-"
+	if exec.StoppedAt != nil {
+		duration = exec.StoppedAt.Sub(exec.StartedAt).Round(time.Millisecond).String()
+	}
+	fmt.Fprintf(w, "%s  #%-6s %s  %-8s  workflow %s  (%s)\n",
+		exec.StartedAt.Local().Format("2006-01-02 15:04:05"), exec.ID, status, duration, exec.WorkflowID, exec.Mode)
+}
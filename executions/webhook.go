@@ -0,0 +1,144 @@
+// Package executions provides local tooling around n8n workflow executions.
+package executions
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+type AwaitOptions struct {
+	Port    int
+	Path    string
+	Match   map[string]string
+	Timeout time.Duration
+}
+
+// maxCallbackBody bounds how much of a callback request body is read.
+const maxCallbackBody = 10 << 20
+
+// AwaitWebhook starts a temporary HTTP listener and blocks until a JSON
+// callback matching every condition in opts.Match arrives, returning its body,
+// or ctx is cancelled. Callbacks that do not match are acknowledged and ignored.
+func AwaitWebhook(ctx context.Context, opts AwaitOptions) ([]byte, error) {
+	if opts.Path == "" {
+		opts.Path = "/"
+	}
+	ln, err := net.Listen("tcp", fmt.Sprintf(":%d", opts.Port))
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on port %d: %w", opts.Port, err)
+	}
+
+	received := make(chan []byte, 1)
+	mux := http.NewServeMux()
+	mux.HandleFunc(opts.Path, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost && r.Method != http.MethodPut {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxCallbackBody))
+		if err != nil {
+			http.Error(w, "failed to read body", http.StatusBadRequest)
+			return
+		}
+		payload, err := decodePayload(body)
+		if err != nil {
+			http.Error(w, "expected a JSON body", http.StatusBadRequest)
+			return
+		}
+		if !matchesAll(payload, opts.Match) {
+			fmt.Fprintf(os.Stderr, "Ignoring callback from %s that does not match\n", r.RemoteAddr)
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		select {
+		case received <- body:
+		default:
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+
+	srv := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go srv.Serve(ln)
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(ctx)
+	}()
+
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+
+	fmt.Fprintf(os.Stderr, "Waiting for callback on http://0.0.0.0:%d%s ...\n", opts.Port, opts.Path)
+	select {
+	case body := <-received:
+		return body, nil
+	case <-ctx.Done():
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return nil, fmt.Errorf("no matching callback received within %s", opts.Timeout)
+		}
+		return nil, fmt.Errorf("interrupted while waiting for callback")
+	}
+}
+
+// decodePayload decodes a JSON callback body, keeping numbers as written so
+// they compare as such: as float64, 1000000 would print as 1e+06.
+func decodePayload(body []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var payload any
+	if err := dec.Decode(&payload); err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, fmt.Errorf("unexpected data after the JSON value")
+	}
+	return payload, nil
+}
+
+// matchesAll reports whether every key=value condition holds for payload.
+// Keys are dotted paths (e.g. execution.status); when a path resolves to an
+// object, its id or name is compared instead, so workflow=X matches either.
+func matchesAll(payload any, conditions map[string]string) bool {
+	for path, want := range conditions {
+		if !matches(lookupPath(payload, path), want) {
+			return false
+		}
+	}
+	return true
+}
+
+func matches(value any, want string) bool {
+	switch v := value.(type) {
+	case nil:
+		return false
+	case map[string]any:
+		return matches(v["id"], want) || matches(v["name"], want)
+	case json.Number:
+		return v.String() == want
+	default:
+		return fmt.Sprint(v) == want
+	}
+}
+
+func lookupPath(value any, path string) any {
+	for part := range strings.SplitSeq(path, ".") {
+		obj, ok := value.(map[string]any)
+		if !ok {
+			return nil
+		}
+		value = obj[part]
+	}
+	return value
+}
@@ -0,0 +1,27 @@
+package executions
+
+import "testing"
+
+func TestMatchesAllComparesNumbersAsWritten(t *testing.T) {
+	payload, err := decodePayload([]byte(`{"execution": {"id": 1000000, "status": "success", "retries": 0.5}, "workflow": {"id": "42", "name": "Nightly"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		conditions map[string]string
+		want       bool
+	}{
+		{map[string]string{"execution.id": "1000000"}, true},
+		{map[string]string{"execution.id": "1e+06"}, false},
+		{map[string]string{"execution.retries": "0.5"}, true},
+		{map[string]string{"execution.status": "success", "workflow": "Nightly"}, true},
+		{map[string]string{"workflow": "42"}, true},
+		{map[string]string{"execution.status": "error"}, false},
+		{map[string]string{"execution.missing": "1"}, false},
+	}
+	for _, tt := range tests {
+		if got := matchesAll(payload, tt.conditions); got != tt.want {
+			t.Errorf("matchesAll(%v) = %v, want %v", tt.conditions, got, tt.want)
+		}
+	}
+}
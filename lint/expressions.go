@@ -0,0 +1,119 @@
+package lint
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// nodeReferencePattern matches the ways expressions refer to other nodes:
+// $('Node'), $node["Node"] and $items("Node").
+var nodeReferencePattern = regexp.MustCompile(`\$(?:\(|items\(|node\[)\s*(?:'([^']*)'|"([^"]*)"|` + "`([^`$]*)`" + `)`)
+
+func expressionSyntax(wf Workflow, report reporter) {
+	stringParameters(wf, func(node Node, path []any, value string) {
+		if _, err := parseExpressions(value); err != nil {
+			report(pointer(path...), "expression in parameter %s of node %q: %v", parameterName(path[3:]), node.Name, err)
+		}
+	})
+}
+
+func unknownExpressionNodes(wf Workflow, report reporter) {
+	nodes := wf.nodeNames()
+	stringParameters(wf, func(node Node, path []any, value string) {
+		expressions, _ := parseExpressions(value)
+		for _, expression := range expressions {
+			for _, match := range nodeReferencePattern.FindAllStringSubmatch(expression, -1) {
+				name := match[1] + match[2] + match[3]
+				if !nodes[name] {
+					report(pointer(path...), "expression in parameter %s of node %q refers to node %q, which does not exist",
+						parameterName(path[3:]), node.Name, name)
+				}
+			}
+		}
+	})
+}
+
+// parseExpressions returns the code of the {{ ... }} expressions of an
+// expression parameter, which starts with "=". Other values have none. The
+// code is checked for balanced brackets and terminated strings, and the
+// expressions read before an error are returned with it.
+func parseExpressions(value string) ([]string, error) {
+	text, ok := strings.CutPrefix(value, "=")
+	if !ok {
+		return nil, nil
+	}
+	var expressions []string
+	for {
+		start := strings.Index(text, "{{")
+		if start < 0 {
+			return expressions, nil
+		}
+		code, rest, err := scanExpression(text[start+2:])
+		if err != nil {
+			return expressions, err
+		}
+		if strings.TrimSpace(code) == "" {
+			return expressions, fmt.Errorf("{{ }} is empty")
+		}
+		expressions = append(expressions, code)
+		text = rest
+	}
+}
+
+// scanExpression reads the code of an expression up to its closing }},
+// returning the code and the text after it.
+func scanExpression(text string) (code, rest string, err error) {
+	closing := map[byte]byte{')': '(', ']': '[', '}': '{'}
+	var open []byte
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch c {
+		case '\'', '"', '`':
+			end := stringEnd(text, i)
+			if end < 0 {
+				return "", "", fmt.Errorf("unterminated string starting with %c", c)
+			}
+			i = end
+		case '(', '[', '{':
+			open = append(open, c)
+		case ')', ']', '}':
+			if len(open) == 0 {
+				if c == '}' && i+1 < len(text) && text[i+1] == '}' {
+					return text[:i], text[i+2:], nil
+				}
+				return "", "", fmt.Errorf("unbalanced %c", c)
+			}
+			if top := open[len(open)-1]; top != closing[c] {
+				if c == '}' && i+1 < len(text) && text[i+1] == '}' {
+					return "", "", fmt.Errorf("unclosed %c", top)
+				}
+				return "", "", fmt.Errorf("%c closes %c", c, top)
+			}
+			open = open[:len(open)-1]
+		}
+	}
+	if len(open) > 0 {
+		return "", "", fmt.Errorf("unclosed %c", open[len(open)-1])
+	}
+	return "", "", fmt.Errorf("{{ is not closed with }}")
+}
+
+// stringEnd returns the index of the quote ending the string literal that
+// starts at start, or -1 if it does not end.
+func stringEnd(text string, start int) int {
+	quote := text[start]
+	for i := start + 1; i < len(text); i++ {
+		switch text[i] {
+		case '\\':
+			i++
+		case quote:
+			return i
+		case '\n':
+			if quote != '`' {
+				return -1
+			}
+		}
+	}
+	return -1
+}
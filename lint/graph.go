@@ -0,0 +1,195 @@
+package lint
+
+import (
+	"slices"
+	"strings"
+)
+
+// edge is a connection from an output of one node to an input of another.
+type edge struct {
+	from, to string
+	typ      string
+	output   int
+	input    int
+	path     string
+}
+
+// edges lists the connections of the workflow in a stable order.
+func (wf Workflow) edges() []edge {
+	var edges []edge
+	for _, source := range sortedKeys(wf.Connections) {
+		for _, typ := range sortedKeys(wf.Connections[source]) {
+			for output, endpoints := range wf.Connections[source][typ] {
+				for i, to := range endpoints {
+					edges = append(edges, edge{
+						from: source, to: to.Node, typ: typ, output: output, input: to.Index,
+						path: pointer("connections", source, typ, output, i),
+					})
+				}
+			}
+		}
+	}
+	return edges
+}
+
+// connectionIndexes finds connections to inputs that cannot exist.
+func connectionIndexes(wf Workflow, report reporter) {
+	for _, e := range wf.edges() {
+		if e.input < 0 {
+			report(e.path+"/index", "%q connects to input %d of %q, input indexes start at 0", e.from, e.input, e.to)
+		}
+	}
+}
+
+// triggerInputs finds connections into triggers, which start workflows and
+// take no input, and workflows with more than one manual trigger, which the
+// editor cannot run.
+func triggerInputs(wf Workflow, report reporter) {
+	byName := map[string]Node{}
+	manual := 0
+	for i, node := range wf.Nodes {
+		byName[node.Name] = node
+		if strings.HasSuffix(node.Type, ".manualTrigger") {
+			if manual++; manual == 2 {
+				report(pointer("nodes", i), "node %q is a second manual trigger, a workflow can have only one", node.Name)
+			}
+		}
+	}
+	for _, e := range wf.edges() {
+		if to, ok := byName[e.to]; ok && e.typ == "main" && isTrigger(to) {
+			report(e.path, "%q connects to the trigger %q, triggers take no input", e.from, e.to)
+		}
+	}
+}
+
+// loopBreakers are the node types that can end a loop: looping over items
+// in batches, or taking another branch once a condition changes.
+var loopBreakers = []string{".splitInBatches", ".if", ".switch", ".filter"}
+
+// endlessLoops finds cycles of main connections without a node that could
+// end them, which would run until the execution times out.
+func endlessLoops(wf Workflow, report reporter) {
+	next := map[string][]string{}
+	for _, e := range wf.edges() {
+		if e.typ == "main" {
+			next[e.from] = append(next[e.from], e.to)
+		}
+	}
+	types := map[string]string{}
+	index := map[string]int{}
+	for i, node := range wf.Nodes {
+		types[node.Name], index[node.Name] = node.Type, i
+	}
+	for _, cycle := range cycles(wf.Nodes, next) {
+		breaks := slices.ContainsFunc(cycle, func(name string) bool {
+			return slices.ContainsFunc(loopBreakers, func(suffix string) bool { return strings.HasSuffix(types[name], suffix) })
+		})
+		if !breaks {
+			report(pointer("nodes", index[cycle[0]]), "nodes %s form a loop that nothing ends, add a Loop Over Items or If node to it",
+				strings.Join(quoted(cycle), ", "))
+		}
+	}
+}
+
+// cycles returns the strongly connected components of the graph that
+// contain a cycle, each starting at its first node in the workflow.
+func cycles(nodes []Node, next map[string][]string) [][]string {
+	index, low := map[string]int{}, map[string]int{}
+	onStack := map[string]bool{}
+	var stack []string
+	var found [][]string
+	var visit func(name string)
+	visit = func(name string) {
+		index[name], low[name] = len(index), len(index)
+		stack = append(stack, name)
+		onStack[name] = true
+		for _, to := range next[name] {
+			if _, seen := index[to]; !seen {
+				visit(to)
+				low[name] = min(low[name], low[to])
+			} else if onStack[to] {
+				low[name] = min(low[name], index[to])
+			}
+		}
+		if low[name] != index[name] {
+			return
+		}
+		var component []string
+		for {
+			top := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[top] = false
+			component = append(component, top)
+			if top == name {
+				break
+			}
+		}
+		if len(component) > 1 || slices.Contains(next[name], name) {
+			found = append(found, component)
+		}
+	}
+	for _, node := range nodes {
+		if _, seen := index[node.Name]; !seen {
+			visit(node.Name)
+		}
+	}
+	// Order each cycle like the nodes of the workflow, for stable messages.
+	order := map[string]int{}
+	for i, node := range nodes {
+		order[node.Name] = i
+	}
+	for _, component := range found {
+		slices.SortFunc(component, func(a, b string) int { return order[a] - order[b] })
+	}
+	return found
+}
+
+// unreachableNodes finds connected nodes that no trigger leads to, which
+// never run. Sub-nodes, such as the model of an AI agent, are reached
+// through the node they serve.
+func unreachableNodes(wf Workflow, report reporter) {
+	var queue []string
+	reached := map[string]bool{}
+	for _, node := range wf.Nodes {
+		if isTrigger(node) {
+			queue = append(queue, node.Name)
+			reached[node.Name] = true
+		}
+	}
+	if len(queue) == 0 {
+		return
+	}
+	next := map[string][]string{}
+	connected := map[string]bool{}
+	for _, e := range wf.edges() {
+		next[e.from] = append(next[e.from], e.to)
+		if e.typ != "main" {
+			next[e.to] = append(next[e.to], e.from)
+		}
+		connected[e.from], connected[e.to] = true, true
+	}
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		for _, to := range next[name] {
+			if !reached[to] {
+				reached[to] = true
+				queue = append(queue, to)
+			}
+		}
+	}
+	for i, node := range wf.Nodes {
+		// Nodes without any connection are reported as disconnected.
+		if !reached[node.Name] && connected[node.Name] {
+			report(pointer("nodes", i), "no trigger leads to node %q, so it never runs", node.Name)
+		}
+	}
+}
+
+func quoted(names []string) []string {
+	out := make([]string, len(names))
+	for i, name := range names {
+		out[i] = `"` + name + `"`
+	}
+	return out
+}
@@ -0,0 +1,365 @@
+// Package lint checks rendered workflows for problems before they are deployed.
+package lint
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Severity tells whether a finding blocks a deploy.
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+	// SeverityOff disables a rule in Overrides.
+	SeverityOff Severity = "off"
+)
+
+// Overrides changes the severity of rules by ID, as the lint section of
+// .n8nctl.yaml does.
+var Overrides = map[string]Severity{}
+
+// Finding is a problem found in a workflow file. Path is a JSON pointer to
+// the offending value of the rendered workflow, e.g. /nodes/2/name. Line and
+// Column are 1-based and zero when the position is unknown.
+type Finding struct {
+	File     string   `json:"file"`
+	Path     string   `json:"path,omitempty"`
+	Line     int      `json:"line,omitempty"`
+	Column   int      `json:"column,omitempty"`
+	Rule     string   `json:"rule"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+}
+
+// Position formats the location as file:line:column, leaving out what is unknown.
+func (f Finding) Position() string {
+	switch {
+	case f.Line > 0 && f.Column > 0:
+		return fmt.Sprintf("%s:%d:%d", f.File, f.Line, f.Column)
+	case f.Line > 0:
+		return fmt.Sprintf("%s:%d", f.File, f.Line)
+	}
+	return f.File
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("%s: %s: %s (%s)", f.Position(), f.Severity, f.Message, f.Rule)
+}
+
+// HasErrors reports whether any finding is an error.
+func HasErrors(findings []Finding) bool {
+	for _, f := range findings {
+		if f.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// Rules describes every rule findings can come from, by ID.
+var Rules = map[string]string{
+	"render":                   "The workflow file renders to JSON",
+	"structure":                "The workflow has the fields the n8n API requires",
+	"duplicate-node-name":      "Node names are unique within a workflow",
+	"unknown-connection-node":  "Connections only refer to nodes of the workflow",
+	"disconnected-node":        "Every node is connected to another node",
+	"missing-trigger":          "The workflow has a trigger node that starts it",
+	"empty-parameter":          "Node parameters are not left empty",
+	"expression-syntax":        "Expressions ={{ ... }} in node parameters are well-formed",
+	"unknown-expression-node":  "Expressions only refer to nodes of the workflow",
+	"unknown-node-type":        "Node types are installed on the instance",
+	"unsupported-type-version": "Node type versions are supported by the instance",
+	"unknown-parameter":        "Node parameters exist in the node type of the instance",
+	"missing-parameter":        "Required node parameters are set",
+}
+
+// Workflow is the part of a rendered workflow the checks look at.
+type Workflow struct {
+	Name        string                             `json:"name"`
+	Nodes       []Node                             `json:"nodes"`
+	Connections map[string]map[string][][]Endpoint `json:"connections"`
+}
+
+type Node struct {
+	Name        string         `json:"name"`
+	Type        string         `json:"type"`
+	TypeVersion float64        `json:"typeVersion"`
+	Parameters  map[string]any `json:"parameters"`
+}
+
+// Endpoint is the receiving end of a connection.
+type Endpoint struct {
+	Node  string `json:"node"`
+	Type  string `json:"type"`
+	Index int    `json:"index"`
+}
+
+// Validate checks that body is a workflow the n8n API accepts: a name, nodes
+// with a name and type, and connections between them.
+func Validate(file string, body []byte) []Finding {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return []Finding{{File: file, Rule: "structure", Severity: SeverityError, Message: fmt.Sprintf("not a JSON object: %v", err)}}
+	}
+	var findings []Finding
+	report := func(path, format string, args ...any) {
+		findings = append(findings, Finding{File: file, Path: path, Rule: "structure", Severity: SeverityError, Message: fmt.Sprintf(format, args...)})
+	}
+	for _, field := range []string{"name", "nodes", "connections"} {
+		if _, ok := raw[field]; !ok {
+			report("", "missing required field %q", field)
+		}
+	}
+	if len(findings) > 0 {
+		return findings
+	}
+	var wf Workflow
+	if err := json.Unmarshal(body, &wf); err != nil {
+		report("", "invalid workflow: %v", err)
+		return findings
+	}
+	if wf.Name == "" {
+		report("/name", "name is empty")
+	}
+	for i, node := range wf.Nodes {
+		if node.Name == "" {
+			report(pointer("nodes", i), "node %d has no name", i+1)
+		}
+		if node.Type == "" {
+			report(pointer("nodes", i), "node %q has no type", node.Name)
+		}
+	}
+	return findings
+}
+
+// Lint validates body and then runs the rules on the workflow, which find
+// mistakes the API would accept but that break the workflow in the editor
+// or at runtime. Overrides change the severity of the findings.
+func Lint(file string, body []byte) []Finding {
+	findings := Validate(file, body)
+	if HasErrors(findings) {
+		return findings
+	}
+	var wf Workflow
+	json.Unmarshal(body, &wf)
+	return append(findings, run(file, wf, rules)...)
+}
+
+// run runs rules on the workflow of file, with the severities Overrides
+// sets.
+func run(file string, wf Workflow, rules []rule) []Finding {
+	var findings []Finding
+	for _, r := range rules {
+		severity := r.severity
+		if override, ok := Overrides[r.id]; ok {
+			severity = override
+		}
+		if severity == SeverityOff {
+			continue
+		}
+		r.check(wf, func(path, format string, args ...any) {
+			findings = append(findings, Finding{File: file, Path: path, Rule: r.id, Severity: severity, Message: fmt.Sprintf(format, args...)})
+		})
+	}
+	return findings
+}
+
+// reporter records a finding of a rule at a JSON pointer.
+type reporter func(path, format string, args ...any)
+
+// rule is a check of Lint, with the severity of its findings unless
+// Overrides changes it.
+type rule struct {
+	id       string
+	severity Severity
+	check    func(wf Workflow, report reporter)
+}
+
+var rules = []rule{
+	{"duplicate-node-name", SeverityError, duplicateNodeNames},
+	{"unknown-connection-node", SeverityError, unknownConnectionNodes},
+	{"connection-index", SeverityError, connectionIndexes},
+	{"trigger-input", SeverityError, triggerInputs},
+	{"endless-loop", SeverityError, endlessLoops},
+	{"unreachable-node", SeverityWarning, unreachableNodes},
+	{"disconnected-node", SeverityWarning, disconnectedNodes},
+	{"missing-trigger", SeverityWarning, missingTrigger},
+	{"empty-parameter", SeverityWarning, emptyParameters},
+	{"expression-syntax", SeverityError, expressionSyntax},
+	{"unknown-expression-node", SeverityError, unknownExpressionNodes},
+}
+
+// CheckOverrides returns an error for overrides of rules that do not exist
+// or to unknown severities.
+func CheckOverrides(overrides map[string]Severity) error {
+	for id, severity := range overrides {
+		if _, ok := Rules[id]; !ok || id == "render" || id == "structure" {
+			return fmt.Errorf("unknown lint rule %q", id)
+		}
+		if severity != SeverityError && severity != SeverityWarning && severity != SeverityOff {
+			return fmt.Errorf("unknown severity %q of lint rule %s, expected error, warning or off", severity, id)
+		}
+	}
+	return nil
+}
+
+func duplicateNodeNames(wf Workflow, report reporter) {
+	seen := map[string]bool{}
+	for i, node := range wf.Nodes {
+		if seen[node.Name] {
+			report(pointer("nodes", i, "name"), "node name %q is used more than once", node.Name)
+		}
+		seen[node.Name] = true
+	}
+}
+
+func unknownConnectionNodes(wf Workflow, report reporter) {
+	nodes := wf.nodeNames()
+	for _, source := range sortedKeys(wf.Connections) {
+		if !nodes[source] {
+			report(pointer("connections", source), "connections refer to node %q, which does not exist", source)
+		}
+		for _, typ := range sortedKeys(wf.Connections[source]) {
+			for output, endpoints := range wf.Connections[source][typ] {
+				for i, to := range endpoints {
+					if !nodes[to.Node] {
+						report(pointer("connections", source, typ, output, i, "node"),
+							"%q connects to node %q, which does not exist", source, to.Node)
+					}
+				}
+			}
+		}
+	}
+}
+
+// disconnectedNodes finds nodes that neither receive nor send data, which
+// never run. Sticky notes are not connected by nature.
+func disconnectedNodes(wf Workflow, report reporter) {
+	connected := map[string]bool{}
+	for source, types := range wf.Connections {
+		for _, outputs := range types {
+			for _, endpoints := range outputs {
+				for _, to := range endpoints {
+					connected[source], connected[to.Node] = true, true
+				}
+			}
+		}
+	}
+	if len(wf.Nodes) < 2 {
+		return
+	}
+	for i, node := range wf.Nodes {
+		if !connected[node.Name] && !isStickyNote(node) {
+			report(pointer("nodes", i), "node %q is not connected to any other node, so it never runs", node.Name)
+		}
+	}
+}
+
+// missingTrigger finds workflows without a trigger, which only run when
+// started from the editor or the API.
+func missingTrigger(wf Workflow, report reporter) {
+	for _, node := range wf.Nodes {
+		if isTrigger(node) {
+			return
+		}
+	}
+	if len(wf.Nodes) > 0 {
+		report("/nodes", "the workflow has no trigger node, so nothing starts it")
+	}
+}
+
+// emptyParameters finds parameters set to an empty string, which usually
+// is a value that was never filled in.
+func emptyParameters(wf Workflow, report reporter) {
+	stringParameters(wf, func(node Node, path []any, value string) {
+		if strings.TrimSpace(value) == "" {
+			report(pointer(path...), "parameter %s of node %q is empty", parameterName(path[3:]), node.Name)
+		}
+	})
+}
+
+// stringParameters calls fn with every string parameter of the nodes, at
+// any depth, and its path from the workflow. Sticky notes are left out.
+func stringParameters(wf Workflow, fn func(node Node, path []any, value string)) {
+	var walk func(node Node, value any, path []any)
+	walk = func(node Node, value any, path []any) {
+		switch v := value.(type) {
+		case string:
+			fn(node, path, v)
+		case map[string]any:
+			for _, key := range sortedKeys(v) {
+				walk(node, v[key], append(slices.Clip(path), key))
+			}
+		case []any:
+			for i, item := range v {
+				walk(node, item, append(slices.Clip(path), i))
+			}
+		}
+	}
+	for i, node := range wf.Nodes {
+		if !isStickyNote(node) {
+			walk(node, node.Parameters, []any{"nodes", i, "parameters"})
+		}
+	}
+}
+
+// parameterName formats the path of a parameter within its node's
+// parameters, e.g. options.headers[0].name.
+func parameterName(path []any) string {
+	var b strings.Builder
+	for _, part := range path {
+		if i, ok := part.(int); ok {
+			fmt.Fprintf(&b, "[%d]", i)
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteByte('.')
+		}
+		fmt.Fprint(&b, part)
+	}
+	return b.String()
+}
+
+func (wf Workflow) nodeNames() map[string]bool {
+	names := make(map[string]bool, len(wf.Nodes))
+	for _, node := range wf.Nodes {
+		names[node.Name] = true
+	}
+	return names
+}
+
+// isTrigger reports whether a node starts the workflow, such as a schedule,
+// webhook or app trigger, the trigger of sub-workflows or the legacy start
+// node.
+func isTrigger(node Node) bool {
+	typ := strings.ToLower(node.Type)
+	return strings.HasSuffix(typ, "trigger") || strings.HasSuffix(typ, ".webhook") || strings.HasSuffix(typ, ".start")
+}
+
+func isStickyNote(node Node) bool {
+	return strings.HasSuffix(node.Type, ".stickyNote")
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// pointer builds a JSON pointer from object keys and array indexes.
+func pointer(parts ...any) string {
+	var b strings.Builder
+	for _, part := range parts {
+		b.WriteByte('/')
+		b.WriteString(strings.NewReplacer("~", "~0", "/", "~1").Replace(fmt.Sprint(part)))
+	}
+	return b.String()
+}
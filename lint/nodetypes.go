@@ -0,0 +1,254 @@
+package lint
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// NodeType describes a version of a node type as the instance serves it in
+// /types/nodes.json.
+type NodeType struct {
+	Name       string     `json:"name"`
+	Versions   []float64  `json:"-"`
+	Properties []Property `json:"properties"`
+	// Inputs and Outputs list the connections of the node, unless they are
+	// expressions that depend on its parameters.
+	Inputs  json.RawMessage `json:"inputs"`
+	Outputs json.RawMessage `json:"outputs"`
+}
+
+// count returns the number of inputs or outputs of main connections, and
+// whether it is fixed.
+func count(connections json.RawMessage) (int, bool) {
+	var list []any
+	if err := json.Unmarshal(connections, &list); err != nil {
+		return 0, false
+	}
+	n := 0
+	for _, c := range list {
+		switch c := c.(type) {
+		case string:
+			if c == "main" {
+				n++
+			}
+		case map[string]any:
+			if c["type"] == "main" {
+				n++
+			}
+		}
+	}
+	return n, true
+}
+
+// Property is a parameter of a node type.
+type Property struct {
+	Name           string          `json:"name"`
+	Required       bool            `json:"required"`
+	Default        any             `json:"default"`
+	DisplayOptions *DisplayOptions `json:"displayOptions"`
+}
+
+// DisplayOptions show or hide a parameter depending on the values of other
+// parameters, by name, and of the node's version, as "@version".
+type DisplayOptions struct {
+	Show map[string][]any `json:"show"`
+	Hide map[string][]any `json:"hide"`
+}
+
+// NodeTypes are the node types of an instance by name, with one entry per
+// set of versions sharing a description.
+type NodeTypes map[string][]NodeType
+
+// ParseNodeTypes parses the node type descriptions of /types/nodes.json.
+func ParseNodeTypes(data []byte) (NodeTypes, error) {
+	var list []struct {
+		NodeType
+		Version json.RawMessage `json:"version"`
+	}
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("invalid node types: %w", err)
+	}
+	types := NodeTypes{}
+	for _, item := range list {
+		nt := item.NodeType
+		var one float64
+		if err := json.Unmarshal(item.Version, &one); err == nil {
+			nt.Versions = []float64{one}
+		} else if err := json.Unmarshal(item.Version, &nt.Versions); err != nil {
+			return nil, fmt.Errorf("invalid version of node type %s: %s", nt.Name, item.Version)
+		}
+		types[nt.Name] = append(types[nt.Name], nt)
+	}
+	return types, nil
+}
+
+// version returns the description of version v of the node type name.
+func (t NodeTypes) version(name string, v float64) (NodeType, bool) {
+	for _, nt := range t[name] {
+		if slices.Contains(nt.Versions, v) {
+			return nt, true
+		}
+	}
+	return NodeType{}, false
+}
+
+func (t NodeTypes) versions(name string) []string {
+	var all []string
+	for _, nt := range t[name] {
+		for _, v := range nt.Versions {
+			all = append(all, strconv.FormatFloat(v, 'f', -1, 64))
+		}
+	}
+	return all
+}
+
+// CheckNodeTypes checks the nodes of body against the node types installed
+// on an instance: that their type and typeVersion exist there, that their
+// parameters are those of the type, and that the required ones are set.
+func CheckNodeTypes(file string, body []byte, types NodeTypes) []Finding {
+	var wf Workflow
+	if err := json.Unmarshal(body, &wf); err != nil {
+		return nil
+	}
+	return run(file, wf, []rule{
+		{"unknown-node-type", SeverityWarning, func(wf Workflow, report reporter) {
+			for i, node := range wf.Nodes {
+				if len(types[node.Type]) == 0 {
+					report(pointer("nodes", i, "type"), "node type %s of node %q is not installed on the instance", node.Type, node.Name)
+				}
+			}
+		}},
+		{"unsupported-type-version", SeverityWarning, func(wf Workflow, report reporter) {
+			for i, node := range wf.Nodes {
+				_, ok := types.version(node.Type, node.TypeVersion)
+				switch {
+				case ok || len(types[node.Type]) == 0:
+				case node.TypeVersion == 0:
+					report(pointer("nodes", i), "node %q has no typeVersion, %s has %s",
+						node.Name, node.Type, strings.Join(types.versions(node.Type), ", "))
+				default:
+					report(pointer("nodes", i, "typeVersion"), "version %g of %s of node %q is not supported by the instance, which has %s",
+						node.TypeVersion, node.Type, node.Name, strings.Join(types.versions(node.Type), ", "))
+				}
+			}
+		}},
+		{"connection-index", SeverityError, func(wf Workflow, report reporter) {
+			byName := map[string]Node{}
+			for _, node := range wf.Nodes {
+				byName[node.Name] = node
+			}
+			for _, e := range wf.edges() {
+				if e.typ != "main" {
+					continue
+				}
+				from, to := byName[e.from], byName[e.to]
+				if nt, ok := types.version(from.Type, from.TypeVersion); ok {
+					if n, fixed := count(nt.Outputs); fixed && e.output >= n {
+						report(e.path, "%q has %d outputs, it cannot connect from output %d", e.from, n, e.output)
+					}
+				}
+				if nt, ok := types.version(to.Type, to.TypeVersion); ok {
+					if n, fixed := count(nt.Inputs); fixed && e.input >= n {
+						report(e.path+"/index", "%q has %d inputs, it cannot be connected to input %d", e.to, n, e.input)
+					}
+				}
+			}
+		}},
+		{"unknown-parameter", SeverityWarning, func(wf Workflow, report reporter) {
+			for i, node := range wf.Nodes {
+				nt, ok := types.version(node.Type, node.TypeVersion)
+				if !ok || len(nt.Properties) == 0 {
+					continue
+				}
+				for _, name := range sortedKeys(node.Parameters) {
+					if !slices.ContainsFunc(nt.Properties, func(p Property) bool { return p.Name == name }) {
+						report(pointer("nodes", i, "parameters", name), "parameter %s of node %q is not a parameter of %s version %g",
+							name, node.Name, node.Type, node.TypeVersion)
+					}
+				}
+			}
+		}},
+		{"missing-parameter", SeverityWarning, func(wf Workflow, report reporter) {
+			for i, node := range wf.Nodes {
+				nt, ok := types.version(node.Type, node.TypeVersion)
+				if !ok {
+					continue
+				}
+				reported := map[string]bool{}
+				for _, p := range nt.Properties {
+					if !p.Required || reported[p.Name] || !isEmpty(p.Default) || !nt.shows(p, node) {
+						continue
+					}
+					if value, ok := node.Parameters[p.Name]; !ok || isEmpty(value) {
+						reported[p.Name] = true
+						report(pointer("nodes", i), "node %q does not set the required parameter %s", node.Name, p.Name)
+					}
+				}
+			}
+		}},
+	})
+}
+
+// shows reports whether the node shows parameter p, given the values of its
+// parameters or their defaults. Conditions of other forms than lists of
+// values are assumed to hold.
+func (nt NodeType) shows(p Property, node Node) bool {
+	if p.DisplayOptions == nil {
+		return true
+	}
+	value := func(name string) any {
+		name = strings.TrimPrefix(name, "/")
+		if name == "@version" {
+			return node.TypeVersion
+		}
+		if v, ok := node.Parameters[name]; ok {
+			return v
+		}
+		for _, other := range nt.Properties {
+			if other.Name == name {
+				return other.Default
+			}
+		}
+		return nil
+	}
+	matches := func(name string, values []any, condition bool) bool {
+		v := value(name)
+		for _, want := range values {
+			if _, ok := want.(map[string]any); ok {
+				return condition
+			}
+			if fmt.Sprint(want) == fmt.Sprint(v) {
+				return true
+			}
+		}
+		return false
+	}
+	for name, values := range p.DisplayOptions.Show {
+		if !matches(name, values, true) {
+			return false
+		}
+	}
+	for name, values := range p.DisplayOptions.Hide {
+		if matches(name, values, false) {
+			return false
+		}
+	}
+	return true
+}
+
+func isEmpty(value any) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return strings.TrimSpace(v) == ""
+	case []any:
+		return len(v) == 0
+	case map[string]any:
+		return len(v) == 0
+	}
+	return false
+}
@@ -0,0 +1,148 @@
+package manifest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Group, version and kind of the workflow custom resource.
+const (
+	KubernetesAPIVersion = "n8n.io/v1alpha1"
+	KubernetesKind       = "Workflow"
+)
+
+// workflowIDAnnotation records the n8n workflow a resource was exported from,
+// so an operator can adopt the workflow instead of creating a copy.
+const workflowIDAnnotation = "n8n.io/workflow-id"
+
+// Kubernetes renders each workflow in res as a custom resource, as a
+// multi-document YAML stream ready for kubectl apply.
+func Kubernetes(res Resources) ([]byte, error) {
+	res.sort()
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	resourceNames := names{}
+	for _, wf := range res.Workflows {
+		spec := mapping(
+			"name", scalar(wf.Name),
+			"active", scalar(wf.Active),
+		)
+		if len(wf.Tags) > 0 {
+			tags := &yaml.Node{Kind: yaml.SequenceNode}
+			for _, tag := range wf.Tags {
+				tags.Content = append(tags.Content, scalar(tag.Name))
+			}
+			spec.Content = append(spec.Content, scalar("tags"), tags)
+		}
+		for _, field := range []struct {
+			key   string
+			value json.RawMessage
+		}{{"nodes", orEmpty(wf.Nodes, "[]")}, {"connections", orEmpty(wf.Connections, "{}")}, {"settings", wf.Settings}} {
+			if len(field.value) == 0 || string(field.value) == "null" {
+				continue
+			}
+			node, err := JSONToYAML(field.value)
+			if err != nil {
+				return nil, fmt.Errorf("workflow %q: invalid %s: %w", wf.Name, field.key, err)
+			}
+			spec.Content = append(spec.Content, scalar(field.key), node)
+		}
+
+		doc := mapping(
+			"apiVersion", scalar(KubernetesAPIVersion),
+			"kind", scalar(KubernetesKind),
+			"metadata", mapping(
+				"name", scalar(strings.ReplaceAll(resourceNames.next(wf.Name, "workflow"), "_", "-")),
+				"annotations", mapping(workflowIDAnnotation, scalar(wf.ID)),
+			),
+			"spec", spec,
+		)
+		if err := encoder.Encode(doc); err != nil {
+			return nil, err
+		}
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func mapping(pairs ...any) *yaml.Node {
+	node := &yaml.Node{Kind: yaml.MappingNode}
+	for i := 0; i < len(pairs); i += 2 {
+		node.Content = append(node.Content, scalar(pairs[i].(string)), pairs[i+1].(*yaml.Node))
+	}
+	return node
+}
+
+func scalar(v any) *yaml.Node {
+	var node yaml.Node
+	node.Encode(v)
+	return &node
+}
+
+// JSONToYAML converts a JSON document to a YAML node, keeping object keys in
+// their original order so nodes read the same as in the n8n editor export.
+func JSONToYAML(data []byte) (*yaml.Node, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	node, err := decodeYAMLNode(decoder)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, fmt.Errorf("unexpected data after JSON value")
+	}
+	return node, nil
+}
+
+func decodeYAMLNode(decoder *json.Decoder) (*yaml.Node, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch t := token.(type) {
+	case json.Delim:
+		node := &yaml.Node{Kind: yaml.SequenceNode}
+		if t == '{' {
+			node.Kind = yaml.MappingNode
+		}
+		for decoder.More() {
+			if node.Kind == yaml.MappingNode {
+				key, err := decoder.Token()
+				if err != nil {
+					return nil, err
+				}
+				node.Content = append(node.Content, scalar(key))
+			}
+			value, err := decodeYAMLNode(decoder)
+			if err != nil {
+				return nil, err
+			}
+			node.Content = append(node.Content, value)
+		}
+		if _, err := decoder.Token(); err != nil {
+			return nil, err
+		}
+		return node, nil
+	case json.Number:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!" + numberTag(t), Value: t.String()}, nil
+	case nil:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"}, nil
+	default:
+		return scalar(t), nil
+	}
+}
+
+func numberTag(n json.Number) string {
+	if _, err := n.Int64(); err == nil {
+		return "int"
+	}
+	return "float"
+}
@@ -0,0 +1,70 @@
+// Package manifest renders the resources of an n8n instance as documents for
+// other infrastructure tooling.
+package manifest
+
+import (
+	"encoding/json"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Tag is an n8n workflow tag.
+type Tag struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// Variable is an n8n instance variable.
+type Variable struct {
+	ID    string `json:"id"`
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// Workflow is an n8n workflow as returned by the public API.
+type Workflow struct {
+	ID          string          `json:"id"`
+	Name        string          `json:"name"`
+	Active      bool            `json:"active"`
+	Nodes       json.RawMessage `json:"nodes"`
+	Connections json.RawMessage `json:"connections"`
+	Settings    json.RawMessage `json:"settings,omitempty"`
+	Tags        []Tag           `json:"tags,omitempty"`
+}
+
+// Resources is everything exported from an instance.
+type Resources struct {
+	Workflows []Workflow
+	Variables []Variable
+	Tags      []Tag
+}
+
+// sort orders every resource list by name so repeated exports diff cleanly.
+func (r *Resources) sort() {
+	sort.SliceStable(r.Workflows, func(i, j int) bool { return r.Workflows[i].Name < r.Workflows[j].Name })
+	sort.SliceStable(r.Variables, func(i, j int) bool { return r.Variables[i].Key < r.Variables[j].Key })
+	sort.SliceStable(r.Tags, func(i, j int) bool { return r.Tags[i].Name < r.Tags[j].Name })
+}
+
+var nonIdentifier = regexp.MustCompile(`[^a-z0-9]+`)
+
+// names hands out unique, identifier-safe names derived from display names.
+type names map[string]bool
+
+func (n names) next(display, fallback string) string {
+	name := strings.Trim(nonIdentifier.ReplaceAllString(strings.ToLower(display), "_"), "_")
+	if name == "" {
+		name = fallback
+	}
+	if name[0] >= '0' && name[0] <= '9' {
+		name = fallback + "_" + name
+	}
+	unique := name
+	for i := 2; n[unique]; i++ {
+		unique = name + "_" + strconv.Itoa(i)
+	}
+	n[unique] = true
+	return unique
+}
@@ -0,0 +1,218 @@
+package manifest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Terraform resource types emitted for each kind of n8n resource.
+const (
+	terraformWorkflow = "n8n_workflow"
+	terraformVariable = "n8n_variable"
+	terraformTag      = "n8n_tag"
+)
+
+type terraformResource struct {
+	typ   string
+	name  string
+	id    string
+	attrs []terraformAttr
+}
+
+// terraformAttr is an attribute whose value is either a literal, a JSON
+// document passed to jsonencode, or a list of references to other resources.
+type terraformAttr struct {
+	key     string
+	literal any
+	json    json.RawMessage
+	refs    []terraformRef
+}
+
+// terraformRef refers to an attribute of another exported resource, or holds
+// a literal when the referenced resource was not exported.
+type terraformRef struct {
+	expr    string
+	literal string
+}
+
+// terraformResources maps res onto Terraform resources, each paired with the
+// ID of the n8n resource so state can be bootstrapped with import blocks.
+func terraformResources(res Resources) []terraformResource {
+	res.sort()
+	var out []terraformResource
+
+	tagRefs := map[string]string{}
+	tagNames := names{}
+	for _, tag := range res.Tags {
+		name := tagNames.next(tag.Name, "tag")
+		tagRefs[tag.ID] = terraformTag + "." + name + ".id"
+		out = append(out, terraformResource{typ: terraformTag, name: name, id: tag.ID, attrs: []terraformAttr{
+			{key: "name", literal: tag.Name},
+		}})
+	}
+
+	variableNames := names{}
+	for _, v := range res.Variables {
+		out = append(out, terraformResource{typ: terraformVariable, name: variableNames.next(v.Key, "variable"), id: v.ID, attrs: []terraformAttr{
+			{key: "key", literal: v.Key},
+			{key: "value", literal: v.Value},
+		}})
+	}
+
+	workflowNames := names{}
+	for _, wf := range res.Workflows {
+		attrs := []terraformAttr{
+			{key: "name", literal: wf.Name},
+			{key: "active", literal: wf.Active},
+		}
+		if len(wf.Tags) > 0 {
+			var refs []terraformRef
+			for _, tag := range wf.Tags {
+				refs = append(refs, terraformRef{expr: tagRefs[tag.ID], literal: tag.ID})
+			}
+			attrs = append(attrs, terraformAttr{key: "tags", refs: refs})
+		}
+		attrs = append(attrs,
+			terraformAttr{key: "nodes", json: orEmpty(wf.Nodes, "[]")},
+			terraformAttr{key: "connections", json: orEmpty(wf.Connections, "{}")},
+		)
+		if len(wf.Settings) > 0 && string(wf.Settings) != "null" {
+			attrs = append(attrs, terraformAttr{key: "settings", json: wf.Settings})
+		}
+		out = append(out, terraformResource{typ: terraformWorkflow, name: workflowNames.next(wf.Name, "workflow"), id: wf.ID, attrs: attrs})
+	}
+	return out
+}
+
+// Terraform renders res as HCL resource blocks, each followed by an import
+// block so `terraform plan` adopts the existing n8n resources instead of
+// creating duplicates.
+func Terraform(res Resources) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString("# Generated by n8nctl export --format terraform.\n")
+	for _, r := range terraformResources(res) {
+		fmt.Fprintf(&buf, "\nresource %q %q {\n", r.typ, r.name)
+		width := 0
+		for _, attr := range r.attrs {
+			width = max(width, len(attr.key))
+		}
+		for _, attr := range r.attrs {
+			value, err := attr.hcl()
+			if err != nil {
+				return nil, fmt.Errorf("%s.%s: %w", r.typ, r.name, err)
+			}
+			fmt.Fprintf(&buf, "  %-*s = %s\n", width, attr.key, value)
+		}
+		buf.WriteString("}\n")
+		fmt.Fprintf(&buf, "\nimport {\n  to = %s.%s\n  id = %s\n}\n", r.typ, r.name, hclString(r.id))
+	}
+	return buf.Bytes(), nil
+}
+
+func (a terraformAttr) hcl() (string, error) {
+	switch {
+	case a.refs != nil:
+		values := make([]string, len(a.refs))
+		for i, ref := range a.refs {
+			values[i] = ref.expr
+			if ref.expr == "" {
+				values[i] = hclString(ref.literal)
+			}
+		}
+		return "[" + strings.Join(values, ", ") + "]", nil
+	case a.json != nil:
+		// HCL accepts JSON object and array syntax as-is; only template
+		// sequences inside strings need escaping.
+		var buf bytes.Buffer
+		if err := json.Indent(&buf, a.json, "  ", "  "); err != nil {
+			return "", fmt.Errorf("invalid %s: %w", a.key, err)
+		}
+		return "jsonencode(" + escapeTemplates(buf.String()) + ")", nil
+	case a.literal != nil:
+		if s, ok := a.literal.(string); ok {
+			return hclString(s), nil
+		}
+		data, err := json.Marshal(a.literal)
+		return string(data), err
+	}
+	return "null", nil
+}
+
+// TerraformJSON renders the same resources and import blocks as Terraform in
+// Terraform's JSON configuration syntax, for tooling that generates or
+// post-processes configuration.
+func TerraformJSON(res Resources) ([]byte, error) {
+	resources := map[string]map[string]map[string]any{}
+	var imports []map[string]string
+	for _, r := range terraformResources(res) {
+		body := map[string]any{}
+		for _, attr := range r.attrs {
+			switch {
+			case attr.refs != nil:
+				values := make([]string, len(attr.refs))
+				for i, ref := range attr.refs {
+					values[i] = "${" + ref.expr + "}"
+					if ref.expr == "" {
+						values[i] = escapeTemplates(ref.literal)
+					}
+				}
+				body[attr.key] = values
+			case attr.json != nil:
+				var compact bytes.Buffer
+				if err := json.Compact(&compact, attr.json); err != nil {
+					return nil, fmt.Errorf("%s.%s: invalid %s: %w", r.typ, r.name, attr.key, err)
+				}
+				body[attr.key] = escapeTemplates(compact.String())
+			default:
+				if s, ok := attr.literal.(string); ok {
+					body[attr.key] = escapeTemplates(s)
+				} else {
+					body[attr.key] = attr.literal
+				}
+			}
+		}
+		if resources[r.typ] == nil {
+			resources[r.typ] = map[string]map[string]any{}
+		}
+		resources[r.typ][r.name] = body
+		imports = append(imports, map[string]string{"to": r.typ + "." + r.name, "id": r.id})
+	}
+
+	doc := map[string]any{"resource": resources}
+	if len(imports) > 0 {
+		doc["import"] = imports
+	}
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// hclString quotes s as an HCL string literal. JSON string escapes are a
+// subset of HCL's, so only template sequences need extra care.
+func hclString(s string) string {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.Encode(s)
+	return escapeTemplates(strings.TrimSuffix(buf.String(), "\n"))
+}
+
+// escapeTemplates stops Terraform from interpolating ${ and %{ sequences,
+// which n8n expressions and Code nodes use freely.
+func escapeTemplates(s string) string {
+	return strings.NewReplacer("${", "$${", "%{", "%%{").Replace(s)
+}
+
+func orEmpty(data json.RawMessage, empty string) json.RawMessage {
+	if len(data) == 0 || string(data) == "null" {
+		return json.RawMessage(empty)
+	}
+	return data
+}
@@ -0,0 +1,144 @@
+// Package mockserver serves a fake n8n API from recorded fixtures, so
+// pipelines and scripts can be tested against deterministic responses.
+package mockserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/brandon-kyle-bailey/n8nctl/pkg/n8n"
+)
+
+// Server answers requests with recorded interactions. Requests match on
+// method, path with query and body, or failing that on method and path
+// alone, as bodies such as deployed workflows rarely repeat byte for byte.
+// Repeated requests get the recorded responses in order, then the last one
+// again.
+type Server struct {
+	// APIKey, when set, is required in the X-N8N-API-KEY header of public
+	// API requests, like a real instance does.
+	APIKey string
+	// Log, when set, gets a line for every request served.
+	Log io.Writer
+	// Faults are injected into the responses, to test how clients cope with
+	// a slow or flaky instance.
+	Faults n8n.Faults
+
+	interactions []n8n.Interaction
+	mu           sync.Mutex
+	served       map[string]int
+}
+
+// New returns a server for the given interactions.
+func New(interactions []n8n.Interaction) *Server {
+	return &Server{interactions: interactions, served: map[string]int{}}
+}
+
+// LoadDir reads every fixture file in dir, in name order.
+func LoadDir(dir string) ([]n8n.Interaction, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no fixture files (*.json) in %s", dir)
+	}
+	sort.Strings(files)
+	var all []n8n.Interaction
+	for _, file := range files {
+		interactions, err := n8n.LoadFixture(file)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, interactions...)
+	}
+	return all, nil
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	path := r.URL.RequestURI()
+
+	status := http.StatusNotFound
+	defer func() {
+		if s.Log != nil {
+			fmt.Fprintf(s.Log, "%s %s -> %d\n", r.Method, path, status)
+		}
+	}()
+
+	if s.Faults.Latency > 0 {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-time.After(s.Faults.Latency):
+		}
+	}
+	if s.Faults.ErrorRate > 0 && rand.Float64() < s.Faults.ErrorRate {
+		status = http.StatusServiceUnavailable
+		writeJSON(w, status, map[string]string{"message": "injected fault"})
+		return
+	}
+
+	if s.APIKey != "" && r.Header.Get("X-N8N-API-KEY") != s.APIKey {
+		status = http.StatusUnauthorized
+		writeJSON(w, status, map[string]string{"message": "unauthorized"})
+		return
+	}
+
+	i, ok := s.match(r.Method, path, string(body))
+	if !ok {
+		writeJSON(w, status, map[string]string{"message": fmt.Sprintf("no fixture for %s %s", r.Method, path)})
+		return
+	}
+	status = i.Status
+	for key, values := range i.Header {
+		for _, value := range values {
+			w.Header().Add(key, value)
+		}
+	}
+	w.Header().Del("Content-Length")
+	w.WriteHeader(i.Status)
+	io.WriteString(w, i.Body)
+}
+
+// match picks the interaction to answer a request with.
+func (s *Server) match(method, path, body string) (n8n.Interaction, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, exact := range []bool{true, false} {
+		var candidates []n8n.Interaction
+		for _, i := range s.interactions {
+			if i.Method == method && i.Path == path && (!exact || i.RequestBody == body) {
+				candidates = append(candidates, i)
+			}
+		}
+		if len(candidates) == 0 {
+			continue
+		}
+		key := fmt.Sprintf("%t %s %s %s", exact, method, path, body)
+		if !exact {
+			key = fmt.Sprintf("%t %s %s", exact, method, path)
+		}
+		n := s.served[key]
+		s.served[key]++
+		return candidates[min(n, len(candidates)-1)], true
+	}
+	return n8n.Interaction{}, false
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,324 @@
+// Package n8n is a client for the n8n public REST API.
+package n8n
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// DefaultTimeout bounds each request unless WithTimeout says otherwise, so a
+// hung instance cannot block a caller forever.
+const DefaultTimeout = 60 * time.Second
+
+// Client sends authenticated requests to an n8n instance.
+type Client struct {
+	baseURL    string
+	apiKey     string
+	timeout    time.Duration
+	proxy      *url.URL
+	tlsConfig  *tls.Config
+	debug      io.Writer
+	recorder   *Recorder
+	faults     Faults
+	middleware []Middleware
+	delegate   func(direct *http.Transport) http.RoundTripper
+	httpClient *http.Client
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithTimeout limits how long each request may take, including reading the
+// response body. Zero disables the limit.
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		c.timeout = timeout
+	}
+}
+
+// WithProxy sends every request through the proxy at proxyURL. Without it
+// the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables apply.
+func WithProxy(proxyURL *url.URL) Option {
+	return func(c *Client) {
+		c.proxy = proxyURL
+	}
+}
+
+// WithTLSConfig uses cfg for HTTPS connections, e.g. to trust a private CA,
+// present a client certificate or skip verification.
+func WithTLSConfig(cfg *tls.Config) Option {
+	return func(c *Client) {
+		c.tlsConfig = cfg
+	}
+}
+
+// WithHTTPClient sends requests through hc instead of a default client. The
+// transport options such as WithProxy and WithTLSConfig do not apply to hc.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = hc
+	}
+}
+
+// WithDelegate sends requests through the round tripper delegate returns,
+// e.g. one that hands them to a long-running process holding connections
+// open. direct is the transport the other options describe, for requests
+// the delegate cannot take.
+func WithDelegate(delegate func(direct *http.Transport) http.RoundTripper) Option {
+	return func(c *Client) {
+		c.delegate = delegate
+	}
+}
+
+// New returns a client for the instance at baseURL, e.g.
+// https://n8n.example.com, authenticating with apiKey.
+func New(baseURL, apiKey string, opts ...Option) *Client {
+	c := &Client{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		apiKey:  apiKey,
+		timeout: DefaultTimeout,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.httpClient == nil {
+		var base http.RoundTripper = c.transport()
+		if c.delegate != nil {
+			base = c.delegate(c.transport())
+		}
+		c.httpClient = &http.Client{Transport: base}
+	}
+	if layers := c.layers(); len(layers) > 0 {
+		// Wrap a copy, leaving a client passed to WithHTTPClient untouched.
+		hc := *c.httpClient
+		next := hc.Transport
+		if next == nil {
+			next = http.DefaultTransport
+		}
+		hc.Transport = chain(next, layers)
+		c.httpClient = &hc
+	}
+	return c
+}
+
+// layers returns the middleware of the client, outermost first: the caller's
+// own, then the debug trace, injected faults and the recorder, so the trace
+// shows what was sent and both faults and the trace apply to replayed
+// requests too.
+func (c *Client) layers() []Middleware {
+	layers := append([]Middleware(nil), c.middleware...)
+	if c.debug != nil {
+		layers = append(layers, func(next http.RoundTripper) http.RoundTripper {
+			return &debugTransport{next: next, w: c.debug, apiKey: c.apiKey}
+		})
+	}
+	if c.faults != (Faults{}) {
+		layers = append(layers, func(next http.RoundTripper) http.RoundTripper {
+			return &faultTransport{next: next, faults: c.faults}
+		})
+	}
+	if c.recorder != nil {
+		layers = append(layers, func(next http.RoundTripper) http.RoundTripper {
+			return &recorderTransport{next: next, recorder: c.recorder}
+		})
+	}
+	return layers
+}
+
+// transport returns the HTTP transport the transport options describe.
+func (c *Client) transport() *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	if c.proxy != nil {
+		t.Proxy = http.ProxyURL(c.proxy)
+	}
+	if c.tlsConfig != nil {
+		t.TLSClientConfig = c.tlsConfig
+	}
+	return t
+}
+
+// HTTPClient returns the underlying HTTP client, for requests outside the
+// API such as webhook calls that should share its proxy and TLS settings.
+func (c *Client) HTTPClient() *http.Client {
+	return c.httpClient
+}
+
+// DirectHTTPClient returns an HTTP client with the proxy and TLS settings of
+// the client but none of its middleware, for requests such as signing in
+// with a password whose credentials must not be traced or recorded.
+func (c *Client) DirectHTTPClient() *http.Client {
+	return &http.Client{Transport: c.transport(), Timeout: c.timeout}
+}
+
+// BaseURL returns the instance URL the client was created for.
+func (c *Client) BaseURL() string {
+	return c.baseURL
+}
+
+// APIURL returns the absolute URL of a public API path such as /workflows.
+func (c *Client) APIURL(path string) string {
+	return c.baseURL + "/api/v1/" + strings.TrimLeft(path, "/")
+}
+
+// APIError is returned for responses with a non-2xx status, and for HTML
+// pages served in place of the API.
+type APIError struct {
+	StatusCode  int
+	Status      string
+	ContentType string
+	Body        string
+}
+
+func (e *APIError) Error() string {
+	if e.IsHTML() {
+		return fmt.Sprintf("API error: %s, got an HTML page instead of an API response: %s\n"+
+			"The page did not come from the n8n API. Check that the base URL points at the instance,\n"+
+			"and that no proxy or login portal in between answers instead", e.Status, pageSummary(e.Body))
+	}
+	return fmt.Sprintf("API error: %s\n%s", e.Status, e.Body)
+}
+
+// IsHTML reports whether the response was an HTML page, as reverse proxies
+// and login portals serve, rather than an API response.
+func (e *APIError) IsHTML() bool {
+	return isHTML(e.ContentType, e.Body)
+}
+
+func isHTML(contentType, body string) bool {
+	if strings.HasPrefix(strings.ToLower(contentType), "text/html") {
+		return true
+	}
+	start := strings.ToLower(strings.TrimSpace(body[:min(len(body), 512)]))
+	return strings.HasPrefix(start, "<!doctype html") || strings.HasPrefix(start, "<html")
+}
+
+var (
+	htmlTitle = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+	htmlTag   = regexp.MustCompile(`(?s)<[^>]*>`)
+)
+
+// pageSummary returns the title of an HTML page, or else its first line of
+// text, shortened to fit a line.
+func pageSummary(body string) string {
+	summary := ""
+	if m := htmlTitle.FindStringSubmatch(body); m != nil {
+		summary = m[1]
+	} else {
+		text := htmlTag.ReplaceAllString(body, "\n")
+		for line := range strings.SplitSeq(text, "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				summary = line
+				break
+			}
+		}
+	}
+	summary = strings.Join(strings.Fields(html.UnescapeString(summary)), " ")
+	if summary == "" {
+		return "(empty page)"
+	}
+	if len(summary) > 100 {
+		summary = summary[:97] + "..."
+	}
+	return fmt.Sprintf("%q", summary)
+}
+
+// Do sends a request to url, an absolute URL on the instance, and returns
+// the response body. The request is abandoned when ctx is cancelled or the
+// client's timeout passes.
+func (c *Client) Do(ctx context.Context, method, url string, body io.Reader) ([]byte, error) {
+	resp, err := c.Stream(ctx, method, url, body)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Close()
+	data, err := io.ReadAll(resp)
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// maxErrorBody bounds how much of an error response is kept in APIError.
+const maxErrorBody = 64 << 10
+
+// Stream is like Do but returns the response body unread, so large responses
+// such as execution data can be decoded without holding them in memory. The
+// caller must close it.
+func (c *Client) Stream(ctx context.Context, method, url string, body io.Reader) (io.ReadCloser, error) {
+	cancel := context.CancelFunc(func() {})
+	if c.timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	req.Header.Set("X-N8N-API-KEY", c.apiKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		// Explain the error before cancel makes every context look cancelled.
+		err = c.contextError(ctx, method, url, err)
+		cancel()
+		return nil, err
+	}
+
+	contentType := resp.Header.Get("Content-Type")
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 || isHTML(contentType, "") {
+		defer cancel()
+		defer resp.Body.Close()
+		data, err := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		if err != nil {
+			return nil, c.contextError(ctx, method, url, err)
+		}
+		return nil, &APIError{StatusCode: resp.StatusCode, Status: resp.Status, ContentType: contentType, Body: string(data)}
+	}
+	return &responseBody{body: resp.Body, cancel: cancel, err: func(err error) error {
+		return c.contextError(ctx, method, url, err)
+	}}, nil
+}
+
+// responseBody releases the request's timeout once the body is closed, and
+// explains read errors caused by it.
+type responseBody struct {
+	body   io.ReadCloser
+	cancel context.CancelFunc
+	err    func(error) error
+}
+
+func (b *responseBody) Read(p []byte) (int, error) {
+	n, err := b.body.Read(p)
+	if err != nil && err != io.EOF {
+		err = b.err(err)
+	}
+	return n, err
+}
+
+func (b *responseBody) Close() error {
+	defer b.cancel()
+	return b.body.Close()
+}
+
+// contextError explains failures caused by a timeout or cancellation, which
+// net/http reports in terms of its internals.
+func (c *Client) contextError(ctx context.Context, method, url string, err error) error {
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return fmt.Errorf("%s %s timed out after %s", method, url, c.timeout)
+	case errors.Is(ctx.Err(), context.Canceled):
+		return fmt.Errorf("%s %s cancelled", method, url)
+	}
+	return err
+}
@@ -0,0 +1,109 @@
+package n8n
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// redactedHeaders carry credentials and are never written to a trace.
+var redactedHeaders = map[string]bool{
+	"X-N8n-Api-Key": true,
+	"Authorization": true,
+	"Cookie":        true,
+	"Set-Cookie":    true,
+}
+
+// WithDebug writes every request and response, with headers, bodies and
+// timing, to w. Credentials in headers, and the API key anywhere, are redacted.
+func WithDebug(w io.Writer) Option {
+	return func(c *Client) {
+		c.debug = w
+	}
+}
+
+// debugTransport traces the requests it passes on to next.
+type debugTransport struct {
+	next   http.RoundTripper
+	w      io.Writer
+	apiKey string
+	mu     sync.Mutex
+}
+
+func (t *debugTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var trace bytes.Buffer
+	fmt.Fprintf(&trace, "--> %s %s\n", req.Method, req.URL)
+	writeHeaders(&trace, req.Header)
+	if req.Body != nil {
+		body, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		writeBody(&trace, body)
+	}
+
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	elapsed := time.Since(start).Round(time.Millisecond)
+	if err != nil {
+		fmt.Fprintf(&trace, "<-- %s %s failed after %s: %v\n\n", req.Method, req.URL, elapsed, err)
+		t.write(trace.Bytes())
+		return nil, err
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	fmt.Fprintf(&trace, "<-- %s %s %s (%s)\n", resp.Status, req.Method, req.URL, elapsed)
+	writeHeaders(&trace, resp.Header)
+	writeBody(&trace, body)
+	if err != nil {
+		fmt.Fprintf(&trace, "(reading the body failed: %v)\n", err)
+	}
+	trace.WriteByte('\n')
+	t.write(trace.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// write emits a whole trace at once so concurrent requests do not interleave.
+func (t *debugTransport) write(trace []byte) {
+	// Short keys would mangle unrelated text, real API keys are far longer.
+	if len(t.apiKey) >= 8 {
+		trace = bytes.ReplaceAll(trace, []byte(t.apiKey), []byte("[REDACTED]"))
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.w.Write(trace)
+}
+
+func writeHeaders(w io.Writer, header http.Header) {
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		value := strings.Join(header[name], ", ")
+		if redactedHeaders[http.CanonicalHeaderKey(name)] {
+			value = "[REDACTED]"
+		}
+		fmt.Fprintf(w, "%s: %s\n", name, value)
+	}
+}
+
+func writeBody(w io.Writer, body []byte) {
+	if len(body) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "\n%s\n", bytes.TrimRight(body, "\n"))
+}
@@ -0,0 +1,60 @@
+package n8n
+
+import (
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Faults describes failures to inject into requests, to test how callers
+// cope with a slow or flaky instance.
+type Faults struct {
+	// Latency delays every request.
+	Latency time.Duration
+	// ErrorRate is the fraction of requests, from 0 to 1, answered with a
+	// 503 Service Unavailable without reaching the instance.
+	ErrorRate float64
+}
+
+// WithFaults injects the given faults into every request.
+func WithFaults(f Faults) Option {
+	return func(c *Client) {
+		c.faults = f
+	}
+}
+
+// faultTransport injects faults in front of next.
+type faultTransport struct {
+	next   http.RoundTripper
+	faults Faults
+}
+
+func (t *faultTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.faults.Latency > 0 {
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-time.After(t.faults.Latency):
+		}
+	}
+	if t.faults.ErrorRate > 0 && rand.Float64() < t.faults.ErrorRate {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		body := `{"message":"injected fault"}`
+		return &http.Response{
+			Status:        "503 Service Unavailable",
+			StatusCode:    http.StatusServiceUnavailable,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        http.Header{"Content-Type": {"application/json"}},
+			Body:          io.NopCloser(strings.NewReader(body)),
+			ContentLength: int64(len(body)),
+			Request:       req,
+		}, nil
+	}
+	return t.next.RoundTrip(req)
+}
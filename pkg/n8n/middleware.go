@@ -0,0 +1,69 @@
+package n8n
+
+import "net/http"
+
+// Middleware wraps the transport requests go through, to add behaviour such
+// as custom authentication, tracing or caching around every request.
+type Middleware func(next http.RoundTripper) http.RoundTripper
+
+// RoundTripperFunc adapts a function to an http.RoundTripper, which makes
+// middleware short to write.
+type RoundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f RoundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// WithMiddleware adds middleware around the client's transport. The first
+// middleware given sees requests first and responses last. Middleware runs
+// outside the client's own layers, so WithDebug traces the requests as the
+// middleware changed them.
+func WithMiddleware(middleware ...Middleware) Option {
+	return func(c *Client) {
+		c.middleware = append(c.middleware, middleware...)
+	}
+}
+
+// InterceptRequest returns middleware that calls fn with every request before
+// it is sent, e.g. to set headers. An error from fn fails the request.
+func InterceptRequest(fn func(*http.Request) error) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			// A RoundTripper must not modify the caller's request.
+			req = req.Clone(req.Context())
+			if err := fn(req); err != nil {
+				if req.Body != nil {
+					req.Body.Close()
+				}
+				return nil, err
+			}
+			return next.RoundTrip(req)
+		})
+	}
+}
+
+// InterceptResponse returns middleware that calls fn with every response
+// received. An error from fn fails the request.
+func InterceptResponse(fn func(*http.Response) error) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			resp, err := next.RoundTrip(req)
+			if err != nil {
+				return nil, err
+			}
+			if err := fn(resp); err != nil {
+				resp.Body.Close()
+				return nil, err
+			}
+			return resp, nil
+		})
+	}
+}
+
+// chain wraps next in middleware so the first one is outermost.
+func chain(next http.RoundTripper, middleware []Middleware) http.RoundTripper {
+	for i := len(middleware) - 1; i >= 0; i-- {
+		next = middleware[i](next)
+	}
+	return next
+}
@@ -0,0 +1,61 @@
+package n8n
+
+import (
+	"io"
+	"net/http"
+	"sync/atomic"
+)
+
+// CacheHeader is set on responses served from a cache, such as the node
+// types the daemon keeps, rather than by the instance.
+const CacheHeader = "X-N8nctl-Cache"
+
+// Stats counts the requests a client sends and the bytes it transfers. It is
+// safe for concurrent use, so several clients can share one. Retries counts
+// the polls of commands waiting for something to happen, and CacheHits the
+// responses, renders and checks served from a cache, which commands add
+// themselves.
+type Stats struct {
+	Requests      atomic.Int64
+	Failed        atomic.Int64
+	BytesSent     atomic.Int64
+	BytesReceived atomic.Int64
+	Retries       atomic.Int64
+	CacheHits     atomic.Int64
+}
+
+// Middleware counts every request passing through it. Failed counts requests
+// that got no response or an error status.
+func (s *Stats) Middleware(next http.RoundTripper) http.RoundTripper {
+	return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		s.Requests.Add(1)
+		if req.ContentLength > 0 {
+			s.BytesSent.Add(req.ContentLength)
+		}
+		resp, err := next.RoundTrip(req)
+		if err != nil {
+			s.Failed.Add(1)
+			return nil, err
+		}
+		if resp.StatusCode >= 400 {
+			s.Failed.Add(1)
+		}
+		if resp.Header.Get(CacheHeader) != "" {
+			s.CacheHits.Add(1)
+		}
+		resp.Body = &countingBody{ReadCloser: resp.Body, n: &s.BytesReceived}
+		return resp, nil
+	})
+}
+
+// countingBody adds the bytes read from a response body to n.
+type countingBody struct {
+	io.ReadCloser
+	n *atomic.Int64
+}
+
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.n.Add(int64(n))
+	return n, err
+}
@@ -0,0 +1,249 @@
+// Package prompt provides interactive prompts for the n8nctl CLI tool that behave
+// the same across shells, with defaults, validation, masked secret input and
+// sensible behaviour when stdin is not a terminal.
+package prompt
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// ErrNoInput is returned when a prompt needs an answer but stdin has none to give.
+var ErrNoInput = errors.New("no input available (stdin is not a terminal)")
+
+// AssumeYes makes every confirmation prompt answer yes without asking.
+var AssumeYes bool
+
+// NonInteractive makes prompts fail immediately instead of waiting for input,
+// so pipelines never hang on stdin.
+var NonInteractive bool
+
+// A single reader is shared by all prompts so buffered input is never lost
+// between consecutive questions.
+var reader = bufio.NewReader(os.Stdin)
+
+// Prompts are written to stderr so they never mix with command output on stdout.
+var out io.Writer = os.Stderr
+
+// IsInteractive reports whether stdin is attached to a terminal.
+func IsInteractive() bool {
+	return term.IsTerminal(int(os.Stdin.Fd()))
+}
+
+// Input asks for a line of text. An empty answer selects def, and validate
+// (when not nil) is applied until an acceptable answer is given. When stdin
+// is not a terminal an invalid answer is returned as an error instead.
+func Input(label, def string, validate func(string) error) (string, error) {
+	if NonInteractive {
+		if def == "" {
+			return "", fmt.Errorf("%s: a value is required but prompts are disabled (non-interactive mode)", label)
+		}
+		return def, nil
+	}
+	for {
+		if def != "" {
+			fmt.Fprintf(out, "%s [%s]: ", label, def)
+		} else {
+			fmt.Fprintf(out, "%s: ", label)
+		}
+		answer, err := readLine()
+		if err != nil {
+			return "", err
+		}
+		if answer == "" {
+			answer = def
+		}
+		if validate == nil {
+			return answer, nil
+		}
+		verr := validate(answer)
+		if verr == nil {
+			return answer, nil
+		}
+		if !IsInteractive() {
+			return "", verr
+		}
+		fmt.Fprintf(out, "Invalid value: %v\n", verr)
+	}
+}
+
+// Secret asks for a value without echoing it to the terminal. The value is
+// never printed back. When stdin is not a terminal it is read as a plain line.
+func Secret(label string) (string, error) {
+	if NonInteractive {
+		return "", fmt.Errorf("%s: a value is required but prompts are disabled (non-interactive mode)", label)
+	}
+	fmt.Fprintf(out, "%s: ", label)
+	if !IsInteractive() {
+		answer, err := readLine()
+		fmt.Fprintln(out)
+		return answer, err
+	}
+	secret, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Fprintln(out)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(secret)), nil
+}
+
+// Confirm asks a yes/no question. An empty answer selects defaultYes.
+// With AssumeYes the answer is always yes; in non-interactive mode without
+// AssumeYes an error is returned rather than waiting for an answer.
+func Confirm(question string, defaultYes bool) (bool, error) {
+	hint := "y/N"
+	if defaultYes {
+		hint = "Y/n"
+	}
+	if AssumeYes {
+		fmt.Fprintf(out, "%s (%s): yes (--yes)\n", question, hint)
+		return true, nil
+	}
+	if NonInteractive {
+		return false, fmt.Errorf("%q requires confirmation, rerun with --yes to confirm non-interactively", question)
+	}
+	for {
+		fmt.Fprintf(out, "%s (%s): ", question, hint)
+		answer, err := readLine()
+		if err != nil {
+			return false, err
+		}
+		switch strings.ToLower(answer) {
+		case "":
+			return defaultYes, nil
+		case "y", "yes":
+			return true, nil
+		case "n", "no":
+			return false, nil
+		}
+		if !IsInteractive() {
+			return false, fmt.Errorf("invalid answer %q, expected y or n", answer)
+		}
+		fmt.Fprintln(out, "Please answer y or n.")
+	}
+}
+
+// ConfirmItems lists items as a numbered table under header and asks to
+// confirm acting on them. Before confirming, numbers and ranges such as
+// "2 5-7" deselect and reselect items. It returns which items are selected,
+// or nil when the answer is no. AssumeYes and NonInteractive apply as they do
+// to Confirm, and select every item.
+func ConfirmItems(question string, header []string, items [][]string) ([]bool, error) {
+	selected := make([]bool, len(items))
+	for i := range selected {
+		selected[i] = true
+	}
+	printItems(header, items, selected)
+	if AssumeYes {
+		fmt.Fprintf(out, "%s (y/N): yes (--yes)\n", question)
+		return selected, nil
+	}
+	if NonInteractive {
+		return nil, fmt.Errorf("%q requires confirmation, rerun with --yes to confirm non-interactively", question)
+	}
+	for {
+		count := 0
+		for _, s := range selected {
+			if s {
+				count++
+			}
+		}
+		fmt.Fprintf(out, "%s %d of %d selected (y/N, or numbers to toggle, e.g. 2 5-7): ", question, count, len(items))
+		answer, err := readLine()
+		if err != nil {
+			return nil, err
+		}
+		switch strings.ToLower(answer) {
+		case "", "n", "no":
+			return nil, nil
+		case "y", "yes":
+			return selected, nil
+		}
+		toggle, err := parseNumbers(answer, len(items))
+		if err != nil {
+			if !IsInteractive() {
+				return nil, err
+			}
+			fmt.Fprintf(out, "%v\n", err)
+			continue
+		}
+		for _, n := range toggle {
+			selected[n-1] = !selected[n-1]
+		}
+		printItems(header, items, selected)
+	}
+}
+
+// printItems prints items as a numbered table, marking the selected ones.
+func printItems(header []string, items [][]string, selected []bool) {
+	widths := make([]int, len(header))
+	for i, h := range header {
+		widths[i] = len(h)
+	}
+	for _, item := range items {
+		for i, cell := range item[:min(len(item), len(widths))] {
+			widths[i] = max(widths[i], len(cell))
+		}
+	}
+	row := func(prefix string, cells []string) {
+		line := prefix
+		for i, cell := range cells[:min(len(cells), len(widths))] {
+			line += fmt.Sprintf("  %-*s", widths[i], cell)
+		}
+		fmt.Fprintln(out, strings.TrimRight(line, " "))
+	}
+	number := len(fmt.Sprint(len(items)))
+	row(fmt.Sprintf("%*s    ", number, "#"), header)
+	for i, item := range items {
+		mark := "[ ]"
+		if selected[i] {
+			mark = "[x]"
+		}
+		row(fmt.Sprintf("%*d %s", number, i+1, mark), item)
+	}
+}
+
+// parseNumbers parses item numbers and ranges separated by spaces or commas,
+// each between 1 and n.
+func parseNumbers(s string, n int) ([]int, error) {
+	var numbers []int
+	for _, field := range strings.FieldsFunc(s, func(r rune) bool { return r == ' ' || r == ',' }) {
+		from, to, isRange := strings.Cut(field, "-")
+		if !isRange {
+			to = from
+		}
+		a, errA := strconv.Atoi(from)
+		b, errB := strconv.Atoi(to)
+		if errA != nil || errB != nil || a < 1 || b > n || a > b {
+			return nil, fmt.Errorf("invalid answer %q, expected y, n or item numbers between 1 and %d", field, n)
+		}
+		for i := a; i <= b; i++ {
+			numbers = append(numbers, i)
+		}
+	}
+	return numbers, nil
+}
+
+// readLine reads a single trimmed line, accepting a final line without a
+// trailing newline. Reaching EOF without any input yields ErrNoInput.
+func readLine() (string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		if err == io.EOF && line != "" {
+			return strings.TrimSpace(line), nil
+		}
+		if err == io.EOF {
+			fmt.Fprintln(out)
+			return "", ErrNoInput
+		}
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
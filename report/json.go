@@ -0,0 +1,37 @@
+package report
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/brandon-kyle-bailey/n8nctl/lint"
+)
+
+// jsonReport is the JSON report of a check, for tools that drive n8nctl.
+type jsonReport struct {
+	Files    []string       `json:"files"`
+	Errors   int            `json:"errors"`
+	Warnings int            `json:"warnings"`
+	Findings []lint.Finding `json:"findings"`
+}
+
+// WriteJSON writes the files checked and the findings in them as JSON.
+func WriteJSON(w io.Writer, files []string, findings []lint.Finding) error {
+	r := jsonReport{Files: files, Findings: findings}
+	if r.Files == nil {
+		r.Files = []string{}
+	}
+	if r.Findings == nil {
+		r.Findings = []lint.Finding{}
+	}
+	for _, f := range findings {
+		if f.Severity == lint.SeverityError {
+			r.Errors++
+		} else {
+			r.Warnings++
+		}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
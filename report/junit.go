@@ -0,0 +1,115 @@
+// Package report writes check results in formats CI systems understand.
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Suite is a group of cases, such as the checks of one file.
+type Suite struct {
+	Name  string
+	Cases []Case
+}
+
+// Case is a single check. Failure is set when the check ran and did not pass,
+// Error when it could not run at all.
+type Case struct {
+	Name      string
+	ClassName string
+	Time      time.Duration
+	Failure   string
+	Error     string
+	Output    string
+}
+
+type junitSuites struct {
+	XMLName  xml.Name     `xml:"testsuites"`
+	Tests    int          `xml:"tests,attr"`
+	Failures int          `xml:"failures,attr"`
+	Errors   int          `xml:"errors,attr"`
+	Time     string       `xml:"time,attr"`
+	Suites   []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name     string      `xml:"name,attr"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Errors   int         `xml:"errors,attr"`
+	Time     string      `xml:"time,attr"`
+	Cases    []junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Error     *junitMessage `xml:"error,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// WriteJUnit writes suites as a JUnit XML report.
+func WriteJUnit(w io.Writer, suites []Suite) error {
+	var doc junitSuites
+	var total time.Duration
+	for _, suite := range suites {
+		js := junitSuite{Name: suite.Name, Tests: len(suite.Cases)}
+		var elapsed time.Duration
+		for _, c := range suite.Cases {
+			jc := junitCase{Name: c.Name, ClassName: c.ClassName, Time: seconds(c.Time), SystemOut: c.Output}
+			if jc.ClassName == "" {
+				jc.ClassName = suite.Name
+			}
+			if c.Failure != "" {
+				jc.Failure = &junitMessage{Message: firstLine(c.Failure), Text: c.Failure}
+				js.Failures++
+			}
+			if c.Error != "" {
+				jc.Error = &junitMessage{Message: firstLine(c.Error), Text: c.Error}
+				js.Errors++
+			}
+			elapsed += c.Time
+			js.Cases = append(js.Cases, jc)
+		}
+		js.Time = seconds(elapsed)
+		doc.Tests += js.Tests
+		doc.Failures += js.Failures
+		doc.Errors += js.Errors
+		total += elapsed
+		doc.Suites = append(doc.Suites, js)
+	}
+	doc.Time = seconds(total)
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func seconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
+
+func firstLine(s string) string {
+	for i, r := range s {
+		if r == '\n' {
+			return s[:i]
+		}
+	}
+	return s
+}
@@ -0,0 +1,130 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/brandon-kyle-bailey/n8nctl/cloud"
+)
+
+// AWSSecretsManager reads secrets from AWS Secrets Manager. References are a
+// secret name or ARN, with the field of a JSON secret after #, e.g.
+// prod/n8n#password. Without a field the whole secret string is used.
+type AWSSecretsManager struct {
+	// Credentials sign the requests. When they are empty, the AWS_*
+	// environment variables are read on first use.
+	Credentials cloud.AWSCredentials
+	// Endpoint overrides https://secretsmanager.<region>.amazonaws.com, e.g.
+	// for LocalStack.
+	Endpoint   string
+	HTTPClient *http.Client
+
+	mu      sync.Mutex
+	secrets map[string]string
+}
+
+func (a *AWSSecretsManager) Resolve(ctx context.Context, ref string) (string, error) {
+	id, key := splitRef(ref)
+	if id == "" {
+		return "", fmt.Errorf("empty AWS Secrets Manager reference")
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	value, ok := a.secrets[id]
+	if !ok {
+		var err error
+		if value, err = a.read(ctx, id); err != nil {
+			return "", fmt.Errorf("failed to read aws-sm:%s: %w", id, err)
+		}
+		if a.secrets == nil {
+			a.secrets = map[string]string{}
+		}
+		a.secrets[id] = value
+	}
+	if key == "" {
+		return value, nil
+	}
+	return jsonField(value, key, "aws-sm:"+id)
+}
+
+func (a *AWSSecretsManager) read(ctx context.Context, id string) (string, error) {
+	if a.Credentials.AccessKeyID == "" {
+		creds, err := cloud.AWSCredentialsFromEnv(a.Credentials.Region)
+		if err != nil {
+			return "", err
+		}
+		a.Credentials = creds
+	}
+	if a.Credentials.Region == "" {
+		return "", fmt.Errorf("no AWS region configured, set AWS_REGION or the profile's aws.region")
+	}
+	endpoint := a.Endpoint
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://secretsmanager.%s.amazonaws.com", a.Credentials.Region)
+	}
+	body, _ := json.Marshal(map[string]string{"SecretId": id})
+	req, err := http.NewRequestWithContext(ctx, "POST", strings.TrimRight(endpoint, "/")+"/", bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+	cloud.SignAWSRequest(req, body, "secretsmanager", a.Credentials)
+
+	data, err := send(a.HTTPClient, req)
+	if err != nil {
+		return "", err
+	}
+	var secret struct {
+		SecretString *string `json:"SecretString"`
+		SecretBinary string  `json:"SecretBinary"`
+	}
+	if err := json.Unmarshal(data, &secret); err != nil {
+		return "", err
+	}
+	if secret.SecretString != nil {
+		return *secret.SecretString, nil
+	}
+	binary, err := base64.StdEncoding.DecodeString(secret.SecretBinary)
+	return string(binary), err
+}
+
+// send sends req and returns the body of a 200 response.
+func send(client *http.Client, req *http.Request) ([]byte, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		// AWS puts the message at the top, Google under "error".
+		var apiErr struct {
+			Message string `json:"message"`
+			Error   struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		json.Unmarshal(data, &apiErr)
+		for _, msg := range []string{apiErr.Message, apiErr.Error.Message} {
+			if msg != "" {
+				return nil, fmt.Errorf("%s: %s", resp.Status, msg)
+			}
+		}
+		return nil, fmt.Errorf("%s", resp.Status)
+	}
+	return data, nil
+}
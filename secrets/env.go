@@ -0,0 +1,21 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Env reads secrets from the environment of n8nctl, as CI systems provide
+// them. References are variable names, e.g. ${{env:GITHUB_TOKEN}}.
+type Env struct{}
+
+func (Env) Resolve(_ context.Context, ref string) (string, error) {
+	name := strings.TrimSpace(ref)
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return "", fmt.Errorf("environment variable %s is not set", name)
+	}
+	return value, nil
+}
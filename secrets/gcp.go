@@ -0,0 +1,91 @@
+package secrets
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/brandon-kyle-bailey/n8nctl/cloud"
+)
+
+// GCPSecretManager reads secrets from Google Cloud Secret Manager. References
+// are secret resource names, optionally with a version, and the field of a
+// JSON secret after #, e.g. projects/x/secrets/y#password. The latest
+// version is used unless one is named.
+type GCPSecretManager struct {
+	// CredentialsFile is a service account key, defaulting to
+	// GOOGLE_APPLICATION_CREDENTIALS.
+	CredentialsFile string
+	// Endpoint overrides https://secretmanager.googleapis.com.
+	Endpoint   string
+	HTTPClient *http.Client
+
+	mu      sync.Mutex
+	token   string
+	secrets map[string]string
+}
+
+func (g *GCPSecretManager) Resolve(ctx context.Context, ref string) (string, error) {
+	name, key := splitRef(ref)
+	name = strings.Trim(name, "/")
+	if !strings.HasPrefix(name, "projects/") || !strings.Contains(name, "/secrets/") {
+		return "", fmt.Errorf("invalid Secret Manager reference %q, expected projects/<project>/secrets/<secret>", ref)
+	}
+	if !strings.Contains(name, "/versions/") {
+		name += "/versions/latest"
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	value, ok := g.secrets[name]
+	if !ok {
+		var err error
+		if value, err = g.read(ctx, name); err != nil {
+			return "", fmt.Errorf("failed to read gcp-sm:%s: %w", name, err)
+		}
+		if g.secrets == nil {
+			g.secrets = map[string]string{}
+		}
+		g.secrets[name] = value
+	}
+	if key == "" {
+		return value, nil
+	}
+	return jsonField(value, key, "gcp-sm:"+name)
+}
+
+func (g *GCPSecretManager) read(ctx context.Context, name string) (string, error) {
+	if g.token == "" {
+		token, err := cloud.GCPAccessToken(ctx, g.CredentialsFile, cloud.GCPScopeCloudPlatform)
+		if err != nil {
+			return "", err
+		}
+		g.token = token
+	}
+	endpoint := g.Endpoint
+	if endpoint == "" {
+		endpoint = "https://secretmanager.googleapis.com"
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/v1/%s:access", strings.TrimRight(endpoint, "/"), name), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+g.token)
+	data, err := send(g.HTTPClient, req)
+	if err != nil {
+		return "", err
+	}
+	var version struct {
+		Payload struct {
+			Data string `json:"data"`
+		} `json:"payload"`
+	}
+	if err := json.Unmarshal(data, &version); err != nil {
+		return "", err
+	}
+	payload, err := base64.StdEncoding.DecodeString(version.Payload.Data)
+	return string(payload), err
+}
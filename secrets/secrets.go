@@ -0,0 +1,68 @@
+// Package secrets resolves the secret references of workflow templates, such
+// as ${{vault:secret/data/app#password}}, against external secret stores, so
+// secrets never have to be written to .env files.
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Resolver fetches secrets from one store.
+type Resolver interface {
+	// Resolve returns the secret ref names. ref is the part of a placeholder
+	// after the store name, e.g. secret/data/app#password.
+	Resolve(ctx context.Context, ref string) (string, error)
+}
+
+// splitRef splits a reference into the secret and the key of the field to
+// use, which is empty when the reference names no field.
+func splitRef(ref string) (secret, key string) {
+	secret, key, _ = strings.Cut(strings.TrimSpace(ref), "#")
+	return secret, key
+}
+
+// field returns the field key of a secret with several fields. When key is
+// empty the secret must have exactly one field.
+func field(fields map[string]any, key, ref string) (string, error) {
+	if key == "" {
+		if len(fields) != 1 {
+			names := make([]string, 0, len(fields))
+			for name := range fields {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			return "", fmt.Errorf("secret %s has the fields %s, name one as %s#<field>", ref, strings.Join(names, ", "), ref)
+		}
+		for name := range fields {
+			key = name
+		}
+	}
+	value, ok := fields[key]
+	if !ok {
+		return "", fmt.Errorf("secret %s has no field %q", ref, key)
+	}
+	return stringValue(value), nil
+}
+
+// jsonField returns a field of a secret holding a JSON object.
+func jsonField(secret, key, ref string) (string, error) {
+	var fields map[string]any
+	if err := json.Unmarshal([]byte(secret), &fields); err != nil {
+		return "", fmt.Errorf("secret %s is not a JSON object, drop #%s to use all of it", ref, key)
+	}
+	return field(fields, key, ref)
+}
+
+// stringValue renders a secret value for a template: strings as they are,
+// anything else as JSON.
+func stringValue(value any) string {
+	if s, ok := value.(string); ok {
+		return s
+	}
+	data, _ := json.Marshal(value)
+	return string(data)
+}
@@ -0,0 +1,147 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// Vault reads secrets from HashiCorp Vault. References are API paths below
+// /v1 with an optional field, e.g. secret/data/app#password for the KV v2
+// engine mounted at secret/ or kv/app#password for KV v1.
+type Vault struct {
+	Address   string
+	Namespace string
+	// Token authenticates directly. Without it, RoleID and SecretID log in
+	// through the AppRole auth method mounted at AppRoleMount.
+	Token        string
+	RoleID       string
+	SecretID     string
+	AppRoleMount string
+	HTTPClient   *http.Client
+
+	mu sync.Mutex
+	// secrets caches the fields of every secret read, so references to
+	// several fields of one secret read it once.
+	secrets map[string]map[string]any
+}
+
+func (v *Vault) Resolve(ctx context.Context, ref string) (string, error) {
+	path, key := splitRef(ref)
+	if path == "" {
+		return "", fmt.Errorf("empty Vault reference")
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	fields, ok := v.secrets[path]
+	if !ok {
+		var err error
+		if fields, err = v.read(ctx, path); err != nil {
+			return "", err
+		}
+		if v.secrets == nil {
+			v.secrets = map[string]map[string]any{}
+		}
+		v.secrets[path] = fields
+	}
+	return field(fields, key, "vault:"+path)
+}
+
+// read returns the fields of the secret at path, unwrapping the data and
+// metadata KV v2 returns.
+func (v *Vault) read(ctx context.Context, path string) (map[string]any, error) {
+	if v.Address == "" {
+		return nil, fmt.Errorf("no Vault address configured, set VAULT_ADDR or the profile's vault.address")
+	}
+	if v.Token == "" {
+		if err := v.login(ctx); err != nil {
+			return nil, err
+		}
+	}
+	var resp struct {
+		Data map[string]any `json:"data"`
+	}
+	if err := v.request(ctx, "GET", strings.TrimLeft(path, "/"), nil, &resp); err != nil {
+		return nil, fmt.Errorf("failed to read vault:%s: %w", path, err)
+	}
+	if inner, ok := resp.Data["data"].(map[string]any); ok {
+		if _, v2 := resp.Data["metadata"]; v2 {
+			return inner, nil
+		}
+	}
+	return resp.Data, nil
+}
+
+// login exchanges the AppRole credentials for a token.
+func (v *Vault) login(ctx context.Context) error {
+	if v.RoleID == "" || v.SecretID == "" {
+		return fmt.Errorf("no Vault credentials configured, set VAULT_TOKEN or the profile's vault.token, or vault.role_id and vault.secret_id")
+	}
+	mount := v.AppRoleMount
+	if mount == "" {
+		mount = "approle"
+	}
+	var resp struct {
+		Auth struct {
+			ClientToken string `json:"client_token"`
+		} `json:"auth"`
+	}
+	payload := map[string]string{"role_id": v.RoleID, "secret_id": v.SecretID}
+	if err := v.request(ctx, "POST", "auth/"+strings.Trim(mount, "/")+"/login", payload, &resp); err != nil {
+		return fmt.Errorf("Vault AppRole login failed: %w", err)
+	}
+	if resp.Auth.ClientToken == "" {
+		return fmt.Errorf("Vault AppRole login returned no token")
+	}
+	v.Token = resp.Auth.ClientToken
+	return nil
+}
+
+func (v *Vault) request(ctx context.Context, method, path string, payload, out any) error {
+	var body io.Reader
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimRight(v.Address, "/")+"/v1/"+path, body)
+	if err != nil {
+		return err
+	}
+	if v.Token != "" {
+		req.Header.Set("X-Vault-Token", v.Token)
+	}
+	if v.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", v.Namespace)
+	}
+	client := v.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		var vaultErr struct {
+			Errors []string `json:"errors"`
+		}
+		if json.Unmarshal(data, &vaultErr) == nil && len(vaultErr.Errors) > 0 {
+			return fmt.Errorf("%s: %s", resp.Status, strings.Join(vaultErr.Errors, "; "))
+		}
+		return fmt.Errorf("%s", resp.Status)
+	}
+	return json.Unmarshal(data, out)
+}
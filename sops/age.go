@@ -0,0 +1,83 @@
+package sops
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+)
+
+// ageIdentities returns the age keys SOPS itself would use: those in
+// SOPS_AGE_KEY, in the file SOPS_AGE_KEY_FILE names, and in sops/age/keys.txt
+// under the user's config directory.
+func ageIdentities() ([]age.Identity, error) {
+	var sources []string
+	if keys := os.Getenv("SOPS_AGE_KEY"); keys != "" {
+		sources = append(sources, keys)
+	}
+	files := []string{os.Getenv("SOPS_AGE_KEY_FILE")}
+	if dir, err := os.UserConfigDir(); err == nil {
+		files = append(files, filepath.Join(dir, "sops", "age", "keys.txt"))
+	}
+	for _, file := range files {
+		if file == "" {
+			continue
+		}
+		data, err := os.ReadFile(file)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, string(data))
+	}
+
+	var identities []age.Identity
+	for _, source := range sources {
+		// age refuses files without keys, which only leave fewer to try.
+		if !hasAgeKey(source) {
+			continue
+		}
+		parsed, err := age.ParseIdentities(strings.NewReader(source))
+		if err != nil {
+			return nil, err
+		}
+		identities = append(identities, parsed...)
+	}
+	return identities, nil
+}
+
+// hasAgeKey reports whether a key file has a line that is not blank or a
+// comment.
+func hasAgeKey(source string) bool {
+	for line := range strings.Lines(source) {
+		if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "#") {
+			return true
+		}
+	}
+	return false
+}
+
+// ageDecrypt decrypts an armored age file, as SOPS stores data keys, with
+// the first of identities that is one of its recipients.
+func ageDecrypt(armored string, identities []age.Identity) ([]byte, error) {
+	r, err := age.Decrypt(armor.NewReader(strings.NewReader(strings.TrimSpace(armored))), identities...)
+	if err != nil {
+		var noMatch *age.NoIdentityMatchError
+		if errors.As(err, &noMatch) {
+			return nil, errors.New("none of the age keys can decrypt it")
+		}
+		return nil, err
+	}
+	var out bytes.Buffer
+	if _, err := io.Copy(&out, r); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
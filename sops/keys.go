@@ -0,0 +1,142 @@
+package sops
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/brandon-kyle-bailey/n8nctl/cloud"
+)
+
+var httpClient = &http.Client{Timeout: 30 * time.Second}
+
+// dataKey decrypts the file's data key with the first master key that
+// works: an age key available locally, then AWS KMS, then Google Cloud KMS.
+func dataKey(ctx context.Context, meta metadata) ([]byte, error) {
+	if len(meta.KeyGroups) > 0 {
+		return nil, errors.New("key groups are not supported, decrypt the file with sops instead")
+	}
+	var errs []error
+	if len(meta.Age) > 0 {
+		identities, err := ageIdentities()
+		switch {
+		case err != nil:
+			errs = append(errs, fmt.Errorf("age: %w", err))
+		case len(identities) == 0:
+			errs = append(errs, errors.New("age: no keys found, set SOPS_AGE_KEY or SOPS_AGE_KEY_FILE"))
+		}
+		for _, key := range meta.Age {
+			if len(identities) == 0 {
+				break
+			}
+			dataKey, err := ageDecrypt(key.Enc, identities)
+			if err == nil {
+				return dataKey, nil
+			}
+			errs = append(errs, fmt.Errorf("age %s: %w", key.Recipient, err))
+		}
+	}
+	for _, key := range meta.KMS {
+		dataKey, err := kmsDecrypt(ctx, key.ARN, key.Enc, key.Context)
+		if err == nil {
+			return dataKey, nil
+		}
+		errs = append(errs, fmt.Errorf("AWS KMS %s: %w", key.ARN, err))
+	}
+	for _, key := range meta.GCPKMS {
+		dataKey, err := gcpKMSDecrypt(ctx, key.ResourceID, key.Enc)
+		if err == nil {
+			return dataKey, nil
+		}
+		errs = append(errs, fmt.Errorf("GCP KMS %s: %w", key.ResourceID, err))
+	}
+	if len(errs) == 0 {
+		return nil, errors.New("the file has no age, AWS KMS or GCP KMS keys")
+	}
+	return nil, errors.Join(errs...)
+}
+
+// kmsDecrypt decrypts a data key with AWS KMS, using the credentials in the
+// AWS_* environment variables.
+func kmsDecrypt(ctx context.Context, arn, enc string, encryptionContext map[string]string) ([]byte, error) {
+	parts := strings.Split(arn, ":")
+	if len(parts) < 4 {
+		return nil, fmt.Errorf("invalid key ARN")
+	}
+	creds, err := cloud.AWSCredentialsFromEnv(parts[3])
+	if err != nil {
+		return nil, err
+	}
+	input := map[string]any{"CiphertextBlob": enc, "KeyId": arn}
+	if len(encryptionContext) > 0 {
+		input["EncryptionContext"] = encryptionContext
+	}
+	body, err := json.Marshal(input)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", fmt.Sprintf("https://kms.%s.amazonaws.com/", creds.Region), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "TrentService.Decrypt")
+	cloud.SignAWSRequest(req, body, "kms", creds)
+	var resp struct {
+		Plaintext string `json:"Plaintext"`
+	}
+	if err := send(req, &resp); err != nil {
+		return nil, err
+	}
+	return base64.StdEncoding.DecodeString(resp.Plaintext)
+}
+
+// gcpKMSDecrypt decrypts a data key with Google Cloud KMS, using the
+// application default service account key.
+func gcpKMSDecrypt(ctx context.Context, resourceID, enc string) ([]byte, error) {
+	token, err := cloud.GCPAccessToken(ctx, "", cloud.GCPScopeCloudPlatform)
+	if err != nil {
+		return nil, err
+	}
+	body, err := json.Marshal(map[string]string{"ciphertext": enc})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", fmt.Sprintf("https://cloudkms.googleapis.com/v1/%s:decrypt", resourceID), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	var resp struct {
+		Plaintext string `json:"plaintext"`
+	}
+	if err := send(req, &resp); err != nil {
+		return nil, err
+	}
+	return base64.StdEncoding.DecodeString(resp.Plaintext)
+}
+
+// send sends req and decodes a 200 response into v.
+func send(req *http.Request, v any) error {
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(data)))
+	}
+	return json.Unmarshal(data, v)
+}
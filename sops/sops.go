@@ -0,0 +1,319 @@
+// Package sops decrypts files encrypted with SOPS (https://getsops.io), so
+// encrypted environment files can be committed next to the workflows. Data
+// keys wrapped with age, AWS KMS or Google Cloud KMS are supported; PGP,
+// Vault transit and Shamir key groups are not.
+package sops
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+type metadata struct {
+	Age []struct {
+		Recipient string `yaml:"recipient"`
+		Enc       string `yaml:"enc"`
+	} `yaml:"age"`
+	KMS []struct {
+		ARN     string            `yaml:"arn"`
+		Enc     string            `yaml:"enc"`
+		Context map[string]string `yaml:"context"`
+	} `yaml:"kms"`
+	GCPKMS []struct {
+		ResourceID string `yaml:"resource_id"`
+		Enc        string `yaml:"enc"`
+	} `yaml:"gcp_kms"`
+	KeyGroups        []any  `yaml:"key_groups"`
+	LastModified     string `yaml:"lastmodified"`
+	MAC              string `yaml:"mac"`
+	MACOnlyEncrypted bool   `yaml:"mac_only_encrypted"`
+}
+
+// isDotenv reports whether SOPS treats path as a dotenv file rather than
+// YAML or JSON.
+func isDotenv(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml", ".json":
+		return false
+	}
+	return true
+}
+
+// IsEncrypted reports whether data, the content of path, was encrypted with
+// SOPS.
+func IsEncrypted(path string, data []byte) bool {
+	if isDotenv(path) {
+		return bytes.Contains(data, []byte("\nsops_mac=")) || bytes.HasPrefix(data, []byte("sops_mac="))
+	}
+	var doc struct {
+		Sops *metadata `yaml:"sops"`
+	}
+	return yaml.Unmarshal(data, &doc) == nil && doc.Sops != nil && doc.Sops.MAC != ""
+}
+
+// leaf is a value of the file in document order, with the keys leading to it.
+type leaf struct {
+	path  []string
+	value string
+	// plain is the value as SOPS hashes it, for values stored unencrypted.
+	plain string
+}
+
+// DecryptEnv decrypts the SOPS-encrypted YAML, JSON or dotenv file path with
+// content data, verifies its MAC and returns its top-level values as
+// strings. Nested values are checked but not returned.
+func DecryptEnv(ctx context.Context, path string, data []byte) (map[string]string, error) {
+	var leaves []leaf
+	var meta metadata
+	var err error
+	if isDotenv(path) {
+		leaves, meta, err = parseDotenv(data)
+	} else {
+		leaves, meta, err = parseTree(data)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if meta.MAC == "" {
+		return nil, fmt.Errorf("%s is not encrypted with SOPS", path)
+	}
+	key, err := dataKey(ctx, meta)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt the data key of %s: %w", path, err)
+	}
+
+	env := map[string]string{}
+	mac := sha512.New()
+	for _, l := range leaves {
+		value, encrypted := l.plain, false
+		if m := encryptedValue.FindStringSubmatch(l.value); m != nil {
+			plaintext, err := decryptValue(key, m, strings.Join(l.path, ":")+":")
+			if err != nil {
+				return nil, fmt.Errorf("failed to decrypt %s in %s: %w", strings.Join(l.path, "."), path, err)
+			}
+			value, encrypted = string(plaintext), true
+		}
+		if encrypted || !meta.MACOnlyEncrypted {
+			mac.Write([]byte(value))
+		}
+		if len(l.path) == 1 {
+			if value == "True" || value == "False" {
+				value = strings.ToLower(value)
+			}
+			env[l.path[0]] = value
+		}
+	}
+
+	m := encryptedValue.FindStringSubmatch(meta.MAC)
+	if m == nil {
+		return nil, fmt.Errorf("%s has a malformed MAC", path)
+	}
+	want, err := decryptValue(key, m, meta.LastModified)
+	if err != nil || !strings.EqualFold(string(want), hex.EncodeToString(mac.Sum(nil))) {
+		return nil, fmt.Errorf("%s has been modified since it was encrypted: its MAC does not match", path)
+	}
+	return env, nil
+}
+
+// Keys returns the names of the top-level values of the SOPS-encrypted YAML,
+// JSON or dotenv file path with content data, which SOPS leaves in plain
+// text, without decrypting it.
+func Keys(path string, data []byte) ([]string, error) {
+	var leaves []leaf
+	var err error
+	if isDotenv(path) {
+		leaves, _, err = parseDotenv(data)
+	} else {
+		leaves, _, err = parseTree(data)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	var keys []string
+	for _, l := range leaves {
+		if len(l.path) == 1 {
+			keys = append(keys, l.path[0])
+		}
+	}
+	return keys, nil
+}
+
+var encryptedValue = regexp.MustCompile(`^ENC\[AES256_GCM,data:(.*),iv:(.*),tag:(.*),type:(.*)\]$`)
+
+// decryptValue decrypts a value SOPS encrypted with AES-256-GCM, authenticated
+// with the path of its key.
+func decryptValue(key []byte, m []string, additionalData string) ([]byte, error) {
+	data, err1 := base64.StdEncoding.DecodeString(m[1])
+	iv, err2 := base64.StdEncoding.DecodeString(m[2])
+	tag, err3 := base64.StdEncoding.DecodeString(m[3])
+	if err1 != nil || err2 != nil || err3 != nil {
+		return nil, fmt.Errorf("malformed encrypted value")
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCMWithNonceSize(block, len(iv))
+	if err != nil {
+		return nil, err
+	}
+	return gcm.Open(nil, iv, append(data, tag...), []byte(additionalData))
+}
+
+// parseTree returns the leaves and metadata of a YAML or JSON file.
+func parseTree(data []byte) ([]leaf, metadata, error) {
+	var meta metadata
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, meta, err
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, meta, fmt.Errorf("expected a mapping at the top level")
+	}
+	root := doc.Content[0]
+	var leaves []leaf
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key, value := root.Content[i], root.Content[i+1]
+		if key.Value == "sops" {
+			if err := value.Decode(&meta); err != nil {
+				return nil, meta, fmt.Errorf("invalid sops metadata: %w", err)
+			}
+			continue
+		}
+		walk(value, []string{key.Value}, &leaves)
+	}
+	return leaves, meta, nil
+}
+
+func walk(node *yaml.Node, path []string, leaves *[]leaf) {
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			walk(node.Content[i+1], append(path[:len(path):len(path)], node.Content[i].Value), leaves)
+		}
+	case yaml.SequenceNode:
+		for _, item := range node.Content {
+			walk(item, path, leaves)
+		}
+	case yaml.AliasNode:
+		walk(node.Alias, path, leaves)
+	case yaml.ScalarNode:
+		*leaves = append(*leaves, leaf{path: path, value: node.Value, plain: scalarBytes(node)})
+	}
+}
+
+// scalarBytes renders an unencrypted scalar the way SOPS hashes it.
+func scalarBytes(node *yaml.Node) string {
+	switch node.ShortTag() {
+	case "!!int":
+		var n int64
+		if node.Decode(&n) == nil {
+			return strconv.FormatInt(n, 10)
+		}
+	case "!!float":
+		var f float64
+		if node.Decode(&f) == nil {
+			return strconv.FormatFloat(f, 'f', -1, 64)
+		}
+	case "!!bool":
+		var b bool
+		if node.Decode(&b) == nil {
+			if b {
+				return "True"
+			}
+			return "False"
+		}
+	case "!!null":
+		return ""
+	}
+	return node.Value
+}
+
+// parseDotenv returns the values and metadata of a dotenv file. SOPS
+// flattens the metadata into sops_ keys, e.g. sops_age__list_0__map_enc.
+func parseDotenv(data []byte) ([]leaf, metadata, error) {
+	var meta metadata
+	var leaves []leaf
+	flat := map[string]any{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, meta, fmt.Errorf("invalid line %q", line)
+		}
+		value = strings.ReplaceAll(value, `\n`, "\n")
+		if name, ok := strings.CutPrefix(key, "sops_"); ok {
+			if err := setFlattened(flat, strings.Split(name, "__"), value); err != nil {
+				return nil, meta, err
+			}
+			continue
+		}
+		leaves = append(leaves, leaf{path: []string{key}, value: value, plain: value})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, meta, err
+	}
+	// Round-trip through YAML to fill the metadata struct.
+	out, err := yaml.Marshal(flat)
+	if err != nil {
+		return nil, meta, err
+	}
+	if err := yaml.Unmarshal(out, &meta); err != nil {
+		return nil, meta, fmt.Errorf("invalid sops metadata: %w", err)
+	}
+	return leaves, meta, nil
+}
+
+// setFlattened stores value at the path of a flattened key, where list_<n>
+// segments index lists and map_<key> segments name map keys.
+func setFlattened(node map[string]any, path []string, value string) error {
+	var set func(container any, path []string) (any, error)
+	set = func(container any, path []string) (any, error) {
+		if len(path) == 0 {
+			if value == "true" || value == "false" {
+				return value == "true", nil
+			}
+			return value, nil
+		}
+		segment := path[0]
+		if n, ok := strings.CutPrefix(segment, "list_"); ok {
+			i, err := strconv.Atoi(n)
+			if err != nil || i < 0 || i > 1000 {
+				return nil, fmt.Errorf("invalid sops metadata key segment %q", segment)
+			}
+			list, _ := container.([]any)
+			for len(list) <= i {
+				list = append(list, nil)
+			}
+			item, err := set(list[i], path[1:])
+			list[i] = item
+			return list, err
+		}
+		m, _ := container.(map[string]any)
+		if m == nil {
+			m = map[string]any{}
+		}
+		item, err := set(m[strings.TrimPrefix(segment, "map_")], path[1:])
+		m[strings.TrimPrefix(segment, "map_")] = item
+		return m, err
+	}
+	_, err := set(node, path)
+	return err
+}
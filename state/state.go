@@ -0,0 +1,114 @@
+// Package state tracks which local workflow files have been deployed to which remote workflows.
+package state
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Path is the project-local state file, relative to the working directory.
+var Path = filepath.Join(".n8nctl", "state.json")
+
+// UseProfile switches Path to the state file of a config profile. Each
+// profile points at its own instance with its own workflow IDs, so only the
+// default profile uses the plain state.json.
+func UseProfile(profile string) {
+	if profile != "" && profile != "default" {
+		Path = filepath.Join(".n8nctl", "state."+profile+".json")
+	}
+}
+
+type Entry struct {
+	WorkflowID string    `json:"workflow_id"`
+	Hash       string    `json:"hash"`
+	DeployedAt time.Time `json:"deployed_at"`
+	// UpdatedAt is the updatedAt the instance reported for the deployed
+	// workflow, by its own clock. Remote changes are detected against it,
+	// so clock skew between the machine and the instance does not matter.
+	UpdatedAt time.Time `json:"updated_at,omitzero"`
+}
+
+type State struct {
+	Workflows map[string]Entry `json:"workflows"`
+}
+
+// Load reads the state file, returning an empty state if none exists yet.
+func Load() (*State, error) {
+	st := &State{Workflows: map[string]Entry{}}
+	f, err := os.Open(Path)
+	if os.IsNotExist(err) {
+		return st, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if err := json.NewDecoder(f).Decode(st); err != nil {
+		return nil, err
+	}
+	if st.Workflows == nil {
+		st.Workflows = map[string]Entry{}
+	}
+	return st, nil
+}
+
+func (s *State) Save() error {
+	if err := os.MkdirAll(filepath.Dir(Path), 0755); err != nil {
+		return err
+	}
+	f, err := os.Create(Path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	encoder := json.NewEncoder(f)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(s)
+}
+
+// Get returns the recorded deployment of a local workflow file.
+func (s *State) Get(file string) (Entry, bool) {
+	entry, ok := s.Workflows[key(file)]
+	return entry, ok
+}
+
+// Record stores that file was deployed as workflowID with the given rendered JSON,
+// and updatedAt as the instance reported it.
+func (s *State) Record(file, workflowID string, body []byte, updatedAt time.Time) {
+	s.Workflows[key(file)] = Entry{
+		WorkflowID: workflowID,
+		Hash:       Hash(body),
+		DeployedAt: time.Now().UTC(),
+		UpdatedAt:  updatedAt,
+	}
+}
+
+// ChangedRemotely reports whether the remote workflow, last updated at
+// updatedAt by the instance's clock, changed since the deploy of entry.
+func (e Entry) ChangedRemotely(updatedAt time.Time) bool {
+	if e.UpdatedAt.IsZero() {
+		// Entries recorded before UpdatedAt only have the local time.
+		return updatedAt.After(e.DeployedAt)
+	}
+	return !updatedAt.Equal(e.UpdatedAt)
+}
+
+// Forget removes the recorded deployment of a local workflow file.
+func (s *State) Forget(file string) {
+	delete(s.Workflows, key(file))
+}
+
+// Hash returns the content hash used to detect changes since the last deploy.
+func Hash(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+// key normalizes file paths so the state file is portable between platforms.
+func key(file string) string {
+	return filepath.ToSlash(filepath.Clean(file))
+}
@@ -0,0 +1,41 @@
+package telemetry
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// Middleware traces each request as a client span of the span in its
+// context, and passes the trace on in a traceparent header so it continues
+// on instances that trace too. It has the shape of an n8n.Middleware.
+func Middleware(next http.RoundTripper) http.RoundTripper {
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		if active == nil {
+			return next.RoundTrip(req)
+		}
+		ctx, span := start(req.Context(), fmt.Sprintf("%s %s", req.Method, req.URL.Path), kindClient)
+		defer span.End()
+		span.SetAttribute("http.request.method", req.Method)
+		span.SetAttribute("url.full", req.URL.Redacted())
+		span.SetAttribute("server.address", req.URL.Hostname())
+
+		req = req.Clone(ctx)
+		req.Header.Set("traceparent", span.traceparent())
+		resp, err := next.RoundTrip(req)
+		if err != nil {
+			span.SetError(err)
+			return nil, err
+		}
+		span.SetAttribute("http.response.status_code", resp.StatusCode)
+		if resp.StatusCode >= 400 {
+			span.SetError(fmt.Errorf("%s", resp.Status))
+		}
+		return resp, nil
+	})
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
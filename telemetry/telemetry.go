@@ -0,0 +1,301 @@
+// Package telemetry records OpenTelemetry traces of a command and its API
+// calls and exports them over OTLP/HTTP, so pipelines can see where the
+// time of a run goes.
+package telemetry
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Span is a timed operation within a trace. All methods are no-ops on a nil
+// span, which is what Start returns while tracing is disabled.
+type Span struct {
+	traceID [16]byte
+	spanID  [8]byte
+	parent  [8]byte
+	name    string
+	kind    int
+	start   time.Time
+	end     time.Time
+	attrs   map[string]any
+	failed  bool
+	message string
+}
+
+// Span kinds as defined by OTLP.
+const (
+	kindInternal = 1
+	kindClient   = 3
+)
+
+type tracer struct {
+	endpoint string
+	headers  map[string]string
+	service  string
+	// remote is the parent of root spans, from a TRACEPARENT environment
+	// variable set by the pipeline that runs n8nctl.
+	remote *Span
+
+	mu    sync.Mutex
+	open  []*Span
+	ended []*Span
+}
+
+var active *tracer
+
+// exitHooks run when the command finishes, see AtExit.
+var exitHooks []func(exitCode int)
+
+// Endpoint returns the OTLP endpoint configured by the environment, as used
+// by the OpenTelemetry SDKs, or "" when there is none.
+func Endpoint() string {
+	if endpoint := os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT"); endpoint != "" {
+		return endpoint
+	}
+	return os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT")
+}
+
+// Init enables tracing, exporting to the OTLP/HTTP collector at endpoint,
+// e.g. http://localhost:4318. OTEL_EXPORTER_OTLP_HEADERS and
+// OTEL_SERVICE_NAME are honoured like the OpenTelemetry SDKs do.
+func Init(endpoint string) {
+	if endpoint == "" {
+		return
+	}
+	endpoint = strings.TrimRight(endpoint, "/")
+	if !strings.HasSuffix(endpoint, "/v1/traces") {
+		endpoint += "/v1/traces"
+	}
+	t := &tracer{endpoint: endpoint, headers: map[string]string{}, service: "n8nctl"}
+	if service := os.Getenv("OTEL_SERVICE_NAME"); service != "" {
+		t.service = service
+	}
+	for pair := range strings.SplitSeq(os.Getenv("OTEL_EXPORTER_OTLP_HEADERS"), ",") {
+		if key, value, ok := strings.Cut(pair, "="); ok {
+			t.headers[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		}
+	}
+	t.remote = parseTraceparent(os.Getenv("TRACEPARENT"))
+	active = t
+}
+
+type spanKey struct{}
+
+// Start begins a span named name as a child of the span in ctx, and returns
+// a context carrying the new span.
+func Start(ctx context.Context, name string) (context.Context, *Span) {
+	return start(ctx, name, kindInternal)
+}
+
+func start(ctx context.Context, name string, kind int) (context.Context, *Span) {
+	t := active
+	if t == nil {
+		return ctx, nil
+	}
+	span := &Span{name: name, kind: kind, start: time.Now(), attrs: map[string]any{}}
+	rand.Read(span.spanID[:])
+	parent, _ := ctx.Value(spanKey{}).(*Span)
+	if parent == nil {
+		parent = t.remote
+	}
+	if parent != nil {
+		span.traceID = parent.traceID
+		span.parent = parent.spanID
+	} else {
+		rand.Read(span.traceID[:])
+	}
+	t.mu.Lock()
+	t.open = append(t.open, span)
+	t.mu.Unlock()
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+// SetAttribute records a string, bool or number describing the operation.
+func (s *Span) SetAttribute(key string, value any) {
+	if s != nil {
+		s.attrs[key] = value
+	}
+}
+
+// SetError marks the operation as failed.
+func (s *Span) SetError(err error) {
+	if s != nil && err != nil {
+		s.failed = true
+		s.message = err.Error()
+	}
+}
+
+// End finishes the span. Spans are exported together by Shutdown.
+func (s *Span) End() {
+	t := active
+	if s == nil || t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for i, open := range t.open {
+		if open == s {
+			t.open = append(t.open[:i], t.open[i+1:]...)
+			s.end = time.Now()
+			t.ended = append(t.ended, s)
+			return
+		}
+	}
+}
+
+// traceparent formats the W3C trace context header for the span.
+func (s *Span) traceparent() string {
+	return fmt.Sprintf("00-%x-%x-01", s.traceID, s.spanID)
+}
+
+func parseTraceparent(header string) *Span {
+	parts := strings.Split(header, "-")
+	if len(parts) != 4 {
+		return nil
+	}
+	span := &Span{}
+	traceID, err1 := hex.DecodeString(parts[1])
+	spanID, err2 := hex.DecodeString(parts[2])
+	if err1 != nil || err2 != nil || len(traceID) != 16 || len(spanID) != 8 {
+		return nil
+	}
+	copy(span.traceID[:], traceID)
+	copy(span.spanID[:], spanID)
+	return span
+}
+
+// AtExit registers fn to run when the command finishes, by Shutdown or Exit,
+// e.g. to print a summary that os.Exit would skip.
+func AtExit(fn func(exitCode int)) {
+	exitHooks = append(exitHooks, fn)
+}
+
+// Shutdown runs the AtExit functions, ends the spans still open, marking them
+// failed when exitCode is not zero, and exports every span. Failures to
+// export are reported but do not fail the command.
+func Shutdown(exitCode int) {
+	hooks := exitHooks
+	exitHooks = nil
+	for _, fn := range hooks {
+		fn(exitCode)
+	}
+	t := active
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	open := append([]*Span(nil), t.open...)
+	t.mu.Unlock()
+	for i := len(open) - 1; i >= 0; i-- {
+		if exitCode != 0 && !open[i].failed {
+			open[i].SetError(fmt.Errorf("exit status %d", exitCode))
+		}
+		open[i].End()
+	}
+	if err := t.export(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to export traces to %s: %v\n", t.endpoint, err)
+	}
+	active = nil
+}
+
+// Exit runs Shutdown and exits, which os.Exit alone would skip.
+func Exit(code int) {
+	Shutdown(code)
+	os.Exit(code)
+}
+
+func (t *tracer) export() error {
+	t.mu.Lock()
+	ended := t.ended
+	t.ended = nil
+	t.mu.Unlock()
+	if len(ended) == 0 {
+		return nil
+	}
+
+	spans := make([]map[string]any, 0, len(ended))
+	for _, s := range ended {
+		span := map[string]any{
+			"traceId":           hex.EncodeToString(s.traceID[:]),
+			"spanId":            hex.EncodeToString(s.spanID[:]),
+			"name":              s.name,
+			"kind":              s.kind,
+			"startTimeUnixNano": fmt.Sprint(s.start.UnixNano()),
+			"endTimeUnixNano":   fmt.Sprint(s.end.UnixNano()),
+			"attributes":        attributes(s.attrs),
+			"status":            map[string]any{"code": 1},
+		}
+		if s.parent != [8]byte{} {
+			span["parentSpanId"] = hex.EncodeToString(s.parent[:])
+		}
+		if s.failed {
+			span["status"] = map[string]any{"code": 2, "message": s.message}
+		}
+		spans = append(spans, span)
+	}
+	payload := map[string]any{"resourceSpans": []any{map[string]any{
+		"resource":   map[string]any{"attributes": attributes(map[string]any{"service.name": t.service})},
+		"scopeSpans": []any{map[string]any{"scope": map[string]any{"name": "n8nctl"}, "spans": spans}},
+	}}}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", t.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range t.headers {
+		req.Header.Set(key, value)
+	}
+	resp, err := (&http.Client{Timeout: 5 * time.Second}).Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("collector responded %s", resp.Status)
+	}
+	return nil
+}
+
+// attributes encodes attributes as OTLP key-value pairs.
+func attributes(attrs map[string]any) []map[string]any {
+	keys := make([]string, 0, len(attrs))
+	for key := range attrs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	list := make([]map[string]any, 0, len(attrs))
+	for _, key := range keys {
+		var v map[string]any
+		value := attrs[key]
+		switch value := value.(type) {
+		case bool:
+			v = map[string]any{"boolValue": value}
+		case int:
+			v = map[string]any{"intValue": fmt.Sprint(value)}
+		case int64:
+			v = map[string]any{"intValue": fmt.Sprint(value)}
+		case float64:
+			v = map[string]any{"doubleValue": value}
+		default:
+			v = map[string]any{"stringValue": fmt.Sprint(value)}
+		}
+		list = append(list, map[string]any{"key": key, "value": v})
+	}
+	return list
+}
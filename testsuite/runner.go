@@ -0,0 +1,249 @@
+package testsuite
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/brandon-kyle-bailey/n8nctl/executions"
+)
+
+// Runner runs suites against an n8n instance. The API calls are supplied by
+// the caller, which owns the credentials.
+type Runner struct {
+	BaseURL string
+	Client  *http.Client
+	// Workflow returns the ID of the named workflow and whether it is active.
+	Workflow func(name string) (id string, active bool, err error)
+	// SetActive activates or deactivates a workflow.
+	SetActive func(id string, active bool) error
+	// Executions lists the most recent executions of a workflow.
+	Executions func(workflowID string) ([]executions.Execution, error)
+}
+
+// SuiteResult holds the outcome of every case of a suite.
+type SuiteResult struct {
+	Suite Suite
+	Cases []CaseResult
+}
+
+// CaseResult is the outcome of a case. Failures are unmet expectations, Err
+// means the case could not run to completion.
+type CaseResult struct {
+	Case            Case
+	Duration        time.Duration
+	Failures        []string
+	Err             error
+	ExecutionID     executions.ID
+	ExecutionStatus string
+}
+
+// Passed reports whether the case ran and met every expectation.
+func (r CaseResult) Passed() bool {
+	return r.Err == nil && len(r.Failures) == 0
+}
+
+// Run activates the workflows under test, runs every case and restores the
+// workflows it activated.
+func (r *Runner) Run(ctx context.Context, suites []Suite) ([]SuiteResult, error) {
+	ids := map[string]string{}
+	for _, name := range Workflows(suites) {
+		id, active, err := r.Workflow(name)
+		if err != nil {
+			return nil, fmt.Errorf("workflow %q: %w", name, err)
+		}
+		ids[name] = id
+		if active {
+			continue
+		}
+		// Production webhooks only listen while the workflow is active.
+		if err := r.SetActive(id, true); err != nil {
+			return nil, fmt.Errorf("failed to activate workflow %q: %w", name, err)
+		}
+		defer r.SetActive(id, false)
+	}
+
+	var results []SuiteResult
+	for _, suite := range suites {
+		result := SuiteResult{Suite: suite}
+		for _, c := range suite.Tests {
+			start := time.Now()
+			cr := r.runCase(ctx, c, ids[c.Workflow])
+			cr.Duration = time.Since(start)
+			result.Cases = append(result.Cases, cr)
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+func (r *Runner) runCase(ctx context.Context, c Case, workflowID string) CaseResult {
+	result := CaseResult{Case: c}
+	ctx, cancel := context.WithTimeout(ctx, c.Timeout)
+	defer cancel()
+
+	// Executions started before the call are not the case's, whatever their timing.
+	previous, err := r.Executions(workflowID)
+	if err != nil {
+		result.Err = fmt.Errorf("failed to list executions: %w", err)
+		return result
+	}
+	var last executions.ID
+	for _, exec := range previous {
+		if exec.ID.After(last) {
+			last = exec.ID
+		}
+	}
+
+	status, body, err := r.call(ctx, c)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	if c.Expect.Status != 0 && status != c.Expect.Status {
+		result.Failures = append(result.Failures, fmt.Sprintf("webhook responded %d, expected %d", status, c.Expect.Status))
+	}
+	if c.Expect.Body != nil {
+		if failure := checkBody(c.Expect.Body, body); failure != "" {
+			result.Failures = append(result.Failures, failure)
+		}
+	}
+
+	exec, err := r.awaitExecution(ctx, workflowID, last)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	result.ExecutionID = exec.ID
+	result.ExecutionStatus = exec.Status
+	if exec.Status != c.Expect.Execution {
+		result.Failures = append(result.Failures, fmt.Sprintf("execution %s ended with status %s, expected %s", exec.ID, exec.Status, c.Expect.Execution))
+	}
+	return result
+}
+
+func (r *Runner) call(ctx context.Context, c Case) (int, []byte, error) {
+	endpoint := strings.TrimRight(r.BaseURL, "/") + "/webhook/" + strings.TrimLeft(c.Webhook, "/")
+	if len(c.Query) > 0 {
+		query := url.Values{}
+		for k, v := range c.Query {
+			query.Set(k, v)
+		}
+		endpoint += "?" + query.Encode()
+	}
+	var reqBody io.Reader
+	if c.Body != nil {
+		data, err := json.Marshal(c.Body)
+		if err != nil {
+			return 0, nil, fmt.Errorf("invalid body: %w", err)
+		}
+		reqBody = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, c.Method, endpoint, reqBody)
+	if err != nil {
+		return 0, nil, err
+	}
+	if reqBody != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for k, v := range c.Headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := r.Client.Do(req)
+	if err != nil {
+		return 0, nil, fmt.Errorf("calling webhook %s failed: %w", c.Webhook, err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	return resp.StatusCode, body, err
+}
+
+// awaitExecution polls for the first execution of the workflow after last
+// and waits for it to finish.
+func (r *Runner) awaitExecution(ctx context.Context, workflowID string, last executions.ID) (*executions.Execution, error) {
+	for {
+		list, err := r.Executions(workflowID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list executions: %w", err)
+		}
+		var next *executions.Execution
+		for i, exec := range list {
+			if exec.ID.After(last) && (next == nil || next.ID.After(exec.ID)) {
+				next = &list[i]
+			}
+		}
+		if next != nil && next.StoppedAt != nil {
+			return next, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("no finished execution of workflow %s within the test timeout", workflowID)
+		case <-time.After(500 * time.Millisecond):
+		}
+	}
+}
+
+// checkBody reports how the response body falls short of the expected one,
+// or "" when it contains it.
+func checkBody(expected any, body []byte) string {
+	var actual any
+	if err := json.Unmarshal(body, &actual); err != nil {
+		actual = string(body)
+	}
+	// Round-trip through JSON so YAML and JSON numbers compare equal.
+	data, err := json.Marshal(expected)
+	if err != nil {
+		return fmt.Sprintf("invalid expected body: %v", err)
+	}
+	var want any
+	json.Unmarshal(data, &want)
+	if path, got, ok := contains(actual, want, "body"); !ok {
+		data, _ := json.Marshal(got)
+		return fmt.Sprintf("%s: got %s", path, data)
+	}
+	return ""
+}
+
+// contains reports whether actual contains want, and otherwise the path and
+// value of the first part of actual that differs.
+func contains(actual, want any, path string) (string, any, bool) {
+	switch w := want.(type) {
+	case map[string]any:
+		a, ok := actual.(map[string]any)
+		if !ok {
+			return path, actual, false
+		}
+		keys := make([]string, 0, len(w))
+		for k := range w {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if p, got, ok := contains(a[k], w[k], path+"."+k); !ok {
+				return p, got, false
+			}
+		}
+		return "", nil, true
+	case []any:
+		a, ok := actual.([]any)
+		if !ok || len(a) != len(w) {
+			return path, actual, false
+		}
+		for i := range w {
+			if p, got, ok := contains(a[i], w[i], fmt.Sprintf("%s[%d]", path, i)); !ok {
+				return p, got, false
+			}
+		}
+		return "", nil, true
+	default:
+		return path, actual, reflect.DeepEqual(actual, want)
+	}
+}
@@ -0,0 +1,123 @@
+// Package testsuite runs a project's workflow tests: each test calls a
+// webhook of a deployed workflow and checks the response and the execution
+// it produced.
+package testsuite
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Dir is where a project keeps its test files, relative to the working directory.
+const Dir = "tests"
+
+const defaultTimeout = 30 * time.Second
+
+// Suite is one test file. Workflow is the default workflow under test for
+// its cases.
+type Suite struct {
+	File     string `yaml:"-"`
+	Workflow string `yaml:"workflow"`
+	Tests    []Case `yaml:"tests"`
+}
+
+// Case calls the webhook at Webhook with the given request and checks the
+// outcome against Expect.
+type Case struct {
+	Name     string            `yaml:"name"`
+	Workflow string            `yaml:"workflow"`
+	Webhook  string            `yaml:"webhook"`
+	Method   string            `yaml:"method"`
+	Query    map[string]string `yaml:"query"`
+	Headers  map[string]string `yaml:"headers"`
+	Body     any               `yaml:"body"`
+	Timeout  time.Duration     `yaml:"timeout"`
+	Expect   Expect            `yaml:"expect"`
+}
+
+// Expect holds the assertions of a case. Body only needs to be contained in
+// the response: objects may have more fields than listed. Execution is the
+// expected status of the execution and defaults to success.
+type Expect struct {
+	Status    int    `yaml:"status"`
+	Body      any    `yaml:"body"`
+	Execution string `yaml:"execution"`
+}
+
+// Load reads every test file in dir.
+func Load(dir string) ([]Suite, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var suites []Suite
+	for _, entry := range entries {
+		ext := strings.ToLower(filepath.Ext(entry.Name()))
+		if entry.IsDir() || (ext != ".yaml" && ext != ".yml") {
+			continue
+		}
+		suite, err := LoadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		suites = append(suites, suite)
+	}
+	if len(suites) == 0 {
+		return nil, fmt.Errorf("no test files found in %s", dir)
+	}
+	return suites, nil
+}
+
+// LoadFile reads a single test file and fills in the defaults of its cases.
+func LoadFile(path string) (Suite, error) {
+	suite := Suite{File: path}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return suite, err
+	}
+	if err := yaml.Unmarshal(data, &suite); err != nil {
+		return suite, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	for i := range suite.Tests {
+		c := &suite.Tests[i]
+		if c.Name == "" {
+			c.Name = fmt.Sprintf("test %d", i+1)
+		}
+		if c.Workflow == "" {
+			c.Workflow = suite.Workflow
+		}
+		if c.Workflow == "" || c.Webhook == "" {
+			return suite, fmt.Errorf("%s: %q needs a workflow and a webhook", path, c.Name)
+		}
+		if c.Method == "" {
+			c.Method = "POST"
+		}
+		if c.Timeout == 0 {
+			c.Timeout = defaultTimeout
+		}
+		if c.Expect.Execution == "" {
+			c.Expect.Execution = "success"
+		}
+	}
+	return suite, nil
+}
+
+// Workflows returns the names of the workflows the suites test, without duplicates.
+func Workflows(suites []Suite) []string {
+	seen := map[string]bool{}
+	var names []string
+	for _, suite := range suites {
+		for _, c := range suite.Tests {
+			if !seen[c.Workflow] {
+				seen[c.Workflow] = true
+				names = append(names, c.Workflow)
+			}
+		}
+	}
+	return names
+}
@@ -0,0 +1,134 @@
+// Package usage records how much an n8n instance is used over time, as a
+// time series of snapshots kept next to the config.
+package usage
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Sample is one snapshot of the instance's usage.
+type Sample struct {
+	Time            time.Time `json:"time"`
+	Workflows       int       `json:"workflows"`
+	ActiveWorkflows int       `json:"active_workflows"`
+	// Credentials is nil when the instance does not list them.
+	Credentials *int `json:"credentials,omitempty"`
+	// Executions and FailedExecutions count those started in the 24 hours
+	// before Time.
+	Executions       int `json:"executions_per_day"`
+	FailedExecutions int `json:"failed_executions_per_day"`
+}
+
+// Metric is a series of a Sample field, as the report charts it.
+type Metric struct {
+	Name  string
+	Value func(Sample) (float64, bool)
+}
+
+var Metrics = []Metric{
+	{"workflows", func(s Sample) (float64, bool) { return float64(s.Workflows), true }},
+	{"active_workflows", func(s Sample) (float64, bool) { return float64(s.ActiveWorkflows), true }},
+	{"credentials", func(s Sample) (float64, bool) {
+		if s.Credentials == nil {
+			return 0, false
+		}
+		return float64(*s.Credentials), true
+	}},
+	{"executions_per_day", func(s Sample) (float64, bool) { return float64(s.Executions), true }},
+	{"failed_executions_per_day", func(s Sample) (float64, bool) { return float64(s.FailedExecutions), true }},
+}
+
+// Path returns the file the snapshots of a config profile are kept in, under
+// ~/.n8nctl/usage, as each profile points at its own instance.
+func Path(profile string) (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	if profile == "" {
+		profile = "default"
+	}
+	return filepath.Join(home, ".n8nctl", "usage", profile+".jsonl"), nil
+}
+
+// Append adds a sample to the end of the file at path, one JSON line each.
+func Append(path string, s Sample) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	if err := json.NewEncoder(f).Encode(s); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Load reads the samples at path taken since since, oldest first.
+func Load(path string, since time.Time) ([]Sample, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var samples []Sample
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		var s Sample
+		if err := json.Unmarshal(scanner.Bytes(), &s); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		if !s.Time.Before(since) {
+			samples = append(samples, s)
+		}
+	}
+	return samples, scanner.Err()
+}
+
+var sparks = []rune("▁▂▃▄▅▆▇█")
+
+// Sparkline draws values as a line of block characters at most width wide,
+// averaging neighbouring values when there are more than fit.
+func Sparkline(values []float64, width int) string {
+	if len(values) == 0 {
+		return ""
+	}
+	if len(values) > width {
+		buckets := make([]float64, width)
+		for i := range buckets {
+			from, to := i*len(values)/width, (i+1)*len(values)/width
+			sum := 0.0
+			for _, v := range values[from:to] {
+				sum += v
+			}
+			buckets[i] = sum / float64(to-from)
+		}
+		values = buckets
+	}
+	low, high := math.Inf(1), math.Inf(-1)
+	for _, v := range values {
+		low, high = min(low, v), max(high, v)
+	}
+	var line strings.Builder
+	for _, v := range values {
+		i := 0
+		if high > low {
+			i = int((v - low) / (high - low) * float64(len(sparks)-1))
+		}
+		line.WriteRune(sparks[i])
+	}
+	return line.String()
+}
@@ -0,0 +1,37 @@
+package utils
+
+import (
+	"os"
+
+	"golang.org/x/term"
+)
+
+const (
+	ColorRed    = "31"
+	ColorGreen  = "32"
+	ColorYellow = "33"
+	ColorCyan   = "36"
+	ColorBold   = "1"
+)
+
+var colorEnabled = detectColor()
+
+// detectColor reports whether stdout is a terminal that can render ANSI colors.
+// NO_COLOR (https://no-color.org) and TERM=dumb always disable colors.
+func detectColor() bool {
+	if os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	if !term.IsTerminal(int(os.Stdout.Fd())) {
+		return false
+	}
+	return enableVirtualTerminal()
+}
+
+// Colorize wraps s in the given ANSI color code when colors are enabled.
+func Colorize(s, code string) string {
+	if !colorEnabled {
+		return s
+	}
+	return "\x1b[" + code + "m" + s + "\x1b[0m"
+}
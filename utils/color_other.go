@@ -0,0 +1,7 @@
+//go:build !windows
+
+package utils
+
+func enableVirtualTerminal() bool {
+	return true
+}
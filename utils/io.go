@@ -1,15 +1,17 @@
-// Package utils provides utility functions for reading from stdin, printing JSON responses, and running diffs on JSON files.
+// Package utils provides utility functions for reading from stdin, printing JSON responses, and diffing JSON files.
 package utils
 
 import (
 	"bufio"
 	"bytes"
 	"encoding/json"
+	"flag"
 	"fmt"
 	"io"
 	"os"
-	"os/exec"
+	"strconv"
 	"strings"
+	"time"
 )
 
 func ReadStdin() string {
@@ -30,55 +32,24 @@ func ReadStdin() string {
 }
 
 func PrintJSONResponse(data []byte) {
-	var prettyJSON bytes.Buffer
-	err := json.Indent(&prettyJSON, data, "", "  ")
-	if err != nil {
+	if query != nil {
+		printQueryResult(data)
+		return
+	}
+	if !json.Valid(data) {
 		fmt.Println(string(data))
 		return
 	}
-	fmt.Println(prettyJSON.String())
+	IndentJSON(os.Stdout, bytes.NewReader(data))
 }
 
 func RunDiff(oldJSON, newJSON []byte) error {
-	oldTmpFile, err := os.CreateTemp("", "oldworkflow-*.json")
-	if err != nil {
-		return fmt.Errorf("failed to create temp file for old JSON: %w", err)
-	}
-	defer os.Remove(oldTmpFile.Name())
-	if _, err := oldTmpFile.Write(oldJSON); err != nil {
-		return fmt.Errorf("failed to write old JSON to temp file: %w", err)
-	}
-	oldTmpFile.Close()
-
-	newTmpFile, err := os.CreateTemp("", "newworkflow-*.json")
-	if err != nil {
-		return fmt.Errorf("failed to create temp file for new JSON: %w", err)
-	}
-	defer os.Remove(newTmpFile.Name())
-	if _, err := newTmpFile.Write(newJSON); err != nil {
-		return fmt.Errorf("failed to write new JSON to temp file: %w", err)
-	}
-	newTmpFile.Close()
-
-	diffCmdName := "diff"
-	if _, err := exec.LookPath("colordiff"); err == nil {
-		diffCmdName = "colordiff"
+	diff := UnifiedDiff("old", "new", string(oldJSON), string(newJSON), 3)
+	if diff == "" {
+		fmt.Println("No differences detected.")
+		return nil
 	}
-
-	diffCmd := exec.Command(diffCmdName, "-u", oldTmpFile.Name(), newTmpFile.Name())
-	diffCmd.Stdout = os.Stdout
-	diffCmd.Stderr = os.Stderr
-
-	err = diffCmd.Run()
-	if err != nil {
-		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
-			// differences found, not an error here
-			return nil
-		}
-		return fmt.Errorf("diff command failed: %w", err)
-	}
-
-	fmt.Println("No differences detected.")
+	fmt.Print(colorizeDiff(diff))
 	return nil
 }
 
@@ -106,3 +77,81 @@ func LoadDotEnv(filename string) (map[string]string, error) {
 	}
 	return env, scanner.Err()
 }
+
+// StringList is a flag.Value that collects every occurrence of a repeatable flag.
+type StringList []string
+
+func (l *StringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *StringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// ParseKeyValues parses KEY=VALUE pairs into a map.
+func ParseKeyValues(pairs []string) (map[string]string, error) {
+	result := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok || strings.TrimSpace(key) == "" {
+			return nil, fmt.Errorf("expected KEY=VALUE, got %q", pair)
+		}
+		result[strings.TrimSpace(key)] = value
+	}
+	return result, nil
+}
+
+// ParseFlags parses args with fs, allowing flags to appear before or after
+// positional arguments, and returns the positional arguments in order.
+func ParseFlags(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		if args[0] == "--" {
+			return append(positional, args[1:]...), nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// ParseAge parses an age such as 30d, 2w or 12h. Days and weeks are added to
+// the units of time.ParseDuration, as ages are usually given in them.
+func ParseAge(s string) (time.Duration, error) {
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if n, ok := strings.CutSuffix(s, suffix); ok {
+			count, err := strconv.Atoi(n)
+			if err != nil || count < 0 {
+				return 0, fmt.Errorf("invalid age %q", s)
+			}
+			return time.Duration(count) * unit, nil
+		}
+	}
+	age, err := time.ParseDuration(s)
+	if err != nil || age < 0 {
+		return 0, fmt.Errorf("invalid age %q, expected e.g. 30d, 2w or 12h", s)
+	}
+	return age, nil
+}
+
+// FormatBytes formats a byte count for humans, e.g. 512 B or 1.5 MB.
+func FormatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,116 @@
+package utils
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// IndentJSON copies the JSON in r to w indented with two spaces, like
+// json.Indent, but a byte at a time, so documents of any size are indented
+// without holding them in memory. The input is not validated.
+func IndentJSON(w io.Writer, r io.Reader) error {
+	in := bufio.NewReaderSize(r, 64<<10)
+	out := bufio.NewWriterSize(w, 64<<10)
+	depth := 0
+	inString, escaped := false, false
+	// opened is set after { or [ until the next token shows whether the
+	// container is empty, which stays on one line.
+	opened := false
+	newline := func() {
+		out.WriteByte('\n')
+		for range depth {
+			out.WriteString("  ")
+		}
+	}
+	for {
+		c, err := in.ReadByte()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if inString {
+			out.WriteByte(c)
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+			continue
+		}
+		switch c {
+		case ' ', '\t', '\n', '\r':
+			continue
+		}
+		if opened {
+			opened = false
+			if c == '}' || c == ']' {
+				depth--
+				out.WriteByte(c)
+				continue
+			}
+			newline()
+		}
+		switch c {
+		case '"':
+			inString = true
+			out.WriteByte(c)
+		case '{', '[':
+			out.WriteByte(c)
+			depth++
+			opened = true
+		case '}', ']':
+			depth--
+			newline()
+			out.WriteByte(c)
+		case ',':
+			out.WriteByte(c)
+			newline()
+		case ':':
+			out.WriteString(": ")
+		default:
+			out.WriteByte(c)
+		}
+	}
+	out.WriteByte('\n')
+	return out.Flush()
+}
+
+// PrintJSONStream prints the JSON response in r like PrintJSONResponse,
+// streaming it instead of reading it whole first. Responses that are not
+// JSON are printed as they are.
+func PrintJSONStream(r io.Reader) error {
+	if query != nil {
+		out, err := applyQueryReader(r)
+		fmt.Print(out)
+		return err
+	}
+	in := bufio.NewReader(r)
+	for {
+		c, err := in.Peek(1)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if !strings.ContainsRune(" \t\r\n", rune(c[0])) {
+			if c[0] != '{' && c[0] != '[' {
+				if _, err := io.Copy(os.Stdout, in); err != nil {
+					return err
+				}
+				fmt.Println()
+				return nil
+			}
+			return IndentJSON(os.Stdout, in)
+		}
+		in.ReadByte()
+	}
+}
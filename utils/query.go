@@ -0,0 +1,116 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/itchyny/gojq"
+)
+
+// query is the --query expression applied to every JSON response, if any.
+var query *gojq.Code
+
+// SetQuery compiles expr as a jq expression that PrintJSONResponse applies
+// before printing. Parse errors point at the failing position in expr.
+func SetQuery(expr string) error {
+	parsed, err := gojq.Parse(expr)
+	if err != nil {
+		var parseErr *gojq.ParseError
+		if errors.As(err, &parseErr) {
+			return fmt.Errorf("invalid query at position %d: %v\n  %s\n  %s^",
+				parseErr.Offset, err, expr, strings.Repeat(" ", caretOffset(expr, parseErr)))
+		}
+		return fmt.Errorf("invalid query: %w", err)
+	}
+	code, err := gojq.Compile(parsed)
+	if err != nil {
+		return fmt.Errorf("invalid query: %w", err)
+	}
+	query = code
+	return nil
+}
+
+// caretOffset returns the column of the token the parser failed on. Offset
+// counts the bytes read so far, which includes the offending token itself.
+func caretOffset(expr string, err *gojq.ParseError) int {
+	offset := min(max(err.Offset-len(err.Token), 0), len(expr))
+	return len([]rune(expr[:offset]))
+}
+
+// applyQuery runs the --query expression against data and returns each result
+// indented on its own, like jq does.
+func applyQuery(data []byte) (string, error) {
+	return applyQueryReader(bytes.NewReader(data))
+}
+
+// applyQueryReader is applyQuery decoding the response straight from r.
+func applyQueryReader(r io.Reader) (string, error) {
+	var input any
+	decoder := json.NewDecoder(r)
+	decoder.UseNumber()
+	if err := decoder.Decode(&input); err != nil {
+		return "", fmt.Errorf("response is not JSON, cannot apply query")
+	}
+	input = normalizeNumbers(input)
+
+	var out strings.Builder
+	iter := query.Run(input)
+	for {
+		v, ok := iter.Next()
+		if !ok {
+			break
+		}
+		if err, ok := v.(error); ok {
+			var haltErr *gojq.HaltError
+			if errors.As(err, &haltErr) && haltErr.Value() == nil {
+				break
+			}
+			return out.String(), fmt.Errorf("query failed: %w", err)
+		}
+		var buf bytes.Buffer
+		encoder := json.NewEncoder(&buf)
+		encoder.SetEscapeHTML(false)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(v); err != nil {
+			return out.String(), fmt.Errorf("query failed: %w", err)
+		}
+		out.Write(buf.Bytes())
+	}
+	return out.String(), nil
+}
+
+// normalizeNumbers converts json.Number values into the int and float64
+// values gojq operates on, keeping integers exact.
+func normalizeNumbers(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, e := range v {
+			v[k] = normalizeNumbers(e)
+		}
+	case []any:
+		for i, e := range v {
+			v[i] = normalizeNumbers(e)
+		}
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return int(n)
+		}
+		f, _ := v.Float64()
+		return f
+	}
+	return v
+}
+
+func printQueryResult(data []byte) {
+	out, err := applyQuery(data)
+	fmt.Print(out)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,102 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxCellWidth caps each column so one long value does not push the rest of
+// the table off screen.
+const maxCellWidth = 48
+
+// PrintTable writes items as space-padded columns, one row per item, with an
+// upper-cased header row unless headers is false. Columns name top-level
+// fields; nested fields are addressed with dots, e.g. "project.name".
+func PrintTable(w io.Writer, items []map[string]any, columns []string, headers bool) {
+	var rows [][]string
+	if headers {
+		header := make([]string, len(columns))
+		for i, col := range columns {
+			header[i] = strings.ToUpper(col)
+		}
+		rows = append(rows, header)
+	}
+	for _, item := range items {
+		row := make([]string, len(columns))
+		for i, col := range columns {
+			row[i] = truncate(cellValue(lookupField(item, col)), maxCellWidth)
+		}
+		rows = append(rows, row)
+	}
+
+	widths := make([]int, len(columns))
+	for _, row := range rows {
+		for i, cell := range row {
+			widths[i] = max(widths[i], utf8.RuneCountInString(cell))
+		}
+	}
+	for _, row := range rows {
+		var line strings.Builder
+		for i, cell := range row {
+			line.WriteString(cell)
+			if i < len(row)-1 {
+				line.WriteString(strings.Repeat(" ", widths[i]-utf8.RuneCountInString(cell)+2))
+			}
+		}
+		fmt.Fprintln(w, strings.TrimRight(line.String(), " "))
+	}
+}
+
+func lookupField(item map[string]any, path string) any {
+	var value any = item
+	for key := range strings.SplitSeq(path, ".") {
+		obj, ok := value.(map[string]any)
+		if !ok {
+			return nil
+		}
+		value = obj[key]
+	}
+	return value
+}
+
+// cellValue formats a JSON value for a table cell. Lists of named objects,
+// such as a workflow's tags, are shown as their comma-separated names.
+func cellValue(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return strings.Join(strings.Fields(v), " ")
+	case float64:
+		// Not fmt's %v, which writes large numbers such as 1e+06 in exponent form.
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case []any:
+		var parts []string
+		for _, e := range v {
+			if obj, ok := e.(map[string]any); ok {
+				if name, ok := obj["name"].(string); ok {
+					parts = append(parts, name)
+					continue
+				}
+			}
+			parts = append(parts, cellValue(e))
+		}
+		return strings.Join(parts, ",")
+	case map[string]any:
+		data, _ := json.Marshal(v)
+		return string(data)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func truncate(s string, width int) string {
+	if utf8.RuneCountInString(s) <= width {
+		return s
+	}
+	return string([]rune(s)[:width-1]) + "…"
+}
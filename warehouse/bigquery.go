@@ -0,0 +1,191 @@
+package warehouse
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/brandon-kyle-bailey/n8nctl/cloud"
+)
+
+const bigQueryAPI = "https://bigquery.googleapis.com/bigquery/v2"
+
+// bigQueryTokenTTL is how long an access token is used before fetching a
+// new one. Google issues them for an hour, and long exports outlive that.
+const bigQueryTokenTTL = 45 * time.Minute
+
+// bigQuerySink streams rows with the tabledata.insertAll API, using the
+// execution ID as insert ID so BigQuery deduplicates re-exports.
+type bigQuerySink struct {
+	project string
+	dataset string
+	table   string
+	token   string
+	tokenAt time.Time
+	client  *http.Client
+}
+
+// newBigQuerySink parses bigquery://<project>/<dataset>.
+func newBigQuerySink(dsn, table string) (*bigQuerySink, error) {
+	u, err := url.Parse(dsn)
+	if err != nil || u.Scheme != "bigquery" || u.Host == "" || strings.Trim(u.Path, "/") == "" {
+		return nil, fmt.Errorf("expected a DSN like bigquery://<project>/<dataset>")
+	}
+	return &bigQuerySink{
+		project: u.Host,
+		dataset: strings.Trim(u.Path, "/"),
+		table:   table,
+		client:  &http.Client{Timeout: 60 * time.Second},
+	}, nil
+}
+
+func (s *bigQuerySink) tablesURL() string {
+	return fmt.Sprintf("%s/projects/%s/datasets/%s/tables", bigQueryAPI, url.PathEscape(s.project), url.PathEscape(s.dataset))
+}
+
+func (s *bigQuerySink) Prepare(ctx context.Context) error {
+	status, body, err := s.call(ctx, "GET", s.tablesURL()+"/"+url.PathEscape(s.table), nil)
+	if err != nil {
+		return err
+	}
+	switch status {
+	case http.StatusOK:
+		return nil
+	case http.StatusNotFound:
+	default:
+		return fmt.Errorf("failed to look up table %s.%s: %s", s.dataset, s.table, body)
+	}
+
+	field := func(name, typ, mode string) map[string]string {
+		return map[string]string{"name": name, "type": typ, "mode": mode}
+	}
+	table := map[string]any{
+		"tableReference": map[string]string{"projectId": s.project, "datasetId": s.dataset, "tableId": s.table},
+		"schema": map[string]any{"fields": []map[string]string{
+			field("id", "STRING", "REQUIRED"),
+			field("workflow_id", "STRING", "REQUIRED"),
+			field("status", "STRING", "NULLABLE"),
+			field("mode", "STRING", "NULLABLE"),
+			field("finished", "BOOLEAN", "NULLABLE"),
+			field("started_at", "TIMESTAMP", "NULLABLE"),
+			field("stopped_at", "TIMESTAMP", "NULLABLE"),
+			field("duration_ms", "INTEGER", "NULLABLE"),
+			field("data", "JSON", "NULLABLE"),
+		}},
+		"timePartitioning": map[string]string{"type": "DAY", "field": "started_at"},
+	}
+	status, body, err = s.call(ctx, "POST", s.tablesURL(), table)
+	if err != nil {
+		return err
+	}
+	if status != http.StatusOK {
+		return fmt.Errorf("failed to create table %s.%s: %s", s.dataset, s.table, body)
+	}
+	return nil
+}
+
+func (s *bigQuerySink) Write(ctx context.Context, rows []Row) error {
+	type insertRow struct {
+		InsertID string         `json:"insertId"`
+		JSON     map[string]any `json:"json"`
+	}
+	request := struct {
+		Rows []insertRow `json:"rows"`
+	}{}
+	for _, row := range rows {
+		values := map[string]any{
+			"id":          row.ID,
+			"workflow_id": row.WorkflowID,
+			"status":      row.Status,
+			"mode":        row.Mode,
+			"finished":    row.Finished,
+			"started_at":  row.StartedAt.Format(time.RFC3339Nano),
+		}
+		if row.StoppedAt != nil {
+			values["stopped_at"] = row.StoppedAt.Format(time.RFC3339Nano)
+		}
+		if row.DurationMS != nil {
+			values["duration_ms"] = *row.DurationMS
+		}
+		if len(row.Data) > 0 {
+			values["data"] = string(row.Data)
+		}
+		request.Rows = append(request.Rows, insertRow{InsertID: row.ID, JSON: values})
+	}
+
+	status, body, err := s.call(ctx, "POST", s.tablesURL()+"/"+url.PathEscape(s.table)+"/insertAll", request)
+	if err != nil {
+		return err
+	}
+	if status != http.StatusOK {
+		return fmt.Errorf("bigquery insert failed: %s", body)
+	}
+	var result struct {
+		InsertErrors []json.RawMessage `json:"insertErrors"`
+	}
+	if err := json.Unmarshal(body, &result); err == nil && len(result.InsertErrors) > 0 {
+		return fmt.Errorf("bigquery rejected %d rows: %s", len(result.InsertErrors), result.InsertErrors[0])
+	}
+	return nil
+}
+
+// authorize fetches an access token when there is none yet or the last one
+// is about to expire.
+func (s *bigQuerySink) authorize(ctx context.Context, renew bool) error {
+	if s.token != "" && !renew && time.Since(s.tokenAt) < bigQueryTokenTTL {
+		return nil
+	}
+	token, err := cloud.GCPAccessToken(ctx, "", cloud.GCPScopeCloudPlatform)
+	if err != nil {
+		return err
+	}
+	s.token, s.tokenAt = token, time.Now()
+	return nil
+}
+
+// call sends a request to the BigQuery API, fetching a new token and
+// trying once more when the token was rejected.
+func (s *bigQuerySink) call(ctx context.Context, method, endpoint string, payload any) (int, []byte, error) {
+	var data []byte
+	if payload != nil {
+		var err error
+		if data, err = json.Marshal(payload); err != nil {
+			return 0, nil, err
+		}
+	}
+	for attempt := 0; ; attempt++ {
+		if err := s.authorize(ctx, attempt > 0); err != nil {
+			return 0, nil, err
+		}
+		var reqBody io.Reader
+		if data != nil {
+			reqBody = bytes.NewReader(data)
+		}
+		req, err := http.NewRequestWithContext(ctx, method, endpoint, reqBody)
+		if err != nil {
+			return 0, nil, err
+		}
+		req.Header.Set("Authorization", "Bearer "+s.token)
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := s.client.Do(req)
+		if err != nil {
+			return 0, nil, err
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode == http.StatusUnauthorized && attempt == 0 {
+			continue
+		}
+		return resp.StatusCode, body, err
+	}
+}
+
+func (s *bigQuerySink) Close() error {
+	return nil
+}
@@ -0,0 +1,97 @@
+package warehouse
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strings"
+
+	_ "github.com/lib/pq"
+)
+
+var identifierPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?$`)
+
+type postgresSink struct {
+	db    *sql.DB
+	table string
+}
+
+func newPostgresSink(dsn, table string) (*postgresSink, error) {
+	if !identifierPattern.MatchString(table) {
+		return nil, fmt.Errorf("invalid table name %q", table)
+	}
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, err
+	}
+	return &postgresSink{db: db, table: table}, nil
+}
+
+func (s *postgresSink) Prepare(ctx context.Context) error {
+	_, err := s.db.ExecContext(ctx, fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	id TEXT PRIMARY KEY,
+	workflow_id TEXT NOT NULL,
+	status TEXT,
+	mode TEXT,
+	finished BOOLEAN,
+	started_at TIMESTAMPTZ,
+	stopped_at TIMESTAMPTZ,
+	duration_ms BIGINT,
+	data JSONB
+)`, s.table))
+	return err
+}
+
+// maxRowsPerInsert keeps each statement below PostgreSQL's limit of 65535 parameters.
+const maxRowsPerInsert = 5000
+
+func (s *postgresSink) Write(ctx context.Context, rows []Row) error {
+	for len(rows) > 0 {
+		n := min(len(rows), maxRowsPerInsert)
+		if err := s.insert(ctx, rows[:n]); err != nil {
+			return err
+		}
+		rows = rows[n:]
+	}
+	return nil
+}
+
+// insert upserts rows in a single statement so re-exports are idempotent.
+func (s *postgresSink) insert(ctx context.Context, rows []Row) error {
+	const columns = 9
+	var placeholders []string
+	args := make([]any, 0, len(rows)*columns)
+	for i, row := range rows {
+		var marks []string
+		for j := 1; j <= columns; j++ {
+			marks = append(marks, fmt.Sprintf("$%d", i*columns+j))
+		}
+		placeholders = append(placeholders, "("+strings.Join(marks, ", ")+")")
+		var data any
+		if len(row.Data) > 0 {
+			data = string(row.Data)
+		}
+		args = append(args, row.ID, row.WorkflowID, row.Status, row.Mode, row.Finished,
+			row.StartedAt, row.StoppedAt, row.DurationMS, data)
+	}
+	query := fmt.Sprintf(`INSERT INTO %s (id, workflow_id, status, mode, finished, started_at, stopped_at, duration_ms, data)
+VALUES %s
+ON CONFLICT (id) DO UPDATE SET
+	workflow_id = EXCLUDED.workflow_id,
+	status = EXCLUDED.status,
+	mode = EXCLUDED.mode,
+	finished = EXCLUDED.finished,
+	started_at = EXCLUDED.started_at,
+	stopped_at = EXCLUDED.stopped_at,
+	duration_ms = EXCLUDED.duration_ms,
+	data = COALESCE(EXCLUDED.data, %s.data)`, s.table, strings.Join(placeholders, ",\n"), s.table)
+	if _, err := s.db.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("postgres insert failed: %w", err)
+	}
+	return nil
+}
+
+func (s *postgresSink) Close() error {
+	return s.db.Close()
+}
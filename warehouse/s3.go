@@ -0,0 +1,97 @@
+package warehouse
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/brandon-kyle-bailey/n8nctl/cloud"
+)
+
+// s3Sink writes each batch as a newline-delimited JSON object, the format
+// Athena, Redshift Spectrum and most lake tooling read directly.
+type s3Sink struct {
+	bucket   string
+	prefix   string
+	endpoint string
+	creds    cloud.AWSCredentials
+	client   *http.Client
+	batch    int
+	started  string
+}
+
+// newS3Sink parses s3://<bucket>/<prefix>?region=<region>&endpoint=<url>. The
+// endpoint parameter selects an S3-compatible service such as MinIO.
+func newS3Sink(dsn string) (*s3Sink, error) {
+	u, err := url.Parse(dsn)
+	if err != nil || u.Scheme != "s3" || u.Host == "" {
+		return nil, fmt.Errorf("expected a DSN like s3://<bucket>/<prefix>?region=<region>")
+	}
+	creds, err := cloud.AWSCredentialsFromEnv(u.Query().Get("region"))
+	if err != nil {
+		return nil, err
+	}
+	endpoint := u.Query().Get("endpoint")
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", creds.Region)
+	}
+	return &s3Sink{
+		bucket:   u.Host,
+		prefix:   strings.Trim(u.Path, "/"),
+		endpoint: strings.TrimRight(endpoint, "/"),
+		creds:    creds,
+		client:   &http.Client{Timeout: 60 * time.Second},
+		started:  time.Now().UTC().Format("20060102T150405Z"),
+	}, nil
+}
+
+// Prepare has nothing to create, objects carry their own schema.
+func (s *s3Sink) Prepare(ctx context.Context) error {
+	return nil
+}
+
+func (s *s3Sink) Write(ctx context.Context, rows []Row) error {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	for _, row := range rows {
+		if err := encoder.Encode(row); err != nil {
+			return err
+		}
+	}
+
+	s.batch++
+	key := fmt.Sprintf("executions-%s-%05d.ndjson", s.started, s.batch)
+	if s.prefix != "" {
+		key = s.prefix + "/" + key
+	}
+	// Path-style addressing works for AWS and S3-compatible services alike.
+	endpoint := fmt.Sprintf("%s/%s/%s", s.endpoint, url.PathEscape(s.bucket), (&url.URL{Path: key}).EscapedPath())
+	body := buf.Bytes()
+	req, err := http.NewRequestWithContext(ctx, "PUT", endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	cloud.SignAWSRequest(req, body, "s3", s.creds)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("s3 upload of %s failed: %s\n%s", key, resp.Status, string(msg))
+	}
+	return nil
+}
+
+func (s *s3Sink) Close() error {
+	return nil
+}
@@ -0,0 +1,83 @@
+// Package warehouse streams n8n execution records into analytics sinks.
+package warehouse
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Row is the flattened record exported for each execution.
+type Row struct {
+	ID         string          `json:"id"`
+	WorkflowID string          `json:"workflow_id"`
+	Status     string          `json:"status"`
+	Mode       string          `json:"mode"`
+	Finished   bool            `json:"finished"`
+	StartedAt  time.Time       `json:"started_at"`
+	StoppedAt  *time.Time      `json:"stopped_at"`
+	DurationMS *int64          `json:"duration_ms"`
+	Data       json.RawMessage `json:"data,omitempty"`
+}
+
+// Sink receives batches of rows. Prepare is called once before the first
+// batch and creates the destination schema if it does not exist yet.
+type Sink interface {
+	Prepare(ctx context.Context) error
+	Write(ctx context.Context, rows []Row) error
+	Close() error
+}
+
+// Open returns the sink of the given kind (bigquery, s3 or postgres) for dsn,
+// writing to table where the sink has tables.
+func Open(kind, dsn, table string) (Sink, error) {
+	switch kind {
+	case "postgres":
+		return newPostgresSink(dsn, table)
+	case "bigquery":
+		return newBigQuerySink(dsn, table)
+	case "s3":
+		return newS3Sink(dsn)
+	default:
+		return nil, fmt.Errorf("unknown sink %q, expected bigquery, s3 or postgres", kind)
+	}
+}
+
+// Exporter batches rows into a sink.
+type Exporter struct {
+	Sink      Sink
+	BatchSize int
+
+	pending  []Row
+	prepared bool
+	Written  int
+}
+
+// Add queues a row, flushing a batch once BatchSize rows are pending.
+func (e *Exporter) Add(ctx context.Context, row Row) error {
+	e.pending = append(e.pending, row)
+	if len(e.pending) >= e.BatchSize {
+		return e.Flush(ctx)
+	}
+	return nil
+}
+
+// Flush writes the pending rows.
+func (e *Exporter) Flush(ctx context.Context) error {
+	if len(e.pending) == 0 {
+		return nil
+	}
+	if !e.prepared {
+		if err := e.Sink.Prepare(ctx); err != nil {
+			return fmt.Errorf("failed to prepare sink: %w", err)
+		}
+		e.prepared = true
+	}
+	if err := e.Sink.Write(ctx, e.pending); err != nil {
+		return err
+	}
+	e.Written += len(e.pending)
+	e.pending = e.pending[:0]
+	return nil
+}
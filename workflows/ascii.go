@@ -0,0 +1,443 @@
+package workflows
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// asciiNameWidth is the widest a node name is drawn before it is cut.
+const asciiNameWidth = 28
+
+// asciiBox is a node drawn in a layer of the diagram, or a dummy that
+// carries an edge across a layer it skips.
+type asciiBox struct {
+	node  int // index in Graph.Nodes, -1 for dummies
+	layer int
+	lines []string
+	x, y  int
+	w, h  int
+	rank  float64
+}
+
+// asciiSegment is the part of an edge between two adjacent layers.
+type asciiSegment struct {
+	from, to *asciiBox
+	edge     GraphEdge
+	first    bool
+	sx, tx   int
+}
+
+// ASCII draws the graph as boxes and arrows for the terminal, flowing from
+// the triggers at the top down. Connections that loop back are listed below
+// the diagram instead of drawn.
+func (g Graph) ASCII() string {
+	if len(g.Nodes) == 0 {
+		return "(no nodes)\n"
+	}
+	index := map[string]int{}
+	for i, node := range g.Nodes {
+		index[node.Name] = i
+	}
+	var edges, loops []GraphEdge
+	for _, e := range g.Edges {
+		if _, ok := index[e.From]; !ok {
+			continue
+		}
+		if _, ok := index[e.To]; !ok {
+			continue
+		}
+		edges = append(edges, e)
+	}
+	edges, loops = splitLoops(g.Nodes, index, edges)
+	layers := assignLayers(g.Nodes, index, edges)
+
+	// Boxes of the nodes, and dummies for edges that skip layers.
+	boxes := make([]*asciiBox, len(g.Nodes))
+	for i, node := range g.Nodes {
+		name := node.Name
+		if len(name) > asciiNameWidth {
+			name = name[:asciiNameWidth-3] + "..."
+		}
+		kind := node.ShortType()
+		if node.Disabled {
+			kind += " (disabled)"
+		}
+		w := max(len(name), len(kind)) + 4
+		// Leave room for the labels of the outputs between them.
+		if outputs := mainOutputs(g, node.Name); outputs > 1 {
+			w = max(w, (outputs+1)*(len(fmt.Sprintf("output %d", outputs-1))+3))
+		}
+		boxes[i] = &asciiBox{node: i, layer: layers[i], lines: []string{name, kind}, w: w, h: 4}
+	}
+	var segments []*asciiSegment
+	for _, e := range edges {
+		from := boxes[index[e.From]]
+		target := boxes[index[e.To]]
+		first := true
+		for from.layer+1 < target.layer {
+			dummy := &asciiBox{node: -1, layer: from.layer + 1, w: 1, h: 4}
+			boxes = append(boxes, dummy)
+			segments = append(segments, &asciiSegment{from: from, to: dummy, edge: e, first: first})
+			from, first = dummy, false
+		}
+		segments = append(segments, &asciiSegment{from: from, to: target, edge: e, first: first})
+	}
+
+	rows := orderLayers(g.Nodes, boxes, segments)
+	return drawASCII(g, rows, segments, loops)
+}
+
+// splitLoops separates the edges that close a cycle, found depth first from
+// the nodes nothing connects to, from the others.
+func splitLoops(nodes []GraphNode, index map[string]int, edges []GraphEdge) (forward, loops []GraphEdge) {
+	out := map[int][]int{}
+	incoming := map[int]bool{}
+	for i, e := range edges {
+		out[index[e.From]] = append(out[index[e.From]], i)
+		incoming[index[e.To]] = true
+	}
+	const (
+		unvisited = iota
+		active
+		done
+	)
+	state := make([]int, len(nodes))
+	back := map[int]bool{}
+	var visit func(n int)
+	visit = func(n int) {
+		state[n] = active
+		for _, i := range out[n] {
+			switch to := index[edges[i].To]; state[to] {
+			case unvisited:
+				visit(to)
+			case active:
+				back[i] = true
+			}
+		}
+		state[n] = done
+	}
+	for i := range nodes {
+		if !incoming[i] && state[i] == unvisited {
+			visit(i)
+		}
+	}
+	for i := range nodes {
+		if state[i] == unvisited {
+			visit(i)
+		}
+	}
+	for i, e := range edges {
+		if back[i] {
+			loops = append(loops, e)
+		} else {
+			forward = append(forward, e)
+		}
+	}
+	return forward, loops
+}
+
+// assignLayers puts every node one layer below the lowest node connecting
+// to it. Nodes nothing connects to that are not triggers, such as the model
+// of an AI agent, are drawn right above the nodes they connect to.
+func assignLayers(nodes []GraphNode, index map[string]int, edges []GraphEdge) []int {
+	layers := make([]int, len(nodes))
+	incoming := make([]int, len(nodes))
+	for _, e := range edges {
+		incoming[index[e.To]]++
+	}
+	var queue []int
+	for i := range nodes {
+		if incoming[i] == 0 {
+			queue = append(queue, i)
+		}
+	}
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		for _, e := range edges {
+			if index[e.From] != n {
+				continue
+			}
+			to := index[e.To]
+			layers[to] = max(layers[to], layers[n]+1)
+			if incoming[to]--; incoming[to] == 0 {
+				queue = append(queue, to)
+			}
+		}
+	}
+	for i, node := range nodes {
+		if node.Trigger() || slices.ContainsFunc(edges, func(e GraphEdge) bool { return e.To == node.Name }) {
+			continue
+		}
+		below := -1
+		for _, e := range edges {
+			if e.From == node.Name && (below < 0 || layers[index[e.To]] < below) {
+				below = layers[index[e.To]]
+			}
+		}
+		if below > 0 {
+			layers[i] = below - 1
+		}
+	}
+	return layers
+}
+
+// orderLayers groups the boxes by layer, ordering the first layer like the
+// editor does from top to bottom and every other layer by the average
+// position of the boxes connecting to them, which keeps edges from
+// crossing.
+func orderLayers(nodes []GraphNode, boxes []*asciiBox, segments []*asciiSegment) [][]*asciiBox {
+	var rows [][]*asciiBox
+	for _, box := range boxes {
+		for len(rows) <= box.layer {
+			rows = append(rows, nil)
+		}
+		rows[box.layer] = append(rows[box.layer], box)
+	}
+	editorY := func(box *asciiBox) float64 {
+		if box.node < 0 || len(nodes[box.node].Position) < 2 {
+			return 0
+		}
+		return nodes[box.node].Position[1]
+	}
+	for l, row := range rows {
+		for _, box := range row {
+			box.rank = editorY(box)
+			if l == 0 {
+				continue
+			}
+			sum, n := 0.0, 0
+			for _, s := range segments {
+				if s.to == box {
+					sum += s.from.rank
+					n++
+				}
+			}
+			if n > 0 {
+				box.rank = sum / float64(n)
+			}
+		}
+		slices.SortStableFunc(row, func(a, b *asciiBox) int {
+			return cmp.Or(cmp.Compare(a.rank, b.rank), cmp.Compare(editorY(a), editorY(b)))
+		})
+		// Later layers rank by the order of this one.
+		for i, box := range row {
+			box.rank = float64(i)
+		}
+	}
+	return rows
+}
+
+// canvas is a grid of characters that lines are drawn on, joining the
+// lines that cross.
+type canvas [][]rune
+
+func newCanvas(w, h int) canvas {
+	c := make(canvas, h)
+	for y := range c {
+		c[y] = []rune(strings.Repeat(" ", w))
+	}
+	return c
+}
+
+func (c canvas) set(x, y int, r rune) {
+	if y < 0 || y >= len(c) || x < 0 || x >= len(c[y]) {
+		return
+	}
+	cur := c[y][x]
+	vertical := func(r rune) bool { return r == '|' || r == ':' }
+	horizontal := func(r rune) bool { return r == '-' || r == '.' }
+	if (vertical(cur) && horizontal(r)) || (horizontal(cur) && vertical(r)) {
+		r = '+'
+	}
+	if cur == 'v' || cur == '+' && r != 'v' {
+		return
+	}
+	c[y][x] = r
+}
+
+func (c canvas) text(x, y int, s string) {
+	for i, r := range s {
+		c[y][x+i] = r
+	}
+}
+
+// free reports whether the cells from x to x+n on row y are empty.
+func (c canvas) free(x, y, n int) bool {
+	if y < 0 || y >= len(c) || x < 0 || x+n > len(c[y]) {
+		return false
+	}
+	return strings.TrimSpace(string(c[y][x:x+n])) == ""
+}
+
+func (c canvas) String() string {
+	var b strings.Builder
+	for _, row := range c {
+		b.WriteString(strings.TrimRight(string(row), " "))
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+func drawASCII(g Graph, rows [][]*asciiBox, segments []*asciiSegment, loops []GraphEdge) string {
+	const gap = 3
+	// Lay the layers out centered, one below the other, with a track in
+	// the space between two layers for every edge that changes column.
+	rowWidth := func(row []*asciiBox) int {
+		w := -gap
+		for _, box := range row {
+			w += box.w + gap
+		}
+		return w
+	}
+	width := 0
+	for _, row := range rows {
+		width = max(width, rowWidth(row))
+	}
+	for _, row := range rows {
+		x := (width - rowWidth(row)) / 2
+		for _, box := range row {
+			box.x = x
+			x += box.w + gap
+		}
+	}
+	for _, s := range segments {
+		s.sx, s.tx = exitX(g, s), entryX(g, s)
+	}
+	tracks := make([][]*asciiSegment, len(rows))
+	for _, s := range segments {
+		if s.sx != s.tx {
+			tracks[s.from.layer] = append(tracks[s.from.layer], s)
+		}
+	}
+	// Between two layers, edges leave in the first row, turn in their
+	// track and arrive in the last.
+	height := 0
+	for l, row := range rows {
+		if l > 0 {
+			height += len(tracks[l-1]) + 3
+		}
+		for _, box := range row {
+			box.y = height
+		}
+		height += 4
+		slices.SortFunc(tracks[l], func(a, b *asciiSegment) int { return cmp.Or(a.sx-b.sx, a.tx-b.tx) })
+	}
+	c := newCanvas(width, height)
+
+	for _, row := range rows {
+		for _, box := range row {
+			if box.node < 0 {
+				for dy := range box.h {
+					c.set(box.x, box.y+dy, '|')
+				}
+				continue
+			}
+			border := "+" + strings.Repeat("-", box.w-2) + "+"
+			c.text(box.x, box.y, border)
+			for i, line := range box.lines {
+				c.text(box.x, box.y+1+i, "| "+line+strings.Repeat(" ", box.w-4-len(line))+" |")
+			}
+			c.text(box.x, box.y+box.h-1, border)
+		}
+	}
+	for l := range rows {
+		for _, s := range segments {
+			if s.from.layer != l {
+				continue
+			}
+			vertical, horizontal := '|', '-'
+			if s.edge.Type != "main" {
+				vertical, horizontal = ':', '.'
+			}
+			top := s.from.y + s.from.h
+			bottom := s.to.y - 1
+			turn := top
+			if k := slices.Index(tracks[l], s); k >= 0 {
+				turn = top + 1 + k
+				for x := min(s.sx, s.tx); x <= max(s.sx, s.tx); x++ {
+					c.set(x, turn, horizontal)
+				}
+			}
+			for yy := top; yy < turn; yy++ {
+				c.set(s.sx, yy, vertical)
+			}
+			for yy := turn; yy < bottom; yy++ {
+				c.set(s.tx, yy, vertical)
+			}
+			if turn != top {
+				c.set(s.sx, turn, '+')
+				c.set(s.tx, turn, '+')
+			}
+			if s.to.node < 0 {
+				c.set(s.tx, bottom, vertical)
+			} else {
+				c.set(s.tx, bottom, 'v')
+			}
+		}
+	}
+	// Labels go next to where edges leave their node, where there is room.
+	for _, s := range segments {
+		label := s.edge.Label(g)
+		if !s.first || label == "" {
+			continue
+		}
+		y := s.from.y + s.from.h
+		if c.free(s.sx+1, y, len(label)+2) {
+			c.text(s.sx+2, y, label)
+		}
+	}
+
+	out := c.String()
+	if len(loops) > 0 {
+		out += "\nLoops back:\n"
+		for _, e := range loops {
+			label := e.Label(g)
+			if label != "" {
+				label = " (" + label + ")"
+			}
+			out += fmt.Sprintf("  %s --> %s%s\n", e.From, e.To, label)
+		}
+	}
+	return out
+}
+
+// exitX spreads the edges leaving a node over its width by output, so the
+// branches of e.g. an If node leave apart.
+func exitX(g Graph, s *asciiSegment) int {
+	box := s.from
+	if box.node < 0 || s.edge.Type != "main" {
+		return box.x + box.w/2
+	}
+	return box.x + (max(s.edge.Output, 0)+1)*box.w/(mainOutputs(g, s.edge.From)+1)
+}
+
+// mainOutputs returns the number of main outputs of a node that are
+// connected, up to the last one.
+func mainOutputs(g Graph, name string) int {
+	outputs := 1
+	for _, e := range g.Edges {
+		if e.From == name && e.Type == "main" {
+			outputs = max(outputs, e.Output+1)
+		}
+	}
+	return outputs
+}
+
+// entryX spreads the edges entering a node over its width by input, as for
+// the inputs of a Merge node.
+func entryX(g Graph, s *asciiSegment) int {
+	box := s.to
+	if box.node < 0 || s.edge.Type != "main" {
+		return box.x + box.w/2
+	}
+	inputs := 1
+	for _, e := range g.Edges {
+		if e.To == s.edge.To && e.Type == "main" {
+			inputs = max(inputs, e.Input+1)
+		}
+	}
+	return box.x + (max(s.edge.Input, 0)+1)*box.w/(inputs+1)
+}
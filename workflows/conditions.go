@@ -0,0 +1,129 @@
+package workflows
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+)
+
+// enabledWhenKey is the node attribute that leaves the node out of builds
+// it is not enabled in, along with its connections. Its value is the list of
+// envs the node is built for, or the condition of a template action:
+//
+//   - name: Debug log
+//     enabledWhen: ne .Env "prod"
+//   - name: Page on-call
+//     enabledWhen: [prod]
+const enabledWhenKey = "enabledWhen"
+
+// applyConditions removes the nodes of src, the YAML of the workflow file
+// path, whose enabledWhen does not hold, and the connections from and to
+// them. It returns the result with the values files conditions may read.
+func applyConditions(src, path string) (string, []string, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(src), &doc); err != nil {
+		return "", nil, fmt.Errorf("invalid YAML: %w", sourceError(err))
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return src, nil, nil
+	}
+	root := doc.Content[0]
+	values, files, err := loadValues()
+	if err != nil {
+		return "", nil, err
+	}
+	nodes := mappingValue(root, "nodes")
+	if nodes == nil || nodes.Kind != yaml.SequenceNode {
+		return src, nil, nil
+	}
+	var removed []string
+	var kept []*yaml.Node
+	for _, node := range nodes.Content {
+		condition := mappingValue(node, enabledWhenKey)
+		if node.Kind != yaml.MappingNode || condition == nil {
+			kept = append(kept, node)
+			continue
+		}
+		removeKey(node, enabledWhenKey)
+		enabled, err := evalCondition(condition, values, path)
+		if err != nil {
+			return "", nil, err
+		}
+		if enabled {
+			kept = append(kept, node)
+		} else if name := mappingValue(node, "name"); name != nil {
+			removed = append(removed, name.Value)
+		}
+	}
+	nodes.Content = kept
+	if connections := mappingValue(root, "connections"); connections != nil && connections.Kind == yaml.MappingNode {
+		pruneConnections(connections, removed)
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return "", nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return "", nil, err
+	}
+	return buf.String(), files, nil
+}
+
+// evalCondition reports whether the enabledWhen value condition holds.
+func evalCondition(condition *yaml.Node, values map[string]any, path string) (bool, error) {
+	switch condition.Kind {
+	case yaml.SequenceNode:
+		for _, env := range condition.Content {
+			if env.Value == Env {
+				return true, nil
+			}
+		}
+		return false, nil
+	case yaml.ScalarNode:
+		var enabled bool
+		if condition.ShortTag() == "!!bool" && condition.Decode(&enabled) == nil {
+			return enabled, nil
+		}
+		text := templateLeftDelim + "if " + condition.Value + templateRightDelim + "true" + templateLeftDelim + "end" + templateRightDelim
+		tmpl, err := template.New(path).Delims(templateLeftDelim, templateRightDelim).Funcs(templateFuncs).Parse(text)
+		if err != nil {
+			return false, fmt.Errorf("%s: line %d: invalid %s: %w", path, condition.Line, enabledWhenKey, err)
+		}
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, map[string]any{"Values": values, "Env": Env}); err != nil {
+			return false, fmt.Errorf("%s: line %d: %s: %w", path, condition.Line, enabledWhenKey, err)
+		}
+		return buf.String() == "true", nil
+	}
+	return false, fmt.Errorf("%s: line %d: %s must be a condition or a list of envs", path, condition.Line, enabledWhenKey)
+}
+
+// pruneConnections removes the connections from and to the nodes named
+// removed.
+func pruneConnections(connections *yaml.Node, removed []string) {
+	for _, name := range removed {
+		removeKey(connections, name)
+	}
+	// Connections map each source to its connection types, which list the
+	// outputs, each a list of the nodes it leads to.
+	for i := 1; i < len(connections.Content); i += 2 {
+		types := connections.Content[i]
+		if types.Kind != yaml.MappingNode {
+			continue
+		}
+		for j := 1; j < len(types.Content); j += 2 {
+			for _, output := range types.Content[j].Content {
+				output.Content = slices.DeleteFunc(output.Content, func(target *yaml.Node) bool {
+					node := mappingValue(target, "node")
+					return node != nil && slices.Contains(removed, node.Value)
+				})
+			}
+		}
+	}
+}
@@ -0,0 +1,277 @@
+package workflows
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/brandon-kyle-bailey/n8nctl/cron"
+)
+
+// Month is the length of the month estimates are made for.
+const Month = 30 * 24 * time.Hour
+
+// Trigger is a node that starts executions of a workflow, with the number of
+// executions a month its schedule implies.
+type Trigger struct {
+	Node string
+	Type string
+	// Scheduled reports a trigger that fires on a schedule, as opposed to
+	// one fired by events such as webhook calls, whose rate only recent
+	// executions tell.
+	Scheduled bool
+	// Polls reports a polling trigger, which only starts an execution when
+	// it finds new data, so PerMonth is an upper bound.
+	Polls    bool
+	PerMonth float64
+	// Schedule describes the schedule, e.g. "every 5 minutes".
+	Schedule string
+}
+
+// manualTrigger starts executions from the editor only, which no plan counts.
+const manualTrigger = "n8n-nodes-base.manualTrigger"
+
+// Triggers returns the enabled triggers of a workflow. The executions of
+// scheduled triggers are estimated from their parameters; those set by
+// expressions cannot be, and fail.
+func Triggers(workflow []byte) ([]Trigger, error) {
+	var wf struct {
+		Nodes []struct {
+			Name       string         `json:"name"`
+			Type       string         `json:"type"`
+			Disabled   bool           `json:"disabled"`
+			Parameters map[string]any `json:"parameters"`
+		} `json:"nodes"`
+	}
+	if err := json.Unmarshal(workflow, &wf); err != nil {
+		return nil, fmt.Errorf("invalid workflow JSON: %w", err)
+	}
+	var triggers []Trigger
+	for _, node := range wf.Nodes {
+		if node.Disabled || node.Type == manualTrigger {
+			continue
+		}
+		t := Trigger{Node: node.Name, Type: node.Type, Scheduled: true}
+		var err error
+		switch {
+		case node.Type == "n8n-nodes-base.scheduleTrigger":
+			t.PerMonth, t.Schedule, err = scheduleTriggerRuns(node.Parameters)
+		case node.Type == "n8n-nodes-base.cron":
+			t.PerMonth, t.Schedule, err = triggerTimesRuns(node.Parameters, "triggerTimes")
+		case node.Type == "n8n-nodes-base.interval":
+			t.PerMonth, t.Schedule, err = intervalRuns(node.Parameters)
+		case node.Parameters["pollTimes"] != nil:
+			t.Polls = true
+			t.PerMonth, t.Schedule, err = triggerTimesRuns(node.Parameters, "pollTimes")
+		case isTrigger(node.Type):
+			t.Scheduled = false
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("node %q: %w", node.Name, err)
+		}
+		triggers = append(triggers, t)
+	}
+	return triggers, nil
+}
+
+// isTrigger reports whether a node type starts executions, going by n8n's
+// naming of trigger nodes.
+func isTrigger(nodeType string) bool {
+	name := strings.ToLower(nodeType[strings.LastIndex(nodeType, ".")+1:])
+	return strings.HasSuffix(name, "trigger") || name == "webhook"
+}
+
+// scheduleTriggerRuns estimates the runs of a Schedule Trigger, which fires
+// on each of the intervals of its rule.
+func scheduleTriggerRuns(params map[string]any) (float64, string, error) {
+	rule, _ := params["rule"].(map[string]any)
+	intervals, _ := rule["interval"].([]any)
+	if len(intervals) == 0 {
+		// n8n fills in a daily interval for a rule left empty.
+		intervals = []any{map[string]any{}}
+	}
+	var total float64
+	var schedules []string
+	for _, item := range intervals {
+		interval, _ := item.(map[string]any)
+		field, _ := interval["field"].(string)
+		if field == "" {
+			field = "days"
+		}
+		if field == "cronExpression" {
+			runs, err := cronRuns(interval["expression"])
+			if err != nil {
+				return 0, "", err
+			}
+			total += runs
+			schedules = append(schedules, fmt.Sprintf("cron %v", interval["expression"]))
+			continue
+		}
+		defaults := map[string]float64{"seconds": 30, "minutes": 5, "hours": 1, "days": 1, "weeks": 1, "months": 1}
+		def, ok := defaults[field]
+		if !ok {
+			return 0, "", fmt.Errorf("unknown schedule interval %q", field)
+		}
+		n, err := number(interval, field+"Interval", def)
+		if err != nil {
+			return 0, "", err
+		}
+		runs := float64(Month) / float64(unitDuration(field)) / n
+		if field == "weeks" {
+			// Weekly rules fire on each of the chosen days, Sunday by default.
+			if days, ok := interval["triggerAtDay"].([]any); ok && len(days) > 0 {
+				runs *= float64(len(days))
+			}
+		}
+		total += runs
+		schedules = append(schedules, every(field, n))
+	}
+	return total, strings.Join(schedules, ", "), nil
+}
+
+// triggerTimesRuns estimates the runs of the trigger times of the legacy
+// Cron node and of polling triggers, kept under key.
+func triggerTimesRuns(params map[string]any, key string) (float64, string, error) {
+	times, _ := params[key].(map[string]any)
+	items, _ := times["item"].([]any)
+	if len(items) == 0 {
+		if key == "pollTimes" {
+			// Polling triggers poll every minute unless told otherwise.
+			items = []any{map[string]any{"mode": "everyMinute"}}
+		} else {
+			return 0, "", nil
+		}
+	}
+	var total float64
+	var schedules []string
+	for _, item := range items {
+		entry, _ := item.(map[string]any)
+		mode, _ := entry["mode"].(string)
+		var runs float64
+		var schedule string
+		switch mode {
+		case "everyMinute":
+			runs, schedule = float64(Month/time.Minute), "every minute"
+		case "everyHour":
+			runs, schedule = float64(Month/time.Hour), "every hour"
+		case "everyDay", "":
+			runs, schedule = float64(Month/(24*time.Hour)), "every day"
+		case "everyWeek":
+			runs, schedule = float64(Month)/float64(7*24*time.Hour), "every week"
+		case "everyMonth":
+			runs, schedule = 1, "every month"
+		case "everyX":
+			unit, _ := entry["unit"].(string)
+			if unit == "" {
+				unit = "hours"
+			}
+			value, err := number(entry, "value", 2)
+			if err != nil {
+				return 0, "", err
+			}
+			runs, schedule = float64(Month)/float64(unitDuration(unit))/value, every(unit, value)
+		case "custom":
+			var err error
+			if runs, err = cronRuns(entry["cronExpression"]); err != nil {
+				return 0, "", err
+			}
+			schedule = fmt.Sprintf("cron %v", entry["cronExpression"])
+		default:
+			return 0, "", fmt.Errorf("unknown trigger time mode %q", mode)
+		}
+		total += runs
+		schedules = append(schedules, schedule)
+	}
+	return total, strings.Join(schedules, ", "), nil
+}
+
+// intervalRuns estimates the runs of the legacy Interval node.
+func intervalRuns(params map[string]any) (float64, string, error) {
+	unit, _ := params["unit"].(string)
+	if unit == "" {
+		unit = "seconds"
+	}
+	value, err := number(params, "interval", 1)
+	if err != nil {
+		return 0, "", err
+	}
+	return float64(Month) / float64(unitDuration(unit)) / value, every(unit, value), nil
+}
+
+// cronRuns counts the runs of a cron expression over a month from today.
+func cronRuns(expr any) (float64, error) {
+	s, ok := expr.(string)
+	if !ok || s == "" {
+		return 0, fmt.Errorf("no cron expression set")
+	}
+	if isExpression(s) {
+		return 0, fmt.Errorf("the cron expression is set by an n8n expression, cannot estimate it")
+	}
+	schedule, err := cron.Parse(s)
+	if err != nil {
+		return 0, err
+	}
+	y, m, d := time.Now().Date()
+	start := time.Date(y, m, d, 0, 0, 0, 0, time.Local)
+	return float64(schedule.RunsBetween(start, start.Add(Month))), nil
+}
+
+// number returns the numeric parameter key, or def if it is not set.
+func number(params map[string]any, key string, def float64) (float64, error) {
+	var n float64
+	switch v := params[key].(type) {
+	case nil:
+		return def, nil
+	case float64:
+		n = v
+	case string:
+		if isExpression(v) {
+			return 0, fmt.Errorf("%s is set by an n8n expression, cannot estimate it", key)
+		}
+		var err error
+		if n, err = strconv.ParseFloat(v, 64); err != nil {
+			return 0, fmt.Errorf("%s is not a number: %q", key, v)
+		}
+	default:
+		return 0, fmt.Errorf("%s is not a number: %v", key, v)
+	}
+	if n <= 0 {
+		return 0, fmt.Errorf("%s must be positive, got %g", key, n)
+	}
+	return n, nil
+}
+
+func isExpression(s string) bool {
+	return strings.HasPrefix(s, "=")
+}
+
+// unitDuration returns the length of an interval unit of n8n's schedule
+// nodes, counting months as Month.
+func unitDuration(unit string) time.Duration {
+	switch strings.TrimSuffix(unit, "s") {
+	case "second":
+		return time.Second
+	case "minute":
+		return time.Minute
+	case "hour":
+		return time.Hour
+	case "day":
+		return 24 * time.Hour
+	case "week":
+		return 7 * 24 * time.Hour
+	}
+	return Month
+}
+
+// every describes an interval, e.g. "every 5 minutes".
+func every(unit string, n float64) string {
+	unit = strings.TrimSuffix(unit, "s")
+	if n == 1 {
+		return "every " + unit
+	}
+	return fmt.Sprintf("every %g %ss", n, unit)
+}
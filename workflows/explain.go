@@ -0,0 +1,215 @@
+package workflows
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/brandon-kyle-bailey/n8nctl/utils"
+)
+
+// RedactedDiff returns the changes of the workflow file path since its .out
+// file was written, for readers outside the team such as a language model:
+// a summary of the nodes added, removed and changed, and a diff of the JSON.
+// Values rendered from variables and secrets are replaced by their
+// ${{...}} placeholders on both sides, so neither old nor new values show,
+// and the values they resolve to now are masked wherever else they appear.
+func RedactedDiff(path string) (string, error) {
+	outPath := OutputPathFor(path)
+	oldJSON, err := os.ReadFile(outPath)
+	if os.IsNotExist(err) {
+		return "", fmt.Errorf("%s does not exist, please run preview and save the JSON first", outPath)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", outPath, err)
+	}
+	_, resolved, err := renderWorkflowFile(path, true)
+	if err != nil {
+		return "", err
+	}
+	oldJSON = maskSecrets(oldJSON, resolved)
+	templateJSON, err := RenderTemplate(path)
+	if err != nil {
+		return "", err
+	}
+	var oldDoc, newDoc, tmpl map[string]any
+	if err := json.Unmarshal(oldJSON, &oldDoc); err != nil {
+		return "", fmt.Errorf("failed to parse %s: %w", outPath, err)
+	}
+	if err := json.Unmarshal(templateJSON, &tmpl); err != nil {
+		return "", err
+	}
+	// The template, with its placeholders, is the new workflow redacted.
+	newDoc = tmpl
+	redactLike(oldDoc, tmpl)
+
+	var b strings.Builder
+	summarizeNodes(&b, oldDoc, newDoc)
+	oldText, err := indentedJSON(oldDoc)
+	if err != nil {
+		return "", err
+	}
+	newText, err := indentedJSON(newDoc)
+	if err != nil {
+		return "", err
+	}
+	diff := utils.UnifiedDiff("old", "new", oldText, newText, 3)
+	if diff == "" && b.Len() == 0 {
+		return "", nil
+	}
+	b.WriteString("\n")
+	b.WriteString(diff)
+	return b.String(), nil
+}
+
+// redactLike replaces the values of doc that tmpl, the same workflow
+// rendered as a template, has placeholders for with those placeholders.
+// Nodes are matched by name, as their order may change. Old nodes with no
+// counterpart, such as renamed ones, have no placeholders to go by, so every
+// string of theirs that the template does not hold too is masked.
+func redactLike(doc, tmpl map[string]any) {
+	known := map[string]bool{}
+	collectStrings(tmpl, known)
+	oldNodes, _ := doc["nodes"].([]any)
+	newNodes, _ := tmpl["nodes"].([]any)
+	for _, node := range oldNodes {
+		if match := nodeNamed(newNodes, nodeName(node)); match != nil {
+			redactValue(node, match)
+		} else {
+			maskUnknown(node, known)
+		}
+	}
+	for key, value := range doc {
+		if key != "nodes" {
+			doc[key] = redactValue(value, tmpl[key])
+		}
+	}
+}
+
+// redactValue returns value with the strings whose counterpart in tmpl holds
+// a placeholder replaced by it, redacting maps and lists in place.
+func redactValue(value, tmpl any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		t, _ := tmpl.(map[string]any)
+		for key := range v {
+			v[key] = redactValue(v[key], t[key])
+		}
+	case []any:
+		t, _ := tmpl.([]any)
+		for i := range v {
+			if i < len(t) {
+				v[i] = redactValue(v[i], t[i])
+			}
+		}
+	default:
+		if s, ok := tmpl.(string); ok && strings.Contains(s, "${{") {
+			return s
+		}
+	}
+	return value
+}
+
+// collectStrings adds the strings of value to strs.
+func collectStrings(value any, strs map[string]bool) {
+	switch v := value.(type) {
+	case map[string]any:
+		for _, item := range v {
+			collectStrings(item, strs)
+		}
+	case []any:
+		for _, item := range v {
+			collectStrings(item, strs)
+		}
+	case string:
+		strs[v] = true
+	}
+}
+
+// maskUnknown returns value with the strings not in known masked, masking
+// maps and lists in place.
+func maskUnknown(value any, known map[string]bool) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key := range v {
+			v[key] = maskUnknown(v[key], known)
+		}
+	case []any:
+		for i := range v {
+			v[i] = maskUnknown(v[i], known)
+		}
+	case string:
+		if !known[v] {
+			return secretMask
+		}
+	}
+	return value
+}
+
+// summarizeNodes writes which nodes were added, removed or changed between
+// the old and new workflow, and whether their connections changed.
+func summarizeNodes(b *strings.Builder, oldDoc, newDoc map[string]any) {
+	oldNodes, _ := oldDoc["nodes"].([]any)
+	newNodes, _ := newDoc["nodes"].([]any)
+	for _, node := range newNodes {
+		name := nodeName(node)
+		old := nodeNamed(oldNodes, name)
+		switch {
+		case old == nil:
+			fmt.Fprintf(b, "added node %q (%s)\n", name, nodeType(node))
+		case !reflect.DeepEqual(old, node):
+			var fields []string
+			for key, value := range node.(map[string]any) {
+				if !reflect.DeepEqual(old.(map[string]any)[key], value) {
+					fields = append(fields, key)
+				}
+			}
+			for key := range old.(map[string]any) {
+				if _, ok := node.(map[string]any)[key]; !ok {
+					fields = append(fields, key)
+				}
+			}
+			slices.Sort(fields)
+			fmt.Fprintf(b, "changed node %q: %s\n", name, strings.Join(fields, ", "))
+		}
+	}
+	for _, node := range oldNodes {
+		if nodeNamed(newNodes, nodeName(node)) == nil {
+			fmt.Fprintf(b, "removed node %q (%s)\n", nodeName(node), nodeType(node))
+		}
+	}
+	for _, key := range []string{"name", "connections", "settings"} {
+		if !reflect.DeepEqual(oldDoc[key], newDoc[key]) {
+			fmt.Fprintf(b, "changed %s\n", key)
+		}
+	}
+}
+
+func nodeNamed(nodes []any, name string) any {
+	for _, node := range nodes {
+		if nodeName(node) == name {
+			return node
+		}
+	}
+	return nil
+}
+
+func nodeName(node any) string {
+	m, _ := node.(map[string]any)
+	name, _ := m["name"].(string)
+	return name
+}
+
+func nodeType(node any) string {
+	m, _ := node.(map[string]any)
+	t, _ := m["type"].(string)
+	return t
+}
+
+func indentedJSON(v any) (string, error) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	return string(data) + "\n", err
+}
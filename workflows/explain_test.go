@@ -0,0 +1,47 @@
+package workflows
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestRedactLikeMasksRenamedNodes(t *testing.T) {
+	var doc, tmpl map[string]any
+	if err := json.Unmarshal([]byte(`{
+		"name": "Notify",
+		"nodes": [
+			{"name": "Post", "type": "n8n-nodes-base.httpRequest",
+			 "parameters": {"method": "POST", "token": "s3cret-token-value"}}
+		]
+	}`), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal([]byte(`{
+		"name": "Notify",
+		"nodes": [
+			{"name": "Post to Slack", "type": "n8n-nodes-base.httpRequest",
+			 "parameters": {"method": "POST", "token": "${{ SLACK_TOKEN }}"}}
+		]
+	}`), &tmpl); err != nil {
+		t.Fatal(err)
+	}
+
+	redactLike(doc, tmpl)
+
+	text, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(text), "s3cret-token-value") {
+		t.Errorf("renamed node went out unredacted: %s", text)
+	}
+	node := doc["nodes"].([]any)[0].(map[string]any)
+	params := node["parameters"].(map[string]any)
+	if params["method"] != "POST" {
+		t.Errorf("method = %v, want POST, which the template holds too", params["method"])
+	}
+	if node["type"] != "n8n-nodes-base.httpRequest" {
+		t.Errorf("type = %v, want it kept", node["type"])
+	}
+}
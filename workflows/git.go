@@ -0,0 +1,128 @@
+package workflows
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Commit is a git commit that changed a workflow file.
+type Commit struct {
+	Hash    string
+	Author  string
+	Time    time.Time
+	Subject string
+}
+
+// Short returns the abbreviated hash of the commit.
+func (c Commit) Short() string {
+	return c.Hash[:min(len(c.Hash), 7)]
+}
+
+func git(ctx context.Context, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if _, ok := err.(*exec.Error); ok {
+			return "", fmt.Errorf("git is required: %w", err)
+		}
+		return "", fmt.Errorf("git %s failed: %s", args[0], strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// FileCommits returns the commits that changed path, newest first, following
+// renames.
+func FileCommits(ctx context.Context, path string) ([]Commit, error) {
+	out, err := git(ctx, "log", "--follow", "--format=%H%x1f%an%x1f%aI%x1f%s", "--", path)
+	if err != nil || out == "" {
+		return nil, err
+	}
+	return parseCommits(out), nil
+}
+
+func parseCommits(out string) []Commit {
+	var commits []Commit
+	for line := range strings.SplitSeq(out, "\n") {
+		fields := strings.Split(line, "\x1f")
+		if len(fields) != 4 {
+			continue
+		}
+		at, _ := time.Parse(time.RFC3339, fields[2])
+		commits = append(commits, Commit{Hash: fields[0], Author: fields[1], Time: at, Subject: fields[3]})
+	}
+	return commits
+}
+
+// FileVersion returns the last commit that changed path and whether the
+// working tree changes it further. It fails outside a git repository.
+func FileVersion(ctx context.Context, path string) (commit string, dirty bool, err error) {
+	if commit, err = git(ctx, "log", "-1", "--format=%H", "--", path); err != nil {
+		return "", false, err
+	}
+	status, err := git(ctx, "status", "--porcelain", "--", path)
+	if err != nil {
+		return "", false, err
+	}
+	return commit, status != "", nil
+}
+
+// CommitsBetween returns the commits after from up to and including to that
+// changed path, oldest first.
+func CommitsBetween(ctx context.Context, path, from, to string) ([]Commit, error) {
+	out, err := git(ctx, "log", "--reverse", "--format=%H%x1f%an%x1f%aI%x1f%s", from+".."+to, "--", path)
+	if err != nil || out == "" {
+		return nil, err
+	}
+	return parseCommits(out), nil
+}
+
+// Worktree is a detached checkout of the repository in a temporary
+// directory, to render workflow files as of another commit.
+type Worktree struct {
+	Dir string
+	// prefix is the path of the working directory within the repository.
+	prefix string
+}
+
+// AddWorktree checks out rev in a new temporary worktree.
+func AddWorktree(ctx context.Context, rev string) (*Worktree, error) {
+	prefix, err := git(ctx, "rev-parse", "--show-prefix")
+	if err != nil {
+		return nil, err
+	}
+	dir, err := os.MkdirTemp("", "n8nctl-worktree-")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := git(ctx, "worktree", "add", "--detach", dir, rev); err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+	return &Worktree{Dir: dir, prefix: prefix}, nil
+}
+
+// Checkout switches the worktree to rev.
+func (w *Worktree) Checkout(ctx context.Context, rev string) error {
+	_, err := git(ctx, "-C", w.Dir, "checkout", "--quiet", "--detach", rev)
+	return err
+}
+
+// Path returns where a path relative to the working directory is in the
+// worktree.
+func (w *Worktree) Path(path string) string {
+	return filepath.Join(w.Dir, filepath.FromSlash(w.prefix), path)
+}
+
+// Remove deletes the worktree.
+func (w *Worktree) Remove(ctx context.Context) error {
+	_, err := git(ctx, "worktree", "remove", "--force", w.Dir)
+	return err
+}
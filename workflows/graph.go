@@ -0,0 +1,198 @@
+package workflows
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// Graph is the nodes of a workflow and the connections between them, for
+// drawing it.
+type Graph struct {
+	Name  string
+	Nodes []GraphNode
+	Edges []GraphEdge
+}
+
+// GraphNode is a node of a workflow graph.
+type GraphNode struct {
+	Name     string
+	Type     string
+	Disabled bool
+	// Position is where the node is in the editor, as [x, y].
+	Position []float64
+}
+
+// ShortType is the type of the node without its package, e.g. "httpRequest".
+func (n GraphNode) ShortType() string {
+	return n.Type[strings.LastIndex(n.Type, ".")+1:]
+}
+
+// Trigger reports whether the node starts the workflow.
+func (n GraphNode) Trigger() bool {
+	return isTrigger(n.Type)
+}
+
+// GraphEdge connects an output of one node to an input of another. Type is
+// "main" for the data flow, and e.g. "ai_languageModel" for sub-nodes.
+type GraphEdge struct {
+	From, To string
+	Type     string
+	Output   int
+	Input    int
+}
+
+// Label describes where an edge leaves its node, when that is not the only
+// main output, e.g. "output 1" of an If node or "ai_tool".
+func (e GraphEdge) Label(g Graph) string {
+	if e.Type != "main" {
+		return e.Type
+	}
+	if e.Output > 0 || slices.ContainsFunc(g.Edges, func(other GraphEdge) bool {
+		return other.From == e.From && other.Type == "main" && other.Output > 0
+	}) {
+		return fmt.Sprintf("output %d", e.Output)
+	}
+	return ""
+}
+
+// ParseGraph reads the graph of workflow JSON. Edges are ordered by the
+// nodes they leave, in the order of the nodes of the workflow.
+func ParseGraph(workflow []byte) (Graph, error) {
+	var wf struct {
+		Name        string      `json:"name"`
+		Nodes       []GraphNode `json:"nodes"`
+		Connections map[string]map[string][][]struct {
+			Node  string `json:"node"`
+			Index int    `json:"index"`
+		} `json:"connections"`
+	}
+	if err := json.Unmarshal(workflow, &wf); err != nil {
+		return Graph{}, fmt.Errorf("invalid workflow JSON: %w", err)
+	}
+	g := Graph{Name: wf.Name, Nodes: wf.Nodes}
+
+	order := map[string]int{}
+	for i, node := range wf.Nodes {
+		order[node.Name] = i
+	}
+	sources := slices.Collect(maps.Keys(wf.Connections))
+	slices.SortFunc(sources, func(a, b string) int {
+		ia, oka := order[a]
+		ib, okb := order[b]
+		switch {
+		case oka && okb:
+			return ia - ib
+		case oka != okb:
+			// Connections of nodes that do not exist come last.
+			if oka {
+				return -1
+			}
+			return 1
+		}
+		return strings.Compare(a, b)
+	})
+	for _, source := range sources {
+		types := wf.Connections[source]
+		for _, typ := range slices.Sorted(maps.Keys(types)) {
+			for output, targets := range types[typ] {
+				for _, target := range targets {
+					g.Edges = append(g.Edges, GraphEdge{From: source, To: target.Node, Type: typ, Output: output, Input: target.Index})
+				}
+			}
+		}
+	}
+	return g, nil
+}
+
+// DOT draws the graph in the Graphviz DOT language.
+func (g Graph) DOT() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "digraph %s {\n", dotQuote(g.Name))
+	b.WriteString("  rankdir=LR;\n")
+	b.WriteString("  node [shape=box, style=rounded, fontname=\"Helvetica\"];\n")
+	b.WriteString("  edge [fontname=\"Helvetica\", fontsize=10];\n")
+	for _, node := range g.Nodes {
+		attrs := []string{"label=" + dotQuote(node.Name+"\n"+node.ShortType())}
+		if node.Trigger() {
+			attrs = append(attrs, "shape=ellipse")
+		}
+		if node.Disabled {
+			attrs = append(attrs, "style=\"rounded,dashed\"", "fontcolor=gray", "color=gray")
+		}
+		fmt.Fprintf(&b, "  %s [%s];\n", dotQuote(node.Name), strings.Join(attrs, ", "))
+	}
+	for _, e := range g.Edges {
+		var attrs []string
+		if label := e.Label(g); label != "" {
+			attrs = append(attrs, "label="+dotQuote(label))
+		}
+		if e.Type != "main" {
+			attrs = append(attrs, "style=dashed")
+		}
+		line := fmt.Sprintf("  %s -> %s", dotQuote(e.From), dotQuote(e.To))
+		if len(attrs) > 0 {
+			line += " [" + strings.Join(attrs, ", ") + "]"
+		}
+		b.WriteString(line + ";\n")
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+func dotQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return `"` + strings.ReplaceAll(s, "\n", `\n`) + `"`
+}
+
+// Mermaid draws the graph as a Mermaid flowchart.
+func (g Graph) Mermaid() string {
+	var b strings.Builder
+	if g.Name != "" {
+		fmt.Fprintf(&b, "---\ntitle: %q\n---\n", g.Name)
+	}
+	b.WriteString("flowchart LR\n")
+	ids := map[string]string{}
+	id := func(name string) string {
+		if _, ok := ids[name]; !ok {
+			ids[name] = fmt.Sprintf("n%d", len(ids))
+		}
+		return ids[name]
+	}
+	var disabled []string
+	for _, node := range g.Nodes {
+		label := mermaidQuote(node.Name + "<br/><small>" + node.ShortType() + "</small>")
+		if node.Trigger() {
+			fmt.Fprintf(&b, "  %s([%s])\n", id(node.Name), label)
+		} else {
+			fmt.Fprintf(&b, "  %s[%s]\n", id(node.Name), label)
+		}
+		if node.Disabled {
+			disabled = append(disabled, id(node.Name))
+		}
+	}
+	for _, e := range g.Edges {
+		arrow := "-->"
+		if e.Type != "main" {
+			// Sub-nodes, such as the model of an AI agent, are dotted.
+			arrow = "-.->"
+		}
+		if label := e.Label(g); label != "" {
+			arrow += "|" + mermaidQuote(label) + "|"
+		}
+		fmt.Fprintf(&b, "  %s %s %s\n", id(e.From), arrow, id(e.To))
+	}
+	if len(disabled) > 0 {
+		b.WriteString("  classDef disabled stroke-dasharray: 5 5,color:#999\n")
+		fmt.Fprintf(&b, "  class %s disabled\n", strings.Join(disabled, ","))
+	}
+	return b.String()
+}
+
+// mermaidQuote quotes a label, escaping the quotes Mermaid cannot take.
+func mermaidQuote(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, "#quot;") + `"`
+}
@@ -0,0 +1,170 @@
+package workflows
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/brandon-kyle-bailey/n8nctl/config"
+)
+
+// historyDir keeps a snapshot of every deployed workflow JSON, one
+// subdirectory per workflow file, see snapshotDir.
+var historyDir = filepath.Join(outDir, "history")
+
+// snapshotLayout is filename-safe on every platform and sorts chronologically.
+const snapshotLayout = "20060102T150405.000Z"
+
+type Snapshot struct {
+	Timestamp string
+	Path      string
+}
+
+// snapshotDir returns the history of yamlPath for the selected profile. Like
+// the .out file it is keyed on the relative path of the file, and like the
+// state file every profile but the default keeps its own, as each points at
+// its own instance.
+func snapshotDir(yamlPath string) string {
+	dir := historyDir
+	if config.Profile != "" && config.Profile != config.DefaultProfile {
+		dir += "." + config.Profile
+	}
+	return filepath.Join(dir, outputKey(yamlPath))
+}
+
+// SaveSnapshot records body as the latest deployed version of yamlPath.
+func SaveSnapshot(yamlPath string, body []byte) (Snapshot, error) {
+	stamp := time.Now().UTC().Format(snapshotLayout)
+	path := filepath.Join(snapshotDir(yamlPath), stamp+".json")
+	if err := WriteOutput(path, body); err != nil {
+		return Snapshot{}, err
+	}
+	return Snapshot{Timestamp: stamp, Path: path}, nil
+}
+
+// Snapshots returns the deployed versions of yamlPath, oldest first.
+func Snapshots(yamlPath string) ([]Snapshot, error) {
+	entries, err := os.ReadDir(snapshotDir(yamlPath))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var snapshots []Snapshot
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || filepath.Ext(name) != ".json" {
+			continue
+		}
+		snapshots = append(snapshots, Snapshot{
+			Timestamp: strings.TrimSuffix(name, ".json"),
+			Path:      filepath.Join(snapshotDir(yamlPath), name),
+		})
+	}
+	slices.SortFunc(snapshots, func(a, b Snapshot) int { return strings.Compare(a.Timestamp, b.Timestamp) })
+	return snapshots, nil
+}
+
+// SelectSnapshot picks a rollback target from snapshots (oldest first, the
+// last one being the currently deployed version). to is either a number of
+// deployments to go back, or a snapshot timestamp (or unique prefix of one).
+func SelectSnapshot(snapshots []Snapshot, to string) (Snapshot, error) {
+	if len(snapshots) < 2 {
+		return Snapshot{}, fmt.Errorf("no earlier deployment to roll back to")
+	}
+	if n, err := strconv.Atoi(to); err == nil {
+		if n < 1 || n >= len(snapshots) {
+			return Snapshot{}, fmt.Errorf("can only go back between 1 and %d deployments", len(snapshots)-1)
+		}
+		return snapshots[len(snapshots)-1-n], nil
+	}
+
+	var found []Snapshot
+	for _, snapshot := range snapshots {
+		if strings.HasPrefix(snapshot.Timestamp, to) {
+			found = append(found, snapshot)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return Snapshot{}, fmt.Errorf("no snapshot matches %q", to)
+	case 1:
+		return found[0], nil
+	default:
+		return Snapshot{}, fmt.Errorf("%q matches %d snapshots, be more specific", to, len(found))
+	}
+}
+
+// Deployment records where and from which commit a snapshot was deployed.
+type Deployment struct {
+	// Snapshot is the timestamp of the snapshot of the deployed JSON.
+	Snapshot   string `json:"snapshot"`
+	Instance   string `json:"instance"`
+	Profile    string `json:"profile,omitempty"`
+	WorkflowID string `json:"workflowId"`
+	// Commit is the last commit of the workflow file, empty outside a git
+	// repository. Dirty tells that the file had uncommitted changes.
+	Commit string `json:"commit,omitempty"`
+	Dirty  bool   `json:"dirty,omitempty"`
+	// RollbackTo is the snapshot a rollback deployed again.
+	RollbackTo string `json:"rollbackTo,omitempty"`
+}
+
+// Time returns when the deployment happened.
+func (d Deployment) Time() time.Time {
+	t, _ := time.Parse(snapshotLayout, d.Snapshot)
+	return t
+}
+
+const deploymentsFile = "deployments.jsonl"
+
+// RecordDeployment appends d to the deploy log of yamlPath.
+func RecordDeployment(yamlPath string, d Deployment) error {
+	line, err := json.Marshal(d)
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(snapshotDir(yamlPath), deploymentsFile)
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.Write(append(line, '\n'))
+	return err
+}
+
+// Deployments returns the deploy log of yamlPath, oldest first. Snapshots
+// deployed before the log was kept are included without an instance.
+func Deployments(yamlPath string) ([]Deployment, error) {
+	snapshots, err := Snapshots(yamlPath)
+	if err != nil {
+		return nil, err
+	}
+	logged := map[string]Deployment{}
+	data, err := os.ReadFile(filepath.Join(snapshotDir(yamlPath), deploymentsFile))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	for line := range strings.SplitSeq(string(data), "\n") {
+		var d Deployment
+		if line != "" && json.Unmarshal([]byte(line), &d) == nil {
+			logged[d.Snapshot] = d
+		}
+	}
+	deployments := make([]Deployment, len(snapshots))
+	for i, snapshot := range snapshots {
+		if d, ok := logged[snapshot.Timestamp]; ok {
+			deployments[i] = d
+		} else {
+			deployments[i] = Deployment{Snapshot: snapshot.Timestamp}
+		}
+	}
+	return deployments, nil
+}
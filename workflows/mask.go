@@ -0,0 +1,79 @@
+package workflows
+
+import (
+	"bytes"
+	"encoding/json"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// secretMask replaces resolved variables and secrets in printed JSON.
+const secretMask = "****"
+
+// minMaskedLength is the length below which values are not masked: short
+// values such as 1, on or yes are everywhere in workflows, and masking
+// them would hide more than it protects.
+const minMaskedLength = 4
+
+// maskSecrets replaces every occurrence of a secret in the string values of
+// JSON data with ****, so previews and diffs can be shown without revealing
+// them. Numbers and booleans are left alone. The result is still JSON.
+func maskSecrets(data []byte, secrets []string) []byte {
+	secrets = masked(secrets)
+	if len(secrets) == 0 {
+		return data
+	}
+	var out bytes.Buffer
+	for i := 0; i < len(data); {
+		if data[i] != '"' {
+			out.WriteByte(data[i])
+			i++
+			continue
+		}
+		end := i + 1
+		for end < len(data) && data[end] != '"' {
+			if data[end] == '\\' {
+				end++
+			}
+			end++
+		}
+		end = min(end+1, len(data))
+		out.Write(maskString(data[i:end], secrets))
+		i = end
+	}
+	return out.Bytes()
+}
+
+// maskString masks the secrets in a JSON string literal.
+func maskString(literal []byte, secrets []string) []byte {
+	var s string
+	if err := json.Unmarshal(literal, &s); err != nil {
+		return literal
+	}
+	masked := s
+	for _, secret := range secrets {
+		masked = strings.ReplaceAll(masked, secret, secretMask)
+	}
+	if masked == s {
+		return literal
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.Encode(masked)
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n"))
+}
+
+// masked returns the distinct secrets long enough to mask, longest first, so
+// a secret containing another is masked whole.
+func masked(secrets []string) []string {
+	var distinct []string
+	for _, s := range secrets {
+		if len(s) >= minMaskedLength && !slices.Contains(distinct, s) {
+			distinct = append(distinct, s)
+		}
+	}
+	sort.SliceStable(distinct, func(i, j int) bool { return len(distinct[i]) > len(distinct[j]) })
+	return distinct
+}
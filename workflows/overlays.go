@@ -0,0 +1,192 @@
+package workflows
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Env names the overlay patched over workflow files when they are rendered,
+// such as prod, so one workflow file serves every environment. Overlays of
+// the single workflow.yaml are overlays/<env>.yaml, and those of the files of
+// a project directory are overlays/<env>/<file> inside it:
+//
+//	workflow.yaml
+//	overlays/prod.yaml
+//	workflows/orders.yaml
+//	workflows/overlays/prod/orders.yaml
+//
+// An overlay holds only what changes. Mappings are merged key by key, nodes
+// are matched by name, a node with "$patch: delete" is removed, a key set
+// to null is removed, and any other value replaces the base's.
+var Env = os.Getenv("N8NCTL_ENV")
+
+// EnvDeclared is set when environments.yaml declares Env, whose workflow
+// files then need no overlays.
+var EnvDeclared bool
+
+// patchKey marks a node of an overlay that is removed from the base.
+const patchKey = "$patch"
+
+// overlayPaths returns where the overlay of path for env may be, the
+// first that exists being used.
+func overlayPaths(path, env string) []string {
+	dir := filepath.Join(filepath.Dir(path), "overlays")
+	paths := []string{filepath.Join(dir, env, filepath.Base(path))}
+	if filepath.Base(path) == WorkflowFile {
+		paths = append(paths, filepath.Join(dir, env+filepath.Ext(path)))
+	}
+	return paths
+}
+
+// applyOverlay patches the overlay of Env for the workflow file path over
+// src, its YAML with file() includes and partials resolved, and returns the
+// result with the files read, including the overlay paths that do not
+// exist so creating one invalidates the render cache. A workflow file
+// without an overlay is left as it is, unless nothing mentions the env: no
+// overlays, values file or environments.yaml, which is more likely a typo.
+func applyOverlay(src, path string) (string, []string, error) {
+	candidates := overlayPaths(path, Env)
+	read := candidates
+	overlay := ""
+	for _, candidate := range candidates {
+		if _, err := os.Stat(candidate); err == nil {
+			overlay = candidate
+			break
+		}
+	}
+	if overlay == "" {
+		if EnvDeclared {
+			return src, read, nil
+		}
+		if info, err := os.Stat(filepath.Join(filepath.Dir(path), "overlays", Env)); err == nil && info.IsDir() {
+			return src, read, nil
+		}
+		// An env may only change values or variables.
+		for _, file := range []string{envValuesFile(Env), envDotFile(Env)} {
+			if _, err := os.Stat(file); err == nil {
+				return src, read, nil
+			}
+		}
+		return "", nil, fmt.Errorf("no overlay of env %q for %s, expected %s", Env, path, strings.Join(candidates, " or "))
+	}
+
+	patchSrc, err := os.ReadFile(overlay)
+	if err != nil {
+		return "", nil, err
+	}
+	if bytes.Contains(patchSrc, []byte("file(")) {
+		var files []string
+		if patchSrc, files, err = injectFiles(overlay); err != nil {
+			return "", nil, fmt.Errorf("failed to inline files into %s: %w", overlay, err)
+		}
+		read = append(read, files...)
+	}
+	if includesPattern.Match(patchSrc) {
+		resolved, partials, err := resolvePartials(string(patchSrc), overlay)
+		if err != nil {
+			return "", nil, err
+		}
+		patchSrc = []byte(resolved)
+		read = append(read, partials...)
+	}
+
+	var base, patch yaml.Node
+	if err := yaml.Unmarshal([]byte(src), &base); err != nil {
+		return "", nil, fmt.Errorf("invalid YAML: %w", sourceError(err))
+	}
+	if err := yaml.Unmarshal(patchSrc, &patch); err != nil {
+		return "", nil, fmt.Errorf("%s: invalid YAML: %w", overlay, sourceError(err))
+	}
+	if len(patch.Content) == 0 {
+		return src, read, nil
+	}
+	if len(base.Content) == 0 || base.Content[0].Kind != yaml.MappingNode || patch.Content[0].Kind != yaml.MappingNode {
+		return "", nil, fmt.Errorf("%s: an overlay patches the mapping of a workflow with one of its own", overlay)
+	}
+	if err := mergeNodes(base.Content[0], patch.Content[0], overlay, ""); err != nil {
+		return "", nil, err
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&base); err != nil {
+		return "", nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return "", nil, err
+	}
+	return buf.String(), read, nil
+}
+
+// mergeNodes patches the mapping patch over the mapping base. at is the
+// path of the mapping in the workflow, such as "settings", for errors.
+func mergeNodes(base, patch *yaml.Node, overlay, at string) error {
+	for i := 0; i+1 < len(patch.Content); i += 2 {
+		key, value := patch.Content[i], patch.Content[i+1]
+		keyPath := strings.TrimPrefix(at+"."+key.Value, ".")
+		current := mappingValue(base, key.Value)
+		switch {
+		case value.ShortTag() == "!!null":
+			removeKey(base, key.Value)
+		case current == nil:
+			base.Content = append(base.Content, key, value)
+		case keyPath == "nodes":
+			if current.Kind != yaml.SequenceNode || value.Kind != yaml.SequenceNode {
+				return fmt.Errorf("%s: line %d: nodes must be a list", overlay, value.Line)
+			}
+			if err := mergeWorkflowNodes(current, value, overlay); err != nil {
+				return err
+			}
+		case current.Kind == yaml.MappingNode && value.Kind == yaml.MappingNode:
+			if err := mergeNodes(current, value, overlay, keyPath); err != nil {
+				return err
+			}
+		default:
+			*current = *value
+		}
+	}
+	return nil
+}
+
+// mergeWorkflowNodes patches the nodes of an overlay over those of the base,
+// matching them by name.
+func mergeWorkflowNodes(base, patch *yaml.Node, overlay string) error {
+	for _, node := range patch.Content {
+		name := mappingValue(node, "name")
+		if node.Kind != yaml.MappingNode || name == nil || name.Value == "" {
+			return fmt.Errorf("%s: line %d: nodes of an overlay need the name of the node they patch or add", overlay, node.Line)
+		}
+		index := -1
+		for i, current := range base.Content {
+			if current.Kind == yaml.MappingNode {
+				if n := mappingValue(current, "name"); n != nil && n.Value == name.Value {
+					index = i
+					break
+				}
+			}
+		}
+		op := mappingValue(node, patchKey)
+		if op != nil && op.Value != "delete" {
+			return fmt.Errorf("%s: line %d: unknown %s %q, expected delete", overlay, op.Line, patchKey, op.Value)
+		}
+		switch {
+		case op != nil && index < 0:
+			return fmt.Errorf("%s: line %d: cannot delete node %q, the workflow has none", overlay, node.Line, name.Value)
+		case op != nil:
+			base.Content = append(base.Content[:index], base.Content[index+1:]...)
+		case index < 0:
+			base.Content = append(base.Content, node)
+		default:
+			if err := mergeNodes(base.Content[index], node, overlay, "nodes."+name.Value); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,288 @@
+package workflows
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// includeTag marks a value read from a partial, another YAML file holding a
+// fragment shared by workflows, such as the nodes of an error handler:
+//
+//	nodes:
+//	  - name: Start
+//	    ...
+//	  - !include partials/error-handler.yaml
+//	settings: !include partials/settings.yaml
+//
+// A partial holding a sequence included as an item of a sequence adds its
+// items there, and one holding a mapping included under the << key merges
+// its entries into the mapping, keeping those the mapping sets itself.
+const includeTag = "!include"
+
+// includesKey names the section listing partials with nodes and the
+// connections between them, which are added to those of the workflow:
+//
+//	includes:
+//	  - partials/slack-alert.yaml
+const includesKey = "includes"
+
+// includesPattern finds the marks of partials in a workflow file.
+var includesPattern = regexp.MustCompile(`(?m)(^includes:|!include\b)`)
+
+// maxIncludeDepth stops partials including each other in a cycle.
+const maxIncludeDepth = 16
+
+// resolvePartials inlines the partials of a workflow file, whose YAML is src
+// with its file() includes inlined, and returns the resulting YAML and the
+// partials it read. Paths are relative to the file including them.
+func resolvePartials(src, path string) (string, []string, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(src), &doc); err != nil {
+		return "", nil, fmt.Errorf("invalid YAML: %w", sourceError(err))
+	}
+	p := &partials{}
+	if err := p.resolve(&doc, path, []string{path}); err != nil {
+		return "", nil, err
+	}
+	if len(doc.Content) > 0 {
+		if err := p.addSections(doc.Content[0], path, []string{path}); err != nil {
+			return "", nil, err
+		}
+	}
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return "", nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return "", nil, err
+	}
+	return buf.String(), p.read, nil
+}
+
+// partials resolves the partials of one workflow file.
+type partials struct {
+	// read are the partials read, and the files they inline with file().
+	read []string
+}
+
+// load reads the partial at ref, relative to the file from, and resolves
+// the partials it includes in turn. stack holds the files including it.
+func (p *partials) load(ref, from string, stack []string) (*yaml.Node, string, error) {
+	path := ref
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(filepath.Dir(from), ref)
+	}
+	if slices.Contains(stack, path) {
+		return nil, "", fmt.Errorf("%s includes itself through %s", path, strings.Join(stack, " -> "))
+	}
+	if len(stack) > maxIncludeDepth {
+		return nil, "", fmt.Errorf("partials nested more than %d deep at %s", maxIncludeDepth, path)
+	}
+	if _, err := os.Stat(path); err != nil {
+		return nil, "", fmt.Errorf("%s: partial %s not found", from, path)
+	}
+	src, files, err := injectFiles(path)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to inline files into %s: %w", path, err)
+	}
+	p.read = append(p.read, path)
+	p.read = append(p.read, files...)
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(src, &doc); err != nil {
+		return nil, "", fmt.Errorf("%s: invalid YAML: %w", path, sourceError(err))
+	}
+	if len(doc.Content) == 0 {
+		return nil, "", fmt.Errorf("partial %s is empty", path)
+	}
+	stack = append(stack, path)
+	if err := p.resolve(&doc, path, stack); err != nil {
+		return nil, "", err
+	}
+	if err := p.addSections(doc.Content[0], path, stack); err != nil {
+		return nil, "", err
+	}
+	return doc.Content[0], path, nil
+}
+
+// resolve replaces the values tagged !include below node, which was read
+// from the file path, by the partials they name.
+func (p *partials) resolve(node *yaml.Node, path string, stack []string) error {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, child := range node.Content {
+			if err := p.resolve(child, path, stack); err != nil {
+				return err
+			}
+		}
+	case yaml.SequenceNode:
+		var items []*yaml.Node
+		for _, item := range node.Content {
+			if item.Tag != includeTag {
+				if err := p.resolve(item, path, stack); err != nil {
+					return err
+				}
+				items = append(items, item)
+				continue
+			}
+			partial, _, err := p.include(item, path, stack)
+			if err != nil {
+				return err
+			}
+			if partial.Kind == yaml.SequenceNode {
+				items = append(items, partial.Content...)
+			} else {
+				items = append(items, partial)
+			}
+		}
+		node.Content = items
+	case yaml.MappingNode:
+		var merged []*yaml.Node
+		content := node.Content
+		node.Content = nil
+		for i := 0; i+1 < len(content); i += 2 {
+			key, value := content[i], content[i+1]
+			if value.Tag == includeTag {
+				partial, partialPath, err := p.include(value, path, stack)
+				if err != nil {
+					return err
+				}
+				if key.Value == "<<" {
+					if partial.Kind != yaml.MappingNode {
+						return fmt.Errorf("%s: line %d: only a partial holding a mapping can be merged with <<, %s holds none", path, key.Line, partialPath)
+					}
+					merged = append(merged, partial)
+					continue
+				}
+				value = partial
+			} else if err := p.resolve(value, path, stack); err != nil {
+				return err
+			}
+			node.Content = append(node.Content, key, value)
+		}
+		for _, partial := range merged {
+			for i := 0; i+1 < len(partial.Content); i += 2 {
+				if !hasKey(node, partial.Content[i].Value) {
+					node.Content = append(node.Content, partial.Content[i], partial.Content[i+1])
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// include loads the partial named by the value of a node tagged !include.
+func (p *partials) include(node *yaml.Node, path string, stack []string) (*yaml.Node, string, error) {
+	if node.Kind != yaml.ScalarNode || strings.TrimSpace(node.Value) == "" {
+		return nil, "", fmt.Errorf("%s: line %d: %s needs the path of a partial", path, node.Line, includeTag)
+	}
+	return p.load(strings.TrimSpace(node.Value), path, stack)
+}
+
+// addSections adds the nodes and connections of the partials listed in the
+// includes section of a workflow, or of a partial, to its own, and removes
+// the section.
+func (p *partials) addSections(root *yaml.Node, path string, stack []string) error {
+	if root.Kind != yaml.MappingNode {
+		return nil
+	}
+	section := mappingValue(root, includesKey)
+	if section == nil {
+		return nil
+	}
+	removeKey(root, includesKey)
+	if section.Kind != yaml.SequenceNode {
+		return fmt.Errorf("%s: line %d: %s must list the paths of partials", path, section.Line, includesKey)
+	}
+	for _, ref := range section.Content {
+		if ref.Kind != yaml.ScalarNode {
+			return fmt.Errorf("%s: line %d: %s must list the paths of partials", path, ref.Line, includesKey)
+		}
+		partial, partialPath, err := p.load(ref.Value, path, stack)
+		if err != nil {
+			return err
+		}
+		if partial.Kind != yaml.MappingNode {
+			return fmt.Errorf("%s: partials listed in %s hold nodes and connections, %s holds no mapping", path, includesKey, partialPath)
+		}
+		for i := 0; i+1 < len(partial.Content); i += 2 {
+			key, value := partial.Content[i].Value, partial.Content[i+1]
+			switch key {
+			case "nodes":
+				if value.Kind != yaml.SequenceNode {
+					return fmt.Errorf("%s: nodes must be a list", partialPath)
+				}
+				nodes, err := ensureKey(root, "nodes", yaml.SequenceNode, path)
+				if err != nil {
+					return err
+				}
+				nodes.Content = append(nodes.Content, value.Content...)
+			case "connections":
+				if value.Kind != yaml.MappingNode {
+					return fmt.Errorf("%s: connections must be a mapping", partialPath)
+				}
+				connections, err := ensureKey(root, "connections", yaml.MappingNode, path)
+				if err != nil {
+					return err
+				}
+				for j := 0; j+1 < len(value.Content); j += 2 {
+					source := value.Content[j].Value
+					if hasKey(connections, source) {
+						return fmt.Errorf("%s: connections of %q are set by the workflow already, connect them there", partialPath, source)
+					}
+					connections.Content = append(connections.Content, value.Content[j], value.Content[j+1])
+				}
+			default:
+				return fmt.Errorf("%s: partials listed in %s hold nodes and connections, not %s", partialPath, includesKey, key)
+			}
+		}
+	}
+	return nil
+}
+
+func mappingValue(mapping *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i+1]
+		}
+	}
+	return nil
+}
+
+func hasKey(mapping *yaml.Node, key string) bool {
+	return mappingValue(mapping, key) != nil
+}
+
+func removeKey(mapping *yaml.Node, key string) {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			mapping.Content = slices.Delete(mapping.Content, i, i+2)
+			return
+		}
+	}
+}
+
+// ensureKey returns the value of key, adding an empty one of kind if the
+// mapping has none or an empty value.
+func ensureKey(mapping *yaml.Node, key string, kind yaml.Kind, path string) (*yaml.Node, error) {
+	value := mappingValue(mapping, key)
+	switch {
+	case value == nil:
+		value = &yaml.Node{Kind: kind}
+		mapping.Content = append(mapping.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, value)
+	case value.Kind == yaml.ScalarNode && value.ShortTag() == "!!null":
+		value.Kind, value.Tag, value.Value = kind, "", ""
+	case value.Kind != kind:
+		return nil, fmt.Errorf("%s: line %d: %s cannot take the %s of partials", path, value.Line, key, key)
+	}
+	return value, nil
+}
@@ -0,0 +1,193 @@
+package workflows
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/brandon-kyle-bailey/n8nctl/config"
+	"github.com/brandon-kyle-bailey/n8nctl/envstore"
+	"github.com/brandon-kyle-bailey/n8nctl/state"
+)
+
+// RenderWorkers is how many workflow files RenderFiles renders at a time.
+var RenderWorkers = runtime.NumCPU()
+
+// CacheDir keeps what is worth not computing again for unchanged files,
+// such as their rendered JSON.
+var CacheDir = filepath.Join(outDir, "cache")
+
+// renderCacheDir keeps the rendered JSON of workflow files with hashes of
+// the files that went into it.
+var renderCacheDir = filepath.Join(CacheDir, "render")
+
+// cacheVersion changes when rendering does, invalidating older entries.
+const cacheVersion = 4
+
+// Rendered is a workflow file rendered by RenderFiles, or the error that
+// rendering it failed with.
+type Rendered struct {
+	File string
+	Body []byte
+	Err  error
+}
+
+// RenderFiles renders files like RenderWorkflowFile, RenderWorkers at a
+// time, and returns them in the order of files. A file that fails does not
+// stop the others. Files are read from the render cache when neither they,
+// their includes nor the env files have changed since they were rendered.
+func RenderFiles(files []string) []Rendered {
+	results := make([]Rendered, len(files))
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, max(RenderWorkers, 1))
+	for i, file := range files {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+			body, err := renderCached(file)
+			results[i] = Rendered{File: file, Body: body, Err: err}
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// CacheHits counts the files read from the render cache, for --stats.
+var CacheHits atomic.Int64
+
+// cacheEntry is the rendered JSON of a workflow file, valid as long as its
+// inputs hash the same. Inputs that did not exist have an empty hash.
+type cacheEntry struct {
+	Version int               `json:"version"`
+	Inputs  map[string]string `json:"inputs"`
+	Body    string            `json:"body"`
+}
+
+// renderCached renders path with its variables and secrets resolved, using
+// the render cache. Renders that resolved secrets are never cached.
+func renderCached(path string) ([]byte, error) {
+	entryPath := cachePath(path)
+	if body, ok := readCache(entryPath); ok {
+		CacheHits.Add(1)
+		return body, nil
+	}
+	r, err := render(path, true)
+	if err != nil {
+		return nil, err
+	}
+	if !r.sensitive {
+		hashes := map[string]string{}
+		inputs := slices.Concat([]string{path}, r.includes, EnvFiles)
+		// Storing variables for the profile later invalidates the entry.
+		if store, err := envstore.Path(config.Profile); err == nil && DecryptEnv {
+			inputs = append(inputs, store)
+		}
+		for _, input := range inputs {
+			hashes[input] = fileHash(input)
+		}
+		writeCache(entryPath, cacheEntry{Version: cacheVersion, Inputs: hashes, Body: string(r.body)})
+	}
+	return r.body, nil
+}
+
+// cachePath returns where the render of path is cached. Registered secret
+// stores, decrypting env files and which env files are read change what
+// renders, so they are part of the key, as are the env of the overlays and
+// the profile, whose stored variables render too.
+func cachePath(path string) string {
+	key := strings.Join([]string{
+		filepath.ToSlash(filepath.Clean(path)),
+		strings.Join(slices.Sorted(maps.Keys(SecretStores)), ","),
+		fmt.Sprint(DecryptEnv),
+		strings.Join(EnvFiles, ","),
+		Env,
+		config.Profile,
+	}, "\x00")
+	return filepath.Join(renderCacheDir, state.Hash([]byte(key))[:16]+".json")
+}
+
+func readCache(entryPath string) ([]byte, bool) {
+	data, err := os.ReadFile(entryPath)
+	if err != nil {
+		return nil, false
+	}
+	var entry cacheEntry
+	if json.Unmarshal(data, &entry) != nil || entry.Version != cacheVersion {
+		return nil, false
+	}
+	for input, hash := range entry.Inputs {
+		if fileHash(input) != hash {
+			return nil, false
+		}
+	}
+	return []byte(entry.Body), true
+}
+
+// writeCache stores entry, ignoring failures: without the cache files are
+// only rendered again.
+func writeCache(entryPath string, entry cacheEntry) {
+	if data, err := json.Marshal(entry); err == nil {
+		WriteCacheFile(entryPath, data)
+	}
+}
+
+// WriteCacheFile writes a file of a cache under CacheDir atomically, so
+// concurrent runs never read half of it. Failures are ignored, as caches
+// only save work.
+func WriteCacheFile(path string, data []byte) {
+	if os.MkdirAll(filepath.Dir(path), 0755) != nil {
+		return
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+}
+
+// fileHash hashes the content of path, or returns "" if it cannot be read.
+func fileHash(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return state.Hash(data)
+}
+
+// Inputs returns the files rendering path reads: the file itself, what it
+// includes, the values files and the env files, including those that do not
+// exist yet. Files only reached past an error in path are missing.
+func Inputs(path string) []string {
+	r, _ := render(path, false)
+	inputs := slices.Concat([]string{path}, r.includes, EnvFiles)
+	slices.Sort(inputs)
+	return slices.Compact(inputs)
+}
+
+// InputHashes returns the hashes of files, empty for those that do not exist,
+// to tell when any of them changes.
+func InputHashes(files []string) map[string]string {
+	hashes := make(map[string]string, len(files))
+	for _, file := range files {
+		hashes[file] = fileHash(file)
+	}
+	return hashes
+}
@@ -0,0 +1,109 @@
+package workflows
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Position is a 1-based line and column in a workflow file.
+type Position struct {
+	Line   int
+	Column int
+}
+
+// SourceError is a problem at a known position of a workflow file.
+type SourceError struct {
+	Position
+	Message string
+}
+
+func (e *SourceError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Message)
+}
+
+// yamlErrorLine matches the position yaml.v3 puts in its syntax errors.
+var yamlErrorLine = regexp.MustCompile(`^yaml: line (\d+): (.*)$`)
+
+// sourceError turns a yaml.v3 syntax error into a SourceError when it names a line.
+func sourceError(err error) error {
+	m := yamlErrorLine.FindStringSubmatch(err.Error())
+	if m == nil {
+		return err
+	}
+	line, _ := strconv.Atoi(m[1])
+	return &SourceError{Position: Position{Line: line}, Message: m[2]}
+}
+
+// Positions maps JSON pointers into a rendered workflow, such as
+// /nodes/2/name, to where the value is written in the YAML source.
+type Positions map[string]Position
+
+// SourcePositions indexes the YAML source of a workflow file. Rendering does
+// not change the structure of the document, so pointers into the rendered
+// JSON lead back to the source.
+func SourcePositions(path string) (Positions, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(normalizeNewlines(data), &doc); err != nil {
+		return nil, sourceError(err)
+	}
+	positions := Positions{}
+	if len(doc.Content) > 0 {
+		positions.index("", doc.Content[0], 0)
+	}
+	return positions, nil
+}
+
+// maxAliasDepth stops indexing alias cycles, which yaml.v3 accepts.
+const maxAliasDepth = 16
+
+func (p Positions) index(pointer string, node *yaml.Node, aliases int) {
+	if _, ok := p[pointer]; !ok {
+		p[pointer] = Position{Line: node.Line, Column: node.Column}
+	}
+	switch node.Kind {
+	case yaml.AliasNode:
+		if aliases < maxAliasDepth {
+			p.index(pointer, node.Alias, aliases+1)
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i]
+			child := pointer + "/" + escapePointer(key.Value)
+			// Point at the key, where editors show the entry starting.
+			p[child] = Position{Line: key.Line, Column: key.Column}
+			p.index(child, node.Content[i+1], aliases)
+		}
+	case yaml.SequenceNode:
+		for i, item := range node.Content {
+			p.index(pointer+"/"+strconv.Itoa(i), item, aliases)
+		}
+	}
+}
+
+// Lookup returns the position of pointer, or of its closest ancestor in the
+// source when the value itself is missing, e.g. a required field.
+func (p Positions) Lookup(pointer string) (Position, bool) {
+	for {
+		if pos, ok := p[pointer]; ok {
+			return pos, true
+		}
+		i := strings.LastIndex(pointer, "/")
+		if i < 0 {
+			return Position{}, false
+		}
+		pointer = pointer[:i]
+	}
+}
+
+func escapePointer(s string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(s)
+}
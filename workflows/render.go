@@ -0,0 +1,171 @@
+package workflows
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// YAMLToJSON converts a YAML document into indented JSON, preserving the key
+// order of mappings so the output stays stable between runs.
+func YAMLToJSON(src []byte) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(normalizeNewlines(src), &doc); err != nil {
+		return nil, fmt.Errorf("invalid YAML: %w", sourceError(err))
+	}
+
+	var compact bytes.Buffer
+	if len(doc.Content) == 0 {
+		compact.WriteString("null")
+	} else if err := writeJSONNode(&compact, doc.Content[0]); err != nil {
+		return nil, err
+	}
+
+	var out bytes.Buffer
+	if err := json.Indent(&out, compact.Bytes(), "", "  "); err != nil {
+		return nil, err
+	}
+	out.WriteByte('\n')
+	return out.Bytes(), nil
+}
+
+func writeJSONNode(buf *bytes.Buffer, node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		if len(node.Content) == 0 {
+			buf.WriteString("null")
+			return nil
+		}
+		return writeJSONNode(buf, node.Content[0])
+	case yaml.AliasNode:
+		return writeJSONNode(buf, node.Alias)
+	case yaml.MappingNode:
+		pairs, err := mappingPairs(node)
+		if err != nil {
+			return err
+		}
+		buf.WriteByte('{')
+		for i, pair := range pairs {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			key, err := marshalJSON(pair[0].Value)
+			if err != nil {
+				return err
+			}
+			buf.Write(key)
+			buf.WriteByte(':')
+			if err := writeJSONNode(buf, pair[1]); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	case yaml.SequenceNode:
+		buf.WriteByte('[')
+		for i, item := range node.Content {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeJSONNode(buf, item); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	case yaml.ScalarNode:
+		var value any = node.Value
+		// Timestamps are kept as written rather than reformatted as times.
+		if tag := node.ShortTag(); tag != "!!str" && tag != "!!timestamp" {
+			if err := node.Decode(&value); err != nil {
+				return &SourceError{Position: Position{Line: node.Line, Column: node.Column}, Message: err.Error()}
+			}
+		}
+		encoded, err := marshalJSON(value)
+		if err != nil {
+			return fmt.Errorf("line %d: cannot represent %q as JSON: %w", node.Line, node.Value, err)
+		}
+		buf.Write(encoded)
+	default:
+		return fmt.Errorf("line %d: unsupported YAML node", node.Line)
+	}
+	return nil
+}
+
+// mappingPairs returns the keys and values of a mapping in order, with its
+// << merge keys expanded. Keys of the mapping itself override merged ones,
+// and of a list of merged mappings the earlier ones override the later.
+func mappingPairs(node *yaml.Node) ([][2]*yaml.Node, error) {
+	explicit := map[string]bool{}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].ShortTag() != "!!merge" {
+			explicit[node.Content[i].Value] = true
+		}
+	}
+	var pairs [][2]*yaml.Node
+	index := map[string]int{}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		if key.ShortTag() != "!!merge" {
+			if j, ok := index[key.Value]; ok {
+				pairs[j][1] = value
+				continue
+			}
+			index[key.Value] = len(pairs)
+			pairs = append(pairs, [2]*yaml.Node{key, value})
+			continue
+		}
+		sources := []*yaml.Node{value}
+		if resolveAlias(value).Kind == yaml.SequenceNode {
+			sources = resolveAlias(value).Content
+		}
+		for _, source := range sources {
+			source = resolveAlias(source)
+			if source.Kind != yaml.MappingNode {
+				return nil, fmt.Errorf("line %d: << must merge a mapping or a list of mappings", key.Line)
+			}
+			merged, err := mappingPairs(source)
+			if err != nil {
+				return nil, err
+			}
+			for _, pair := range merged {
+				if _, ok := index[pair[0].Value]; ok || explicit[pair[0].Value] {
+					continue
+				}
+				index[pair[0].Value] = len(pairs)
+				pairs = append(pairs, pair)
+			}
+		}
+	}
+	return pairs, nil
+}
+
+// resolveAlias returns the node an alias refers to, or node itself.
+func resolveAlias(node *yaml.Node) *yaml.Node {
+	for node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	return node
+}
+
+// marshalJSON encodes v without escaping HTML characters, which are common in
+// embedded code and would otherwise show up as \u003c sequences.
+func marshalJSON(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimRight(buf.Bytes(), "\n"), nil
+}
+
+// normalizeNewlines converts Windows line endings to plain newlines.
+func normalizeNewlines(b []byte) []byte {
+	return bytes.ReplaceAll(b, []byte("\r\n"), []byte("\n"))
+}
+
+func normalizeNewlinesString(s string) string {
+	return strings.ReplaceAll(s, "\r\n", "\n")
+}
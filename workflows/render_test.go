@@ -0,0 +1,65 @@
+package workflows
+
+import "testing"
+
+func TestYAMLToJSONKeepsTimestampsAsWritten(t *testing.T) {
+	got, err := YAMLToJSON([]byte("since: 2024-01-02\nat: 2024-01-02T03:04:05+02:00\nquoted: \"2024-01-02\"\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{
+  "since": "2024-01-02",
+  "at": "2024-01-02T03:04:05+02:00",
+  "quoted": "2024-01-02"
+}
+`
+	if string(got) != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestYAMLToJSONExpandsMergeKeys(t *testing.T) {
+	src := `defaults: &defaults
+  method: GET
+  timeout: 30
+auth: &auth
+  method: POST
+  token: abc
+single:
+  <<: *defaults
+  url: https://example.com
+list:
+  url: https://example.com
+  <<: [*auth, *defaults]
+  timeout: 60
+`
+	got, err := YAMLToJSON([]byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{
+  "defaults": {
+    "method": "GET",
+    "timeout": 30
+  },
+  "auth": {
+    "method": "POST",
+    "token": "abc"
+  },
+  "single": {
+    "method": "GET",
+    "timeout": 30,
+    "url": "https://example.com"
+  },
+  "list": {
+    "url": "https://example.com",
+    "method": "POST",
+    "token": "abc",
+    "timeout": 60
+  }
+}
+`
+	if string(got) != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
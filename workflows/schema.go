@@ -0,0 +1,111 @@
+package workflows
+
+import (
+	"encoding/json"
+	"sort"
+)
+
+// JSONSchema returns a JSON Schema for workflow YAML files, for editors to
+// validate and complete them. nodeTypes, when given, are suggested for the
+// type of each node. The schema uses draft-07, which editors support best.
+//
+// Values may be ${{ VAR }} placeholders, file() includes or partials, so only fields
+// that are always literal have types beyond string.
+func JSONSchema(nodeTypes []string) ([]byte, error) {
+	str := func(description string) map[string]any {
+		return map[string]any{"type": "string", "description": description}
+	}
+
+	nodeType := str("Node type, e.g. n8n-nodes-base.httpRequest")
+	if len(nodeTypes) > 0 {
+		types := append([]string(nil), nodeTypes...)
+		sort.Strings(types)
+		nodeType["examples"] = types
+	}
+
+	node := map[string]any{
+		"type":     "object",
+		"required": []string{"name", "type"},
+		"properties": map[string]any{
+			"id":          str("Unique ID of the node within the workflow"),
+			"name":        str("Node name, unique within the workflow and used by connections"),
+			"type":        nodeType,
+			"typeVersion": map[string]any{"type": "number", "description": "Version of the node type"},
+			"position": map[string]any{
+				"type":        "array",
+				"description": "Canvas position as [x, y]",
+				"items":       map[string]any{"type": "number"},
+				"minItems":    2,
+				"maxItems":    2,
+			},
+			"parameters": map[string]any{"type": "object", "description": "Node parameters; string parameters such as jsCode, pythonCode, query, html or jsonBody may be file(<path>) to inline a file, relative to the workflow file"},
+			"credentials": map[string]any{
+				"type":        "object",
+				"description": "Credentials by credential type",
+				"additionalProperties": map[string]any{
+					"type":       "object",
+					"properties": map[string]any{"id": str("Credential ID"), "name": str("Credential name")},
+				},
+			},
+			"disabled": map[string]any{"type": "boolean"},
+			"enabledWhen": map[string]any{
+				"description": "Builds the node only for these envs, or when this template condition holds, e.g. ne .Env \"prod\"",
+				"type":        []string{"array", "string", "boolean"},
+				"items":       map[string]any{"type": "string"},
+			},
+			"notes":            str("Notes shown in the editor"),
+			"webhookId":        str("Stable ID of the webhook path"),
+			"continueOnFail":   map[string]any{"type": "boolean"},
+			"alwaysOutputData": map[string]any{"type": "boolean"},
+			"retryOnFail":      map[string]any{"type": "boolean"},
+		},
+	}
+
+	endpoint := map[string]any{
+		"type":                 "object",
+		"required":             []string{"node", "type", "index"},
+		"additionalProperties": false,
+		"properties": map[string]any{
+			"node":  str("Name of the receiving node"),
+			"type":  str("Connection type, usually main"),
+			"index": map[string]any{"type": "integer", "minimum": 0, "description": "Input of the receiving node"},
+		},
+	}
+
+	schema := map[string]any{
+		"$schema":     "http://json-schema.org/draft-07/schema#",
+		"title":       "n8nctl workflow",
+		"description": "An n8n workflow template rendered by n8nctl",
+		"type":        "object",
+		"required":    []string{"name", "nodes", "connections"},
+		"properties": map[string]any{
+			"name":  str("Workflow name, used to match the remote workflow on deploy"),
+			"nodes": map[string]any{"type": "array", "items": node},
+			"connections": map[string]any{
+				"type":        "object",
+				"description": "Outgoing connections by source node name, then connection type, then output index",
+				"additionalProperties": map[string]any{
+					"type": "object",
+					"additionalProperties": map[string]any{
+						"type":  "array",
+						"items": map[string]any{"type": "array", "items": endpoint},
+					},
+				},
+			},
+			"settings":   map[string]any{"type": "object"},
+			"staticData": map[string]any{"type": []string{"object", "null"}},
+			"includes": map[string]any{
+				"type":        "array",
+				"description": "Partials whose nodes and connections are added to the workflow's, relative to the workflow file; values may also be !include <path>",
+				"items":       str("Path of a partial"),
+			},
+		},
+		// The rendered JSON is deployed as is and the API rejects other fields.
+		"additionalProperties": false,
+	}
+	out, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(out, '\n'), nil
+}
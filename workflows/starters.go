@@ -0,0 +1,160 @@
+package workflows
+
+import (
+	"crypto/rand"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+)
+
+// Starters are the workflow files workflows create writes, by the trigger
+// they start with.
+var Starters = map[string]string{
+	"manual": `
+name: Sample Workflow
+nodes:
+  - id: "1"
+    name: Start
+    type: n8n-nodes-base.manualTrigger
+    typeVersion: 1
+    position: [250, 300]
+  - id: "2"
+    name: HTTP Request
+    type: n8n-nodes-base.httpRequest
+    typeVersion: 1
+    position: [450, 300]
+    credentials:
+      httpBasicAuth:
+        id: "credential-id"
+        name: "My HTTP Basic Auth"
+    parameters:
+      url: "https://jsonplaceholder.typicode.com/posts/1"
+connections:
+  Start:
+    main:
+      - - node: HTTP Request
+          type: main
+          index: 0
+settings: {}
+`,
+	"form": `
+name: Sample Form
+nodes:
+  - id: "1"
+    name: Form
+    type: n8n-nodes-base.formTrigger
+    typeVersion: 2.2
+    position: [250, 300]
+    webhookId: "{{webhookId}}"
+    parameters:
+      formTitle: Contact us
+      formDescription: We will get back to you within a day.
+      formFields:
+        values:
+          - fieldLabel: Name
+            requiredField: true
+          - fieldLabel: Email
+            fieldType: email
+            requiredField: true
+          - fieldLabel: Message
+            fieldType: textarea
+      options: {}
+  - id: "2"
+    name: Save Submission
+    type: n8n-nodes-base.set
+    typeVersion: 3.4
+    position: [450, 300]
+    parameters:
+      assignments:
+        assignments:
+          - id: "1"
+            name: submittedAt
+            value: "={{ $json.submittedAt }}"
+            type: string
+          - id: "2"
+            name: email
+            value: "={{ $json.Email }}"
+            type: string
+      includeOtherFields: true
+      options: {}
+connections:
+  Form:
+    main:
+      - - node: Save Submission
+          type: main
+          index: 0
+settings: {}
+`,
+	"chat": `
+name: Sample Chat
+nodes:
+  - id: "1"
+    name: Chat
+    type: "@n8n/n8n-nodes-langchain.chatTrigger"
+    typeVersion: 1.1
+    position: [250, 300]
+    webhookId: "{{webhookId}}"
+    parameters:
+      public: true
+      mode: hostedChat
+      initialMessages: Hi there! Ask me anything.
+      options:
+        title: Sample Chat
+  - id: "2"
+    name: Reply
+    type: n8n-nodes-base.set
+    typeVersion: 3.4
+    position: [450, 300]
+    parameters:
+      assignments:
+        assignments:
+          - id: "1"
+            name: output
+            value: "=You said: {{ $json.chatInput }}"
+            type: string
+      options: {}
+connections:
+  Chat:
+    main:
+      - - node: Reply
+          type: main
+          index: 0
+settings: {}
+`,
+}
+
+// GenerateStarterWorkflowYAML writes the starter named template to
+// workflow.yaml. Form and chat triggers get a webhook ID of their own, which
+// their URLs are built from.
+func GenerateStarterWorkflowYAML(template string) error {
+	yamlContent, ok := Starters[template]
+	if !ok {
+		return fmt.Errorf("unknown template %q, use one of %s", template, strings.Join(slices.Sorted(maps.Keys(Starters)), ", "))
+	}
+	if strings.Contains(yamlContent, "{{webhookId}}") {
+		id, err := newWebhookID()
+		if err != nil {
+			return err
+		}
+		yamlContent = strings.ReplaceAll(yamlContent, "{{webhookId}}", id)
+	}
+
+	if _, err := os.Stat(WorkflowFile); err == nil {
+		return fmt.Errorf("%s already exists", WorkflowFile)
+	}
+	return os.WriteFile(WorkflowFile, []byte(yamlContent), 0644)
+}
+
+// newWebhookID returns a random UUID, like the editor gives the webhook of a
+// new trigger.
+func newWebhookID() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]), nil
+}
@@ -0,0 +1,119 @@
+package workflows
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/Masterminds/sprig/v3"
+	"gopkg.in/yaml.v3"
+)
+
+// Workflow files named like orders.tmpl.yaml are Go templates, executed
+// before anything else renders with the values of values.yaml as .Values and
+// the env of the overlays as .Env:
+//
+//	nodes:
+//	{% range .Values.regions %}
+//	  - name: Sync {% . | upper %}
+//	    type: n8n-nodes-base.httpRequest
+//	{% end %}
+//	{% if ne .Env "prod" %}
+//	  - name: Debug log
+//	{% end %}
+//
+// The delimiters are not {{ }}, which n8n expressions use. The functions are
+// those of sprig, less the ones that do not repeat such as env and now. Other
+// files are never executed, so a {% in them is only text.
+const (
+	templateLeftDelim  = "{%"
+	templateRightDelim = "%}"
+)
+
+// templateSuffixes end the names of workflow files that are templates.
+var templateSuffixes = []string{".tmpl.yaml", ".tmpl.yml"}
+
+// IsTemplate reports whether the workflow file path is a template.
+func IsTemplate(path string) bool {
+	name := strings.ToLower(filepath.Base(path))
+	for _, suffix := range templateSuffixes {
+		if strings.HasSuffix(name, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// ValuesFile holds the values of workflow templates, relative to the working
+// directory. With an env selected, values.<env>.yaml is merged over it.
+const ValuesFile = "values.yaml"
+
+// expandTemplate executes src, the workflow file path, as a template and
+// returns the result with the values files read, including those that do
+// not exist so creating one invalidates the render cache.
+func expandTemplate(src, path string) (string, []string, error) {
+	values, files, err := loadValues()
+	if err != nil {
+		return "", nil, err
+	}
+	tmpl, err := template.New(path).Delims(templateLeftDelim, templateRightDelim).Funcs(templateFuncs).Parse(src)
+	if err != nil {
+		return "", nil, err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, map[string]any{"Values": values, "Env": Env}); err != nil {
+		return "", nil, err
+	}
+	return buf.String(), files, nil
+}
+
+// loadValues returns the values of templates, and the values files they are
+// read from when they exist.
+func loadValues() (map[string]any, []string, error) {
+	files := []string{ValuesFile}
+	if Env != "" {
+		files = append(files, envValuesFile(Env))
+	}
+	values := map[string]any{}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		var more map[string]any
+		if err := yaml.Unmarshal(data, &more); err != nil {
+			return nil, nil, fmt.Errorf("failed to parse %s: %w", file, err)
+		}
+		mergeValues(values, more)
+	}
+	return values, files, nil
+}
+
+// envValuesFile returns the values file of env, merged over values.yaml.
+func envValuesFile(env string) string {
+	return strings.TrimSuffix(ValuesFile, ".yaml") + "." + env + ".yaml"
+}
+
+// mergeValues merges src into dst, merging maps both hold key by key.
+func mergeValues(dst, src map[string]any) {
+	for key, value := range src {
+		from, ok := value.(map[string]any)
+		into, isMap := dst[key].(map[string]any)
+		if ok && isMap {
+			mergeValues(into, from)
+			continue
+		}
+		dst[key] = value
+	}
+}
+
+// templateFuncs are the functions of sprig that always give the same result
+// for the same arguments, so renders can be cached: env, now and the random
+// functions are left out.
+var templateFuncs = template.FuncMap(sprig.HermeticTxtFuncMap())
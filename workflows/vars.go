@@ -0,0 +1,116 @@
+package workflows
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/brandon-kyle-bailey/n8nctl/config"
+	"github.com/brandon-kyle-bailey/n8nctl/envstore"
+	"github.com/brandon-kyle-bailey/n8nctl/sops"
+	"github.com/brandon-kyle-bailey/n8nctl/utils"
+)
+
+// VarUsage is a ${{VAR_NAME}} placeholder in a workflow file.
+type VarUsage struct {
+	Name string
+	File string
+	// Where is the field holding it, such as `node "Fetch" parameters.url`.
+	Where string
+}
+
+// Variables returns the variables used by files, after their includes,
+// partials and overlay, in the order they appear.
+func Variables(files []string) ([]VarUsage, error) {
+	var usages []VarUsage
+	for _, file := range files {
+		body, err := RenderTemplate(file)
+		if err != nil {
+			return nil, err
+		}
+		var workflow map[string]any
+		if err := json.Unmarshal(body, &workflow); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", file, err)
+		}
+		for _, key := range slices.Sorted(maps.Keys(workflow)) {
+			if nodes, ok := workflow[key].([]any); ok && key == "nodes" {
+				for i, node := range nodes {
+					where := fmt.Sprintf("nodes[%d]", i)
+					if name, ok := node.(map[string]any)["name"].(string); ok {
+						where = fmt.Sprintf("node %q", name)
+					}
+					usages = appendVarUsages(usages, file, where, "", node)
+				}
+				continue
+			}
+			usages = appendVarUsages(usages, file, "", key, workflow[key])
+		}
+	}
+	return usages, nil
+}
+
+// appendVarUsages adds the placeholders of value, found at path below the
+// part of the workflow named by where, to usages.
+func appendVarUsages(usages []VarUsage, file, where, path string, value any) []VarUsage {
+	switch v := value.(type) {
+	case string:
+		at := strings.TrimSpace(where + " " + path)
+		for _, match := range envPattern.FindAllStringSubmatch(v, -1) {
+			usages = append(usages, VarUsage{Name: match[1], File: file, Where: at})
+		}
+	case []any:
+		for i, item := range v {
+			usages = appendVarUsages(usages, file, where, fmt.Sprintf("%s[%d]", path, i), item)
+		}
+	case map[string]any:
+		for _, key := range slices.Sorted(maps.Keys(v)) {
+			usages = appendVarUsages(usages, file, where, strings.TrimPrefix(path+"."+key, "."), v[key])
+		}
+	}
+	return usages
+}
+
+// EnvNames returns the variables the EnvFiles that exist define, with the
+// file defining each, later files overriding earlier ones like they do when
+// rendering. Encrypted files are not decrypted, SOPS leaves their names in
+// plain text, but the store of the profile is.
+func EnvNames() (map[string]string, error) {
+	names := map[string]string{}
+	for _, path := range EnvFiles {
+		data, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to load %s: %w", path, err)
+		}
+		var keys []string
+		if sops.IsEncrypted(path, data) {
+			if keys, err = sops.Keys(path, data); err != nil {
+				return nil, err
+			}
+		} else {
+			vars, err := utils.LoadDotEnv(path)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load %s: %w", path, err)
+			}
+			keys = slices.Collect(maps.Keys(vars))
+		}
+		for _, key := range keys {
+			names[key] = path
+		}
+	}
+	if envstore.Exists(config.Profile) {
+		store, err := envstore.Open(config.Profile)
+		if err != nil {
+			return nil, err
+		}
+		for name := range store.Vars {
+			names[name] = store.Path()
+		}
+	}
+	return names, nil
+}
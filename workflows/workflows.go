@@ -2,203 +2,692 @@
 package workflows
 
 import (
-	"bufio"
+	"context"
 	"fmt"
+	"maps"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/brandon-kyle-bailey/n8nctl/config"
+	"github.com/brandon-kyle-bailey/n8nctl/envstore"
+	"github.com/brandon-kyle-bailey/n8nctl/prompt"
+	"github.com/brandon-kyle-bailey/n8nctl/secrets"
+	"github.com/brandon-kyle-bailey/n8nctl/sops"
+	"github.com/brandon-kyle-bailey/n8nctl/state"
 	"github.com/brandon-kyle-bailey/n8nctl/utils"
+	"gopkg.in/yaml.v3"
 )
 
-func GenerateStarterWorkflowYAML() error {
-	yamlContent := `
-name: Sample Workflow
-nodes:
-  - id: "1"
-    name: Start
-    type: n8n-nodes-base.manualTrigger
-    typeVersion: 1
-    position: [250, 300]
-  - id: "2"
-    name: HTTP Request
-    type: n8n-nodes-base.httpRequest
-    typeVersion: 1
-    position: [450, 300]
-    credentials:
-      httpBasicAuth:
-        id: "credential-id"
-        name: "My HTTP Basic Auth"
-    parameters:
-      url: "https://jsonplaceholder.typicode.com/posts/1"
-connections:
-  Start:
-    main:
-      - - node: HTTP Request
-          type: main
-          index: 0
-settings: {}
-`
-
-	fileName := "workflow.yaml"
-	if _, err := os.Stat(fileName); err == nil {
-		return fmt.Errorf("%s already exists", fileName)
-	}
-	return os.WriteFile(fileName, []byte(yamlContent), 0644)
+const (
+	// WorkflowFile is the default workflow template in the project root.
+	WorkflowFile = "workflow.yaml"
+	// ProjectDir holds the workflow templates of projects managing several workflows.
+	ProjectDir = "workflows"
+	outDir     = ".out"
+)
+
+// ResolveAt values: variables and secrets are resolved when previewing, so
+// the .out files hold them, or only when deploying.
+const (
+	ResolveAtPreview = "preview"
+	ResolveAtDeploy  = "deploy"
+)
+
+// ResolveAt is when variables and secrets are resolved. Resolving them at
+// deploy keeps them out of the .out files, which then hold the placeholders.
+var ResolveAt = ResolveAtPreview
+
+// OutputPath is where previewed workflow JSON is written and deployed from.
+var OutputPath = OutputPathFor(WorkflowFile)
+
+// RenderWorkflowFile converts a workflow YAML file into n8n workflow JSON,
+// inlining file() includes and variables from .env.
+func RenderWorkflowFile(path string) ([]byte, error) {
+	body, _, err := renderWorkflowFile(path, true)
+	return body, err
 }
 
-func PreviewWorkflowJSONWithPrompt() (bool, error) {
-	// Read workflow.yaml first
-	yamlBytes, err := os.ReadFile("workflow.yaml")
+// RenderTemplate converts a workflow YAML file into n8n workflow JSON like
+// RenderWorkflowFile, but keeps the ${{...}} placeholders of variables and
+// secrets.
+func RenderTemplate(path string) ([]byte, error) {
+	body, _, err := renderWorkflowFile(path, false)
+	return body, err
+}
+
+// renderOutput renders path with its variables and secrets resolved, and
+// returns it with the values to mask and the JSON of its .out file, which
+// is the same render unless ResolveAt is deploy. Secret stores are only
+// asked once.
+func renderOutput(path string) ([]byte, []string, []byte, error) {
+	resolved, secrets, err := renderWorkflowFile(path, true)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if ResolveAt != ResolveAtDeploy {
+		return resolved, secrets, resolved, nil
+	}
+	output, err := RenderTemplate(path)
+	return resolved, secrets, output, err
+}
+
+// renderWorkflowFile renders path, resolving its variables and secrets if
+// resolve is set, and returns the values substituted for them so printed
+// output can mask them.
+func renderWorkflowFile(path string, resolve bool) ([]byte, []string, error) {
+	r, err := render(path, resolve)
+	return r.body, r.resolved, err
+}
+
+// rendering is a rendered workflow file with what went into it.
+type rendering struct {
+	body     []byte
+	resolved []string
+	// includes are the files inlined with file(), partials, overlays and
+	// the values of templates.
+	includes []string
+	// sensitive is set when secrets or variables of encrypted env files
+	// were resolved, which must not be written to the render cache.
+	sensitive bool
+}
+
+func render(path string, resolve bool) (rendering, error) {
+	var r rendering
+	yamlBytes, err := os.ReadFile(path)
 	if err != nil {
-		return false, fmt.Errorf("workflow.yaml not found")
+		return r, fmt.Errorf("%s not found", path)
+	}
+
+	yamlStr := normalizeNewlinesString(string(yamlBytes))
+
+	if IsTemplate(path) {
+		expanded, values, err := expandTemplate(yamlStr, path)
+		if err != nil {
+			return r, err
+		}
+		yamlStr = expanded
+		r.includes = append(r.includes, values...)
+	}
+
+	// Only inline files if the marker exists
+	if strings.Contains(yamlStr, "file(") {
+		yamlWithFiles, includes, err := inlineFiles(yamlStr, path)
+		if err != nil {
+			return r, fmt.Errorf("failed to inline files into %s: %w", path, err)
+		}
+		yamlStr = string(yamlWithFiles)
+		r.includes = append(r.includes, includes...)
 	}
 
-	yamlStr := string(yamlBytes)
+	if includesPattern.MatchString(yamlStr) {
+		resolved, partials, err := resolvePartials(yamlStr, path)
+		if err != nil {
+			return r, err
+		}
+		yamlStr = resolved
+		r.includes = append(r.includes, partials...)
+	}
+
+	if Env != "" {
+		patched, read, err := applyOverlay(yamlStr, path)
+		if err != nil {
+			return r, err
+		}
+		yamlStr = patched
+		r.includes = append(r.includes, read...)
+	}
 
-	// Only inject JS code if the marker exists
-	if strings.Contains(yamlStr, "jsCode: file(index.js)") {
-		yamlWithJSBytes, err := injectJSCode("workflow.yaml")
+	if strings.Contains(yamlStr, enabledWhenKey) {
+		built, values, err := applyConditions(yamlStr, path)
 		if err != nil {
-			return false, fmt.Errorf("failed to inject index.js code: %w", err)
+			return r, err
+		}
+		yamlStr = built
+		r.includes = append(r.includes, values...)
+	}
+
+	if resolve {
+		if yamlStr, err = r.resolve(yamlStr, path); err != nil {
+			return r, err
 		}
-		yamlStr = string(yamlWithJSBytes)
 	}
 
-	// Load env and inject variables as before
-	envMap, err := utils.LoadDotEnv(".env")
-	if err != nil && !os.IsNotExist(err) {
-		return false, fmt.Errorf("failed to load .env file: %w", err)
+	if r.body, err = YAMLToJSON([]byte(yamlStr)); err != nil {
+		return r, fmt.Errorf("failed to convert %s: %w", path, err)
 	}
+	return r, nil
+}
 
+// resolve substitutes the variables and secrets of src, the YAML of path,
+// recording the values it used.
+func (r *rendering) resolve(src, path string) (string, error) {
+	envMap, encrypted, err := loadEnv()
+	if err != nil {
+		return "", err
+	}
+
+	var values []string
 	if envMap != nil {
-		yamlStr = injectEnvVariables(yamlStr, envMap)
+		for _, match := range envPattern.FindAllStringSubmatch(src, -1) {
+			if encrypted[match[1]] {
+				r.sensitive = true
+			}
+		}
+		if src, values, err = injectEnvVariables(src, envMap, encrypted); err != nil {
+			return "", fmt.Errorf("failed to resolve variables in %s: %w", path, err)
+		}
+		r.resolved = append(r.resolved, values...)
 	}
+	if src, values, err = injectSecrets(src); err != nil {
+		return "", fmt.Errorf("failed to resolve secrets in %s: %w", path, err)
+	}
+	if len(values) > 0 {
+		r.sensitive = true
+	}
+	r.resolved = append(r.resolved, values...)
+	return src, nil
+}
 
-	// Proceed with yq, diff, prompt, etc...
-	cmd := exec.Command("yq", ".", "-")
-	cmd.Stdin = strings.NewReader(yamlStr)
-	newJSON, err := cmd.Output()
+// ResolveJSON substitutes the variables and secrets of workflow JSON
+// rendered with its placeholders kept, such as a history snapshot deployed
+// with ResolveAt deploy. path names the JSON in errors.
+func ResolveJSON(path string, body []byte) ([]byte, error) {
+	var r rendering
+	src, err := r.resolve(string(body), path)
+	if err != nil {
+		return nil, err
+	}
+	if src == string(body) {
+		return body, nil
+	}
+	resolved, err := YAMLToJSON([]byte(src))
 	if err != nil {
-		return false, fmt.Errorf("yq failed: %w", err)
+		return nil, fmt.Errorf("failed to convert %s: %w", path, err)
+	}
+	return resolved, nil
+}
+
+// WorkflowFiles returns the workflow YAML files directly inside dir, sorted by name.
+func WorkflowFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, entry := range entries {
+		ext := strings.ToLower(filepath.Ext(entry.Name()))
+		if !entry.IsDir() && (ext == ".yaml" || ext == ".yml") {
+			files = append(files, filepath.Join(dir, entry.Name()))
+		}
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no workflow YAML files found in %s", dir)
 	}
+	return files, nil
+}
 
-	oldJSONBytes, err := os.ReadFile(".out/workflow.json")
+// ProjectFiles returns the workflow files managed by the project: those in dir
+// when given, otherwise those in the workflows/ directory if it exists, and
+// otherwise the single workflow.yaml.
+func ProjectFiles(dir string) ([]string, error) {
+	if dir != "" {
+		return WorkflowFiles(dir)
+	}
+	if info, err := os.Stat(ProjectDir); err == nil && info.IsDir() {
+		return WorkflowFiles(ProjectDir)
+	}
+	if _, err := os.Stat(WorkflowFile); err != nil {
+		return nil, fmt.Errorf("no %s directory or %s found", ProjectDir, WorkflowFile)
+	}
+	return []string{WorkflowFile}, nil
+}
+
+// OutputPathFor returns the .out path where the rendered JSON of a workflow file is kept.
+// The path mirrors the file's path relative to the working directory, so files of the
+// same name in different directories, or a.yaml and a.yml, do not share one.
+func OutputPathFor(yamlPath string) string {
+	return filepath.Join(outDir, outputKey(yamlPath)+".json")
+}
+
+// outputKey returns the relative path of a workflow file without its .yaml
+// extension. Other extensions are kept, and files outside the working
+// directory have their ".." elements replaced by "__".
+func outputKey(yamlPath string) string {
+	rel := filepath.Clean(yamlPath)
+	if abs, err := filepath.Abs(yamlPath); err == nil {
+		if wd, err := os.Getwd(); err == nil {
+			if r, err := filepath.Rel(wd, abs); err == nil {
+				rel = r
+			}
+		}
+	}
+	parts := strings.Split(filepath.ToSlash(rel), "/")
+	for i, part := range parts {
+		if part == ".." {
+			parts[i] = "__"
+		}
+	}
+	return filepath.FromSlash(strings.TrimSuffix(strings.Join(parts, "/"), ".yaml"))
+}
+
+// WriteOutput stores rendered workflow JSON at path, creating the .out directory if needed.
+func WriteOutput(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create %s directory: %w", filepath.Dir(path), err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+func PreviewWorkflowJSONWithPrompt() (bool, error) {
+	resolvedJSON, secrets, newJSON, err := renderOutput(WorkflowFile)
+	if err != nil {
+		return false, err
+	}
+
+	oldJSONBytes, err := os.ReadFile(OutputPath)
 	oldExists := err == nil
 
 	fmt.Println("Workflow JSON preview:")
-	fmt.Println(string(newJSON))
+	fmt.Println(string(maskSecrets(resolvedJSON, secrets)))
 
 	if oldExists {
 		fmt.Println("\nShowing diff between existing and new workflow JSON:")
-		if err := utils.RunDiff(oldJSONBytes, newJSON); err != nil {
+		if err := utils.RunDiff(maskSecrets(oldJSONBytes, secrets), maskSecrets(newJSON, secrets)); err != nil {
 			return false, err
 		}
 	} else {
-		fmt.Println("\nNo existing .out/workflow.json found, skipping diff.")
+		fmt.Printf("\nNo existing %s found, skipping diff.\n", OutputPath)
 	}
 
-	fmt.Print("\nWrite this JSON to .out/workflow.json? (y/N): ")
-	reader := bufio.NewReader(os.Stdin)
-	answer, _ := reader.ReadString('\n')
-	answer = strings.TrimSpace(strings.ToLower(answer))
-	if answer == "y" || answer == "yes" {
-		if _, err := os.Stat(".out"); os.IsNotExist(err) {
-			if err := os.Mkdir(".out", 0755); err != nil {
-				return false, fmt.Errorf("failed to create .out directory: %w", err)
-			}
-		}
-
-		err = os.WriteFile(".out/workflow.json", newJSON, 0644)
-		if err != nil {
-			return false, fmt.Errorf("failed to write .out/workflow.json: %w", err)
-		}
-		fmt.Println("\nSaved to .out/workflow.json")
-		return true, nil
-	} else {
+	fmt.Println()
+	confirmed, err := prompt.Confirm(fmt.Sprintf("Write this JSON to %s?", OutputPath), false)
+	if err != nil {
+		return false, err
+	}
+	if !confirmed {
 		fmt.Println("Aborted, no changes written.")
 		return false, nil
 	}
+	if err := WriteOutput(OutputPath, newJSON); err != nil {
+		return false, err
+	}
+	fmt.Printf("\nSaved to %s\n", OutputPath)
+	return true, nil
 }
 
 func DiffWorkflowJSON() error {
-	if _, err := os.Stat("workflow.yaml"); os.IsNotExist(err) {
-		return fmt.Errorf("workflow.yaml not found")
+	if _, err := os.Stat(OutputPath); os.IsNotExist(err) {
+		return fmt.Errorf("%s does not exist, please run preview and save the JSON first", OutputPath)
 	}
-	if _, err := os.Stat(".out/workflow.json"); os.IsNotExist(err) {
-		return fmt.Errorf(".out/workflow.json does not exist, please run preview and save the JSON first")
+
+	resolvedJSON, secrets, newJSON, err := renderOutput(WorkflowFile)
+	if err != nil {
+		return err
 	}
 
-	cmd := exec.Command("yq", ".", "workflow.yaml")
-	newJSON, err := cmd.Output()
+	oldJSONBytes, err := os.ReadFile(OutputPath)
 	if err != nil {
-		return fmt.Errorf("yq failed: %w", err)
+		return fmt.Errorf("failed to read %s: %w", OutputPath, err)
 	}
 
-	oldJSONBytes, err := os.ReadFile(".out/workflow.json")
+	if err := utils.RunDiff(maskSecrets(oldJSONBytes, secrets), maskSecrets(newJSON, secrets)); err != nil {
+		return err
+	}
+	return printDeployStatus(WorkflowFile, resolvedJSON)
+}
+
+// printDeployStatus reports when file was last deployed and whether its
+// rendered JSON has changed since.
+func printDeployStatus(file string, rendered []byte) error {
+	st, err := state.Load()
 	if err != nil {
-		return fmt.Errorf("failed to read .out/workflow.json: %w", err)
+		return fmt.Errorf("failed to load %s: %w", state.Path, err)
+	}
+	entry, ok := st.Get(file)
+	if !ok {
+		fmt.Printf("\n%s has not been deployed yet.\n", file)
+		return nil
+	}
+	status := "unchanged"
+	if entry.Hash != state.Hash(rendered) {
+		status = "changed"
+	}
+	fmt.Printf("\n%s was last deployed as workflow %s at %s (%s since).\n",
+		file, entry.WorkflowID, entry.DeployedAt.Local().Format(time.RFC1123), status)
+	return nil
+}
+
+// EnvFiles are read in order for the values of ${{VAR_NAME}} placeholders,
+// later files overriding earlier ones. Any of them may be encrypted with SOPS.
+// The variables stored for the profile with n8nctl env set override them all.
+var EnvFiles = []string{".env", ".env.sops.yaml", ".env.sops.json"}
+
+// UseEnvDotFile reads the variables of env from .env.<env> instead of .env,
+// if that file exists.
+func UseEnvDotFile(env string) {
+	path := envDotFile(env)
+	if _, err := os.Stat(path); err != nil {
+		return
+	}
+	EnvFiles = slices.Clone(EnvFiles)
+	if i := slices.Index(EnvFiles, ".env"); i >= 0 {
+		EnvFiles[i] = path
 	}
+}
 
-	return utils.RunDiff(oldJSONBytes, newJSON)
+// envDotFile returns the dotenv file of the variables of env.
+func envDotFile(env string) string {
+	return ".env." + env
 }
 
-// injectEnvVariables replaces ${{VAR_NAME}} with values from env map
-func injectEnvVariables(yaml string, env map[string]string) string {
-	re := regexp.MustCompile(`\${{\s*([A-Za-z_][A-Za-z0-9_]*)\s*}}`)
-	return re.ReplaceAllStringFunc(yaml, func(match string) string {
-		matches := re.FindStringSubmatch(match)
-		if len(matches) < 2 {
-			return match
+// DecryptEnv enables decrypting env files encrypted with SOPS. Like
+// SecretStores it is left off for lint and validate, which then leave the
+// variables of encrypted files unresolved.
+var DecryptEnv bool
+
+// decryptedEnv caches decrypted env files by path, as decrypting may call a
+// KMS and every workflow of a deploy is rendered with the same files. Files
+// are rendered in parallel, so it is guarded by decryptMu.
+var (
+	decryptedEnv = map[string]map[string]string{}
+	decryptMu    sync.Mutex
+)
+
+// loadEnv returns the variables of the EnvFiles that exist, or nil if none
+// do, and which of them come from encrypted files.
+func loadEnv() (map[string]string, map[string]bool, error) {
+	var env map[string]string
+	encrypted := map[string]bool{}
+	for _, path := range EnvFiles {
+		data, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			continue
 		}
-		if val, ok := env[matches[1]]; ok {
-			return val
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to load %s: %w", path, err)
 		}
-		return match // leave unresolved if missing
-	})
+		var vars map[string]string
+		isEncrypted := sops.IsEncrypted(path, data)
+		switch {
+		case isEncrypted:
+			if !DecryptEnv {
+				continue
+			}
+			if vars, err = decryptEnv(path, data); err != nil {
+				return nil, nil, err
+			}
+		case !slices.Contains([]string{".yaml", ".yml", ".json"}, filepath.Ext(path)):
+			if vars, err = utils.LoadDotEnv(path); err != nil {
+				return nil, nil, fmt.Errorf("failed to load %s: %w", path, err)
+			}
+		default:
+			return nil, nil, fmt.Errorf("%s is not encrypted with SOPS, keep plain variables in .env", path)
+		}
+		if env == nil {
+			env = map[string]string{}
+		}
+		maps.Copy(env, vars)
+		for name := range vars {
+			encrypted[name] = isEncrypted
+		}
+	}
+	if DecryptEnv && envstore.Exists(config.Profile) {
+		vars, err := profileEnv()
+		if err != nil {
+			return nil, nil, err
+		}
+		if env == nil {
+			env = map[string]string{}
+		}
+		maps.Copy(env, vars)
+		for name := range vars {
+			encrypted[name] = true
+		}
+	}
+	return env, encrypted, nil
 }
 
-// injectJSCode replaces lines like `jsCode: file(index.js)` in the YAML
-// with the actual contents of the file using a YAML block scalar.
-func injectJSCode(yamlPath string) ([]byte, error) {
-	yamlBytes, err := os.ReadFile(yamlPath)
+// profileEnv returns the variables stored for the selected profile, which
+// override those of the env files. They are decrypted once, asking for the
+// passphrase at most once per run.
+func profileEnv() (map[string]string, error) {
+	path, err := envstore.Path(config.Profile)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read YAML: %w", err)
+		return nil, err
+	}
+	decryptMu.Lock()
+	defer decryptMu.Unlock()
+	if vars := decryptedEnv[path]; vars != nil {
+		return vars, nil
 	}
-	lines := strings.Split(string(yamlBytes), "\n")
+	store, err := envstore.Open(config.Profile)
+	if err != nil {
+		return nil, err
+	}
+	decryptedEnv[path] = store.Vars
+	return store.Vars, nil
+}
 
-	var outputLines []string
-	for _, line := range lines {
-		trimmed := strings.TrimSpace(line)
+func decryptEnv(path string, data []byte) (map[string]string, error) {
+	decryptMu.Lock()
+	defer decryptMu.Unlock()
+	if vars := decryptedEnv[path]; vars != nil {
+		return vars, nil
+	}
+	vars, err := sops.DecryptEnv(context.Background(), path, data)
+	if err != nil {
+		return nil, err
+	}
+	decryptedEnv[path] = vars
+	return vars, nil
+}
 
-		// Match pattern: jsCode: file(index.js)
-		if strings.HasPrefix(trimmed, "jsCode: file(") && strings.HasSuffix(trimmed, ")") {
-			fileName := trimmed[len("jsCode: file(") : len(trimmed)-1]
-			jsPath := filepath.Join(filepath.Dir(yamlPath), fileName)
+var envPattern = regexp.MustCompile(`\${{\s*([A-Za-z_][A-Za-z0-9_]*)\s*}}`)
 
-			jsBytes, err := os.ReadFile(jsPath)
-			if err != nil {
-				return nil, fmt.Errorf("failed to read %s: %w", jsPath, err)
+// injectEnvVariables replaces ${{VAR_NAME}} with values from env map, and
+// returns the values it used. Like secrets, the values are substituted in
+// the parsed scalars rather than the text, so a value with quotes, a colon
+// or a newline cannot change the YAML around it. A plain scalar holding
+// nothing but the placeholder takes the type of its value, so
+// `timeout: ${{TIMEOUT}}` is still a number, unless the variable is
+// encrypted: those are strings like secrets, so a decrypted 0012 keeps its
+// zeros.
+func injectEnvVariables(src string, env map[string]string, encrypted map[string]bool) (string, []string, error) {
+	if !envPattern.MatchString(src) {
+		return src, nil, nil
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(src), &doc); err != nil {
+		return "", nil, fmt.Errorf("invalid YAML: %w", sourceError(err))
+	}
+	var used []string
+	var walk func(node *yaml.Node)
+	walk = func(node *yaml.Node) {
+		if node.Kind != yaml.ScalarNode {
+			for _, child := range node.Content {
+				walk(child)
+			}
+			return
+		}
+		typed := node.Style == 0
+		value := envPattern.ReplaceAllStringFunc(node.Value, func(match string) string {
+			matches := envPattern.FindStringSubmatch(match)
+			if val, ok := env[matches[1]]; ok {
+				used = append(used, val)
+				typed = typed && !encrypted[matches[1]]
+				return val
 			}
-			jsCode := string(jsBytes)
+			return match // leave unresolved if missing
+		})
+		if value == node.Value {
+			return
+		}
+		whole := typed && envPattern.FindString(node.Value) == node.Value
+		node.Value, node.Tag, node.Style = value, "!!str", 0
+		if whole {
+			node.Tag = ""
+		}
+	}
+	walk(&doc)
+	if len(used) == 0 {
+		return src, nil, nil
+	}
+	injected, err := yaml.Marshal(&doc)
+	if err != nil {
+		return "", nil, err
+	}
+	return string(injected), used, nil
+}
 
-			// Find indentation of original line (spaces before 'jsCode')
-			indentation := line[:len(line)-len(strings.TrimLeft(line, " "))]
+// SecretStores resolve ${{store:reference}} placeholders, by store name such
+// as "vault". Placeholders of stores that are not registered are left as they
+// are, like unknown variables, so validating templates needs no secrets.
+var SecretStores = map[string]secrets.Resolver{}
 
-			// Write the block scalar line with the same indentation
-			outputLines = append(outputLines, indentation+"jsCode: |")
+var secretPattern = regexp.MustCompile(`\${{\s*([a-z][a-z0-9-]*):([^}]+?)\s*}}`)
 
-			// Indent JS code lines one level further (e.g. 2 spaces more)
-			jsIndent := indentation + "  "
-			for jsLine := range strings.SplitSeq(jsCode, "\n") {
-				outputLines = append(outputLines, jsIndent+jsLine)
+// injectSecrets replaces the placeholders of registered secret stores with
+// the secrets they name, and returns the secrets. The placeholders are
+// replaced in the parsed scalars rather than the text, so a secret with
+// quotes, a colon or a newline stays the string it is instead of changing
+// the YAML around it.
+func injectSecrets(src string) (string, []string, error) {
+	if !secretPattern.MatchString(src) {
+		return src, nil, nil
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(src), &doc); err != nil {
+		return "", nil, fmt.Errorf("invalid YAML: %w", sourceError(err))
+	}
+	var firstErr error
+	var used []string
+	var walk func(node *yaml.Node)
+	walk = func(node *yaml.Node) {
+		if node.Kind != yaml.ScalarNode {
+			for _, child := range node.Content {
+				walk(child)
+			}
+			return
+		}
+		value := secretPattern.ReplaceAllStringFunc(node.Value, func(match string) string {
+			matches := secretPattern.FindStringSubmatch(match)
+			store, ok := SecretStores[matches[1]]
+			if !ok || firstErr != nil {
+				return match
 			}
-		} else {
+			value, err := store.Resolve(context.Background(), matches[2])
+			if err != nil {
+				firstErr = err
+				return match
+			}
+			used = append(used, value)
+			return value
+		})
+		if value != node.Value {
+			// Secrets are strings, even those that look like numbers.
+			node.Value, node.Tag, node.Style = value, "!!str", 0
+		}
+	}
+	walk(&doc)
+	if firstErr != nil || len(used) == 0 {
+		return src, used, firstErr
+	}
+	resolved, err := yaml.Marshal(&doc)
+	if err != nil {
+		return "", nil, err
+	}
+	return string(resolved), used, nil
+}
+
+// includePattern matches a mapping entry whose whole value is a file()
+// include, such as `jsCode: file(index.js)`, `- query: file("sql/daily report.sql")`
+// or `html: file(page.html)  # comment`. Quoted values such as
+// "file(notes.txt)" are left alone.
+var includePattern = regexp.MustCompile(`^(\s*(?:- +)?)([^\s#'"{}\[\],:][^#{}\[\],]*?|"[^"]*"|'[^']*'):[ \t]+file\([ \t]*(?:"([^"]+)"|'([^']+)'|([^)"'\s]+))[ \t]*\)[ \t]*(?:#.*)?$`)
+
+// injectFiles replaces mapping entries whose value is file(path), for any
+// string parameter such as jsCode, pythonCode, query, html or jsonBody,
+// with the content of the file as a YAML block scalar, and returns the
+// files it read. Paths are relative to the directory of the YAML file.
+func injectFiles(yamlPath string) ([]byte, []string, error) {
+	yamlBytes, err := os.ReadFile(yamlPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read YAML: %w", err)
+	}
+	return inlineFiles(string(yamlBytes), yamlPath)
+}
+
+// inlineFiles is injectFiles for src, the YAML of the file yamlPath.
+func inlineFiles(src, yamlPath string) ([]byte, []string, error) {
+	lines := strings.Split(normalizeNewlinesString(src), "\n")
+
+	var outputLines, included []string
+	for _, line := range lines {
+		m := includePattern.FindStringSubmatch(line)
+		if m == nil {
 			outputLines = append(outputLines, line)
+			continue
 		}
+		prefix, key, fileName := m[1], m[2], m[3]+m[4]+m[5]
+		includePath := fileName
+		if !filepath.IsAbs(includePath) {
+			includePath = filepath.Join(filepath.Dir(yamlPath), fileName)
+		}
+		content, err := os.ReadFile(includePath)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read %s: %w", includePath, err)
+		}
+		included = append(included, includePath)
+
+		// The content is indented one level further than the key, which
+		// follows the "- " of a sequence entry.
+		indent := strings.Repeat(" ", len(prefix)+2)
+		header, body := blockScalar(normalizeNewlinesString(string(content)), indent)
+		outputLines = append(outputLines, prefix+key+": "+header)
+		outputLines = append(outputLines, body...)
 	}
 
-	return []byte(strings.Join(outputLines, "\n")), nil
+	return []byte(strings.Join(outputLines, "\n")), included, nil
+}
+
+// blockScalar returns the header and the indented lines of a literal block
+// scalar holding text exactly: its chomping indicator keeps the trailing
+// newlines of text, and an indentation indicator is added when text starts
+// with spaces, which would otherwise be taken for indentation.
+func blockScalar(text, indent string) (string, []string) {
+	if text == "" {
+		return `""`, nil
+	}
+	content := strings.TrimRight(text, "\n")
+	header := "|"
+	if strings.HasPrefix(strings.TrimLeft(content, "\n"), " ") {
+		header += "2"
+	}
+	switch trailing := len(text) - len(content); {
+	case trailing == 0:
+		header += "-"
+	case trailing > 1:
+		header += "+"
+	}
+	var lines []string
+	for line := range strings.SplitSeq(content, "\n") {
+		if line == "" {
+			lines = append(lines, "")
+			continue
+		}
+		lines = append(lines, indent+line)
+	}
+	// Keep mode writes the trailing newlines as empty lines.
+	for range len(text) - len(content) - 1 {
+		lines = append(lines, "")
+	}
+	return header, lines
 }
@@ -0,0 +1,57 @@
+package workflows
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestInjectEnvVariablesKeepsValuesScalars(t *testing.T) {
+	env := map[string]string{
+		"GREETING": "line one\nline two: \"quoted\"\n  - not a list",
+		"QUOTE":    `it's "quoted", isn't it`,
+		"TIMEOUT":  "30",
+		"HOST":     "example.com",
+		"PIN":      "0012",
+	}
+	src := `name: Notify
+parameters:
+  message: ${{ GREETING }}
+  quoted: "${{QUOTE}}"
+  timeout: ${{ TIMEOUT }}
+  code: "30"
+  url: https://${{ HOST }}/hook
+  missing: ${{ MISSING }}
+  pin: ${{ PIN }}
+`
+	injected, used, err := injectEnvVariables(src, env, map[string]bool{"PIN": true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(used) != 5 {
+		t.Errorf("got %d values used, want 5: %q", len(used), used)
+	}
+	body, err := YAMLToJSON([]byte(injected))
+	if err != nil {
+		t.Fatalf("injected YAML does not convert: %v\n%s", err, injected)
+	}
+	var wf struct {
+		Parameters map[string]any `json:"parameters"`
+	}
+	if err := json.Unmarshal(body, &wf); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{
+		"message": env["GREETING"],
+		"quoted":  env["QUOTE"],
+		"timeout": float64(30),
+		"code":    "30",
+		"url":     "https://example.com/hook",
+		"missing": "${{ MISSING }}",
+		"pin":     "0012",
+	}
+	for key, value := range want {
+		if wf.Parameters[key] != value {
+			t.Errorf("%s = %#v, want %#v", key, wf.Parameters[key], value)
+		}
+	}
+}